@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditRecord is one line of the audit log
+type auditRecord struct {
+	Time    time.Time   `json:"time"`
+	Session string      `json:"session"`
+	Tool    string      `json:"tool"`
+	Files   []auditFile `json:"files,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Refused string      `json:"refused,omitempty"`
+}
+
+// auditFile is a file changed by a tool call. The hashes identify the content
+// before and after the change, and so the diff, without storing the code.
+type auditFile struct {
+	Path   string `json:"path"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// auditor records the calls of mutating tools and enforces per-session quotas
+// on the files they modify
+type auditor struct {
+	cfg settings.AuditSettings
+	log io.Writer
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*sessionEdits
+}
+
+// sessionEdits are the files a session has modified
+type sessionEdits struct {
+	// modified holds when each file was last modified
+	modified map[string]time.Time
+}
+
+// newAuditor creates an auditor writing JSON lines to log, which may be nil
+func newAuditor(cfg settings.AuditSettings, log io.Writer) *auditor {
+	return &auditor{
+		cfg:      cfg,
+		log:      log,
+		now:      time.Now,
+		sessions: make(map[string]*sessionEdits),
+	}
+}
+
+// openAuditLog opens the audit log for appending. It returns nil when no log
+// file is configured.
+func openAuditLog(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return file, nil
+}
+
+// enabled reports whether mutating calls need to be observed at all
+func (a *auditor) enabled() bool {
+	return a.log != nil || a.cfg.MaxFilesPerHour > 0 || a.cfg.MaxFilesPerSession > 0
+}
+
+// exceeded returns why a session may not modify more files, or "" if it may
+func (a *auditor) exceeded(session string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	edits, ok := a.sessions[session]
+	if !ok {
+		return ""
+	}
+
+	if limit := a.cfg.MaxFilesPerSession; limit > 0 && len(edits.modified) >= limit {
+		return fmt.Sprintf("this session has modified %d files, the limit per session is %d (audit.maxFilesPerSession)", len(edits.modified), limit)
+	}
+	if limit := a.cfg.MaxFilesPerHour; limit > 0 {
+		hourAgo := a.now().Add(-time.Hour)
+		recent := 0
+		for _, at := range edits.modified {
+			if at.After(hourAgo) {
+				recent++
+			}
+		}
+		if recent >= limit {
+			return fmt.Sprintf("this session has modified %d files in the last hour, the limit is %d (audit.maxFilesPerHour)", recent, limit)
+		}
+	}
+	return ""
+}
+
+// record counts the files a session modified towards its quotas
+func (a *auditor) record(session string, files []auditFile) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	edits, ok := a.sessions[session]
+	if !ok {
+		edits = &sessionEdits{modified: make(map[string]time.Time)}
+		a.sessions[session] = edits
+	}
+	for _, file := range files {
+		edits.modified[file.Path] = a.now()
+	}
+}
+
+// Forget drops the quota counters of a session that has ended
+func (a *auditor) Forget(ctx context.Context, session server.ClientSession) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, session.SessionID())
+}
+
+// Run runs a mutating tool call, refusing it when the session is over its
+// quota, and writes an audit record for the files the mutation wrote
+func (a *auditor) Run(m *mutation, session, tool string, call func() (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	entry := auditRecord{Time: a.now().UTC(), Session: session, Tool: tool}
+	if reason := a.exceeded(session); reason != "" {
+		entry.Refused = reason
+		a.write(entry)
+		return mcp.NewToolResultError(fmt.Sprintf("%s refused: %s", tool, reason)), nil
+	}
+
+	// Keep the first content seen before and the last content seen after
+	// each file's changes
+	type change struct{ before, after []byte }
+	changes := make(map[string]*change)
+	var changesMu sync.Mutex
+	m.Observe(func(path string, before, after []byte) {
+		changesMu.Lock()
+		defer changesMu.Unlock()
+		if c, ok := changes[path]; ok {
+			c.after = after
+			return
+		}
+		changes[path] = &change{before: before, after: after}
+	})
+	result, err := call()
+
+	changesMu.Lock()
+	for path, c := range changes {
+		entry.Files = append(entry.Files, auditFile{Path: path, Before: contentHash(c.before), After: contentHash(c.after)})
+	}
+	changesMu.Unlock()
+	sort.Slice(entry.Files, func(i, j int) bool { return entry.Files[i].Path < entry.Files[j].Path })
+
+	if err != nil {
+		entry.Error = err.Error()
+	} else if result != nil && result.IsError {
+		entry.Error = resultText(result)
+	}
+
+	a.record(session, entry.Files)
+	a.write(entry)
+	return result, err
+}
+
+// write appends a record to the audit log
+func (a *auditor) write(entry auditRecord) {
+	if a.log == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		coreLogger.Error("Failed to encode audit record: %v", err)
+		return
+	}
+	if _, err := a.log.Write(append(data, '\n')); err != nil {
+		coreLogger.Error("Failed to write audit log: %v", err)
+	}
+}
+
+// contentHash returns the sha256 of file content, or "" for no content
+func contentHash(content []byte) string {
+	if content == nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// resultText returns the text of a tool result
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+// auditMiddleware runs mutating tool calls through the auditor
+func (s *mcpServer) auditMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		m := currentMutation(ctx)
+		if m == nil || !s.auditor.enabled() {
+			return next(ctx, request)
+		}
+		return s.auditor.Run(m, sessionID(ctx), request.Params.Name, func() (*mcp.CallToolResult, error) {
+			return next(ctx, request)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditRecords decodes the JSON lines written to an audit log
+func auditRecords(t *testing.T, log *bytes.Buffer) []auditRecord {
+	t.Helper()
+	var records []auditRecord
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var record auditRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// editCall returns a tool call that replaces the first line of a file
+func editCall(path, text string) func() (*mcp.CallToolResult, error) {
+	return func() (*mcp.CallToolResult, error) {
+		err := utilities.ApplyTextEdits(protocol.URIFromPath(path), []protocol.TextEdit{{
+			Range:   protocol.Range{End: protocol.Position{Line: 1}},
+			NewText: text,
+		}})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText("ok"), nil
+	}
+}
+
+// auditedRun returns a function running edit_file calls through an auditor
+func auditedRun(a *auditor) func(session string, call func() (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	return func(session string, call func() (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+		return runMutating(func(m *mutation) (*mcp.CallToolResult, error) {
+			return a.Run(m, session, "edit_file", call)
+		})
+	}
+}
+
+func TestAuditorRecordsChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package old\n"), 0644))
+
+	var log bytes.Buffer
+	a := newAuditor(settings.AuditSettings{}, &log)
+	a.now = func() time.Time { return time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC) }
+	run := auditedRun(a)
+
+	result, err := run("s1", editCall(path, "package new\n"))
+	assert.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	_, err = run("s1", func() (*mcp.CallToolResult, error) {
+		return nil, errors.New("no such symbol")
+	})
+	assert.Error(t, err)
+
+	records := auditRecords(t, &log)
+	require.Len(t, records, 2)
+	assert.Equal(t, auditRecord{
+		Time:    time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+		Session: "s1",
+		Tool:    "edit_file",
+		Files: []auditFile{{
+			Path:   path,
+			Before: contentHash([]byte("package old\n")),
+			After:  contentHash([]byte("package new\n")),
+		}},
+	}, records[0])
+	assert.Equal(t, "no such symbol", records[1].Error)
+	assert.Empty(t, records[1].Files)
+}
+
+func TestAuditorQuotas(t *testing.T) {
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, string(rune('a'+i))+".go")
+		require.NoError(t, os.WriteFile(paths[i], []byte("package p\n"), 0644))
+	}
+
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	var log bytes.Buffer
+	a := newAuditor(settings.AuditSettings{MaxFilesPerHour: 2, MaxFilesPerSession: 3}, &log)
+	a.now = func() time.Time { return now }
+	run := auditedRun(a)
+
+	for _, path := range paths[:2] {
+		result, err := run("s1", editCall(path, "package q\n"))
+		assert.NoError(t, err)
+		assert.False(t, result.IsError)
+	}
+
+	// The hourly quota is reached, but only for this session
+	result, err := run("s1", editCall(paths[2], "package q\n"))
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(result), "modified 2 files in the last hour, the limit is 2 (audit.maxFilesPerHour)")
+	content, _ := os.ReadFile(paths[2])
+	assert.Equal(t, "package p\n", string(content))
+
+	result, _ = run("s2", editCall(paths[2], "package q\n"))
+	assert.False(t, result.IsError)
+
+	// An hour later the session may edit again, until its total quota
+	now = now.Add(time.Hour + time.Minute)
+	result, _ = run("s1", editCall(paths[2], "package r\n"))
+	assert.False(t, result.IsError)
+	result, _ = run("s1", editCall(paths[0], "package r\n"))
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(result), "modified 3 files, the limit per session is 3 (audit.maxFilesPerSession)")
+
+	records := auditRecords(t, &log)
+	require.Len(t, records, 6)
+	assert.NotEmpty(t, records[2].Refused)
+	assert.Empty(t, records[2].Files)
+	assert.NotEmpty(t, records[5].Refused)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxBookmarks bounds the working set of a session
+const maxBookmarks = 100
+
+// sessionBookmarks keeps the bookmarked symbols of each client session
+type sessionBookmarks struct {
+	bookmarks map[string][]tools.Bookmark
+	mu        sync.Mutex
+}
+
+func newSessionBookmarks() *sessionBookmarks {
+	return &sessionBookmarks{bookmarks: make(map[string][]tools.Bookmark)}
+}
+
+// bookmarkKey identifies the symbol of a bookmark, which stays the same when
+// the bookmark moves
+func bookmarkKey(bookmark tools.Bookmark) string {
+	return fmt.Sprintf("%s\x00%s\x00%d", bookmark.Path, bookmark.Name, bookmark.Kind)
+}
+
+// Add adds bookmarks to the working set of a session. A symbol bookmarked
+// again gets the new note. It returns the size of the working set.
+func (b *sessionBookmarks) Add(session string, added []tools.Bookmark) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bookmarks := b.bookmarks[session]
+	var fresh []tools.Bookmark
+	for _, bookmark := range added {
+		replaced := false
+		for i, existing := range bookmarks {
+			if bookmarkKey(existing) == bookmarkKey(bookmark) {
+				bookmarks[i] = bookmark
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			fresh = append(fresh, bookmark)
+		}
+	}
+	if len(bookmarks)+len(fresh) > maxBookmarks {
+		return len(bookmarks), fmt.Errorf("a session keeps at most %d bookmarks, remove some with bookmark_symbol and remove: true", maxBookmarks)
+	}
+	b.bookmarks[session] = append(bookmarks, fresh...)
+	return len(b.bookmarks[session]), nil
+}
+
+// Remove removes the bookmarks of a session bookmarked by a name, or whose
+// symbol has that name, and returns how many it removed and how many are left
+func (b *sessionBookmarks) Remove(session, name string) (int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var kept []tools.Bookmark
+	for _, bookmark := range b.bookmarks[session] {
+		if bookmark.Query != name && bookmark.Name != name {
+			kept = append(kept, bookmark)
+		}
+	}
+	removed := len(b.bookmarks[session]) - len(kept)
+	b.bookmarks[session] = kept
+	return removed, len(kept)
+}
+
+// List returns the bookmarks of a session in the order they were added
+func (b *sessionBookmarks) List(session string) []tools.Bookmark {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]tools.Bookmark(nil), b.bookmarks[session]...)
+}
+
+// Update stores where refreshed bookmarks were found. Bookmarks removed while
+// they were being refreshed stay removed.
+func (b *sessionBookmarks) Update(session string, refreshed []tools.RefreshedBookmark) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	found := make(map[string]tools.Bookmark, len(refreshed))
+	for _, bookmark := range refreshed {
+		found[bookmarkKey(bookmark.Bookmark)] = bookmark.Bookmark
+	}
+	for i, bookmark := range b.bookmarks[session] {
+		if update, ok := found[bookmarkKey(bookmark)]; ok {
+			bookmark.Line, bookmark.Text = update.Line, update.Text
+			b.bookmarks[session][i] = bookmark
+		}
+	}
+}
+
+// Forget drops the bookmarks of a session that has disconnected
+func (b *sessionBookmarks) Forget(ctx context.Context, session server.ClientSession) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.bookmarks, session.SessionID())
+}
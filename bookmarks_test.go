@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionBookmarks(t *testing.T) {
+	bookmarks := newSessionBookmarks()
+	client := tools.Bookmark{Query: "api.Client", Name: "Client", Kind: protocol.Struct, Path: "/ws/api/client.go", Line: 4}
+	serve := tools.Bookmark{Query: "Serve", Name: "Serve", Kind: protocol.Function, Path: "/ws/server.go", Line: 10}
+
+	total, err := bookmarks.Add("s1", []tools.Bookmark{client, serve})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Empty(t, bookmarks.List("s2"))
+
+	// Bookmarking a symbol again updates its note
+	client.Note = "the SDK entry point"
+	total, err = bookmarks.Add("s1", []tools.Bookmark{client})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "the SDK entry point", bookmarks.List("s1")[0].Note)
+
+	// Refreshed locations are stored
+	moved := client
+	moved.Line = 6
+	bookmarks.Update("s1", []tools.RefreshedBookmark{{Bookmark: moved, Status: tools.BookmarkMoved, PreviousLine: 4}})
+	assert.Equal(t, 6, bookmarks.List("s1")[0].Line)
+
+	// Bookmarks are removed by the name they were added by or their symbol's
+	removed, left := bookmarks.Remove("s1", "Client")
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, 1, left)
+	removed, _ = bookmarks.Remove("s1", "Missing")
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, []tools.Bookmark{serve}, bookmarks.List("s1"))
+}
+
+func TestSessionBookmarksLimit(t *testing.T) {
+	bookmarks := newSessionBookmarks()
+	var many []tools.Bookmark
+	for i := 0; i <= maxBookmarks; i++ {
+		many = append(many, tools.Bookmark{Name: "Symbol", Path: "/ws/a.go", Line: i + 1, Kind: protocol.SymbolKind(i)})
+	}
+	_, err := bookmarks.Add("s1", many[:maxBookmarks])
+	require.NoError(t, err)
+	_, err = bookmarks.Add("s1", many[maxBookmarks:])
+	assert.ErrorContains(t, err, "at most 100 bookmarks")
+	assert.Len(t, bookmarks.List("s1"), maxBookmarks)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// outputBudget tracks the estimated tokens of tool output each session has
+// received against the configured budget
+type outputBudget struct {
+	cfg  settings.OutputBudgetSettings
+	used map[string]int
+	mu   sync.Mutex
+}
+
+func newOutputBudget(cfg settings.OutputBudgetSettings) *outputBudget {
+	return &outputBudget{cfg: cfg, used: make(map[string]int)}
+}
+
+// enabled reports whether a budget is configured
+func (b *outputBudget) enabled() bool {
+	return b.cfg.MaxTokens > 0
+}
+
+// Add counts tokens of output towards a session and returns its total
+func (b *outputBudget) Add(session string, tokens int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used[session] += tokens
+	return b.used[session]
+}
+
+// Used returns the tokens of output a session has received
+func (b *outputBudget) Used(session string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used[session]
+}
+
+// warnTokens is the total after which results carry a warning
+func (b *outputBudget) warnTokens() int {
+	return b.cfg.MaxTokens * b.cfg.WarnPercent / 100
+}
+
+// Summarizing reports whether results for a session are shrunk to save budget
+func (b *outputBudget) Summarizing(session string) bool {
+	return b.enabled() && b.cfg.Summarize && b.cfg.SummaryTokens > 0 && b.Used(session) >= b.warnTokens()
+}
+
+// Warning returns the note added to results once a session has used the
+// given tokens, or "" while it is below the warning threshold
+func (b *outputBudget) Warning(used int) string {
+	if !b.enabled() || used < b.warnTokens() {
+		return ""
+	}
+	percent := used * 100 / b.cfg.MaxTokens
+	warning := fmt.Sprintf("Session output budget: ~%d of %d tokens used (%d%%).", used, b.cfg.MaxTokens, percent)
+	if used >= b.cfg.MaxTokens {
+		warning += " The budget is exhausted; prefer narrow queries and small max_tokens."
+	}
+	if b.cfg.Summarize && b.cfg.SummaryTokens > 0 {
+		warning += fmt.Sprintf(" Results are summarized to ~%d tokens unless max_tokens is passed.", b.cfg.SummaryTokens)
+	}
+	return warning
+}
+
+// Status describes the budget of a session for the status tool
+func (b *outputBudget) Status(session string) string {
+	used := b.Used(session)
+	status := fmt.Sprintf("Output budget: ~%d of %d tokens used by this session (%d%%)", used, b.cfg.MaxTokens, used*100/b.cfg.MaxTokens)
+	if b.Summarizing(session) {
+		status += ", results summarized"
+	}
+	return status + "\n"
+}
+
+// Forget drops the count of a session that has disconnected
+func (b *outputBudget) Forget(ctx context.Context, session server.ClientSession) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.used, session.SessionID())
+}
+
+// resultTokens estimates the tokens of a tool result
+func resultTokens(result *mcp.CallToolResult) int {
+	tokens := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			tokens += textTokens(text.Text)
+		}
+	}
+	return tokens
+}
+
+// textTokens estimates the tokens of text
+func textTokens(text string) int {
+	return (len(text) + format.CharsPerToken - 1) / format.CharsPerToken
+}
+
+// budgetMiddleware counts the output of every tool call towards the session's
+// budget and adds a warning to results once the budget is nearly used
+func (s *mcpServer) budgetMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || !s.outputBudget.enabled() || request.Params.Name == statusTool {
+			return result, err
+		}
+
+		used := s.outputBudget.Add(sessionID(ctx), resultTokens(result))
+		if warning := s.outputBudget.Warning(used); warning != "" {
+			result.Content = append(result.Content, mcp.NewTextContent(warning))
+		}
+		return result, nil
+	}
+}
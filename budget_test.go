@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputBudgetWarnsAndSummarizes(t *testing.T) {
+	budget := newOutputBudget(settings.OutputBudgetSettings{MaxTokens: 100, WarnPercent: 80, Summarize: true, SummaryTokens: 20})
+
+	assert.Equal(t, 50, budget.Add("a", 50))
+	assert.Empty(t, budget.Warning(50))
+	assert.False(t, budget.Summarizing("a"))
+
+	assert.Equal(t, 85, budget.Add("a", 35))
+	assert.Equal(t, "Session output budget: ~85 of 100 tokens used (85%). Results are summarized to ~20 tokens unless max_tokens is passed.", budget.Warning(85))
+	assert.True(t, budget.Summarizing("a"))
+	assert.False(t, budget.Summarizing("b"))
+
+	assert.Contains(t, budget.Warning(120), "The budget is exhausted")
+	assert.Equal(t, "Output budget: ~85 of 100 tokens used by this session (85%), results summarized\n", budget.Status("a"))
+}
+
+func TestOutputBudgetDisabled(t *testing.T) {
+	budget := newOutputBudget(settings.Default().OutputBudget)
+	budget.Add("a", 1000000)
+	assert.False(t, budget.enabled())
+	assert.Empty(t, budget.Warning(1000000))
+	assert.False(t, budget.Summarizing("a"))
+}
+
+func TestBudgetMiddleware(t *testing.T) {
+	cfg := settings.Default()
+	cfg.OutputBudget.MaxTokens = 10
+	s := &mcpServer{outputBudget: newOutputBudget(cfg.OutputBudget)}
+
+	handler := s.budgetMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(strings.Repeat("x", 20)), nil
+	})
+	var request mcp.CallToolRequest
+	request.Params.Name = "references"
+
+	// 20 characters are about 5 tokens, below the warning at 8
+	result, err := handler(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Len(t, result.Content, 1)
+
+	result, _ = handler(context.Background(), request)
+	assert.Len(t, result.Content, 2)
+	assert.Contains(t, result.Content[1].(mcp.TextContent).Text, "~10 of 10 tokens used (100%)")
+	assert.Equal(t, 10, s.outputBudget.Used(""))
+}
+
+func TestResultTokens(t *testing.T) {
+	assert.Equal(t, 0, resultTokens(mcp.NewToolResultText("")))
+	assert.Equal(t, 1, resultTokens(mcp.NewToolResultText("abc")))
+	assert.Equal(t, 2, resultTokens(mcp.NewToolResultText("abcde")))
+}
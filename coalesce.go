@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// refreshArgument makes a read-only call run on its own instead of sharing the
+// result of an identical call already running
+const refreshArgument = "refresh"
+
+// coalescedTools are the read-only tools whose identical concurrent calls share
+// one run, as when an orchestrator fans the same question out to several agents
+var coalescedTools = map[string]bool{
+	"definition":     true,
+	"references":     true,
+	"diagnostics":    true,
+	"get_codelens":   true,
+	"hover":          true,
+	"completion":     true,
+	"incoming_calls": true,
+	"review_changes": true,
+	"find_tests":     true,
+	"entry_points":   true,
+	"trace_sink":     true,
+	"peek_symbol":    true,
+	"search_symbols": true,
+	"run_pipeline":   true,
+}
+
+// coalescer runs identical concurrent calls once
+type coalescer struct {
+	mu       sync.Mutex
+	inflight map[string]*flight
+	shared   int
+}
+
+// flight is a call in progress that identical calls wait for
+type flight struct {
+	done   chan struct{}
+	result *mcp.CallToolResult
+	err    error
+
+	// abandoned is set when the call that ran stopped because its own
+	// context ended, so that waiting calls run for themselves
+	abandoned bool
+
+	// resultID is the ID the run recorded its result under, if any
+	resultID string
+}
+
+// resultIDKey is the context key under which renderDocument reports the ID of
+// the result it recorded, so that calls sharing the run can read it too
+type resultIDKey struct{}
+
+// reportResultID passes the ID of a recorded result to the coalescer running
+// the call, if any
+func reportResultID(ctx context.Context, id string) {
+	if sink, ok := ctx.Value(resultIDKey{}).(*string); ok {
+		*sink = id
+	}
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{inflight: make(map[string]*flight)}
+}
+
+// Do runs call, unless a call with the same key is already running, in which
+// case it waits for that call and returns a copy of its result. It also
+// returns the ID the run recorded its result under, if any.
+func (c *coalescer) Do(ctx context.Context, key string, call func(ctx context.Context) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, string, error) {
+	c.mu.Lock()
+	if f, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+		if f.abandoned {
+			var resultID string
+			result, err := call(context.WithValue(ctx, resultIDKey{}, &resultID))
+			return result, resultID, err
+		}
+		c.mu.Lock()
+		c.shared++
+		c.mu.Unlock()
+		return copyResult(f.result), f.resultID, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	c.inflight[key] = f
+	c.mu.Unlock()
+
+	f.result, f.err = call(context.WithValue(ctx, resultIDKey{}, &f.resultID))
+	f.abandoned = ctx.Err() != nil
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(f.done)
+	return copyResult(f.result), f.resultID, f.err
+}
+
+// Status describes how many calls were answered by another call's run
+func (c *coalescer) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf("Coalescing: %d calls in progress, %d calls shared the result of an identical call\n", len(c.inflight), c.shared)
+}
+
+// copyResult copies a result so that middlewares of each call can add to its
+// content without affecting the others
+func copyResult(result *mcp.CallToolResult) *mcp.CallToolResult {
+	if result == nil {
+		return nil
+	}
+	copied := *result
+	copied.Content = append([]mcp.Content(nil), result.Content...)
+	return &copied
+}
+
+// coalesceKey identifies calls that give the same result: the tool, its
+// arguments and the session settings that change its output
+func (s *mcpServer) coalesceKey(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	args := make(map[string]any, len(request.Params.Arguments))
+	for name, value := range request.Params.Arguments {
+		if name != timeoutArgument && name != refreshArgument {
+			args[name] = value
+		}
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+
+	tc := &tools.ToolContext{ContextLines: s.contextLines}
+	s.overrides.Apply(ctx, tc)
+	summarizing := s.outputBudget.Summarizing(sessionID(ctx))
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%s\x00%t", request.Params.Name, encoded, s.outputVersions.Format(ctx), tc.ContextLines, tc.FocusDir, summarizing), nil
+}
+
+// coalesceMiddleware shares the run of identical read-only calls made at the
+// same time. It runs inside the timeout middleware, so each call keeps its own
+// timeout while waiting, and outside the scheduler, so waiting calls do not
+// take a slot.
+func (s *mcpServer) coalesceMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Streamed sections only reach the caller that made the run, so
+		// streaming calls run on their own too
+		refresh, _ := request.Params.Arguments[refreshArgument].(bool)
+		stream, _ := request.Params.Arguments[streamArgument].(bool)
+		if refresh || stream || !coalescedTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+		key, err := s.coalesceKey(ctx, request)
+		if err != nil {
+			return next(ctx, request)
+		}
+		result, resultID, err := s.coalescer.Do(ctx, key, func(ctx context.Context) (*mcp.CallToolResult, error) {
+			return next(ctx, request)
+		})
+		if resultID != "" && s.results != nil {
+			// Calls from other sessions that shared the run may compare
+			// the result too
+			s.results.Share(sessionID(ctx), resultID)
+		}
+		return result, err
+	}
+}
+
+// withRefreshParameter is a tool filter that advertises refresh on the tools
+// whose calls are coalesced
+func (s *mcpServer) withRefreshParameter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	for i, tool := range tools {
+		if !coalescedTools[tool.Name] {
+			continue
+		}
+		properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+1)
+		for name, property := range tool.InputSchema.Properties {
+			properties[name] = property
+		}
+		properties[refreshArgument] = map[string]interface{}{
+			"type":        "boolean",
+			"description": "Run this call on its own instead of sharing the result of an identical call already in progress",
+		}
+		tools[i].InputSchema.Properties = properties
+	}
+	return tools
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCoalescingServer() *mcpServer {
+	return &mcpServer{
+		config:         config{settings: settings.Default()},
+		coalescer:      newCoalescer(),
+		outputVersions: newOutputVersions("v1"),
+		overrides:      newSessionOverrides(),
+		outputBudget:   newOutputBudget(settings.OutputBudgetSettings{}),
+	}
+}
+
+// blockingHandler counts its runs and answers once release is closed
+func blockingHandler(runs *atomic.Int32, started chan<- struct{}, release <-chan struct{}) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		runs.Add(1)
+		started <- struct{}{}
+		select {
+		case <-release:
+			return mcp.NewToolResultText("result"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// waitForInflight waits until a call with the key is in progress
+func waitForInflight(t *testing.T, c *coalescer, key string) {
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, ok := c.inflight[key]
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestCoalesceMiddleware(t *testing.T) {
+	s := newCoalescingServer()
+	var runs atomic.Int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	handler := s.coalesceMiddleware(blockingHandler(&runs, started, release))
+
+	request := callWithArguments(map[string]interface{}{"symbolName": "Foo"})
+	withTimeout := callWithArguments(map[string]interface{}{"symbolName": "Foo", "timeout_ms": float64(5000)})
+
+	var wg sync.WaitGroup
+	results := make([]*mcp.CallToolResult, 3)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = handler(context.Background(), request)
+	}()
+	<-started
+	for i, r := range []mcp.CallToolRequest{request, withTimeout} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i+1], _ = handler(context.Background(), r)
+		}()
+	}
+	// Let the other calls find the one in progress before it finishes
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), runs.Load())
+	for _, result := range results {
+		require.NotNil(t, result)
+		assert.Equal(t, "result", result.Content[0].(mcp.TextContent).Text)
+	}
+	// Each call gets its own copy to add notes to
+	results[1].Content = append(results[1].Content, mcp.NewTextContent("note"))
+	assert.Len(t, results[2].Content, 1)
+	assert.Contains(t, s.coalescer.Status(), "2 calls shared the result")
+}
+
+func TestCoalesceMiddlewareRefresh(t *testing.T) {
+	s := newCoalescingServer()
+	var runs atomic.Int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	handler := s.coalesceMiddleware(blockingHandler(&runs, started, release))
+
+	var wg sync.WaitGroup
+	for _, args := range []map[string]interface{}{
+		{"symbolName": "Foo"},
+		{"symbolName": "Foo", "refresh": true},
+		{"symbolName": "Foo", "stream": true},
+		{"symbolName": "Bar"},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = handler(context.Background(), callWithArguments(args))
+		}()
+		<-started
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(4), runs.Load())
+	assert.Contains(t, s.coalescer.Status(), "0 calls shared the result")
+
+	// Tools that are not read-only are never coalesced
+	rename := callWithArguments(map[string]interface{}{"symbolName": "Foo"})
+	rename.Params.Name = "rename_symbol"
+	_, _ = s.coalesceMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		assert.Empty(t, s.coalescer.inflight)
+		return mcp.NewToolResultText("renamed"), nil
+	})(context.Background(), rename)
+}
+
+func TestCoalesceSharesResultID(t *testing.T) {
+	s := newCoalescingServer()
+	s.results = newResultStore()
+	var runs atomic.Int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	blocking := blockingHandler(&runs, started, release)
+	handler := s.coalesceMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := blocking(ctx, request)
+		reportResultID(ctx, s.results.Record(sessionID(ctx), request.Params.Name, request.Params.Arguments, format.Document{}))
+		return result, err
+	})
+	request := callWithArguments(map[string]interface{}{"symbolName": "Foo"})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = handler(sessionContext("first"), request)
+	}()
+	<-started
+	key, err := s.coalesceKey(sessionContext("second"), request)
+	require.NoError(t, err)
+	waitForInflight(t, s.coalescer, key)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = handler(sessionContext("second"), request)
+	}()
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// The session that shared the run can compare its result
+	assert.Equal(t, int32(1), runs.Load())
+	_, err = s.results.Get("first", "r1")
+	assert.NoError(t, err)
+	_, err = s.results.Get("second", "r1")
+	assert.NoError(t, err)
+}
+
+func TestCoalesceAbandonedCall(t *testing.T) {
+	c := newCoalescer()
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		_, _, err := c.Do(leaderCtx, "key", func(ctx context.Context) (*mcp.CallToolResult, error) {
+			runs.Add(1)
+			<-leaderCtx.Done()
+			return nil, leaderCtx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	waitForInflight(t, c, "key")
+
+	followerDone := make(chan *mcp.CallToolResult)
+	go func() {
+		result, _, err := c.Do(context.Background(), "key", func(ctx context.Context) (*mcp.CallToolResult, error) {
+			runs.Add(1)
+			return mcp.NewToolResultText("own result"), nil
+		})
+		assert.NoError(t, err)
+		followerDone <- result
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-leaderDone
+
+	// The waiting call runs for itself instead of sharing the cancellation
+	result := <-followerDone
+	assert.Equal(t, "own result", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, int32(2), runs.Load())
+}
+
+func TestCoalesceKey(t *testing.T) {
+	s := newCoalescingServer()
+	ctx := context.Background()
+
+	key := func(args map[string]interface{}) string {
+		k, err := s.coalesceKey(ctx, callWithArguments(args))
+		require.NoError(t, err)
+		return k
+	}
+	base := key(map[string]interface{}{"symbolName": "Foo", "format": "markdown"})
+	assert.Equal(t, base, key(map[string]interface{}{"format": "markdown", "symbolName": "Foo", "timeout_ms": float64(100), "refresh": false}))
+	assert.NotEqual(t, base, key(map[string]interface{}{"symbolName": "Foo", "format": "plain"}))
+
+	references := callWithArguments(map[string]interface{}{"symbolName": "Foo", "format": "markdown"})
+	references.Params.Name = "references"
+	other, err := s.coalesceKey(ctx, references)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, other)
+}
+
+func TestWithRefreshParameter(t *testing.T) {
+	s := &mcpServer{}
+	definition := mcp.NewTool("definition", mcp.WithString("symbolName", mcp.Required()))
+	rename := mcp.NewTool("rename_symbol", mcp.WithString("symbolName", mcp.Required()))
+
+	listed := s.withRefreshParameter(context.Background(), []mcp.Tool{definition, rename})
+	assert.Contains(t, listed[0].InputSchema.Properties, "refresh")
+	assert.NotContains(t, listed[1].InputSchema.Properties, "refresh")
+	// The registered tool is not modified
+	assert.NotContains(t, definition.InputSchema.Properties, "refresh")
+}
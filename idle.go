@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// keepaliveMethod is the no-op notification sent to keep language servers
+// alive. Servers ignore notifications starting with $/ that they do not know.
+const keepaliveMethod = "$/keepalive"
+
+// idleShutdowns are the values of the idle.shutdown setting
+var idleShutdowns = []string{"lsp", "process"}
+
+// validateIdle returns an error for unknown idle settings
+func validateIdle(cfg settings.IdleSettings) error {
+	if cfg.ShutdownMinutes > 0 && !slices.Contains(idleShutdowns, cfg.Shutdown) {
+		return fmt.Errorf("unknown idle shutdown %q, expected one of %v", cfg.Shutdown, idleShutdowns)
+	}
+	return nil
+}
+
+// idleMonitor tracks when a tool was last called
+type idleMonitor struct {
+	last    time.Time
+	running int
+	mu      sync.Mutex
+
+	// now returns the current time, replaceable in tests
+	now func() time.Time
+}
+
+func newIdleMonitor() *idleMonitor {
+	return &idleMonitor{last: time.Now(), now: time.Now}
+}
+
+// begin records the start of a tool call. It waits while the server is being
+// shut down for being idle.
+func (m *idleMonitor) begin() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running++
+}
+
+// end records the end of a tool call
+func (m *idleMonitor) end() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running--
+	m.last = m.now()
+}
+
+// whenIdle runs fn if no tool call has run for limit, and reports whether it
+// did. Tool calls do not start while fn runs.
+func (m *idleMonitor) whenIdle(limit time.Duration, fn func()) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running > 0 || m.now().Sub(m.last) < limit {
+		return false
+	}
+	fn()
+	return true
+}
+
+// idleMiddleware records tool calls as activity and starts the language
+// servers again if they were stopped for being idle. The status tool reports
+// on the stopped servers instead of starting them.
+func (s *mcpServer) idleMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s.idle.begin()
+		defer s.idle.end()
+		if request.Params.Name != statusTool {
+			if err := s.pool.Resume(); err != nil {
+				return s.toolError(ctx, request, tools.NewError(tools.KindServerNotReady, fmt.Errorf("failed to restart language server: %w", err))), nil
+			}
+		}
+		return next(ctx, request)
+	}
+}
+
+// watchIdle sends keepalives and shuts down after the configured time without
+// tool calls
+func (s *mcpServer) watchIdle(ctx context.Context) {
+	cfg := s.config.settings.Idle
+	if cfg.KeepaliveSeconds > 0 {
+		go s.sendKeepalives(ctx, time.Duration(cfg.KeepaliveSeconds)*time.Second)
+	}
+	if cfg.ShutdownMinutes > 0 {
+		limit := time.Duration(cfg.ShutdownMinutes) * time.Minute
+		go s.shutdownWhenIdle(ctx, limit, min(limit/10, time.Minute))
+	}
+}
+
+// sendKeepalives notifies every running language server at each interval
+func (s *mcpServer) sendKeepalives(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.pool.Stopped() {
+			continue
+		}
+		for _, client := range s.pool.Clients() {
+			if err := client.Notify(ctx, keepaliveMethod, struct{}{}); err != nil {
+				coreLogger.Debug("Keepalive failed: %v", err)
+			}
+		}
+	}
+}
+
+// shutdownWhenIdle checks at each interval whether no tool was called for
+// limit, and then stops the language servers or exits
+func (s *mcpServer) shutdownWhenIdle(ctx context.Context, limit, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.pool.Stopped() {
+			continue
+		}
+		if s.config.settings.Idle.Shutdown == "process" {
+			if s.idle.whenIdle(limit, func() {
+				coreLogger.Info("No tool calls for %v, shutting down", limit)
+			}) {
+				s.shutdown()
+				return
+			}
+			continue
+		}
+		s.idle.whenIdle(limit, func() {
+			coreLogger.Info("No tool calls for %v, stopping language servers until the next call", limit)
+			stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			s.pool.Stop(stopCtx)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIdle(t *testing.T) {
+	assert.NoError(t, validateIdle(settings.Default().Idle))
+	assert.NoError(t, validateIdle(settings.IdleSettings{ShutdownMinutes: 10, Shutdown: "process"}))
+	assert.EqualError(t, validateIdle(settings.IdleSettings{ShutdownMinutes: 10, Shutdown: "sleep"}),
+		`unknown idle shutdown "sleep", expected one of [lsp process]`)
+}
+
+func TestIdleMonitor(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := &idleMonitor{last: now, now: func() time.Time { return now }}
+	ran := 0
+	run := func() { ran++ }
+
+	now = now.Add(4 * time.Minute)
+	assert.False(t, m.whenIdle(5*time.Minute, run))
+
+	// Calls in progress are never idle, however long they run
+	m.begin()
+	now = now.Add(10 * time.Minute)
+	assert.False(t, m.whenIdle(5*time.Minute, run))
+	m.end()
+	assert.False(t, m.whenIdle(5*time.Minute, run))
+
+	now = now.Add(5 * time.Minute)
+	assert.True(t, m.whenIdle(5*time.Minute, run))
+	assert.Equal(t, 1, ran)
+}
+
+func TestIdleShutdownStopsAndResumes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan *lsp.Client, 4)
+	stopped := make(chan *lsp.Client, 4)
+	pool := newClientPool(config{settings: settings.Default()})
+	pool.startClient = startSleepingClient(t, started)
+	pool.stopClient = stopByKilling(stopped)
+	require.NoError(t, pool.Start(ctx))
+	<-started
+
+	s := &mcpServer{config: config{settings: settings.Default()}, pool: pool, idle: newIdleMonitor(), outputVersions: newOutputVersions("v1")}
+	go s.shutdownWhenIdle(ctx, 50*time.Millisecond, 10*time.Millisecond)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle language server was not stopped")
+	}
+	assert.True(t, pool.Stopped())
+
+	// The status tool does not start the server, other tools do
+	handler := s.idleMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	status := mcp.CallToolRequest{}
+	status.Params.Name = statusTool
+	_, err := handler(ctx, status)
+	require.NoError(t, err)
+	assert.True(t, pool.Stopped())
+
+	result, err := handler(ctx, callWithArguments(nil))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.False(t, pool.Stopped())
+	assert.Equal(t, <-started, pool.Active())
+}
+
+func TestIdleShutdownExits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := config{settings: settings.Default()}
+	cfg.settings.Idle.Shutdown = "process"
+	exited := make(chan struct{})
+	s := &mcpServer{config: cfg, pool: newClientPool(cfg), idle: newIdleMonitor(), shutdown: func() { close(exited) }}
+
+	go s.shutdownWhenIdle(ctx, 50*time.Millisecond, 10*time.Millisecond)
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle process did not exit")
+	}
+}
@@ -0,0 +1,7 @@
+// Placeholder file for helper.cpp
+#include <iostream>
+#include "helper.hpp"
+const int TEST_CONSTANT = 42;
+int TEST_VARIABLE = 100;  // A test variable used for integration testing purposes.
+
+void helperFunction() { std::cout << "Helper function" << std::endl; }
\ No newline at end of file
@@ -0,0 +1,7 @@
+// Placeholder file for clean.cpp
+#include <iostream>
+
+int main() {
+  std::cout << "Clean file" << std::endl;
+  return 0;
+}
\ No newline at end of file
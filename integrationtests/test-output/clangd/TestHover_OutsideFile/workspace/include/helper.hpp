@@ -0,0 +1 @@
+void helperFunction();
\ No newline at end of file
@@ -0,0 +1,10 @@
+// Placeholder file for types.cpp
+#include <iostream>
+
+void printType() { std::cout << "Type function" << std::endl; }
+
+struct TestStruct {
+  int value;
+};
+
+using TestType = int;
\ No newline at end of file
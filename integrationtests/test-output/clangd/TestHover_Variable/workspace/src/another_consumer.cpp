@@ -0,0 +1,4 @@
+// Placeholder file for another_consumer.cpp
+#include <iostream>
+
+void anotherConsume() { std::cout << "Another consume function" << std::endl; }
\ No newline at end of file
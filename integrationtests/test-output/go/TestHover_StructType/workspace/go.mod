@@ -0,0 +1,3 @@
+module github.com/isaacphi/mcp-language-server/integrationtests/test-output/go/workspace
+
+go 1.20
@@ -0,0 +1,6 @@
+package main
+
+// HelperFunction returns a string for testing
+func HelperFunction() string {
+	return "hello world"
+}
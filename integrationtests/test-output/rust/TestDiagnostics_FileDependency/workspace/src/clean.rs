@@ -0,0 +1,4 @@
+// A clean file with no errors for testing
+pub fn clean_function() -> String {
+    String::from("This file has no errors")
+}
\ No newline at end of file
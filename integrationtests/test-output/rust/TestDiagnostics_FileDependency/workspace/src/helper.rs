@@ -0,0 +1,6 @@
+// Helper functions for testing
+
+// A function that will be referenced from other files
+pub fn helper_function() -> String {
+    String::from("hello world")
+}
\ No newline at end of file
@@ -59,7 +59,7 @@ func TestFindIncomingCalls(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the FindIncomingCalls tool
-			result, err := tools.FindIncomingCalls(ctx, suite.Client, tc.symbolName)
+			result, err := tools.FindIncomingCalls(ctx, tools.NewToolContext(suite.Client), tc.symbolName)
 			if err != nil {
 				t.Fatalf("Failed to find incoming calls: %v", err)
 			}
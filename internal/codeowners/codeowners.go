@@ -0,0 +1,129 @@
+// Package codeowners reads CODEOWNERS files to tell which team owns a file.
+// Patterns follow gitignore syntax and the last matching rule wins, as on
+// GitHub and GitLab.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// Locations are the paths, relative to the repository root, where CODEOWNERS
+// files are looked for, in order
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// File is a parsed CODEOWNERS file
+type File struct {
+	rules []rule
+}
+
+// rule assigns owners to the paths matching a pattern
+type rule struct {
+	pattern string
+	matcher *gitignore.GitIgnore
+	owners  []string
+}
+
+// Parse reads CODEOWNERS rules. Comments, blank lines and GitLab section
+// headers such as "[Backend]" are skipped.
+func Parse(content string) *File {
+	file := &File{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		fields := strings.Fields(line)
+		file.rules = append(file.rules, rule{
+			pattern: fields[0],
+			matcher: gitignore.CompileIgnoreLines(fields[0]),
+			owners:  fields[1:],
+		})
+	}
+	return file
+}
+
+// Owners returns the owners of a path relative to the repository root. A path
+// matched by a rule without owners, or by no rule, has none.
+func (f *File) Owners(relPath string) []string {
+	relPath = filepath.ToSlash(relPath)
+	for i := len(f.rules) - 1; i >= 0; i-- {
+		if f.rules[i].matcher.MatchesPath(relPath) {
+			return f.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Cache loads the CODEOWNERS file of a workspace and reloads it when it changes
+type Cache struct {
+	workspaceDir string
+
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	file    *File
+}
+
+// NewCache creates a cache for the CODEOWNERS file of a workspace
+func NewCache(workspaceDir string) *Cache {
+	return &Cache{workspaceDir: workspaceDir}
+}
+
+// Owners returns the owners of a file, which may be absolute or relative to the
+// workspace. ok is false when the workspace has no CODEOWNERS file or the file
+// is outside the workspace.
+func (c *Cache) Owners(path string) (owners []string, ok bool, err error) {
+	file, err := c.Load()
+	if err != nil || file == nil {
+		return nil, false, err
+	}
+
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(c.workspaceDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, false, nil
+		}
+		path = rel
+	}
+	return file.Owners(path), true, nil
+}
+
+// Load returns the current CODEOWNERS file, or nil when there is none
+func (c *Cache) Load() (*File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, location := range Locations {
+		path := filepath.Join(c.workspaceDir, location)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if c.file != nil && c.path == path && info.ModTime().Equal(c.modTime) {
+			return c.file, nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		c.path, c.modTime, c.file = path, info.ModTime(), Parse(string(content))
+		return c.file, nil
+	}
+
+	c.path, c.file = "", nil
+	return nil, nil
+}
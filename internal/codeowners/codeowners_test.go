@@ -0,0 +1,69 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = `# Default owners
+*       @acme/platform
+
+[Frontend]
+*.ts    @acme/web # TypeScript
+/docs/  @acme/docs
+apps/**/payments/ @acme/payments @alice
+/internal/generated/
+`
+
+func TestOwners(t *testing.T) {
+	file := Parse(sample)
+
+	tests := []struct {
+		path   string
+		owners []string
+	}{
+		{"main.go", []string{"@acme/platform"}},
+		{"web/src/app.ts", []string{"@acme/web"}},
+		{"docs/guide.md", []string{"@acme/docs"}},
+		{"sub/docs/guide.md", []string{"@acme/platform"}},
+		{"apps/shop/payments/charge.go", []string{"@acme/payments", "@alice"}},
+		{"internal/generated/api.go", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.owners, file.Owners(tt.path))
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir)
+
+	_, ok, err := cache.Owners(filepath.Join(dir, "main.go"))
+	assert.NoError(t, err)
+	assert.False(t, ok, "no CODEOWNERS file yet")
+
+	path := filepath.Join(dir, ".github", "CODEOWNERS")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte("* @acme/platform\n"), 0644))
+
+	owners, ok, err := cache.Owners(filepath.Join(dir, "main.go"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"@acme/platform"}, owners)
+
+	// Edits are picked up
+	assert.NoError(t, os.WriteFile(path, []byte("* @acme/core\n"), 0644))
+	later := time.Now().Add(time.Second)
+	assert.NoError(t, os.Chtimes(path, later, later))
+	owners, _, _ = cache.Owners("main.go")
+	assert.Equal(t, []string{"@acme/core"}, owners)
+
+	_, ok, _ = cache.Owners("/elsewhere/main.go")
+	assert.False(t, ok, "files outside the workspace have no owners")
+}
@@ -0,0 +1,181 @@
+// Package debugbundle packages what is needed to diagnose a problem, such as
+// recent logs and JSON-RPC traffic, into a zip archive that users can attach
+// to bug reports. Everything is passed through Redact before it is written,
+// and file contents sent to the language server are left out.
+package debugbundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/redact"
+)
+
+// Bundle is the content of a debug bundle
+type Bundle struct {
+	// Version is the version of mcp-language-server
+	Version string
+
+	// Command is the language server command line
+	Command []string
+
+	// Settings is the configuration in use
+	Settings any
+
+	// ClientCapabilities and InitializeResult are the two sides of the
+	// initialize handshake
+	ClientCapabilities any
+	InitializeResult   any
+
+	// Logs are recent log messages, oldest first
+	Logs []string
+
+	// Exchanges are recent JSON-RPC messages, oldest first
+	Exchanges []lsp.Exchange
+}
+
+// redacted replaces secrets
+const redacted = redact.Replacement
+
+var (
+	// secretKey matches the names of settings and fields holding secrets
+	secretKey = regexp.MustCompile(`(?i)(api[_-]?key|token|secret|password|passwd|credential|private[_-]?key|authorization)`)
+
+	// secretPatterns match secrets in free text that redact.Secrets leaves
+	// alone because they could be code, such as any value of a secret field
+	secretPatterns = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		{regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret|password|passwd|credential|authorization)["']?\s*[:=]\s*["']?)(?:(?:bearer|basic)\s+)?[^"'\s,;&}]+`), "${1}" + redacted},
+		{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}" + redacted},
+	}
+)
+
+// Redact replaces secrets in text, such as tokens, passwords, credentials in
+// URLs and private keys, and replaces the home directory with ~
+func Redact(text string) string {
+	text = redact.Secrets(text)
+	for _, secret := range secretPatterns {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		text = strings.ReplaceAll(text, home, "~")
+	}
+	return text
+}
+
+// redactValue redacts a decoded JSON value. Values of secret fields are
+// replaced, strings are passed through Redact, and when omitText is set the
+// text fields carrying file contents are replaced by their size.
+func redactValue(value any, omitText bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			text, isString := field.(string)
+			switch {
+			case isString && secretKey.MatchString(key):
+				v[key] = redacted
+			case isString && omitText && key == "text":
+				v[key] = fmt.Sprintf("[%d bytes of file content omitted]", len(text))
+			default:
+				v[key] = redactValue(field, omitText)
+			}
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i], omitText)
+		}
+		return v
+	case string:
+		return Redact(v)
+	default:
+		return v
+	}
+}
+
+// redactJSON encodes a value as redacted, indented JSON
+func redactJSON(value any, omitText bool, indent bool) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	decoded = redactValue(decoded, omitText)
+	if indent {
+		return json.MarshalIndent(decoded, "", "  ")
+	}
+	return json.Marshal(decoded)
+}
+
+// Write writes the bundle as a zip archive
+func Write(w io.Writer, bundle Bundle) error {
+	archive := zip.NewWriter(w)
+
+	var version strings.Builder
+	version.WriteString(fmt.Sprintf("mcp-language-server: %s\n", bundle.Version))
+	version.WriteString(fmt.Sprintf("go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	version.WriteString(fmt.Sprintf("language server command: %s\n", Redact(strings.Join(bundle.Command, " "))))
+	version.WriteString(fmt.Sprintf("created: %s\n", time.Now().UTC().Format(time.RFC3339)))
+
+	capabilities, err := redactJSON(map[string]any{
+		"client": bundle.ClientCapabilities,
+		"server": bundle.InitializeResult,
+	}, false, true)
+	if err != nil {
+		return fmt.Errorf("failed to encode capabilities: %v", err)
+	}
+
+	settings, err := redactJSON(bundle.Settings, false, true)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %v", err)
+	}
+
+	var logs strings.Builder
+	for _, line := range bundle.Logs {
+		logs.WriteString(Redact(line) + "\n")
+	}
+
+	var exchanges strings.Builder
+	for _, exchange := range bundle.Exchanges {
+		line, err := redactJSON(exchange, true, false)
+		if err != nil {
+			return fmt.Errorf("failed to encode exchange: %v", err)
+		}
+		exchanges.Write(line)
+		exchanges.WriteString("\n")
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"version.txt", []byte(version.String())},
+		{"capabilities.json", capabilities},
+		{"settings.json", settings},
+		{"logs.txt", []byte(logs.String())},
+		{"exchanges.jsonl", []byte(exchanges.String())},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %v", file.name, err)
+		}
+		if _, err := f.Write(file.content); err != nil {
+			return fmt.Errorf("failed to write %s: %v", file.name, err)
+		}
+	}
+	return archive.Close()
+}
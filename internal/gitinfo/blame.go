@@ -0,0 +1,146 @@
+package gitinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notCommitted is the commit git blame reports for uncommitted lines
+const notCommitted = "0000000000000000000000000000000000000000"
+
+// BlameLine is the last commit that changed a line
+type BlameLine struct {
+	// Commit is the abbreviated commit hash, empty for uncommitted lines
+	Commit string
+	Author string
+	Time   time.Time
+}
+
+// Describe summarizes the commit for a result, e.g. "Jane Doe, 3 months ago
+// (1a2b3c4d)", relative to now
+func (b BlameLine) Describe(now time.Time) string {
+	if b.Commit == "" {
+		return "not committed yet"
+	}
+	return fmt.Sprintf("%s, %s (%s)", b.Author, Age(now, b.Time), b.Commit)
+}
+
+// Blame returns the last commit that changed each of the 1-indexed lines of a
+// file. Lines git does not know, such as lines of untracked files, are left
+// out.
+func Blame(ctx context.Context, path string, lines []int) (map[int]BlameLine, error) {
+	if len(lines) == 0 {
+		return map[int]BlameLine{}, nil
+	}
+	args := []string{"blame", "--porcelain"}
+	for _, r := range lineRanges(lines) {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", r[0], r[1]))
+	}
+	args = append(args, "--", filepath.Base(path))
+	output, err := runGit(ctx, filepath.Dir(path), args...)
+	if err != nil {
+		return nil, fmt.Errorf("git blame failed: %v", err)
+	}
+	return parseBlame(output, lines), nil
+}
+
+// lineRanges merges lines into sorted ranges of consecutive lines
+func lineRanges(lines []int) [][2]int {
+	sorted := append([]int(nil), lines...)
+	sort.Ints(sorted)
+	var ranges [][2]int
+	for _, line := range sorted {
+		if n := len(ranges); n > 0 && line <= ranges[n-1][1]+1 {
+			if line > ranges[n-1][1] {
+				ranges[n-1][1] = line
+			}
+			continue
+		}
+		ranges = append(ranges, [2]int{line, line})
+	}
+	return ranges
+}
+
+// parseBlame reads the output of git blame --porcelain, keeping the wanted
+// lines. The details of a commit are only given the first time it appears.
+func parseBlame(output []byte, wanted []int) map[int]BlameLine {
+	want := make(map[int]bool, len(wanted))
+	for _, line := range wanted {
+		want[line] = true
+	}
+
+	commits := make(map[string]*BlameLine)
+	result := make(map[int]BlameLine)
+	var current *BlameLine
+	var currentLine int
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// The content of the line ends its entry
+			if current != nil && want[currentLine] {
+				result[currentLine] = *current
+			}
+			current = nil
+		case current == nil:
+			// Header: <commit> <original line> <final line> [<lines in group>]
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				continue
+			}
+			line, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+			commit, ok := commits[fields[0]]
+			if !ok {
+				commit = &BlameLine{}
+				if fields[0] != notCommitted {
+					commit.Commit = fields[0][:min(8, len(fields[0]))]
+				}
+				commits[fields[0]] = commit
+			}
+			current, currentLine = commit, line
+		case strings.HasPrefix(text, "author "):
+			current.Author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.Time = time.Unix(seconds, 0)
+			}
+		}
+	}
+	return result
+}
+
+// Age describes how long before now a time was, e.g. "3 days ago"
+func Age(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Hour:
+		return "less than an hour ago"
+	case d < 24*time.Hour:
+		return ago(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return ago(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return ago(int(d/(30*24*time.Hour)), "month")
+	default:
+		return ago(int(d/(365*24*time.Hour)), "year")
+	}
+}
+
+func ago(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit + " ago"
+	}
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
@@ -0,0 +1,88 @@
+package gitinfo
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const porcelain = `1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d 1 1 1
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1700000000
+author-tz +0000
+summary Add handler
+filename main.go
+	package main
+0000000000000000000000000000000000000000 3 3 1
+author Not Committed Yet
+author-time 1710000000
+filename main.go
+	func main() {}
+1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d 4 4
+filename main.go
+	}
+`
+
+func TestParseBlame(t *testing.T) {
+	lines := parseBlame([]byte(porcelain), []int{1, 3, 4})
+	jane := BlameLine{Commit: "1a2b3c4d", Author: "Jane Doe", Time: time.Unix(1700000000, 0)}
+	assert.Equal(t, map[int]BlameLine{
+		1: jane,
+		3: {Author: "Not Committed Yet", Time: time.Unix(1710000000, 0)},
+		4: jane,
+	}, lines)
+
+	now := time.Unix(1700000000, 0).Add(72 * time.Hour)
+	assert.Equal(t, "Jane Doe, 3 days ago (1a2b3c4d)", lines[1].Describe(now))
+	assert.Equal(t, "not committed yet", lines[3].Describe(now))
+}
+
+func TestLineRanges(t *testing.T) {
+	assert.Equal(t, [][2]int{{3, 5}, {9, 9}, {12, 13}}, lineRanges([]int{13, 4, 3, 9, 5, 12, 4}))
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago      time.Duration
+		expected string
+	}{
+		{10 * time.Minute, "less than an hour ago"},
+		{time.Hour, "1 hour ago"},
+		{50 * time.Hour, "2 days ago"},
+		{70 * 24 * time.Hour, "2 months ago"},
+		{800 * 24 * time.Hour, "2 years ago"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Age(now, now.Add(-tt.ago)))
+	}
+}
+
+func TestBlame(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	git(t, dir, "init", "--quiet")
+	writeFile(t, path, "package main\n\nfunc main() {}\n")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "--quiet", "-m", "initial")
+	writeFile(t, path, "package main\n\nfunc main() { run() }\n")
+
+	lines, err := Blame(context.Background(), path, []int{1, 3})
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "test", lines[1].Author)
+	assert.Len(t, lines[1].Commit, 8)
+	assert.Equal(t, "not committed yet", lines[3].Describe(time.Now()))
+
+	_, err = Blame(context.Background(), filepath.Join(dir, "untracked.go"), []int{1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+// Package gitinfo tells how files in a workspace stand in git: their working
+// tree status, the current branch, and whether they changed on the branch
+// compared to the repository's default branch. It runs the git CLI.
+package gitinfo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAge is how long a computed status is reused. Results of one tool call
+// share a status, later calls see new changes.
+const maxAge = 2 * time.Second
+
+// Status is the git state of a repository
+type Status struct {
+	// Root is the top level directory of the repository
+	Root string
+
+	// Branch is the current branch, empty when HEAD is detached
+	Branch string
+
+	// DefaultBranch is the branch changes are compared to, e.g. origin/main,
+	// empty when none was found
+	DefaultBranch string
+
+	// files holds the working tree status of changed files by path relative
+	// to Root
+	files map[string]string
+
+	// branchChanges holds the files changed on the branch since it left the
+	// default branch
+	branchChanges map[string]bool
+}
+
+// File describes the git state of one file
+type File struct {
+	// Status is modified, added, deleted, renamed, untracked or conflicted,
+	// or empty for files without uncommitted changes
+	Status string
+
+	// ChangedOnBranch is set for files changed on the current branch
+	// compared to the default branch
+	ChangedOnBranch bool
+}
+
+// File returns the git state of a file, which may be absolute or relative to
+// the repository root. ok is false for files outside the repository.
+func (s *Status) File(path string) (file File, ok bool) {
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			// git reports the root with symlinks resolved
+			resolved, resolveErr := filepath.EvalSymlinks(path)
+			if resolveErr != nil {
+				return File{}, false
+			}
+			if rel, err = filepath.Rel(s.Root, resolved); err != nil || strings.HasPrefix(rel, "..") {
+				return File{}, false
+			}
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(path)
+	return File{Status: s.files[path], ChangedOnBranch: s.branchChanges[path]}, true
+}
+
+// Describe summarizes the git state of a file for a result header, e.g.
+// "modified, changed on feature-x vs main", or "unchanged"
+func (s *Status) Describe(file File) string {
+	var parts []string
+	if file.Status != "" {
+		parts = append(parts, file.Status)
+	}
+	if file.ChangedOnBranch {
+		parts = append(parts, fmt.Sprintf("changed on %s vs %s", s.branchName(), s.DefaultBranch))
+	}
+	if len(parts) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary describes the branch for the top of a result
+func (s *Status) Summary() string {
+	summary := "Git branch: " + s.branchName()
+	if s.DefaultBranch != "" {
+		summary += fmt.Sprintf(" (compared to %s)", s.DefaultBranch)
+	}
+	return summary
+}
+
+func (s *Status) branchName() string {
+	if s.Branch == "" {
+		return "detached HEAD"
+	}
+	return s.Branch
+}
+
+// Cache computes the status of the repository containing a workspace, reusing
+// it for a short while
+type Cache struct {
+	workspaceDir string
+	now          func() time.Time
+
+	mu       sync.Mutex
+	status   *Status
+	loadedAt time.Time
+}
+
+// NewCache creates a cache for the repository containing workspaceDir
+func NewCache(workspaceDir string) *Cache {
+	return &Cache{workspaceDir: workspaceDir, now: time.Now}
+}
+
+// Load returns the status of the repository, or nil when the workspace is not
+// in a git repository or git is not installed
+func (c *Cache) Load(ctx context.Context) (*Status, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.status != nil && c.now().Sub(c.loadedAt) < maxAge {
+		return c.status, nil
+	}
+
+	root, err := runGit(ctx, c.workspaceDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		// Not a repository, or no git
+		return nil, nil
+	}
+	status := &Status{
+		Root:          strings.TrimSpace(string(root)),
+		files:         make(map[string]string),
+		branchChanges: make(map[string]bool),
+	}
+
+	if branch, err := runGit(ctx, status.Root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		status.Branch = strings.TrimSpace(string(branch))
+	}
+
+	porcelain, err := runGit(ctx, status.Root, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %v", err)
+	}
+	status.files = parseStatus(porcelain)
+
+	status.DefaultBranch = c.defaultBranch(ctx, status.Root)
+	if status.DefaultBranch != "" && status.DefaultBranch != status.Branch {
+		if base, err := runGit(ctx, status.Root, "merge-base", "HEAD", status.DefaultBranch); err == nil {
+			changed, err := runGit(ctx, status.Root, "diff", "--name-only", "-z", strings.TrimSpace(string(base)), "HEAD")
+			if err == nil {
+				for _, path := range splitNul(changed) {
+					status.branchChanges[path] = true
+				}
+			}
+		}
+	}
+
+	c.status, c.loadedAt = status, c.now()
+	return status, nil
+}
+
+// defaultBranch returns the branch the remote's HEAD points to, or a local
+// main or master branch
+func (c *Cache) defaultBranch(ctx context.Context, root string) string {
+	if ref, err := runGit(ctx, root, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(string(ref))
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := runGit(ctx, root, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch
+		}
+	}
+	return ""
+}
+
+// parseStatus reads the output of git status --porcelain=v1 -z
+func parseStatus(output []byte) map[string]string {
+	files := make(map[string]string)
+	entries := splitNul(output)
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		files[path] = describeCode(code)
+		// Renames and copies are followed by the original path
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+	}
+	return files
+}
+
+// describeCode names the two letter status code of git status
+func describeCode(code string) string {
+	switch {
+	case code == "??":
+		return "untracked"
+	case code == "DD" || code == "AA" || strings.Contains(code, "U"):
+		return "conflicted"
+	case strings.Contains(code, "D"):
+		return "deleted"
+	case strings.Contains(code, "R"):
+		return "renamed"
+	case strings.Contains(code, "A"):
+		return "added"
+	default:
+		return "modified"
+	}
+}
+
+func splitNul(output []byte) []string {
+	var parts []string
+	for _, part := range bytes.Split(output, []byte{0}) {
+		if len(part) > 0 {
+			parts = append(parts, string(part))
+		}
+	}
+	return parts
+}
+
+// runGit runs git in a directory and returns its standard output
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,102 @@
+package gitinfo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// git runs a git command in dir, failing the test if it fails
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestParseStatus(t *testing.T) {
+	output := []byte(" M a.go\x00?? new.go\x00R  renamed.go\x00old.go\x00A  added.go\x00 D gone.go\x00UU conflict.go\x00")
+	assert.Equal(t, map[string]string{
+		"a.go":        "modified",
+		"new.go":      "untracked",
+		"renamed.go":  "renamed",
+		"added.go":    "added",
+		"gone.go":     "deleted",
+		"conflict.go": "conflicted",
+	}, parseStatus(output))
+}
+
+func TestCache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "--quiet", "--initial-branch=main")
+	writeFile(t, filepath.Join(dir, "a.go"), "package a\n")
+	writeFile(t, filepath.Join(dir, "b.go"), "package b\n")
+	writeFile(t, filepath.Join(dir, "c.go"), "package c\n")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "--quiet", "-m", "initial")
+
+	git(t, dir, "checkout", "--quiet", "-b", "feature")
+	writeFile(t, filepath.Join(dir, "a.go"), "package a // changed\n")
+	git(t, dir, "commit", "--quiet", "-am", "change a")
+	writeFile(t, filepath.Join(dir, "b.go"), "package b // uncommitted\n")
+	writeFile(t, filepath.Join(dir, "new.go"), "package a\n")
+
+	cache := NewCache(dir)
+	status, err := cache.Load(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, status)
+	assert.Equal(t, "feature", status.Branch)
+	assert.Equal(t, "main", status.DefaultBranch)
+	assert.Equal(t, "Git branch: feature (compared to main)", status.Summary())
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{filepath.Join(dir, "a.go"), "changed on feature vs main"},
+		{filepath.Join(dir, "b.go"), "modified"},
+		{"new.go", "untracked"},
+		{filepath.Join(dir, "c.go"), "unchanged"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			file, ok := status.File(tt.path)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, status.Describe(file))
+		})
+	}
+	_, ok := status.File(filepath.Join(t.TempDir(), "outside.go"))
+	assert.False(t, ok)
+
+	// The status is reused for a while
+	writeFile(t, filepath.Join(dir, "c.go"), "package c // changed\n")
+	again, err := cache.Load(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, status, again)
+
+	cache.now = func() time.Time { return time.Now().Add(maxAge) }
+	again, err = cache.Load(context.Background())
+	require.NoError(t, err)
+	file, _ := again.File("c.go")
+	assert.Equal(t, "modified", file.Status)
+}
+
+func TestCacheOutsideRepository(t *testing.T) {
+	status, err := NewCache(t.TempDir()).Load(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+}
@@ -0,0 +1,267 @@
+// Package journal records the files a session changes so that the workspace
+// can be restored to a snapshot taken earlier, e.g. as a checkpoint before a
+// risky refactor, without relying on git.
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Store keeps file contents by their hash, so that content shared by several
+// entries is kept once
+type Store struct {
+	contents map[string][]byte
+	mu       sync.RWMutex
+}
+
+// NewStore creates an empty content store
+func NewStore() *Store {
+	return &Store{contents: make(map[string][]byte)}
+}
+
+// Put stores content and returns its hash. Nil content, a file that does not
+// exist, has the hash "".
+func (s *Store) Put(content []byte) string {
+	if content == nil {
+		return ""
+	}
+	hash := Hash(content)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.contents[hash]; !ok {
+		s.contents[hash] = append([]byte{}, content...)
+	}
+	return hash
+}
+
+// Get returns the content with a hash
+func (s *Store) Get(hash string) ([]byte, bool) {
+	if hash == "" {
+		return nil, true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	content, ok := s.contents[hash]
+	return content, ok
+}
+
+// Hash returns the hash content is stored under
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Entry is one change to a file. Before and After are hashes in the store,
+// "" when the file did not exist.
+type Entry struct {
+	Seq    int
+	Tool   string
+	Path   string
+	Before string
+	After  string
+	Time   time.Time
+}
+
+// Snapshot marks a point in the journal that the workspace can be restored to
+type Snapshot struct {
+	Name string
+	Time time.Time
+
+	// Changed are the files changed since the snapshot, sorted
+	Changed []string
+
+	seq int
+}
+
+// Journal is the edit journal of a session
+type Journal struct {
+	store     *Store
+	now       func() time.Time
+	mu        sync.Mutex
+	entries   []Entry
+	snapshots []Snapshot
+	seq       int
+}
+
+// New creates an empty journal
+func New() *Journal {
+	return &Journal{store: NewStore(), now: time.Now}
+}
+
+// Record adds a change to a file made by a tool. before is nil for created
+// files and after is nil for deleted files.
+func (j *Journal) Record(tool, path string, before, after []byte) {
+	beforeHash, afterHash := j.store.Put(before), j.store.Put(after)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	j.entries = append(j.entries, Entry{
+		Seq:    j.seq,
+		Tool:   tool,
+		Path:   path,
+		Before: beforeHash,
+		After:  afterHash,
+		Time:   j.now(),
+	})
+}
+
+// Snapshot takes a snapshot of the files changed so far. An empty name is
+// replaced by snapshot-N. Names must be unique within the journal.
+func (j *Journal) Snapshot(name string) (Snapshot, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if name == "" {
+		name = fmt.Sprintf("snapshot-%d", len(j.snapshots)+1)
+	}
+	if _, ok := j.find(name); ok {
+		return Snapshot{}, fmt.Errorf("a snapshot named %s already exists", name)
+	}
+	snapshot := Snapshot{Name: name, Time: j.now(), seq: j.seq}
+	j.snapshots = append(j.snapshots, snapshot)
+	return snapshot, nil
+}
+
+// Snapshots returns the snapshots in the order they were taken, with the
+// files changed since each
+func (j *Journal) Snapshots() []Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	snapshots := make([]Snapshot, len(j.snapshots))
+	for i, snapshot := range j.snapshots {
+		snapshot.Changed = j.changedSince(snapshot.seq)
+		snapshots[i] = snapshot
+	}
+	return snapshots
+}
+
+// find returns the snapshot with a name
+func (j *Journal) find(name string) (Snapshot, bool) {
+	for _, snapshot := range j.snapshots {
+		if snapshot.Name == name {
+			return snapshot, true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// changedSince returns the files with entries after seq, sorted
+func (j *Journal) changedSince(seq int) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, entry := range j.entries {
+		if entry.Seq > seq && !seen[entry.Path] {
+			seen[entry.Path] = true
+			paths = append(paths, entry.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// RestoreResult lists what a restore did to each file
+type RestoreResult struct {
+	Restored []string
+	Deleted  []string
+
+	// Skipped are files changed outside the server since it last wrote them,
+	// with the reason
+	Skipped map[string]string
+}
+
+// restoreStep is what a restore does to one file
+type restoreStep struct {
+	path    string
+	content []byte
+	remove  bool
+}
+
+// Restore returns the files changed since a snapshot to their content at the
+// snapshot, deleting files created since. Files whose content is no longer
+// what the server last wrote were changed by someone else and are skipped
+// unless force is set.
+func (j *Journal) Restore(name string, force bool) (RestoreResult, error) {
+	steps, result, err := j.plan(name, force)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	// The writes are recorded in the journal like those of any other tool, so
+	// they must not happen while it is locked
+	for _, step := range steps {
+		if step.remove {
+			if err := utilities.RemoveFile(step.path); err != nil && !os.IsNotExist(err) {
+				result.Skipped[step.path] = err.Error()
+				continue
+			}
+			result.Deleted = append(result.Deleted, step.path)
+			continue
+		}
+		if err := utilities.WriteFile(step.path, step.content); err != nil {
+			result.Skipped[step.path] = err.Error()
+			continue
+		}
+		result.Restored = append(result.Restored, step.path)
+	}
+	return result, nil
+}
+
+// plan works out what restoring to a snapshot does to each file
+func (j *Journal) plan(name string, force bool) ([]restoreStep, RestoreResult, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	snapshot, ok := j.find(name)
+	if !ok {
+		return nil, RestoreResult{}, fmt.Errorf("no snapshot named %s", name)
+	}
+
+	// The content at the snapshot is the content before the first change
+	// after it, the content the server last left is the content after the
+	// last change
+	first := make(map[string]Entry)
+	last := make(map[string]Entry)
+	for _, entry := range j.entries {
+		if entry.Seq <= snapshot.seq {
+			continue
+		}
+		if _, ok := first[entry.Path]; !ok {
+			first[entry.Path] = entry
+		}
+		last[entry.Path] = entry
+	}
+
+	result := RestoreResult{Skipped: make(map[string]string)}
+	var steps []restoreStep
+	for _, path := range j.changedSince(snapshot.seq) {
+		current := ""
+		if content, err := os.ReadFile(path); err == nil {
+			current = Hash(content)
+		}
+		target := first[path].Before
+		if current == target {
+			continue
+		}
+		if current != last[path].After && !force {
+			result.Skipped[path] = "changed outside the server since it was last modified, restore with force to overwrite"
+			continue
+		}
+		if target == "" {
+			steps = append(steps, restoreStep{path: path, remove: true})
+			continue
+		}
+		content, ok := j.store.Get(target)
+		if !ok {
+			result.Skipped[path] = "content at the snapshot is missing from the store"
+			continue
+		}
+		steps = append(steps, restoreStep{path: path, content: content})
+	}
+	return steps, result, nil
+}
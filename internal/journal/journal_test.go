@@ -0,0 +1,131 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// write changes a file on disk and records the change like the server does
+func write(t *testing.T, j *Journal, path, content string) {
+	t.Helper()
+	before, err := os.ReadFile(path)
+	if err != nil {
+		before = nil
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	j.Record("edit_file", path, before, []byte(content))
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	hash := store.Put([]byte("package main\n"))
+	assert.Equal(t, hash, store.Put([]byte("package main\n")))
+	assert.Len(t, store.contents, 1)
+
+	content, ok := store.Get(hash)
+	assert.True(t, ok)
+	assert.Equal(t, "package main\n", string(content))
+
+	assert.Equal(t, "", store.Put(nil))
+	content, ok = store.Get("")
+	assert.True(t, ok)
+	assert.Nil(t, content)
+
+	_, ok = store.Get("sha256:missing")
+	assert.False(t, ok)
+}
+
+func TestSnapshotNames(t *testing.T) {
+	j := New()
+	first, err := j.Snapshot("")
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot-1", first.Name)
+
+	_, err = j.Snapshot("before-rename")
+	require.NoError(t, err)
+	_, err = j.Snapshot("before-rename")
+	assert.EqualError(t, err, "a snapshot named before-rename already exists")
+
+	var names []string
+	for _, snapshot := range j.Snapshots() {
+		names = append(names, snapshot.Name)
+	}
+	assert.Equal(t, []string{"snapshot-1", "before-rename"}, names)
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	created := filepath.Join(dir, "created.go")
+	untouched := filepath.Join(dir, "untouched.go")
+	require.NoError(t, os.WriteFile(edited, []byte("v1"), 0644))
+	require.NoError(t, os.WriteFile(untouched, []byte("v1"), 0644))
+
+	j := New()
+	write(t, j, untouched, "before snapshot")
+	_, err := j.Snapshot("checkpoint")
+	require.NoError(t, err)
+	write(t, j, edited, "v2")
+	write(t, j, edited, "v3")
+	write(t, j, created, "new file")
+
+	snapshots := j.Snapshots()
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, []string{created, edited}, snapshots[0].Changed)
+
+	result, err := j.Restore("checkpoint", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{edited}, result.Restored)
+	assert.Equal(t, []string{created}, result.Deleted)
+	assert.Empty(t, result.Skipped)
+
+	assert.Equal(t, "v1", read(t, edited))
+	assert.Equal(t, "before snapshot", read(t, untouched))
+	_, err = os.Stat(created)
+	assert.True(t, os.IsNotExist(err))
+
+	// Restoring again finds nothing to do
+	result, err = j.Restore("checkpoint", false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Restored)
+	assert.Empty(t, result.Deleted)
+}
+
+func TestRestoreSkipsFilesChangedOutsideServer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0644))
+
+	j := New()
+	_, err := j.Snapshot("checkpoint")
+	require.NoError(t, err)
+	write(t, j, path, "v2")
+	require.NoError(t, os.WriteFile(path, []byte("changed by hand"), 0644))
+
+	result, err := j.Restore("checkpoint", false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Restored)
+	assert.Contains(t, result.Skipped[path], "changed outside the server")
+	assert.Equal(t, "changed by hand", read(t, path))
+
+	result, err = j.Restore("checkpoint", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, result.Restored)
+	assert.Equal(t, "v1", read(t, path))
+}
+
+func TestRestoreUnknownSnapshot(t *testing.T) {
+	_, err := New().Restore("missing", false)
+	assert.EqualError(t, err, "no snapshot named missing")
+}
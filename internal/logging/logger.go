@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 // LogLevel represents the severity of a log message
@@ -76,6 +77,18 @@ var TestOutput io.Writer
 // logMu protects concurrent modifications to logging config
 var logMu sync.Mutex
 
+// redact, when set, is applied to every log message, see SetRedact
+var redact func(string) string
+
+// recentSize is the number of log messages kept in memory for debug bundles
+const recentSize = 2000
+
+var (
+	recent     = make([]string, 0, recentSize)
+	recentNext int
+	recentMu   sync.Mutex
+)
+
 // Initialize from environment variables
 func init() {
 	// Set default levels for each component
@@ -192,12 +205,20 @@ func (l *ComponentLogger) log(level LogLevel, format string, v ...any) {
 	}
 
 	message := fmt.Sprintf(format, v...)
+	logMu.Lock()
+	redactMessage := redact
+	logMu.Unlock()
+	if redactMessage != nil {
+		message = redactMessage(message)
+	}
 	logMessage := fmt.Sprintf("[%s][%s] %s", level, l.component, message)
 
 	if err := log.Output(3, logMessage); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to output log: %v\n", err)
 	}
 
+	remember(time.Now().Format("2006/01/02 15:04:05.000") + " " + logMessage)
+
 	// Write to test output if set
 	if TestOutput != nil {
 		if _, err := fmt.Fprintln(TestOutput, logMessage); err != nil {
@@ -259,6 +280,14 @@ func SetWriter(w io.Writer) {
 	log.SetOutput(Writer)
 }
 
+// SetRedact sets a function that removes secrets from log messages before they
+// are written or kept for debug bundles. Nil logs messages as they are.
+func SetRedact(f func(string) string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	redact = f
+}
+
 // SetupFileLogging configures logging to a file in addition to stderr
 func SetupFileLogging(filePath string) error {
 	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -274,6 +303,28 @@ func SetupFileLogging(filePath string) error {
 	return nil
 }
 
+// remember keeps a log message in the in-memory buffer of recent messages
+func remember(message string) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	if len(recent) < recentSize {
+		recent = append(recent, message)
+		return
+	}
+	recent[recentNext] = message
+	recentNext = (recentNext + 1) % recentSize
+}
+
+// Recent returns the most recent log messages that passed their level
+// threshold, oldest first
+func Recent() []string {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	messages := make([]string, 0, len(recent))
+	messages = append(messages, recent[recentNext:]...)
+	return append(messages, recent[:recentNext]...)
+}
+
 // SetupTestLogging configures logging for tests
 func SetupTestLogging(captureOutput io.Writer) {
 	logMu.Lock()
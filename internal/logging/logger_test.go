@@ -106,3 +106,22 @@ func TestLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestSetRedact(t *testing.T) {
+	originalWriter := Writer
+	var buf bytes.Buffer
+	SetWriter(&buf)
+	SetRedact(func(message string) string { return strings.ReplaceAll(message, "hunter2", "[REDACTED]") })
+	defer func() {
+		SetRedact(nil)
+		SetWriter(originalWriter)
+	}()
+
+	NewLogger(Core).Error("password is hunter2")
+	if strings.Contains(buf.String(), "hunter2") || !strings.Contains(buf.String(), "password is [REDACTED]") {
+		t.Errorf("Expected the message to be redacted: %s", buf.String())
+	}
+	if recent := Recent(); strings.Contains(recent[len(recent)-1], "hunter2") {
+		t.Errorf("Expected the remembered message to be redacted: %s", recent[len(recent)-1])
+	}
+}
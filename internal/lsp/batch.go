@@ -0,0 +1,100 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// maxBatchConcurrency is how many requests of a batch are sent to the server
+// at once
+const maxBatchConcurrency = 8
+
+// BatchPosition is a position in a file that a batched request is made at
+type BatchPosition struct {
+	Path     string
+	Position protocol.Position
+}
+
+// BatchResult is the result of a batched request at one position
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// DocumentOpener opens files in the language server before requests about
+// them. *Client implements it.
+type DocumentOpener interface {
+	OpenFile(ctx context.Context, path string) error
+}
+
+// BatchRequest makes a positional request at many positions and returns the
+// results in the order of the positions. Every file is opened once before any
+// request is sent, then the requests run concurrently. Positions in files that
+// cannot be opened, and requests not sent before ctx is done, get an error as
+// their result.
+func BatchRequest[T any](ctx context.Context, opener DocumentOpener, positions []BatchPosition,
+	request func(ctx context.Context, params protocol.TextDocumentPositionParams) (T, error)) []BatchResult[T] {
+	results := make([]BatchResult[T], len(positions))
+
+	openErrors := make(map[string]error)
+	for _, position := range positions {
+		if _, opened := openErrors[position.Path]; opened {
+			continue
+		}
+		openErrors[position.Path] = opener.OpenFile(ctx, position.Path)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxBatchConcurrency)
+	for i, position := range positions {
+		if err := openErrors[position.Path]; err != nil {
+			results[i].Err = fmt.Errorf("failed to open %s: %w", position.Path, err)
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i].Value, results[i].Err = request(ctx, protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(position.Path)},
+				Position:     position.Position,
+			})
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// BatchHover requests hover information at many positions
+func (c *Client) BatchHover(ctx context.Context, positions []BatchPosition) []BatchResult[protocol.Hover] {
+	return BatchRequest(ctx, c, positions, func(ctx context.Context, params protocol.TextDocumentPositionParams) (protocol.Hover, error) {
+		return c.Hover(ctx, protocol.HoverParams{TextDocumentPositionParams: params})
+	})
+}
+
+// BatchDefinition requests the definitions of the symbols at many positions
+func (c *Client) BatchDefinition(ctx context.Context, positions []BatchPosition) []BatchResult[protocol.Or_Result_textDocument_definition] {
+	return BatchRequest(ctx, c, positions, func(ctx context.Context, params protocol.TextDocumentPositionParams) (protocol.Or_Result_textDocument_definition, error) {
+		return c.Definition(ctx, protocol.DefinitionParams{TextDocumentPositionParams: params})
+	})
+}
+
+// BatchReferences requests the references to the symbols at many positions
+func (c *Client) BatchReferences(ctx context.Context, positions []BatchPosition, includeDeclaration bool) []BatchResult[[]protocol.Location] {
+	return BatchRequest(ctx, c, positions, func(ctx context.Context, params protocol.TextDocumentPositionParams) ([]protocol.Location, error) {
+		return c.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: params,
+			Context:                    protocol.ReferenceContext{IncludeDeclaration: includeDeclaration},
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOpener records the files it opens and fails for missing ones
+type fakeOpener struct {
+	opened []string
+	mu     sync.Mutex
+}
+
+func (f *fakeOpener) OpenFile(ctx context.Context, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opened = append(f.opened, path)
+	if path == "/ws/missing.go" {
+		return errors.New("no such file")
+	}
+	return nil
+}
+
+func TestBatchRequest(t *testing.T) {
+	opener := &fakeOpener{}
+	var positions []BatchPosition
+	for i := 0; i < 20; i++ {
+		positions = append(positions, BatchPosition{Path: "/ws/a.go", Position: protocol.Position{Line: uint32(i)}})
+	}
+	positions = append(positions,
+		BatchPosition{Path: "/ws/missing.go"},
+		BatchPosition{Path: "/ws/b.go", Position: protocol.Position{Line: 100}},
+	)
+
+	var running, most atomic.Int32
+	results := BatchRequest(context.Background(), opener, positions, func(ctx context.Context, params protocol.TextDocumentPositionParams) (uint32, error) {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := most.Load()
+			if now <= seen || most.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		// Later positions answer first
+		time.Sleep(time.Duration(30-params.Position.Line%30) * time.Millisecond / 10)
+		return params.Position.Line, nil
+	})
+
+	// Each file is opened once
+	assert.Equal(t, []string{"/ws/a.go", "/ws/missing.go", "/ws/b.go"}, opener.opened)
+
+	// Results come back in the order of the positions
+	assert.Len(t, results, len(positions))
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, results[i].Err)
+		assert.Equal(t, uint32(i), results[i].Value)
+	}
+	assert.ErrorContains(t, results[20].Err, "failed to open /ws/missing.go")
+	assert.Equal(t, uint32(100), results[21].Value)
+
+	assert.LessOrEqual(t, most.Load(), int32(maxBatchConcurrency))
+	assert.Greater(t, most.Load(), int32(1))
+}
+
+func TestBatchRequestCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	positions := make([]BatchPosition, maxBatchConcurrency*2)
+	for i := range positions {
+		positions[i] = BatchPosition{Path: "/ws/a.go"}
+	}
+	results := BatchRequest(ctx, &fakeOpener{}, positions, func(ctx context.Context, params protocol.TextDocumentPositionParams) (string, error) {
+		return "", ctx.Err()
+	})
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
 )
 
 type Client struct {
@@ -41,9 +42,31 @@ type Client struct {
 	diagnostics   map[protocol.DocumentUri][]protocol.Diagnostic
 	diagnosticsMu sync.RWMutex
 
+	// Diagnostic listeners notified when the server publishes diagnostics
+	diagnosticListeners   map[int]DiagnosticsListener
+	nextListenerID        int
+	diagnosticListenersMu sync.RWMutex
+
 	// Files are currently opened by the LSP
 	openFiles   map[string]*OpenFileInfo
 	openFilesMu sync.RWMutex
+
+	// languageId overrides applied when opening files
+	languageOverrides   []LanguageOverride
+	languageOverridesMu sync.RWMutex
+
+	// done is closed when the connection to the server is lost
+	done chan struct{}
+
+	// exchanges remembers the latest JSON-RPC messages for debug bundles
+	exchanges *exchangeLog
+
+	// Capabilities negotiated in the initialize handshake
+	clientCapabilities protocol.ClientCapabilities
+	initializeResult   *protocol.InitializeResult
+
+	// workspaceDir is the root the server was initialized with
+	workspaceDir string
 }
 
 func NewClient(command string, args ...string) (*Client, error) {
@@ -75,7 +98,10 @@ func NewClient(command string, args ...string) (*Client, error) {
 		notificationHandlers:  make(map[string]NotificationHandler),
 		serverRequestHandlers: make(map[string]ServerRequestHandler),
 		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		diagnosticListeners:   make(map[int]DiagnosticsListener),
 		openFiles:             make(map[string]*OpenFileInfo),
+		done:                  make(chan struct{}),
+		exchanges:             &exchangeLog{},
 	}
 
 	// Start the LSP server process
@@ -114,11 +140,12 @@ func (c *Client) RegisterServerRequestHandler(method string, handler ServerReque
 }
 
 func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
+	c.workspaceDir = workspaceDir
 	initParams := &protocol.InitializeParams{
 		WorkspaceFoldersInitializeParams: protocol.WorkspaceFoldersInitializeParams{
 			WorkspaceFolders: []protocol.WorkspaceFolder{
 				{
-					URI:  protocol.URI("file://" + workspaceDir),
+					URI:  string(protocol.URIFromPath(workspaceDir)),
 					Name: workspaceDir,
 				},
 			},
@@ -131,8 +158,13 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 				Version: "0.1.0",
 			},
 			RootPath: workspaceDir,
-			RootURI:  protocol.DocumentUri("file://" + workspaceDir),
+			RootURI:  protocol.URIFromPath(workspaceDir),
 			Capabilities: protocol.ClientCapabilities{
+				// Positions in utf-8 are byte offsets into the text read from
+				// files, so prefer it over utf-16, which every server supports
+				General: &protocol.GeneralClientCapabilities{
+					PositionEncodings: []protocol.PositionEncodingKind{protocol.UTF8, protocol.UTF16},
+				},
 				Workspace: protocol.WorkspaceClientCapabilities{
 					Configuration: true,
 					DidChangeConfiguration: protocol.DidChangeConfigurationClientCapabilities{
@@ -142,6 +174,11 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						DynamicRegistration:    true,
 						RelativePatternSupport: true,
 					},
+					Symbol: &protocol.WorkspaceSymbolClientCapabilities{
+						TagSupport: &protocol.ClientSymbolTagOptions{
+							ValueSet: []protocol.SymbolTag{protocol.DeprecatedSymbol},
+						},
+					},
 				},
 				TextDocument: protocol.TextDocumentClientCapabilities{
 					Synchronization: &protocol.TextDocumentSyncClientCapabilities{
@@ -149,7 +186,12 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						DidSave:             true,
 					},
 					Completion: protocol.CompletionClientCapabilities{
-						CompletionItem: protocol.ClientCompletionItemOptions{},
+						CompletionItem: protocol.ClientCompletionItemOptions{
+							DeprecatedSupport: true,
+							TagSupport: &protocol.CompletionItemTagOptions{
+								ValueSet: []protocol.CompletionItemTag{protocol.ComplDeprecated},
+							},
+						},
 					},
 					CodeLens: &protocol.CodeLensClientCapabilities{
 						DynamicRegistration: true,
@@ -158,7 +200,7 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 					CodeAction: protocol.CodeActionClientCapabilities{
 						CodeActionLiteralSupport: protocol.ClientCodeActionLiteralOptions{
 							CodeActionKind: protocol.ClientCodeActionKindOptions{
-								ValueSet: []protocol.CodeActionKind{},
+								ValueSet: []protocol.CodeActionKind{protocol.QuickFix},
 							},
 						},
 					},
@@ -195,13 +237,16 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 	if err := c.Call(ctx, "initialize", initParams, &result); err != nil {
 		return nil, fmt.Errorf("initialize failed: %w", err)
 	}
+	c.clientCapabilities = initParams.Capabilities
+	c.initializeResult = &result
 
 	if err := c.Notify(ctx, "initialized", struct{}{}); err != nil {
 		return nil, fmt.Errorf("initialized notification failed: %w", err)
 	}
 
 	// Register handlers
-	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
+	c.RegisterServerRequestHandler("workspace/applyEdit",
+		func(params json.RawMessage) (any, error) { return HandleApplyEdit(c, params) })
 	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
 	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
@@ -281,13 +326,39 @@ func (c *Client) WaitForServerReady(ctx context.Context) error {
 	return nil
 }
 
+// Done returns a channel that is closed when the connection to the language
+// server is lost, either because it exited or because the client was closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// SetLanguageOverrides configures glob based languageId overrides used when
+// opening files, for extensionless or unconventionally named files
+func (c *Client) SetLanguageOverrides(overrides []LanguageOverride) {
+	c.languageOverridesMu.Lock()
+	defer c.languageOverridesMu.Unlock()
+	c.languageOverrides = overrides
+}
+
+// WorkspaceDir returns the workspace root the server was initialized with
+func (c *Client) WorkspaceDir() string {
+	return c.workspaceDir
+}
+
+// LanguageID returns the languageId used for the file at path
+func (c *Client) LanguageID(path string) protocol.LanguageKind {
+	c.languageOverridesMu.RLock()
+	defer c.languageOverridesMu.RUnlock()
+	return DetectLanguageIDWithOverrides(path, c.languageOverrides)
+}
+
 type OpenFileInfo struct {
 	Version int32
 	URI     protocol.DocumentUri
 }
 
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(protocol.URIFromPath(filepath))
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; exists {
@@ -302,12 +373,31 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
+	// Language servers expect UTF-8, so legacy encodings are transcoded
+	text, enc := textenc.Decode(content)
+	if !enc.IsUTF8() {
+		lspLogger.Debug("Transcoding %s from %s", filepath, enc.Name)
+	}
+
+	if err := c.OpenDocument(ctx, protocol.DocumentUri(uri), c.LanguageID(filepath), text); err != nil {
+		return err
+	}
+
+	lspLogger.Debug("Opened file: %s", filepath)
+
+	return nil
+}
+
+// OpenDocument opens a document with the given content. Unlike OpenFile, the
+// document does not need to exist on disk, which allows in-memory documents
+// with untitled: URIs.
+func (c *Client) OpenDocument(ctx context.Context, uri protocol.DocumentUri, languageID protocol.LanguageKind, text string) error {
 	params := protocol.DidOpenTextDocumentParams{
 		TextDocument: protocol.TextDocumentItem{
-			URI:        protocol.DocumentUri(uri),
-			LanguageID: DetectLanguageID(uri),
+			URI:        uri,
+			LanguageID: languageID,
 			Version:    1,
-			Text:       string(content),
+			Text:       text,
 		},
 	}
 
@@ -316,30 +406,33 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	}
 
 	c.openFilesMu.Lock()
-	c.openFiles[uri] = &OpenFileInfo{
+	c.openFiles[string(uri)] = &OpenFileInfo{
 		Version: 1,
-		URI:     protocol.DocumentUri(uri),
+		URI:     uri,
 	}
 	c.openFilesMu.Unlock()
 
-	lspLogger.Debug("Opened file: %s", filepath)
-
 	return nil
 }
 
 func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(protocol.URIFromPath(filepath))
 
-	content, err := os.ReadFile(filepath)
+	content, err := textenc.ReadFile(filepath)
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
+	return c.ChangeDocument(ctx, protocol.DocumentUri(uri), content)
+}
+
+// ChangeDocument replaces the full content of an open document
+func (c *Client) ChangeDocument(ctx context.Context, uri protocol.DocumentUri, text string) error {
 	c.openFilesMu.Lock()
-	fileInfo, isOpen := c.openFiles[uri]
+	fileInfo, isOpen := c.openFiles[string(uri)]
 	if !isOpen {
 		c.openFilesMu.Unlock()
-		return fmt.Errorf("cannot notify change for unopened file: %s", filepath)
+		return fmt.Errorf("cannot notify change for unopened document: %s", uri)
 	}
 
 	// Increment version
@@ -350,14 +443,14 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 	params := protocol.DidChangeTextDocumentParams{
 		TextDocument: protocol.VersionedTextDocumentIdentifier{
 			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
-				URI: protocol.DocumentUri(uri),
+				URI: uri,
 			},
 			Version: version,
 		},
 		ContentChanges: []protocol.TextDocumentContentChangeEvent{
 			{
 				Value: protocol.TextDocumentContentChangeWholeDocument{
-					Text: string(content),
+					Text: text,
 				},
 			},
 		},
@@ -367,10 +460,13 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 }
 
 func (c *Client) CloseFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	return c.CloseDocument(ctx, protocol.URIFromPath(filepath))
+}
 
+// CloseDocument closes an open document by URI
+func (c *Client) CloseDocument(ctx context.Context, uri protocol.DocumentUri) error {
 	c.openFilesMu.Lock()
-	if _, exists := c.openFiles[uri]; !exists {
+	if _, exists := c.openFiles[string(uri)]; !exists {
 		c.openFilesMu.Unlock()
 		return nil // Already closed
 	}
@@ -378,23 +474,23 @@ func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 
 	params := protocol.DidCloseTextDocumentParams{
 		TextDocument: protocol.TextDocumentIdentifier{
-			URI: protocol.DocumentUri(uri),
+			URI: uri,
 		},
 	}
-	lspLogger.Debug("Closing file: %s", params.TextDocument.URI.Dir())
+	lspLogger.Debug("Closing document: %s", uri)
 	if err := c.Notify(ctx, "textDocument/didClose", params); err != nil {
 		return err
 	}
 
 	c.openFilesMu.Lock()
-	delete(c.openFiles, uri)
+	delete(c.openFiles, string(uri))
 	c.openFilesMu.Unlock()
 
 	return nil
 }
 
 func (c *Client) IsFileOpen(filepath string) bool {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(protocol.URIFromPath(filepath))
 	c.openFilesMu.RLock()
 	defer c.openFilesMu.RUnlock()
 	_, exists := c.openFiles[uri]
@@ -404,21 +500,19 @@ func (c *Client) IsFileOpen(filepath string) bool {
 // CloseAllFiles closes all currently open files
 func (c *Client) CloseAllFiles(ctx context.Context) {
 	c.openFilesMu.Lock()
-	filesToClose := make([]string, 0, len(c.openFiles))
+	filesToClose := make([]protocol.DocumentUri, 0, len(c.openFiles))
 
 	// First collect all URIs that need to be closed
 	for uri := range c.openFiles {
-		// Convert URI back to file path by trimming "file://" prefix
-		filePath := strings.TrimPrefix(uri, "file://")
-		filesToClose = append(filesToClose, filePath)
+		filesToClose = append(filesToClose, protocol.DocumentUri(uri))
 	}
 	c.openFilesMu.Unlock()
 
 	// Then close them all
-	for _, filePath := range filesToClose {
-		err := c.CloseFile(ctx, filePath)
+	for _, uri := range filesToClose {
+		err := c.CloseDocument(ctx, uri)
 		if err != nil {
-			lspLogger.Error("Error closing file %s: %v", filePath, err)
+			lspLogger.Error("Error closing file %s: %v", uri, err)
 		}
 	}
 
@@ -431,3 +525,46 @@ func (c *Client) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnos
 
 	return c.diagnostics[uri]
 }
+
+// HasDiagnostics reports whether the server has published diagnostics for a
+// document, even an empty list
+func (c *Client) HasDiagnostics(uri protocol.DocumentUri) bool {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+
+	_, ok := c.diagnostics[uri]
+	return ok
+}
+
+// DiagnosticsListener is called whenever the server publishes diagnostics for a document
+type DiagnosticsListener func(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic)
+
+// SubscribeDiagnostics registers a listener for published diagnostics and returns
+// a function that removes it
+func (c *Client) SubscribeDiagnostics(listener DiagnosticsListener) func() {
+	c.diagnosticListenersMu.Lock()
+	id := c.nextListenerID
+	c.nextListenerID++
+	c.diagnosticListeners[id] = listener
+	c.diagnosticListenersMu.Unlock()
+
+	return func() {
+		c.diagnosticListenersMu.Lock()
+		delete(c.diagnosticListeners, id)
+		c.diagnosticListenersMu.Unlock()
+	}
+}
+
+// notifyDiagnosticListeners passes published diagnostics on to all listeners
+func (c *Client) notifyDiagnosticListeners(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) {
+	c.diagnosticListenersMu.RLock()
+	listeners := make([]DiagnosticsListener, 0, len(c.diagnosticListeners))
+	for _, listener := range c.diagnosticListeners {
+		listeners = append(listeners, listener)
+	}
+	c.diagnosticListenersMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(uri, diagnostics)
+	}
+}
@@ -7,6 +7,47 @@ import (
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
+// LanguageOverride maps files matching a glob pattern to a languageId. Patterns
+// without a slash match the file name (e.g. "*.gohtml", "Tiltfile"); patterns with
+// a slash match the trailing components of the path (e.g. "db/*.cql").
+type LanguageOverride struct {
+	Pattern    string
+	LanguageID protocol.LanguageKind
+}
+
+// matchesOverride reports whether path matches the override pattern
+func matchesOverride(pattern, path string) bool {
+	path = filepath.ToSlash(path)
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "**/")
+
+	if !strings.Contains(pattern, "/") {
+		matched, err := filepath.Match(pattern, filepath.Base(path))
+		return err == nil && matched
+	}
+
+	// Match the pattern against the same number of trailing path components
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < len(patternParts) {
+		return false
+	}
+	tail := strings.Join(pathParts[len(pathParts)-len(patternParts):], "/")
+	matched, err := filepath.Match(pattern, tail)
+	return err == nil && matched
+}
+
+// DetectLanguageIDWithOverrides returns the languageId of the first matching
+// override, falling back to detection by file extension
+func DetectLanguageIDWithOverrides(uri string, overrides []LanguageOverride) protocol.LanguageKind {
+	path := protocol.PathFromURI(uri)
+	for _, override := range overrides {
+		if matchesOverride(override.Pattern, path) {
+			return override.LanguageID
+		}
+	}
+	return DetectLanguageID(uri)
+}
+
 func DetectLanguageID(uri string) protocol.LanguageKind {
 	ext := strings.ToLower(filepath.Ext(uri))
 	switch ext {
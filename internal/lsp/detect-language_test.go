@@ -0,0 +1,43 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguageIDWithOverrides(t *testing.T) {
+	overrides := []LanguageOverride{
+		{Pattern: "*.gohtml", LanguageID: protocol.LangHTML},
+		{Pattern: "Tiltfile", LanguageID: protocol.LangPython},
+		{Pattern: "db/*.cql", LanguageID: protocol.LangSQL},
+		{Pattern: "**/scripts/*.go", LanguageID: protocol.LangShellScript},
+	}
+
+	tests := []struct {
+		uri      string
+		expected protocol.LanguageKind
+	}{
+		{"file:///work/templates/index.gohtml", protocol.LangHTML},
+		{"file:///work/Tiltfile", protocol.LangPython},
+		{"file:///work/service/Tiltfile", protocol.LangPython},
+		{"file:///work/db/schema.cql", protocol.LangSQL},
+		{"file:///work/other/schema.cql", protocol.LanguageKind("")},
+		{"file:///work/tools/scripts/run.go", protocol.LangShellScript},
+		// Falls back to extension based detection
+		{"file:///work/main.go", protocol.LangGo},
+		{"file:///work/app.py", protocol.LangPython},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.uri, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DetectLanguageIDWithOverrides(tc.uri, overrides))
+		})
+	}
+}
+
+func TestDetectLanguageIDWithoutOverrides(t *testing.T) {
+	assert.Equal(t, protocol.LangTypeScript, DetectLanguageIDWithOverrides("file:///work/index.ts", nil))
+	assert.Equal(t, protocol.LanguageKind(""), DetectLanguageIDWithOverrides("file:///work/Tiltfile", nil))
+}
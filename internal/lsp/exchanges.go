@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// exchangeLogSize is the number of JSON-RPC messages a client remembers
+const exchangeLogSize = 200
+
+// Exchange is a JSON-RPC message sent to or received from the language server
+type Exchange struct {
+	Time time.Time `json:"time"`
+	// Direction is "send" for messages to the server and "receive" for messages from it
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// exchangeLog is a ring buffer of the most recent exchanges
+type exchangeLog struct {
+	mu      sync.Mutex
+	entries []Exchange
+	next    int
+}
+
+// add records a message, dropping the oldest one when the log is full
+func (l *exchangeLog) add(direction string, msg *Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	entry := Exchange{Time: time.Now(), Direction: direction, Message: data}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) < exchangeLogSize {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % exchangeLogSize
+}
+
+// last returns up to n of the most recent exchanges, oldest first
+func (l *exchangeLog) last(n int) []Exchange {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ordered := make([]Exchange, 0, len(l.entries))
+	ordered = append(ordered, l.entries[l.next:]...)
+	ordered = append(ordered, l.entries[:l.next]...)
+	if n >= 0 && len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// Exchanges returns up to n of the most recent JSON-RPC messages exchanged
+// with the language server, oldest first
+func (c *Client) Exchanges(n int) []Exchange {
+	return c.exchanges.last(n)
+}
+
+// send writes a message to the server and records it
+func (c *Client) send(msg *Message) error {
+	c.exchanges.add("send", msg)
+	return WriteMessage(c.stdin, msg)
+}
+
+// Capabilities returns the capabilities the client announced and the
+// initialize result of the server, which is nil before the handshake
+func (c *Client) Capabilities() (protocol.ClientCapabilities, *protocol.InitializeResult) {
+	return c.clientCapabilities, c.initializeResult
+}
+
+// PositionEncoding returns the position encoding the server chose in the
+// initialize handshake. It is utf-16, the LSP default, when the server did not
+// choose one or before the handshake.
+func (c *Client) PositionEncoding() protocol.PositionEncodingKind {
+	if c.initializeResult == nil || c.initializeResult.Capabilities.PositionEncoding == nil {
+		return protocol.UTF16
+	}
+	return *c.initializeResult.Capabilities.PositionEncoding
+}
@@ -60,14 +60,17 @@ func HandleRegisterCapability(params json.RawMessage) (any, error) {
 	return nil, nil
 }
 
-func HandleApplyEdit(params json.RawMessage) (any, error) {
+func HandleApplyEdit(client *Client, params json.RawMessage) (any, error) {
 	var workspaceEdit protocol.ApplyWorkspaceEditParams
 	if err := json.Unmarshal(params, &workspaceEdit); err != nil {
 		return protocol.ApplyWorkspaceEditResult{Applied: false}, err
 	}
 
 	// Apply the edits
-	err := utilities.ApplyWorkspaceEdit(workspaceEdit.Edit)
+	edit, err := utilities.WorkspaceEditToUTF8(workspaceEdit.Edit, client.PositionEncoding())
+	if err == nil {
+		err = utilities.ApplyWorkspaceEdit(edit)
+	}
 	if err != nil {
 		lspLogger.Error("Error applying workspace edit: %v", err)
 		return protocol.ApplyWorkspaceEditResult{
@@ -125,4 +128,6 @@ func HandleDiagnostics(client *Client, params json.RawMessage) {
 	client.diagnosticsMu.Unlock()
 
 	lspLogger.Info("Received diagnostics for %s: %d items", diagParams.URI, len(diagParams.Diagnostics))
+
+	client.notifyDiagnosticListeners(diagParams.URI, diagParams.Diagnostics)
 }
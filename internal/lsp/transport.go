@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
 // Create component-specific loggers
@@ -16,6 +18,10 @@ var lspLogger = logging.NewLogger(logging.LSP)
 var wireLogger = logging.NewLogger(logging.LSPWire)
 var processLogger = logging.NewLogger(logging.LSPProcess)
 
+// ErrConnectionClosed is returned by requests to a language server that has
+// exited
+var ErrConnectionClosed = errors.New("language server connection closed")
+
 // WriteMessage writes an LSP message to the given writer
 func WriteMessage(w io.Writer, msg *Message) error {
 	data, err := json.Marshal(msg)
@@ -96,6 +102,7 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 
 // handleMessages reads and dispatches messages in a loop
 func (c *Client) handleMessages() {
+	defer close(c.done)
 	for {
 		msg, err := ReadMessage(c.stdout)
 		if err != nil {
@@ -107,6 +114,7 @@ func (c *Client) handleMessages() {
 			}
 			return
 		}
+		c.exchanges.add("receive", msg)
 
 		// Handle server->client request (has both Method and ID)
 		if msg.Method != "" && msg.ID != nil && msg.ID.Value != nil {
@@ -150,7 +158,7 @@ func (c *Client) handleMessages() {
 			}
 
 			// Send response back to server
-			if err := WriteMessage(c.stdin, response); err != nil {
+			if err := c.send(response); err != nil {
 				lspLogger.Error("Error sending response to server: %v", err)
 			}
 
@@ -217,20 +225,32 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	}()
 
 	// Send request
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.send(msg); err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
 	lspLogger.Debug("Waiting for response to request ID: %v", msg.ID)
 
-	// Wait for response
-	resp := <-ch
+	// Wait for response, or give up when the caller's context ends
+	var resp *Message
+	select {
+	case resp = <-ch:
+	case <-c.done:
+		return fmt.Errorf("%s request failed: %w", method, ErrConnectionClosed)
+	case <-ctx.Done():
+		// Tell the server the result is no longer needed. Use a fresh context since
+		// ctx is already done.
+		if err := c.Notify(context.Background(), "$/cancelRequest", protocol.CancelParams{ID: id}); err != nil {
+			lspLogger.Debug("Failed to cancel request %v: %v", id, err)
+		}
+		return fmt.Errorf("%s request cancelled: %w", method, ctx.Err())
+	}
 
 	lspLogger.Debug("Received response for request ID: %v", msg.ID)
 
 	if resp.Error != nil {
 		lspLogger.Error("Request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
-		return fmt.Errorf("request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+		return resp.Error
 	}
 
 	if result != nil {
@@ -258,7 +278,7 @@ func (c *Client) Notify(ctx context.Context, method string, params any) error {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.send(msg); err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 
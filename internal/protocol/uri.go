@@ -143,6 +143,11 @@ func ParseDocumentUri(s string) (DocumentUri, error) {
 		return "", nil
 	}
 
+	// In-memory documents created by the client are passed through unchanged.
+	if strings.HasPrefix(s, UntitledScheme+":") {
+		return DocumentUri(s), nil
+	}
+
 	if !strings.HasPrefix(s, "file://") {
 		return "", fmt.Errorf("DocumentUri scheme is not 'file': %s", s)
 	}
@@ -194,8 +199,28 @@ func URIFromPath(path string) DocumentUri {
 	return DocumentUri(u.String())
 }
 
+// PathFromURI returns the file path of a file URI, decoding percent-encoded
+// characters such as spaces and non-ASCII names. URIs that cannot be decoded
+// fall back to the text after "file://", and other schemes such as untitled:
+// are returned unchanged, so that the result can always be displayed.
+func PathFromURI(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+	if parsed, err := ParseDocumentUri(uri); err == nil {
+		if path, err := filename(parsed); err == nil {
+			return filepath.FromSlash(path)
+		}
+	}
+	return strings.TrimPrefix(uri, "file://")
+}
+
 const fileScheme = "file"
 
+// UntitledScheme is the URI scheme of in-memory documents that are not backed
+// by a file on disk.
+const UntitledScheme = "untitled"
+
 // isWindowsDrivePath returns true if the file path is of the form used by
 // Windows. We check if the path begins with a drive letter, followed by a ":".
 // For example: C:/x/y/z.
@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURIFromPathEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		uri  DocumentUri
+	}{
+		{"ascii", "/ws/main.go", "file:///ws/main.go"},
+		{"space", "/ws/my file.go", "file:///ws/my%20file.go"},
+		{"percent", "/ws/100%.go", "file:///ws/100%25.go"},
+		{"cjk", "/ws/文件.go", "file:///ws/%E6%96%87%E4%BB%B6.go"},
+		{"emoji", "/ws/🚀 launch.py", "file:///ws/%F0%9F%9A%80%20launch.py"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.uri, URIFromPath(tt.path))
+			assert.Equal(t, tt.path, PathFromURI(string(tt.uri)))
+			assert.Equal(t, tt.path, tt.uri.Path())
+		})
+	}
+}
+
+func TestParseDocumentUriCanonicalizesServerForms(t *testing.T) {
+	// Servers may send non-ASCII characters unencoded or over-encode
+	// characters; both must match the URI built from the path
+	for _, raw := range []string{
+		"file:///ws/日本語/テスト.go",
+		"file:///ws/%E6%97%A5%E6%9C%AC%E8%AA%9E/%E3%83%86%E3%82%B9%E3%83%88.go",
+		"file://ws/日本語/テスト.go",
+	} {
+		uri, err := ParseDocumentUri(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, URIFromPath("/ws/日本語/テスト.go"), uri, raw)
+	}
+}
+
+func TestPathFromURIFallbacks(t *testing.T) {
+	assert.Equal(t, "untitled:scratch.go", PathFromURI("untitled:scratch.go"))
+	assert.Equal(t, "/ws/bad%zz.go", PathFromURI("file:///ws/bad%zz.go"))
+	assert.Equal(t, "", PathFromURI(""))
+}
@@ -0,0 +1,219 @@
+// Package redact keeps credentials and other sensitive content of a workspace
+// out of tool output and logs. Files matching the configured globs are never
+// shown, and text matching the built-in secret patterns or the configured
+// patterns is replaced with [REDACTED].
+package redact
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+)
+
+// Replacement replaces redacted text
+const Replacement = "[REDACTED]"
+
+// HiddenNote replaces the snippets of files hidden by the redaction settings
+const HiddenNote = "Content hidden by the redaction settings"
+
+// secretPatterns match secrets by their shape, precisely enough to run over
+// source code without mangling it: code that merely names a token or a
+// password is left alone, while string literals assigned to such names are
+// redacted
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), Replacement},
+	{regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret|password|passwd|credential)[\w-]*["']?\s*(?::=|=>|[:=])\s*["'])[^"'\s]{8,}(["'])`), "${1}" + Replacement + "${2}"},
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{20,}`), "${1}" + Replacement},
+	{regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`), "://" + Replacement + "@"},
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`), Replacement},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), Replacement},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`), Replacement},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`), Replacement},
+}
+
+// privateKeyBoundary matches the first and last line of a private key, to
+// redact keys spread over the lines of a snippet
+var privateKeyBoundary = regexp.MustCompile(`-----(BEGIN|END) [A-Z ]*PRIVATE KEY-----`)
+
+// Secrets replaces well-known kinds of secrets in text: private keys,
+// credentials in URLs, bearer tokens, GitHub, AWS, OpenAI and Slack tokens,
+// and string literals assigned to names like password or api_key
+func Secrets(text string) string {
+	for _, secret := range secretPatterns {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+	return text
+}
+
+// Validate checks the file globs and patterns of redaction settings
+func Validate(s settings.RedactionSettings) error {
+	for i, glob := range s.Files {
+		if glob == "" {
+			return fmt.Errorf("redaction.files[%d] is empty", i)
+		}
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("redaction.files[%d] has an invalid glob: %w", i, err)
+		}
+	}
+	for i, pattern := range s.Patterns {
+		if pattern == "" {
+			return fmt.Errorf("redaction.patterns[%d] is empty", i)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("redaction.patterns[%d] has an invalid pattern: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Redactor applies redaction settings. A nil Redactor, like one for disabled
+// settings, returns everything as it is.
+type Redactor struct {
+	files    []string
+	patterns []*regexp.Regexp
+}
+
+// New returns a redactor for settings checked with Validate. Invalid globs
+// and patterns are skipped.
+func New(s settings.RedactionSettings) *Redactor {
+	if s.Disabled {
+		return nil
+	}
+	r := &Redactor{}
+	for _, glob := range s.Files {
+		if _, err := filepath.Match(glob, ""); err == nil && glob != "" {
+			r.files = append(r.files, glob)
+		}
+	}
+	for _, pattern := range s.Patterns {
+		if compiled, err := regexp.Compile(pattern); err == nil && pattern != "" {
+			r.patterns = append(r.patterns, compiled)
+		}
+	}
+	return r
+}
+
+// Text replaces the secrets and the matches of the configured patterns in text
+func (r *Redactor) Text(text string) string {
+	if r == nil {
+		return text
+	}
+	text = Secrets(text)
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllString(text, Replacement)
+	}
+	return text
+}
+
+// HidesFile reports whether the content of a file is never shown. Globs
+// without a slash match the file name in any directory, e.g. ".env.*", and
+// others the trailing components of the path, e.g. "config/secrets/*.yaml".
+func (r *Redactor) HidesFile(path string) bool {
+	if r == nil {
+		return false
+	}
+	path = filepath.ToSlash(path)
+	for _, glob := range r.files {
+		if matchesGlob(glob, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Document redacts the sections of a document. Sections of hidden files lose
+// their snippets, and the snippets, notes and fields of the others are passed
+// through Text. Every line of a private key in a snippet is replaced. Marks
+// on lines that changed are dropped, since they may no longer fit the line.
+func (r *Redactor) Document(doc format.Document) format.Document {
+	if r == nil {
+		return doc
+	}
+	doc.Preamble = r.Text(doc.Preamble)
+	doc.Footer = r.Text(doc.Footer)
+	sections := make([]format.Section, len(doc.Sections))
+	for i, section := range doc.Sections {
+		sections[i] = r.section(section)
+	}
+	doc.Sections = sections
+	return doc
+}
+
+func (r *Redactor) section(section format.Section) format.Section {
+	if section.Path != "" && r.HidesFile(section.Path) {
+		if len(section.Snippets) > 0 {
+			section.Notes = append(slices.Clone(section.Notes), HiddenNote)
+		}
+		section.Snippets, section.Focus, section.Marks = nil, nil, nil
+		return section
+	}
+
+	fields := make([]format.Field, len(section.Fields))
+	for i, field := range section.Fields {
+		fields[i] = format.Field{Name: field.Name, Value: r.Text(field.Value)}
+	}
+	section.Fields = fields
+	notes := make([]string, len(section.Notes))
+	for i, note := range section.Notes {
+		notes[i] = r.Text(note)
+	}
+	section.Notes = notes
+
+	changed := make(map[int]bool)
+	snippets := make([]format.Snippet, len(section.Snippets))
+	for i, snippet := range section.Snippets {
+		lines := make([]string, len(snippet.Lines))
+		inKey := false
+		for j, line := range snippet.Lines {
+			lines[j] = r.Text(line)
+			if boundary := privateKeyBoundary.FindStringSubmatch(lines[j]); boundary != nil {
+				inKey = boundary[1] == "BEGIN"
+				lines[j] = Replacement
+			} else if inKey {
+				lines[j] = Replacement
+			}
+			if lines[j] != line {
+				changed[snippet.StartLine+j] = true
+			}
+		}
+		snippets[i] = format.Snippet{StartLine: snippet.StartLine, Lines: lines}
+	}
+	section.Snippets = snippets
+	if len(changed) > 0 {
+		var marks []format.Mark
+		for _, mark := range section.Marks {
+			if !changed[mark.Line] {
+				marks = append(marks, mark)
+			}
+		}
+		section.Marks = marks
+	}
+	return section
+}
+
+// matchesGlob reports whether a slash separated path matches a glob
+func matchesGlob(glob, path string) bool {
+	glob = strings.TrimPrefix(filepath.ToSlash(glob), "**/")
+	if !strings.Contains(glob, "/") {
+		matched, err := filepath.Match(glob, filepath.Base(path))
+		return err == nil && matched
+	}
+
+	// Match the glob against the same number of trailing path components
+	globParts := strings.Split(glob, "/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < len(globParts) {
+		return false
+	}
+	tail := strings.Join(pathParts[len(pathParts)-len(globParts):], "/")
+	matched, err := filepath.Match(glob, tail)
+	return err == nil && matched
+}
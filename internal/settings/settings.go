@@ -0,0 +1,356 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Settings holds optional server configuration loaded from a JSON file passed
+// with --config. Every field has a usable zero value so the file may omit
+// anything it does not need.
+type Settings struct {
+	// RunCommand configures the opt-in run_command tool
+	RunCommand RunCommandSettings `json:"runCommand"`
+
+	// EditPolicy guards mutating tools against editing already broken files
+	EditPolicy EditPolicySettings `json:"editPolicy"`
+
+	// LanguageOverrides assigns languageIds to files by glob pattern, in order
+	LanguageOverrides []LanguageOverride `json:"languageOverrides"`
+
+	// SymbolMatch weights how workspace symbols are matched against symbol names
+	SymbolMatch SymbolMatchSettings `json:"symbolMatch"`
+
+	// ToolTimeouts bounds how long a single tool call may run
+	ToolTimeouts ToolTimeoutSettings `json:"toolTimeouts"`
+
+	// Standby keeps a second language server ready to replace one that crashes
+	Standby StandbySettings `json:"standby"`
+
+	// OutputVersion is the output contract used by sessions that do not choose
+	// one: "v1" for the original text output or "v2" for structured JSON
+	OutputVersion string `json:"outputVersion"`
+
+	// MaxLineLength is the most characters of a line shown in code snippets.
+	// Longer lines, such as minified JavaScript or embedded data, are shortened
+	// in the middle around the matched range. Zero shows lines whole.
+	MaxLineLength int `json:"maxLineLength"`
+
+	// Rename configures rename_symbol
+	Rename RenameSettings `json:"rename"`
+
+	// Scheduler shares the language server fairly between sessions
+	Scheduler SchedulerSettings `json:"scheduler"`
+
+	// ExternalSources configures reading definitions from dependencies
+	ExternalSources ExternalSourceSettings `json:"externalSources"`
+
+	// Audit records mutating tool calls and limits how many files they change
+	Audit AuditSettings `json:"audit"`
+
+	// OutputBudget tracks how much tool output each session has received
+	OutputBudget OutputBudgetSettings `json:"outputBudget"`
+
+	// Idle configures keepalives and what happens when no tool is called
+	Idle IdleSettings `json:"idle"`
+
+	// Redaction keeps credentials in the workspace out of tool output and logs
+	Redaction RedactionSettings `json:"redaction"`
+}
+
+// RedactionSettings configures what is redacted from code snippets and other
+// tool output, so that secrets in application repositories do not end up in
+// the model's context or in logs. Tokens, private keys, credentials in URLs
+// and string literals assigned to names like password are always redacted
+// unless Disabled is set.
+type RedactionSettings struct {
+	// Disabled shows file content as it is
+	Disabled bool `json:"disabled"`
+
+	// Files are globs of files whose content is never shown, e.g. ".env.*" or
+	// "config/secrets/*.yaml". Globs without a slash match the file name in
+	// any directory. Setting the list replaces the defaults.
+	Files []string `json:"files"`
+
+	// Patterns are regular expressions of further sensitive text, e.g.
+	// "internal\\.example\\.com". Every match is replaced with [REDACTED].
+	Patterns []string `json:"patterns"`
+}
+
+// IdleSettings configures the server while no MCP client calls a tool, to save
+// battery and memory on laptops and keep servers that expect traffic alive
+type IdleSettings struct {
+	// KeepaliveSeconds sends a no-op notification to every language server
+	// at this interval, for servers that exit or drop their index when they
+	// hear nothing for a while. Zero disables keepalives.
+	KeepaliveSeconds int `json:"keepaliveSeconds"`
+
+	// ShutdownMinutes is how long no tool may be called before the server
+	// shuts down. Zero disables idle shutdown.
+	ShutdownMinutes int `json:"shutdownMinutes"`
+
+	// Shutdown is "lsp" to stop the language servers, which start again on
+	// the next tool call, or "process" to exit the whole process
+	Shutdown string `json:"shutdown"`
+}
+
+// OutputBudgetSettings bounds the tool output a session receives over its
+// lifetime, so long sessions degrade gracefully instead of overflowing the
+// model's context window
+type OutputBudgetSettings struct {
+	// MaxTokens is the estimated number of tokens of tool output a session
+	// should receive in total. Zero disables the budget.
+	MaxTokens int `json:"maxTokens"`
+
+	// WarnPercent is the share of the budget after which results carry a
+	// warning with the tokens used so far
+	WarnPercent int `json:"warnPercent"`
+
+	// Summarize shrinks the results of tools that accept max_tokens to
+	// SummaryTokens once the warning starts, unless the call passes max_tokens
+	Summarize bool `json:"summarize"`
+
+	// SummaryTokens is the size results are shrunk to when summarizing
+	SummaryTokens int `json:"summaryTokens"`
+}
+
+// AuditSettings configures the audit log of mutating tool calls and the quotas
+// on the files each session may modify
+type AuditSettings struct {
+	// LogFile is a file that receives one JSON line per mutating tool call,
+	// with the files it changed, hashes of their content before and after,
+	// the time and the session ID. Empty disables the log.
+	LogFile string `json:"logFile"`
+
+	// MaxFilesPerHour is the number of distinct files a session may modify in
+	// a rolling hour. Zero means no limit.
+	MaxFilesPerHour int `json:"maxFilesPerHour"`
+
+	// MaxFilesPerSession is the number of distinct files a session may modify
+	// in total. Zero means no limit.
+	//
+	// Both limits are checked before each call, against the files modified
+	// by earlier calls. A call that starts under a limit completes even when
+	// its own edits take the session past it.
+	MaxFilesPerSession int `json:"maxFilesPerSession"`
+}
+
+// ExternalSourceSettings controls where definitions of symbols from
+// dependencies are read from, and how much of them
+type ExternalSourceSettings struct {
+	// Prefer is "cache" to read the module cache, site-packages or node_modules
+	// copy the language server points to, "vendor" to read the workspace's
+	// vendored copy when there is one, or "off" to only read definitions in
+	// the workspace's own code
+	Prefer string `json:"prefer"`
+
+	// MaxFileBytes skips dependency files larger than this. Zero means no limit.
+	MaxFileBytes int `json:"maxFileBytes"`
+
+	// MaxLines truncates longer dependency definitions. Zero means no limit.
+	MaxLines int `json:"maxLines"`
+}
+
+// SchedulerSettings bounds the tool calls running against the language server
+// at once. Calls over the limit wait, and sessions take turns when admitted.
+type SchedulerSettings struct {
+	// MaxConcurrent is the number of tool calls that may run at once. Zero
+	// means no limit.
+	MaxConcurrent int `json:"maxConcurrent"`
+}
+
+// RenameSettings configures renames that span several language servers
+type RenameSettings struct {
+	// PeerServers are extra language servers, usually for other languages, that
+	// rename every symbol with the old name as part of rename_symbol, e.g. a
+	// TypeScript server for bindings generated from Go types
+	PeerServers []ServerCommand `json:"peerServers"`
+}
+
+// ServerCommand is the command line of a language server
+type ServerCommand struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// StandbySettings configures the warm standby language server. It is meant for
+// servers that crash regularly and take long to index, such as omnisharp or jdtls.
+type StandbySettings struct {
+	// Enabled starts a standby server next to the active one. When the active
+	// server exits, the standby takes over and a new standby is started.
+	Enabled bool `json:"enabled"`
+}
+
+// ToolTimeoutSettings configures the timeout_ms argument accepted by every tool
+type ToolTimeoutSettings struct {
+	// DefaultMs is used when a call does not pass timeout_ms. Zero means no timeout.
+	DefaultMs int `json:"defaultMs"`
+
+	// MaxMs caps the timeout_ms a call may ask for. Zero means no cap.
+	MaxMs int `json:"maxMs"`
+}
+
+// SymbolMatchSettings scores workspace symbol results against the name a tool was
+// called with. Each symbol gets the weight of the best tier it matches minus any
+// penalties, and symbols scoring below MinScore are ignored.
+type SymbolMatchSettings struct {
+	// Exact is the score of a symbol whose name is the query
+	Exact int `json:"exact"`
+
+	// SamePackage is the score of a qualified match whose package, type or module
+	// agrees with the query, e.g. "Method" in container "Type" for "Type.Method"
+	SamePackage int `json:"samePackage"`
+
+	// Qualified is the score of a symbol matching only the last component of a
+	// qualified query, e.g. "Method" for "Type.Method" in an unrelated container.
+	// Like Prefix and Fuzzy it is below MinScore by default, so these weaker
+	// tiers are only used when MinScore is lowered.
+	Qualified int `json:"qualified"`
+
+	// Prefix is the score of a symbol whose name starts with the query
+	Prefix int `json:"prefix"`
+
+	// Fuzzy is the score of a symbol containing the query's characters in order
+	Fuzzy int `json:"fuzzy"`
+
+	// TestPenalty is subtracted for symbols defined in test files
+	TestPenalty int `json:"testPenalty"`
+
+	// VendorPenalty is subtracted for symbols in vendored or third party code
+	VendorPenalty int `json:"vendorPenalty"`
+
+	// MinScore is the lowest score a symbol needs to be used. definition and
+	// references only show the matches of the best tier reaching it.
+	MinScore int `json:"minScore"`
+
+	// Normalize rewrites symbol names before they are matched, so that names
+	// can be given in the notation of any language community
+	Normalize []NormalizeRule `json:"normalize"`
+}
+
+// NormalizeRule rewrites the symbol names tools are called with, e.g.
+// {"pattern": "<[^<>]*>", "replace": ""} turns Foo<T> into Foo. Every match of
+// the regular expression Pattern is replaced by Replace, which may refer to
+// groups as $1, until the name stops changing. Rules run in order.
+type NormalizeRule struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+
+	// Languages limits the rule to symbols defined in files of these
+	// languageIds, e.g. ["go"]. Empty applies the rule to every language.
+	Languages []string `json:"languages,omitempty"`
+}
+
+// LanguageOverride maps files matching Pattern to a languageId, e.g.
+// {"pattern": "*.gohtml", "languageId": "html"} or {"pattern": "Tiltfile", "languageId": "python"}
+type LanguageOverride struct {
+	Pattern    string `json:"pattern"`
+	LanguageID string `json:"languageId"`
+}
+
+// RunCommandSettings configures which build and test commands the server may run
+type RunCommandSettings struct {
+	// Allowlist holds the exact commands that may be executed, e.g. "go test ./...".
+	// The run_command tool is only registered when this list is non-empty.
+	Allowlist []string `json:"allowlist"`
+
+	// TimeoutSeconds bounds the runtime of a single command
+	TimeoutSeconds int `json:"timeoutSeconds"`
+
+	// MaxOutputBytes limits how much raw command output is echoed back
+	MaxOutputBytes int `json:"maxOutputBytes"`
+}
+
+// EditPolicySettings blocks edits to files that currently have too many
+// diagnostics unless the caller passes force
+type EditPolicySettings struct {
+	// Enabled turns the policy on
+	Enabled bool `json:"enabled"`
+
+	// MinSeverity is the least severe diagnostic that counts towards the
+	// threshold: "error", "warning", "info" or "hint"
+	MinSeverity string `json:"minSeverity"`
+
+	// MaxDiagnostics is the number of counted diagnostics a file may have
+	// before edits to it are blocked
+	MaxDiagnostics int `json:"maxDiagnostics"`
+}
+
+// Default returns settings with sensible defaults
+func Default() *Settings {
+	return &Settings{
+		RunCommand: RunCommandSettings{
+			TimeoutSeconds: 300,
+			MaxOutputBytes: 20000,
+		},
+		EditPolicy: EditPolicySettings{
+			MinSeverity: "error",
+		},
+		SymbolMatch: SymbolMatchSettings{
+			Exact:         100,
+			SamePackage:   80,
+			Qualified:     30,
+			Prefix:        40,
+			Fuzzy:         10,
+			TestPenalty:   15,
+			VendorPenalty: 30,
+			MinScore:      50,
+			Normalize: []NormalizeRule{
+				// Generic arguments: Foo<T>, Map<K, List<V>>
+				{Pattern: `<[^<>]*>`, Replace: ""},
+				// Type parameters and subscripts: Set[T], list[int]
+				{Pattern: `\[[^\[\]]*\]`, Replace: "", Languages: []string{"go", "python"}},
+				// Parameter lists and call parentheses: run(), area(self)
+				{Pattern: `\(.*\)$`, Replace: ""},
+				// Instance members in Java and Ruby docs: Type#method
+				{Pattern: `#`, Replace: "."},
+			},
+		},
+		ToolTimeouts: ToolTimeoutSettings{
+			DefaultMs: 120000,
+			MaxMs:     600000,
+		},
+		OutputVersion: "v1",
+		MaxLineLength: 500,
+		Scheduler: SchedulerSettings{
+			MaxConcurrent: 4,
+		},
+		ExternalSources: ExternalSourceSettings{
+			Prefer:       "cache",
+			MaxFileBytes: 1000000,
+			MaxLines:     200,
+		},
+		OutputBudget: OutputBudgetSettings{
+			WarnPercent:   80,
+			Summarize:     true,
+			SummaryTokens: 1000,
+		},
+		Idle: IdleSettings{
+			Shutdown: "lsp",
+		},
+		Redaction: RedactionSettings{
+			Files: []string{".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "id_rsa", "id_ecdsa", "id_ed25519", ".netrc", ".npmrc", ".pypirc"},
+		},
+	}
+}
+
+// Load reads settings from a JSON file. Values not present in the file keep
+// their defaults. An empty path returns the defaults.
+func Load(path string) (*Settings, error) {
+	s := Default()
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return s, nil
+}
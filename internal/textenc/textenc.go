@@ -0,0 +1,141 @@
+// Package textenc detects the character encoding of source files so that
+// legacy files, e.g. Latin-1 or Shift-JIS, are shown and sent to the language
+// server as valid UTF-8 and written back in their original encoding.
+package textenc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// Encoding is a character encoding detected for a file
+type Encoding struct {
+	// Name is the IANA name of the encoding, e.g. "Shift_JIS"
+	Name string
+
+	encoding encoding.Encoding
+}
+
+var (
+	// UTF8 is the encoding of files that need no transcoding
+	UTF8 = Encoding{Name: "UTF-8"}
+
+	// Legacy encodings that are transcoded. UTF-16 keeps its byte order mark
+	// when written back.
+	UTF16LE     = Encoding{Name: "UTF-16LE", encoding: xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM)}
+	UTF16BE     = Encoding{Name: "UTF-16BE", encoding: xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM)}
+	ShiftJIS    = Encoding{Name: "Shift_JIS", encoding: japanese.ShiftJIS}
+	Windows1252 = Encoding{Name: "windows-1252", encoding: charmap.Windows1252}
+)
+
+// IsUTF8 reports whether the encoding is UTF-8, i.e. content is used as is
+func (e Encoding) IsUTF8() bool {
+	return e.encoding == nil
+}
+
+// Detect guesses the encoding of file content. Content with a UTF-16 byte
+// order mark is UTF-16 and valid UTF-8 is UTF-8. Otherwise the content is
+// Shift-JIS when every non-ASCII byte sequence decodes to Japanese text, and
+// windows-1252 (a superset of printable Latin-1) in all other cases.
+func Detect(data []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return UTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return UTF16BE
+	case utf8.Valid(data):
+		return UTF8
+	case looksLikeShiftJIS(data):
+		return ShiftJIS
+	default:
+		return Windows1252
+	}
+}
+
+// looksLikeShiftJIS reports whether data is well formed Shift-JIS whose
+// double-byte characters are kana, kanji or full-width forms. Latin-1 text
+// rarely passes because accented letters are followed by ASCII, which
+// leaves either an invalid pair or a pair that is not Japanese.
+func looksLikeShiftJIS(data []byte) bool {
+	pairs := 0
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x80, b >= 0xA1 && b <= 0xDF:
+			// ASCII or half-width katakana
+		case b >= 0x81 && b <= 0x9F, b >= 0xE0 && b <= 0xFC:
+			if i+1 >= len(data) {
+				return false
+			}
+			trail := data[i+1]
+			if trail < 0x40 || trail == 0x7F || trail > 0xFC {
+				return false
+			}
+			decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data[i : i+2])
+			if err != nil {
+				return false
+			}
+			r, _ := utf8.DecodeRune(decoded)
+			if !isJapanese(r) {
+				return false
+			}
+			pairs++
+			i++
+		default:
+			return false
+		}
+	}
+	return pairs > 0
+}
+
+// isJapanese reports whether r is commonly found in Japanese text
+func isJapanese(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK punctuation
+		(r >= 0xFF00 && r <= 0xFFEF) // full-width forms
+}
+
+// Decode detects the encoding of data and returns it as UTF-8 text
+func Decode(data []byte) (string, Encoding) {
+	enc := Detect(data)
+	if enc.IsUTF8() {
+		return string(data), enc
+	}
+	decoded, err := enc.encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		// Detection only picks encodings the content decodes in, but keep the
+		// bytes rather than failing if that ever changes
+		return string(data), UTF8
+	}
+	return string(decoded), enc
+}
+
+// Encode converts UTF-8 text to the encoding
+func (e Encoding) Encode(text string) ([]byte, error) {
+	if e.IsUTF8() {
+		return []byte(text), nil
+	}
+	encoded, err := e.encoding.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("text cannot be written as %s: %v", e.Name, err)
+	}
+	return encoded, nil
+}
+
+// ReadFile reads a file and returns its content as UTF-8 text
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	text, _ := Decode(data)
+	return text, nil
+}
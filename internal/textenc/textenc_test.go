@@ -0,0 +1,71 @@
+package textenc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Encoding
+	}{
+		{"ascii", []byte("package main\n"), UTF8},
+		{"utf-8", []byte("// café, 日本語\n"), UTF8},
+		{"utf-16le", []byte{0xFF, 0xFE, 'a', 0}, UTF16LE},
+		{"utf-16be", []byte{0xFE, 0xFF, 0, 'a'}, UTF16BE},
+		// "// 日本語のコメント" in Shift-JIS
+		{"shift-jis", []byte("// \x93\xfa\x96\x7b\x8c\xea\x82\xcc\x83\x52\x83\x81\x83\x93\x83\x67\n"), ShiftJIS},
+		// "// café résumé naïve" in Latin-1
+		{"latin-1", []byte("// caf\xe9 r\xe9sum\xe9 na\xefve\n"), Windows1252},
+		{"latin-1 pair", []byte("x = '\xe9t\xe9'\n"), Windows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want.Name, Detect(tt.data).Name)
+		})
+	}
+}
+
+func TestDecodeAndEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		text string
+	}{
+		{"shift-jis", []byte("s := \"\x93\xfa\x96\x7b\x8c\xea\"\n"), "s := \"日本語\"\n"},
+		{"latin-1", []byte("# caf\xe9\n"), "# café\n"},
+		{"utf-16le", []byte{0xFF, 0xFE, 'h', 0, 'i', 0, 0xE9, 0}, "hié"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, enc := Decode(tt.data)
+			assert.Equal(t, tt.text, text)
+
+			encoded, err := enc.Encode(text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.data, encoded)
+		})
+	}
+}
+
+func TestEncodeUnrepresentable(t *testing.T) {
+	_, err := Windows1252.Encode("日本語")
+	assert.ErrorContains(t, err, "cannot be written as windows-1252")
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.c")
+	require.NoError(t, os.WriteFile(path, []byte("/* \xa9 1998 */\n"), 0644))
+
+	text, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "/* © 1998 */\n", text)
+}
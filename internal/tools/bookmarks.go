@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// Bookmark is a resolved symbol kept in a session's working set
+type Bookmark struct {
+	// Query is the name the symbol was bookmarked by, used to resolve it again
+	Query string
+
+	// Name and Kind are the symbol as the language server reported it
+	Name string
+	Kind protocol.SymbolKind
+
+	// Path and Line are where the symbol was last found, Line 1-indexed
+	Path string
+	Line int
+
+	// Text is the trimmed content of the line, which finds the symbol again
+	// when the language server no longer does
+	Text string
+
+	// Note is a reminder of why the symbol matters
+	Note string
+}
+
+// String describes a bookmark as "Name [Kind] path:L12"
+func (b Bookmark) String() string {
+	return fmt.Sprintf("%s [%s] %s:L%d", b.Name, protocol.TableKindMap[b.Kind], b.Path, b.Line)
+}
+
+// BookmarkStatus says how a bookmark fared when it was resolved again
+type BookmarkStatus string
+
+const (
+	// BookmarkUnchanged means the symbol is still on its line
+	BookmarkUnchanged BookmarkStatus = "unchanged"
+
+	// BookmarkMoved means edits moved the symbol to another line
+	BookmarkMoved BookmarkStatus = "moved"
+
+	// BookmarkMissing means the symbol was not found again, e.g. because it
+	// was renamed or deleted. The bookmark keeps its last location.
+	BookmarkMissing BookmarkStatus = "missing"
+)
+
+// RefreshedBookmark is a bookmark as it was found again
+type RefreshedBookmark struct {
+	Bookmark
+	Status BookmarkStatus
+
+	// PreviousLine is the line a moved bookmark was on
+	PreviousLine int
+}
+
+// BookmarkSymbols resolves a symbol name like the other tools and returns a
+// bookmark for every symbol it matches
+func BookmarkSymbols(ctx context.Context, tc *ToolContext, symbolName, note string) ([]Bookmark, error) {
+	matches, err := tc.lookup(ctx, symbolName)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, errorf(KindSymbolNotFound, "symbol not found: %s", symbolName)
+	}
+
+	bookmarks := make([]Bookmark, 0, len(matches))
+	for _, match := range matches {
+		loc := match.Symbol.GetLocation()
+		bookmark := Bookmark{
+			Query: symbolName,
+			Name:  match.Symbol.GetName(),
+			Kind:  symbolKind(match.Symbol),
+			Path:  protocol.PathFromURI(string(loc.URI)),
+			Line:  int(loc.Range.Start.Line) + 1,
+			Note:  note,
+		}
+		if lines, err := bookmarkLines(bookmark.Path); err == nil && bookmark.Line <= len(lines) {
+			bookmark.Text = strings.TrimSpace(lines[bookmark.Line-1])
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, nil
+}
+
+// RefreshBookmark finds a bookmarked symbol again. A symbol still on its line
+// costs a file read. Otherwise its name is resolved again and the symbol of
+// the same kind in the same file closest to its old line is used, or failing
+// that the line with the same text closest to it.
+func RefreshBookmark(ctx context.Context, tc *ToolContext, bookmark Bookmark) RefreshedBookmark {
+	return refreshBookmark(ctx, tc.Client, tc.Resolver, bookmark)
+}
+
+func refreshBookmark(ctx context.Context, client resolve.SymbolSearcher, resolver *resolve.Resolver, bookmark Bookmark) RefreshedBookmark {
+	lines, err := bookmarkLines(bookmark.Path)
+	if err != nil {
+		return RefreshedBookmark{Bookmark: bookmark, Status: BookmarkMissing}
+	}
+	if bookmark.Text != "" && bookmark.Line <= len(lines) && strings.TrimSpace(lines[bookmark.Line-1]) == bookmark.Text {
+		return RefreshedBookmark{Bookmark: bookmark, Status: BookmarkUnchanged}
+	}
+
+	line := 0
+	matches, err := resolver.Lookup(ctx, client, bookmark.Query)
+	if err != nil {
+		toolsLogger.Debug("Could not resolve bookmark %s again: %v", bookmark.Query, err)
+	}
+	for _, match := range matches {
+		loc := match.Symbol.GetLocation()
+		if symbolKind(match.Symbol) != bookmark.Kind || protocol.PathFromURI(string(loc.URI)) != bookmark.Path {
+			continue
+		}
+		if candidate := int(loc.Range.Start.Line) + 1; line == 0 || distance(candidate, bookmark.Line) < distance(line, bookmark.Line) {
+			line = candidate
+		}
+	}
+	if line == 0 && bookmark.Text != "" {
+		for i, text := range lines {
+			if strings.TrimSpace(text) == bookmark.Text && (line == 0 || distance(i+1, bookmark.Line) < distance(line, bookmark.Line)) {
+				line = i + 1
+			}
+		}
+	}
+	if line == 0 || line > len(lines) {
+		return RefreshedBookmark{Bookmark: bookmark, Status: BookmarkMissing}
+	}
+	if line == bookmark.Line {
+		// The line changed but the symbol is still on it
+		bookmark.Text = strings.TrimSpace(lines[line-1])
+		return RefreshedBookmark{Bookmark: bookmark, Status: BookmarkUnchanged}
+	}
+
+	refreshed := RefreshedBookmark{Bookmark: bookmark, Status: BookmarkMoved, PreviousLine: bookmark.Line}
+	refreshed.Line = line
+	refreshed.Text = strings.TrimSpace(lines[line-1])
+	return refreshed
+}
+
+// FormatBookmarks lists bookmarks with their status, numbered from 1
+func FormatBookmarks(bookmarks []RefreshedBookmark) string {
+	if len(bookmarks) == 0 {
+		return "No bookmarks yet. Add symbols to the working set with bookmark_symbol."
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Bookmarks: %d\n\n", len(bookmarks)))
+	for i, bookmark := range bookmarks {
+		result.WriteString(fmt.Sprintf("%d. %s", i+1, bookmark.Bookmark))
+		switch bookmark.Status {
+		case BookmarkMoved:
+			result.WriteString(fmt.Sprintf(" (moved from L%d)", bookmark.PreviousLine))
+		case BookmarkMissing:
+			result.WriteString(" (not found: renamed or removed since it was bookmarked, last seen here)")
+		}
+		result.WriteString("\n")
+		if bookmark.Note != "" {
+			result.WriteString("   Note: " + bookmark.Note + "\n")
+		}
+		if bookmark.Text != "" && bookmark.Status != BookmarkMissing {
+			result.WriteString("   " + bookmark.Text + "\n")
+		}
+	}
+	return result.String()
+}
+
+// symbolKind returns the kind of a workspace symbol
+func symbolKind(symbol protocol.WorkspaceSymbolResult) protocol.SymbolKind {
+	switch v := symbol.(type) {
+	case *protocol.SymbolInformation:
+		return v.Kind
+	case *protocol.WorkspaceSymbol:
+		return v.Kind
+	}
+	return 0
+}
+
+// bookmarkLines returns the lines of a bookmarked file
+func bookmarkLines(path string) ([]string, error) {
+	content, err := textenc.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(content, "\n"), nil
+}
+
+// distance is how many lines apart two lines are
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshBookmark(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "client.go")
+	writeFile(t, path, "package api\n\n// Client talks to the API\ntype Client struct{}\n")
+	resolver := resolve.New(settings.Default().SymbolMatch)
+	bookmark := Bookmark{Query: "Client", Name: "Client", Kind: protocol.Struct, Path: path, Line: 4, Text: "type Client struct{}"}
+
+	// A symbol still on its line is not looked up
+	refreshed := refreshBookmark(context.Background(), fakeSymbolSearcher{}, resolver, bookmark)
+	assert.Equal(t, BookmarkUnchanged, refreshed.Status)
+	assert.Equal(t, bookmark, refreshed.Bookmark)
+
+	// Edits above the symbol move it, and the language server finds it again.
+	// Symbols of other kinds or in other files are not taken for it.
+	writeFile(t, path, "package api\n\nimport \"net/http\"\n\n// Client talks to the API\ntype Client struct {\n\thttp *http.Client\n}\n")
+	server := fakeSymbolSearcher{
+		symbolAt("Client", protocol.Struct, filepath.Join(dir, "other.go"), 5),
+		symbolAt("Client", protocol.Field, path, 6),
+		symbolAt("Client", protocol.Struct, path, 5),
+	}
+	refreshed = refreshBookmark(context.Background(), server, resolver, bookmark)
+	assert.Equal(t, BookmarkMoved, refreshed.Status)
+	assert.Equal(t, 4, refreshed.PreviousLine)
+	assert.Equal(t, 6, refreshed.Line)
+	assert.Equal(t, "type Client struct {", refreshed.Text)
+
+	// Without the language server, the same line is looked for
+	writeFile(t, path, "package api\n\n\ntype Client struct{}\n\nvar x = 1\n")
+	bookmark.Line = 2
+	refreshed = refreshBookmark(context.Background(), fakeSymbolSearcher{}, resolver, bookmark)
+	assert.Equal(t, BookmarkMoved, refreshed.Status)
+	assert.Equal(t, 4, refreshed.Line)
+
+	// A renamed symbol is missing and keeps its last location
+	writeFile(t, path, "package api\n\ntype APIClient struct{}\n")
+	refreshed = refreshBookmark(context.Background(), fakeSymbolSearcher{}, resolver, bookmark)
+	assert.Equal(t, BookmarkMissing, refreshed.Status)
+	assert.Equal(t, bookmark, refreshed.Bookmark)
+}
+
+func TestFormatBookmarks(t *testing.T) {
+	assert.Contains(t, FormatBookmarks(nil), "No bookmarks yet")
+
+	text := FormatBookmarks([]RefreshedBookmark{
+		{Bookmark: Bookmark{Name: "Client", Kind: protocol.Struct, Path: "/ws/api/client.go", Line: 6, Text: "type Client struct {", Note: "entry point of the SDK"}, Status: BookmarkMoved, PreviousLine: 4},
+		{Bookmark: Bookmark{Name: "Serve", Kind: protocol.Function, Path: "/ws/server.go", Line: 10, Text: "func Serve() {"}, Status: BookmarkMissing},
+	})
+	assert.Equal(t, "Bookmarks: 2\n\n"+
+		"1. Client [Struct] /ws/api/client.go:L6 (moved from L4)\n"+
+		"   Note: entry point of the SDK\n"+
+		"   type Client struct {\n"+
+		"2. Serve [Function] /ws/server.go:L10 (not found: renamed or removed since it was bookmarked, last seen here)\n", text)
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// Kinds of callers incoming calls can be limited to
+const (
+	CallerFunction = "function"
+	CallerMethod   = "method"
+	CallerLambda   = "lambda"
+	CallerInit     = "init"
+)
+
+// CallerKinds returns the kinds of callers in the order they are described
+func CallerKinds() []string {
+	return []string{CallerFunction, CallerMethod, CallerLambda, CallerInit}
+}
+
+// CallerFilter limits incoming calls to callers of some kinds or visibility,
+// e.g. exported functions and methods for the impact of an API change. The
+// zero value keeps every caller.
+type CallerFilter struct {
+	// Kinds are the kinds of callers kept, all of them when empty
+	Kinds []string
+
+	// ExportedOnly keeps only callers that can be called from outside their
+	// package or module
+	ExportedOnly bool
+}
+
+// validate returns an error for unknown caller kinds
+func (f CallerFilter) validate() error {
+	for _, kind := range f.Kinds {
+		if !slices.Contains(CallerKinds(), kind) {
+			return fmt.Errorf("unknown caller kind %q, expected one of: %s", kind, strings.Join(CallerKinds(), ", "))
+		}
+	}
+	return nil
+}
+
+// active reports whether the filter drops any callers
+func (f CallerFilter) active() bool {
+	return len(f.Kinds) > 0 || f.ExportedOnly
+}
+
+// keeps reports whether the filter keeps a caller. lines are the lines of the
+// caller's file, read to find modifiers such as pub or export.
+func (f CallerFilter) keeps(item protocol.CallHierarchyItem, lines func(path string) []string) bool {
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, callerKind(item)) {
+		return false
+	}
+	if !f.ExportedOnly {
+		return true
+	}
+	path := protocol.PathFromURI(string(item.URI))
+	declaration := ""
+	if fileLines := lines(path); int(item.SelectionRange.Start.Line) < len(fileLines) {
+		declaration = fileLines[item.SelectionRange.Start.Line]
+	}
+	return resolve.StrategyFor(path).IsExported(item.Name, item.Kind, declaration)
+}
+
+// note tells how many callers the filter hid, or "" when it hid none
+func (f CallerFilter) note(hidden int) string {
+	if hidden == 0 {
+		return ""
+	}
+	kinds := "callers"
+	if len(f.Kinds) > 0 {
+		kinds = strings.Join(f.Kinds, ", ") + " callers"
+	}
+	if f.ExportedOnly {
+		kinds = "exported " + kinds
+	}
+	return fmt.Sprintf("Showing only %s: %s hidden\n", kinds, pluralize(hidden, "incoming call"))
+}
+
+// callerKind classifies the function an incoming call is made from. Code run
+// when a module, type or variable is initialized counts as init, and
+// anonymous functions are recognized by the names servers give them, such as
+// <anonymous>, <lambda> or func literal.
+func callerKind(item protocol.CallHierarchyItem) string {
+	name := strings.TrimSpace(item.Name)
+	lower := strings.ToLower(name)
+	switch {
+	case isInitializer(item, name):
+		return CallerInit
+	case name == "" || name == "func" || strings.HasPrefix(lower, "func(") || strings.HasPrefix(lower, "func literal") ||
+		strings.HasPrefix(lower, "<anonymous") || strings.Contains(lower, "lambda") || strings.Contains(lower, "closure") ||
+		strings.Contains(name, "=>"):
+		return CallerLambda
+	case item.Kind == protocol.Method || item.Kind == protocol.Constructor || item.Kind == protocol.Operator:
+		return CallerMethod
+	default:
+		return CallerFunction
+	}
+}
+
+// isInitializer reports whether a caller is code run on initialization: Go
+// init functions, static initializer blocks, module level code and the
+// initializers of variables and fields
+func isInitializer(item protocol.CallHierarchyItem, name string) bool {
+	switch item.Kind {
+	case protocol.File, protocol.Module, protocol.Namespace, protocol.Package, protocol.Class, protocol.Struct,
+		protocol.Variable, protocol.Constant, protocol.Field, protocol.Property, protocol.EnumMember:
+		return true
+	}
+	path := protocol.PathFromURI(string(item.URI))
+	return (name == "init" && filepath.Ext(path) == ".go") ||
+		name == "<clinit>" || name == "<module>" || name == "{...}" || strings.HasPrefix(name, "static {")
+}
+
+// fileLines returns a function reading the lines of files, each file once
+func fileLines() func(path string) []string {
+	cache := make(map[string][]string)
+	return func(path string) []string {
+		if lines, ok := cache[path]; ok {
+			return lines
+		}
+		content, err := textenc.ReadFile(path)
+		if err != nil {
+			toolsLogger.Debug("Could not read %s: %v", path, err)
+		}
+		lines := strings.Split(content, "\n")
+		cache[path] = lines
+		return lines
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func callerItem(name string, kind protocol.SymbolKind, path string, line uint32) protocol.CallHierarchyItem {
+	return protocol.CallHierarchyItem{
+		Name:           name,
+		Kind:           kind,
+		URI:            protocol.URIFromPath(path),
+		SelectionRange: protocol.Range{Start: protocol.Position{Line: line}},
+	}
+}
+
+func TestCallerKind(t *testing.T) {
+	tests := []struct {
+		item     protocol.CallHierarchyItem
+		expected string
+	}{
+		{callerItem("run", protocol.Function, "/ws/main.go", 0), CallerFunction},
+		{callerItem("Server.Start", protocol.Method, "/ws/server.go", 0), CallerMethod},
+		{callerItem("__init__", protocol.Constructor, "/ws/app.py", 0), CallerMethod},
+		{callerItem("init", protocol.Function, "/ws/main.go", 0), CallerInit},
+		{callerItem("<module>", protocol.Function, "/ws/app.py", 0), CallerInit},
+		{callerItem("app.py", protocol.File, "/ws/app.py", 0), CallerInit},
+		{callerItem("defaultClient", protocol.Variable, "/ws/client.go", 0), CallerInit},
+		{callerItem("<anonymous>", protocol.Function, "/ws/app.ts", 0), CallerLambda},
+		{callerItem("func literal", protocol.Function, "/ws/main.go", 0), CallerLambda},
+		{callerItem("lambda", protocol.Function, "/ws/app.py", 0), CallerLambda},
+	}
+	for _, tt := range tests {
+		t.Run(tt.item.Name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, callerKind(tt.item))
+		})
+	}
+}
+
+func TestCallerFilterKeeps(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lib.rs")
+	writeFile(t, path, "pub fn open() {}\nfn helper() {}\nimpl Client {\n    pub fn send(&self) {}\n}\n")
+
+	open := callerItem("open", protocol.Function, path, 0)
+	helper := callerItem("helper", protocol.Function, path, 1)
+	send := callerItem("send", protocol.Method, path, 3)
+	lines := fileLines()
+
+	exported := CallerFilter{ExportedOnly: true}
+	assert.True(t, exported.keeps(open, lines))
+	assert.False(t, exported.keeps(helper, lines))
+	assert.True(t, exported.keeps(send, lines))
+
+	methods := CallerFilter{Kinds: []string{CallerMethod}, ExportedOnly: true}
+	assert.False(t, methods.keeps(open, lines))
+	assert.True(t, methods.keeps(send, lines))
+
+	assert.False(t, CallerFilter{}.active())
+	assert.True(t, methods.active())
+}
+
+func TestCallerFilterNote(t *testing.T) {
+	assert.Empty(t, CallerFilter{ExportedOnly: true}.note(0))
+	assert.Equal(t, "Showing only exported callers: 3 incoming calls hidden\n", CallerFilter{ExportedOnly: true}.note(3))
+	assert.Equal(t, "Showing only function, method callers: 1 incoming call hidden\n",
+		CallerFilter{Kinds: []string{CallerFunction, CallerMethod}}.note(1))
+
+	err := CallerFilter{Kinds: []string{"closure"}}.validate()
+	assert.EqualError(t, err, `unknown caller kind "closure", expected one of: function, method, lambda, init`)
+}
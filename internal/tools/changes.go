@@ -0,0 +1,392 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// ChangedFile is a file with the lines a change touches in its new version
+type ChangedFile struct {
+	Path string
+	// Ranges are inclusive ranges of 1-indexed lines
+	Ranges []LineRange
+}
+
+// Analyses run by ReviewChanges
+const (
+	AnalysisDiagnostics = "diagnostics"
+	AnalysisReferences  = "references"
+	AnalysisCallers     = "callers"
+)
+
+// maxReviewFiles bounds the files listed for the references of a touched symbol
+const maxReviewFiles = 5
+
+// diagnosticsWait is how long ReviewChanges gives the server to publish
+// diagnostics for the changed files
+var diagnosticsWait = 3 * time.Second
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// changeReviewClient is the part of the LSP client used to review changes
+type changeReviewClient interface {
+	callHierarchyClient
+	OpenFile(ctx context.Context, filepath string) error
+	DocumentSymbol(ctx context.Context, params protocol.DocumentSymbolParams) (protocol.Or_Result_textDocument_documentSymbol, error)
+	References(ctx context.Context, params protocol.ReferenceParams) ([]protocol.Location, error)
+	Diagnostic(ctx context.Context, params protocol.DocumentDiagnosticParams) (protocol.DocumentDiagnosticReport, error)
+	GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic
+	PositionEncoding() protocol.PositionEncodingKind
+}
+
+// ParseUnifiedDiff returns the lines each file touches in its new version:
+// added lines, and the line following removed lines. Deleted files are left
+// out. Paths are relative to the repository unless the diff holds absolute paths.
+func ParseUnifiedDiff(diff string) ([]ChangedFile, error) {
+	var files []ChangedFile
+	var lines map[int]bool
+	var path string
+	newLine, oldLeft, newLeft := 0, 0, 0
+
+	flush := func() {
+		if path != "" && len(lines) > 0 {
+			files = append(files, ChangedFile{Path: path, Ranges: lineRanges(lines)})
+		}
+		path, lines = "", nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Hunk bodies are consumed by their line counts, so that content such
+		// as "+++" or "---" in them is not mistaken for a file header
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				if lines != nil {
+					lines[newLine] = true
+				}
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				if lines != nil {
+					lines[max(newLine, 1)] = true
+				}
+				oldLeft--
+			case strings.HasPrefix(line, `\`):
+			default:
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			flush()
+		case strings.HasPrefix(line, "+++ "):
+			flush()
+			path = diffPath(strings.TrimPrefix(line, "+++ "))
+			lines = make(map[int]bool)
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeader.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header: %s", line)
+			}
+			newLine, _ = strconv.Atoi(match[2])
+			oldLeft, newLeft = hunkCount(match[1]), hunkCount(match[3])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+	flush()
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the diff does not change any file")
+	}
+	return files, nil
+}
+
+// hunkCount parses a line count of a hunk header, which defaults to 1
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// diffPath returns the path of a "+++" line, or "" for a deleted file
+func diffPath(name string) string {
+	if i := strings.Index(name, "\t"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Trim(name, "\"")
+	if name == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(name, "b/")
+}
+
+// lineRanges merges a set of lines into sorted ranges
+func lineRanges(lines map[int]bool) []LineRange {
+	sorted := make([]int, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+
+	var ranges []LineRange
+	for _, line := range sorted {
+		if n := len(ranges); n > 0 && ranges[n-1].End+1 >= line {
+			ranges[n-1].End = line
+			continue
+		}
+		ranges = append(ranges, LineRange{Start: line, End: line})
+	}
+	return ranges
+}
+
+// overlaps reports whether a 0-indexed LSP range overlaps any of the ranges
+func overlaps(r protocol.Range, ranges []LineRange) bool {
+	for _, lines := range ranges {
+		if int(r.Start.Line)+1 <= lines.End && int(r.End.Line)+1 >= lines.Start {
+			return true
+		}
+	}
+	return false
+}
+
+// touchedSymbol is a symbol whose definition overlaps changed lines
+type touchedSymbol struct {
+	Name  string
+	Kind  protocol.SymbolKind
+	Range protocol.Range
+	// Position is where references and calls to the symbol are looked up from
+	Position protocol.Position
+}
+
+// touchedSymbols returns the innermost symbols of a file overlapping changed lines
+func touchedSymbols(symbols []protocol.DocumentSymbolResult, uri protocol.DocumentUri, ranges []LineRange) []touchedSymbol {
+	var touched []touchedSymbol
+	var walk func(symbols []protocol.DocumentSymbolResult)
+	walk = func(symbols []protocol.DocumentSymbolResult) {
+		for _, symbol := range symbols {
+			var children []protocol.DocumentSymbolResult
+			entry := touchedSymbol{Name: symbol.GetName(), Range: symbol.GetRange()}
+			switch v := symbol.(type) {
+			case *protocol.DocumentSymbol:
+				entry.Kind, entry.Position = v.Kind, v.SelectionRange.Start
+				for i := range v.Children {
+					children = append(children, &v.Children[i])
+				}
+			case *protocol.SymbolInformation:
+				if v.Location.URI != uri {
+					continue
+				}
+				entry.Kind, entry.Position = v.Kind, v.Location.Range.Start
+			}
+			if !overlaps(entry.Range, ranges) {
+				continue
+			}
+
+			before := len(touched)
+			walk(children)
+			if len(touched) == before {
+				touched = append(touched, entry)
+			}
+		}
+	}
+	walk(symbols)
+	return touched
+}
+
+// ReviewChanges limits analysis to the symbols touched by a change, such as a
+// pending pull request: diagnostics on the changed lines, and the references
+// and callers of every symbol whose definition overlaps them.
+func ReviewChanges(ctx context.Context, client changeReviewClient, workspaceDir string, changes []ChangedFile, analyses []string) (string, error) {
+	enabled := make(map[string]bool, len(analyses))
+	for _, analysis := range analyses {
+		switch analysis {
+		case AnalysisDiagnostics, AnalysisReferences, AnalysisCallers:
+			enabled[analysis] = true
+		default:
+			return "", fmt.Errorf("unknown analysis %q, expected diagnostics, references or callers", analysis)
+		}
+	}
+
+	for i := range changes {
+		if !filepath.IsAbs(changes[i].Path) {
+			changes[i].Path = filepath.Join(workspaceDir, changes[i].Path)
+		}
+		if err := client.OpenFile(ctx, changes[i].Path); err != nil {
+			return "", fmt.Errorf("could not open file %s: %w", changes[i].Path, err)
+		}
+	}
+	if enabled[AnalysisDiagnostics] && diagnosticsWait > 0 {
+		select {
+		case <-time.After(diagnosticsWait):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	var body strings.Builder
+	changedLines, totalSymbols := 0, 0
+	for _, change := range changes {
+		uri := protocol.URIFromPath(change.Path)
+		var spans []string
+		for _, lines := range change.Ranges {
+			changedLines += lines.End - lines.Start + 1
+			if lines.Start == lines.End {
+				spans = append(spans, fmt.Sprintf("L%d", lines.Start))
+			} else {
+				spans = append(spans, fmt.Sprintf("L%d-%d", lines.Start, lines.End))
+			}
+		}
+		body.WriteString(fmt.Sprintf("\n%s (%s)\n", change.Path, strings.Join(spans, ", ")))
+
+		if enabled[AnalysisDiagnostics] {
+			if _, err := client.Diagnostic(ctx, protocol.DocumentDiagnosticParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}}); err != nil {
+				toolsLogger.Debug("Pull diagnostics not available for %s: %v", change.Path, err)
+			}
+			var onChanged []protocol.Diagnostic
+			for _, diag := range client.GetFileDiagnostics(uri) {
+				if overlaps(diag.Range, change.Ranges) {
+					onChanged = append(onChanged, diag)
+				}
+			}
+			body.WriteString(fmt.Sprintf("Diagnostics on changed lines: %d\n", len(onChanged)))
+			columns := readColumns(change.Path, client.PositionEncoding())
+			for _, diag := range onChanged {
+				body.WriteString("  " + formatDiagnostic(diag, columns) + "\n")
+			}
+		}
+
+		if !enabled[AnalysisReferences] && !enabled[AnalysisCallers] {
+			continue
+		}
+		symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}})
+		if err != nil {
+			return "", fmt.Errorf("failed to get document symbols: %w", err)
+		}
+		symbols, err := symResult.Results()
+		if err != nil {
+			return "", fmt.Errorf("failed to process document symbols: %w", err)
+		}
+		touched := touchedSymbols(symbols, uri, change.Ranges)
+		totalSymbols += len(touched)
+		if len(touched) == 0 {
+			body.WriteString("Touched symbols: none\n")
+			continue
+		}
+
+		body.WriteString("Touched symbols:\n")
+		for _, symbol := range touched {
+			body.WriteString(fmt.Sprintf("  %s %s L%d-%d\n", protocol.TableKindMap[symbol.Kind], symbol.Name, symbol.Range.Start.Line+1, symbol.Range.End.Line+1))
+			position := protocol.TextDocumentPositionParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}, Position: symbol.Position}
+			if enabled[AnalysisReferences] {
+				body.WriteString("    " + summarizeReferences(ctx, client, position, change.Path) + "\n")
+			}
+			if enabled[AnalysisCallers] && isCallableKind(symbol.Kind) {
+				body.WriteString("    " + summarizeCallers(ctx, client, position) + "\n")
+			}
+		}
+	}
+
+	var result strings.Builder
+	summary := fmt.Sprintf("Changed: %s, %s", pluralize(len(changes), "file"), pluralize(changedLines, "line"))
+	if enabled[AnalysisReferences] || enabled[AnalysisCallers] {
+		summary += fmt.Sprintf(", %s touched", pluralize(totalSymbols, "symbol"))
+	}
+	result.WriteString(summary + "\n")
+	result.WriteString(body.String())
+	return result.String(), nil
+}
+
+// isCallableKind reports whether symbols of a kind have callers
+func isCallableKind(kind protocol.SymbolKind) bool {
+	return kind == protocol.Function || kind == protocol.Method || kind == protocol.Constructor
+}
+
+// summarizeReferences counts the references to a symbol and lists the other
+// files using it, e.g. "References: 5 in 3 files (a.go, b.go)"
+func summarizeReferences(ctx context.Context, client changeReviewClient, position protocol.TextDocumentPositionParams, ownPath string) string {
+	refs, err := client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: position,
+		Context:                    protocol.ReferenceContext{IncludeDeclaration: false},
+	})
+	if err != nil {
+		return fmt.Sprintf("References: unavailable (%v)", err)
+	}
+	if len(refs) == 0 {
+		return "References: none"
+	}
+
+	counts := make(map[string]int)
+	for _, ref := range refs {
+		counts[protocol.PathFromURI(string(ref.URI))]++
+	}
+	var others []string
+	for path := range counts {
+		if path != ownPath {
+			others = append(others, path)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if counts[others[i]] != counts[others[j]] {
+			return counts[others[i]] > counts[others[j]]
+		}
+		return others[i] < others[j]
+	})
+
+	summary := fmt.Sprintf("References: %d in %s", len(refs), pluralize(len(counts), "file"))
+	if len(others) == 0 {
+		return summary + " (only this file)"
+	}
+	shown := others
+	if len(shown) > maxReviewFiles {
+		shown = shown[:maxReviewFiles]
+	}
+	summary += " (" + strings.Join(shown, ", ")
+	if len(others) > len(shown) {
+		summary += fmt.Sprintf(" and %d more", len(others)-len(shown))
+	}
+	return summary + ")"
+}
+
+// summarizeCallers lists the direct callers of a function
+func summarizeCallers(ctx context.Context, client changeReviewClient, position protocol.TextDocumentPositionParams) string {
+	items, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{TextDocumentPositionParams: position})
+	if err != nil || len(items) == 0 {
+		return "Callers: unavailable"
+	}
+
+	var callers []string
+	for _, item := range items {
+		calls, err := client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{Item: item})
+		if err != nil {
+			return fmt.Sprintf("Callers: unavailable (%v)", err)
+		}
+		for _, call := range calls {
+			callers = append(callers, fmt.Sprintf("%s (%s:L%d)", call.From.Name, protocol.PathFromURI(string(call.From.URI)), call.From.SelectionRange.Start.Line+1))
+		}
+	}
+	if len(callers) == 0 {
+		return "Callers: none"
+	}
+	return fmt.Sprintf("Callers: %d: %s", len(callers), strings.Join(callers, ", "))
+}
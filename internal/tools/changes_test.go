@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleDiff = `diff --git a/config/parse.go b/config/parse.go
+index 1111111..2222222 100644
+--- a/config/parse.go
++++ b/config/parse.go
+@@ -10,5 +10,6 @@ func ParseConfig() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	d := 5
+ 	e := 6
+ 	f := 7
+@@ -40,3 +41,2 @@ func Load() {
+ 	x := 1
+-	y := 2
+ 	z := 3
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+diff --git a/notes.md b/notes.md
+--- a/notes.md
++++ b/notes.md
+@@ -1 +1,2 @@
+ # Notes
++++ a list
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	files, err := ParseUnifiedDiff(sampleDiff)
+	assert.NoError(t, err)
+	assert.Equal(t, []ChangedFile{
+		{Path: "config/parse.go", Ranges: []LineRange{{Start: 11, End: 12}, {Start: 42, End: 42}}},
+		{Path: "notes.md", Ranges: []LineRange{{Start: 2, End: 2}}},
+	}, files)
+
+	_, err = ParseUnifiedDiff("not a diff")
+	assert.Error(t, err)
+}
+
+// fakeReviewClient serves fixed symbols, references, callers and diagnostics
+type fakeReviewClient struct {
+	fakeCallHierarchy
+	symbols     []protocol.DocumentSymbol
+	refs        []protocol.Location
+	diagnostics []protocol.Diagnostic
+}
+
+func (f *fakeReviewClient) OpenFile(ctx context.Context, filepath string) error {
+	return nil
+}
+
+func (f *fakeReviewClient) DocumentSymbol(ctx context.Context, params protocol.DocumentSymbolParams) (protocol.Or_Result_textDocument_documentSymbol, error) {
+	return protocol.Or_Result_textDocument_documentSymbol{Value: f.symbols}, nil
+}
+
+func (f *fakeReviewClient) References(ctx context.Context, params protocol.ReferenceParams) ([]protocol.Location, error) {
+	return f.refs, nil
+}
+
+func (f *fakeReviewClient) Diagnostic(ctx context.Context, params protocol.DocumentDiagnosticParams) (protocol.DocumentDiagnosticReport, error) {
+	return protocol.DocumentDiagnosticReport{}, nil
+}
+
+func (f *fakeReviewClient) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic {
+	return f.diagnostics
+}
+
+func (f *fakeReviewClient) PositionEncoding() protocol.PositionEncodingKind {
+	return protocol.UTF16
+}
+
+func (f *fakeReviewClient) PrepareCallHierarchy(ctx context.Context, params protocol.CallHierarchyPrepareParams) ([]protocol.CallHierarchyItem, error) {
+	return []protocol.CallHierarchyItem{fakeItems["runTool"]}, nil
+}
+
+func lineSpan(start, end uint32) protocol.Range {
+	return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end}}
+}
+
+func TestReviewChanges(t *testing.T) {
+	diagnosticsWait = 0
+	client := &fakeReviewClient{
+		fakeCallHierarchy: fakeCallHierarchy{"runTool": {"handleRun", "TestRunTool"}},
+		symbols: []protocol.DocumentSymbol{
+			{Name: "Runner", Kind: protocol.Struct, Range: lineSpan(0, 30), SelectionRange: lineSpan(0, 0), Children: []protocol.DocumentSymbol{
+				{Name: "runTool", Kind: protocol.Method, Range: lineSpan(9, 20), SelectionRange: lineSpan(9, 9)},
+				{Name: "stop", Kind: protocol.Method, Range: lineSpan(22, 28), SelectionRange: lineSpan(22, 22)},
+			}},
+			{Name: "unchanged", Kind: protocol.Function, Range: lineSpan(40, 50), SelectionRange: lineSpan(40, 40)},
+		},
+		refs: []protocol.Location{
+			refAt("file:///ws/run.go", 3),
+			refAt("file:///ws/cmd/main.go", 3),
+			refAt("file:///ws/cmd/main.go", 8),
+		},
+		diagnostics: []protocol.Diagnostic{
+			{Range: lineSpan(11, 11), Severity: protocol.SeverityError, Message: "undefined: b"},
+			{Range: lineSpan(45, 45), Severity: protocol.SeverityWarning, Message: "unused"},
+		},
+	}
+
+	output, err := ReviewChanges(context.Background(), client, "/ws", []ChangedFile{
+		{Path: "run.go", Ranges: []LineRange{{Start: 11, End: 12}}},
+	}, []string{AnalysisDiagnostics, AnalysisReferences, AnalysisCallers})
+	assert.NoError(t, err)
+	assert.Equal(t, `Changed: 1 file, 2 lines, 1 symbol touched
+
+/ws/run.go (L11-12)
+Diagnostics on changed lines: 1
+  ERROR at L12:C1: undefined: b
+Touched symbols:
+  Method runTool L10-21
+    References: 3 in 2 files (/ws/cmd/main.go)
+    Callers: 2: handleRun (/ws/cmd/server.go:L12), TestRunTool (/ws/internal/run_test.go:L5)
+`, output)
+
+	// Lines outside any symbol touch nothing, and analyses can be skipped
+	output, err = ReviewChanges(context.Background(), client, "/ws", []ChangedFile{
+		{Path: "/ws/run.go", Ranges: []LineRange{{Start: 35, End: 35}}},
+	}, []string{AnalysisReferences})
+	assert.NoError(t, err)
+	assert.Equal(t, "Changed: 1 file, 1 line, 0 symbols touched\n\n/ws/run.go (L35)\nTouched symbols: none\n", output)
+}
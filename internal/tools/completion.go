@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+var completionKindNames = map[protocol.CompletionItemKind]string{
+	protocol.TextCompletion:          "Text",
+	protocol.MethodCompletion:        "Method",
+	protocol.FunctionCompletion:      "Function",
+	protocol.ConstructorCompletion:   "Constructor",
+	protocol.FieldCompletion:         "Field",
+	protocol.VariableCompletion:      "Variable",
+	protocol.ClassCompletion:         "Class",
+	protocol.InterfaceCompletion:     "Interface",
+	protocol.ModuleCompletion:        "Module",
+	protocol.PropertyCompletion:      "Property",
+	protocol.UnitCompletion:          "Unit",
+	protocol.ValueCompletion:         "Value",
+	protocol.EnumCompletion:          "Enum",
+	protocol.KeywordCompletion:       "Keyword",
+	protocol.SnippetCompletion:       "Snippet",
+	protocol.ColorCompletion:         "Color",
+	protocol.FileCompletion:          "File",
+	protocol.ReferenceCompletion:     "Reference",
+	protocol.FolderCompletion:        "Folder",
+	protocol.EnumMemberCompletion:    "EnumMember",
+	protocol.ConstantCompletion:      "Constant",
+	protocol.StructCompletion:        "Struct",
+	protocol.EventCompletion:         "Event",
+	protocol.OperatorCompletion:      "Operator",
+	protocol.TypeParameterCompletion: "TypeParameter",
+}
+
+// GetCompletions lists the completion items the server offers at a position.
+// Items are ordered by the server's sort text, except that deprecated items are
+// labeled and moved to the end so that they are not picked by accident.
+func GetCompletions(ctx context.Context, client *lsp.Client, filePath string, line, column, limit int) (string, error) {
+	err := client.OpenFile(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+
+	params := protocol.CompletionParams{}
+	params.TextDocument = protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)}
+	params.Position = readColumns(filePath, client.PositionEncoding()).at(line, column)
+
+	result, err := client.Completion(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get completions: %w", err)
+	}
+
+	var items []protocol.CompletionItem
+	incomplete := false
+	switch v := result.Value.(type) {
+	case protocol.CompletionList:
+		items = v.Items
+		incomplete = v.IsIncomplete
+	case []protocol.CompletionItem:
+		items = v
+	}
+
+	return formatCompletions(items, incomplete, line, column, limit), nil
+}
+
+// formatCompletions lists up to limit completion items, one per line
+func formatCompletions(items []protocol.CompletionItem, incomplete bool, line, column, limit int) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("No completions available at L%d:C%d", line, column)
+	}
+
+	sorted := make([]protocol.CompletionItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if di, dj := isDeprecatedCompletion(sorted[i]), isDeprecatedCompletion(sorted[j]); di != dj {
+			return dj
+		}
+		return sortKey(sorted[i]) < sortKey(sorted[j])
+	})
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Completions at L%d:C%d: %d", line, column, len(sorted)))
+	if limit > 0 && len(sorted) > limit {
+		result.WriteString(fmt.Sprintf(", showing %d", limit))
+		sorted = sorted[:limit]
+	}
+	if incomplete {
+		result.WriteString(" (incomplete, type more characters to narrow the list)")
+	}
+	result.WriteString("\n")
+
+	for _, item := range sorted {
+		entry := item.Label
+		if kind, ok := completionKindNames[item.Kind]; ok {
+			entry += " (" + kind + ")"
+		}
+		if item.Detail != "" {
+			entry += ": " + item.Detail
+		}
+		if isDeprecatedCompletion(item) {
+			entry = deprecatedLabel + " " + entry
+		}
+		result.WriteString(entry + "\n")
+	}
+	return result.String()
+}
+
+// sortKey is the text servers want completion items ordered by
+func sortKey(item protocol.CompletionItem) string {
+	if item.SortText != "" {
+		return item.SortText
+	}
+	return item.Label
+}
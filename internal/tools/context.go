@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/redact"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// ToolContext holds what tools read besides their arguments: the language
+// servers, the settings, the symbol resolver and how results are rendered.
+// The server builds one for each call with the session's overrides applied.
+// Programs embedding the tools create one with NewToolContext and change the
+// fields they need.
+type ToolContext struct {
+	// Client is the language server tools query
+	Client *lsp.Client
+
+	// Peers are the other language servers, used by renames across languages
+	Peers []*lsp.Client
+
+	// WorkspaceDir is the root of the workspace
+	WorkspaceDir string
+
+	// Settings configures the tools. It may be shared between contexts, so
+	// tools only read it.
+	Settings *settings.Settings
+
+	// Resolver resolves symbol names to workspace symbols
+	Resolver *resolve.Resolver
+
+	// Redactor removes secrets from rendered documents. Nil renders them as
+	// they are.
+	Redactor *redact.Redactor
+
+	// ContextLines is how many lines around each match are shown. Negative
+	// values use each tool's default.
+	ContextLines int
+
+	// Format is the format tools returning text render in, plain if empty
+	Format string
+
+	// FocusDir limits symbol searches, references, incoming calls and command
+	// findings to a directory subtree. Empty means the whole workspace.
+	FocusDir string
+
+	// FromFile is the file the caller is reading. Ambiguous symbol names
+	// resolve to the symbol that file uses.
+	FromFile string
+
+	// Stream, when set, is given each file section of a large result as soon
+	// as it is complete, instead of the section being kept in the document.
+	// doc carries the layout of the document the section belongs to. It
+	// returns false when the section was left out to stay within a token
+	// limit.
+	Stream func(doc format.Document, section format.Section) bool
+}
+
+// NewToolContext returns a context for a client with the default settings
+func NewToolContext(client *lsp.Client) *ToolContext {
+	cfg := settings.Default()
+	return &ToolContext{
+		Client:       client,
+		WorkspaceDir: client.WorkspaceDir(),
+		Settings:     cfg,
+		Resolver:     resolve.New(cfg.SymbolMatch),
+		Redactor:     redact.New(cfg.Redaction),
+		ContextLines: -1,
+	}
+}
+
+// contextLines returns the number of context lines to show, or the tool's
+// default when the context does not set one
+func (tc *ToolContext) contextLines(toolDefault int) int {
+	if tc.ContextLines < 0 {
+		return toolDefault
+	}
+	return tc.ContextLines
+}
+
+// render renders a document in the context's format
+func (tc *ToolContext) render(doc format.Document) (string, error) {
+	renderer, err := format.Get(tc.Format)
+	if err != nil {
+		return "", err
+	}
+	if tc.Settings != nil {
+		doc.MaxLineLength = tc.Settings.MaxLineLength
+	}
+	return renderer.Render(tc.Redactor.Document(doc)), nil
+}
+
+// addSection adds a complete section to a document, or passes it to the
+// context's stream and counts it as streamed or omitted
+func (tc *ToolContext) addSection(doc *format.Document, section format.Section) {
+	if tc.Stream == nil {
+		doc.Sections = append(doc.Sections, section)
+		return
+	}
+	if tc.Stream(*doc, section) {
+		doc.Streamed++
+	} else {
+		doc.StreamOmitted++
+	}
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolContextContextLines(t *testing.T) {
+	tc := &ToolContext{ContextLines: -1}
+	assert.Equal(t, 5, tc.contextLines(5))
+
+	tc.ContextLines = 0
+	assert.Equal(t, 0, tc.contextLines(5))
+}
+
+func TestToolContextRender(t *testing.T) {
+	doc := format.Document{Empty: "nothing found"}
+
+	text, err := (&ToolContext{}).render(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "nothing found", text)
+
+	text, err = (&ToolContext{Format: "json"}).render(doc)
+	assert.NoError(t, err)
+	assert.Contains(t, text, `"schemaVersion"`)
+
+	_, err = (&ToolContext{Format: "yaml"}).render(doc)
+	assert.Error(t, err)
+}
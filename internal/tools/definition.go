@@ -2,67 +2,94 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
 )
 
-func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
-	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
-		Query: symbolName,
-	})
+func ReadDefinition(ctx context.Context, tc *ToolContext, symbolName string) (string, error) {
+	doc, err := ReadDefinitionDocument(ctx, tc, symbolName)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch symbol: %v", err)
+		return "", err
 	}
+	return tc.render(doc)
+}
 
-	results, err := symbolResult.Results()
+// ReadDefinitionDocument finds the definitions of a symbol, one section per definition
+func ReadDefinitionDocument(ctx context.Context, tc *ToolContext, symbolName string) (format.Document, error) {
+	client := tc.Client
+	matches, err := tc.lookup(ctx, symbolName)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse results: %v", err)
+		return format.Document{}, err
 	}
+	matches = resolve.TopTier(matches)
 
-	var definitions []string
-	for _, symbol := range results {
-		kind := ""
-		container := ""
+	doc := format.Document{
+		Banner:    "---\n\n",
+		Separator: "\n",
+		Footer:    "\n",
+		Empty:     fmt.Sprintf("%s not found", symbolName),
+	}
+	if len(matches) == 0 {
+		doc.EmptyKind = string(KindSymbolNotFound)
+	}
 
-		// Skip symbols that we are not looking for. workspace/symbol may return
-		// a large number of fuzzy matches.
-		switch v := symbol.(type) {
-		case *protocol.SymbolInformation:
-			// SymbolInformation results have richer data.
-			kind = fmt.Sprintf("Kind: %s\n", protocol.TableKindMap[v.Kind])
-			if v.ContainerName != "" {
-				container = fmt.Sprintf("Container Name: %s\n", v.ContainerName)
-			}
+	workspaceDir := tc.WorkspaceDir
+	cfg := tc.Settings.ExternalSources
+	skipped := 0
 
-			// Handle different matching strategies based on the search term
-			if strings.Contains(symbolName, ".") {
-				// For qualified names like "Type.Method", require exact match
-				if symbol.GetName() != symbolName {
-					continue
-				}
-			} else {
-				// For unqualified names like "Method"
-				if v.Kind == protocol.Method {
-					// For methods, only match if the method name matches exactly Type.symbolName or Type::symbolName or symbolName
-					if !strings.HasSuffix(symbol.GetName(), "::"+symbolName) && !strings.HasSuffix(symbol.GetName(), "."+symbolName) && symbol.GetName() != symbolName {
-						continue
-					}
-				} else if symbol.GetName() != symbolName {
-					// For non-methods, exact match only
-					continue
-				}
+	// Symbols defined in dependencies are usually not in the server's
+	// workspace index, follow a usage in the workspace instead
+	if len(matches) == 0 && workspaceDir != "" && cfg.Prefer != PreferOff {
+		for _, loc := range findExternalDefinitions(ctx, client, workspaceDir, symbolName) {
+			loc, source, err := locateDefinition(workspaceDir, loc, cfg)
+			section := format.Section{}
+			section.AddField("Symbol", symbolName)
+			section.AddField("File", protocol.PathFromURI(string(loc.URI)))
+			if err != nil {
+				section.Error = err.Error()
+				doc.Sections = append(doc.Sections, section)
+				continue
 			}
-		default:
-			if symbol.GetName() != symbolName {
+			if err := client.OpenFile(ctx, loc.URI.Path()); err != nil {
+				toolsLogger.Error("Error opening file: %v", err)
 				continue
 			}
+			definition, loc, err := GetFullDefinition(ctx, client, loc)
+			if err != nil {
+				toolsLogger.Error("Error getting definition: %v", err)
+				continue
+			}
+			addDefinitionRange(&section, source, loc, definition, cfg.MaxLines, client.PositionEncoding())
+			doc.Sections = append(doc.Sections, section)
 		}
+		return doc, nil
+	}
+
+	// workspace/symbol may return a large number of fuzzy matches, the
+	// resolver only keeps the symbols that score well against the name
+	for _, match := range matches {
+		symbol := match.Symbol
 
 		toolsLogger.Debug("Found symbol: %s", symbol.GetName())
-		loc := symbol.GetLocation()
+		loc, source, locateErr := locateDefinition(workspaceDir, symbol.GetLocation(), cfg)
+		if errors.Is(locateErr, errExternalDisabled) {
+			skipped++
+			continue
+		}
+
+		section := format.Section{}
+		section.AddField("Symbol", symbol.GetName())
+		if locateErr != nil {
+			section.AddField("File", protocol.PathFromURI(string(loc.URI)))
+			section.Error = locateErr.Error()
+			doc.Sections = append(doc.Sections, section)
+			continue
+		}
 
 		err := client.OpenFile(ctx, loc.URI.Path())
 		if err != nil {
@@ -70,35 +97,52 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 			continue
 		}
 
-		banner := "---\n\n"
 		definition, loc, err := GetFullDefinition(ctx, client, loc)
-		locationInfo := fmt.Sprintf(
-			"Symbol: %s\n"+
-				"File: %s\n"+
-				kind+
-				container+
-				"Range: L%d:C%d - L%d:C%d\n\n",
-			symbol.GetName(),
-			strings.TrimPrefix(string(loc.URI), "file://"),
-			loc.Range.Start.Line+1,
-			loc.Range.Start.Character+1,
-			loc.Range.End.Line+1,
-			loc.Range.End.Character+1,
-		)
-
 		if err != nil {
 			toolsLogger.Error("Error getting definition: %v", err)
 			continue
 		}
 
-		definition = addLineNumbers(definition, int(loc.Range.Start.Line)+1)
+		section.AddField("File", protocol.PathFromURI(string(loc.URI)))
+		if v, ok := symbol.(*protocol.SymbolInformation); ok {
+			// SymbolInformation results have richer data.
+			section.AddField("Kind", protocol.TableKindMap[v.Kind])
+			if v.ContainerName != "" {
+				section.AddField("Container Name", v.ContainerName)
+			}
+		}
+		if isDeprecatedSymbol(symbol) {
+			section.AddField("Deprecated", "yes, avoid new uses of this symbol")
+		}
+		addDefinitionRange(&section, source, loc, definition, cfg.MaxLines, client.PositionEncoding())
 
-		definitions = append(definitions, banner+locationInfo+definition+"\n")
+		doc.Sections = append(doc.Sections, section)
 	}
 
-	if len(definitions) == 0 {
-		return fmt.Sprintf("%s not found", symbolName), nil
+	if skipped > 0 && len(doc.Sections) == 0 {
+		doc.Empty = fmt.Sprintf("%s is only defined in dependencies, and reading dependency sources is disabled (externalSources.prefer is off)", symbolName)
 	}
 
-	return strings.Join(definitions, ""), nil
+	return doc, nil
+}
+
+// addDefinitionRange adds the range and code of a definition to a section.
+// Definitions outside the workspace's own code are labeled with their source
+// and cut to maxLines. Character offsets are counted in the given position
+// encoding.
+func addDefinitionRange(section *format.Section, source string, loc protocol.Location, definition string, maxLines int, encoding protocol.PositionEncodingKind) {
+	lines := strings.Split(definition, "\n")
+	if source != "" {
+		section.AddField("External", source)
+		if maxLines > 0 && len(lines) > maxLines {
+			section.Notes = append(section.Notes, fmt.Sprintf("Definition cut to %d of %d lines (externalSources.maxLines)", maxLines, len(lines)))
+			lines = lines[:maxLines]
+		}
+	}
+	columns := readColumns(loc.URI.Path(), encoding)
+	section.AddField("Range", columns.format(loc.Range.Start)+" - "+columns.format(loc.Range.End))
+	section.Snippets = []format.Snippet{{
+		StartLine: int(loc.Range.Start.Line) + 1,
+		Lines:     lines,
+	}}
 }
@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"slices"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// deprecatedLabel marks deprecated symbols and completion items in tool output
+const deprecatedLabel = "[DEPRECATED]"
+
+// isDeprecatedSymbol reports whether the server marked a workspace symbol as
+// deprecated, either with the deprecated tag or the older deprecated field
+func isDeprecatedSymbol(symbol protocol.WorkspaceSymbolResult) bool {
+	switch v := symbol.(type) {
+	case *protocol.SymbolInformation:
+		return v.Deprecated || slices.Contains(v.Tags, protocol.DeprecatedSymbol)
+	case *protocol.WorkspaceSymbol:
+		return slices.Contains(v.Tags, protocol.DeprecatedSymbol)
+	}
+	return false
+}
+
+// isDeprecatedCompletion reports whether the server marked a completion item
+// as deprecated, either with the deprecated tag or the older deprecated field
+func isDeprecatedCompletion(item protocol.CompletionItem) bool {
+	return item.Deprecated || slices.Contains(item.Tags, protocol.ComplDeprecated)
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDeprecatedSymbol(t *testing.T) {
+	tagged := symbolAt("Old", protocol.Function, "/ws/a.go", 0)
+	tagged.Tags = []protocol.SymbolTag{protocol.DeprecatedSymbol}
+	legacy := symbolAt("Older", protocol.Function, "/ws/a.go", 1)
+	legacy.Deprecated = true
+	current := symbolAt("New", protocol.Function, "/ws/a.go", 2)
+
+	assert.True(t, isDeprecatedSymbol(&tagged))
+	assert.True(t, isDeprecatedSymbol(&legacy))
+	assert.False(t, isDeprecatedSymbol(&current))
+
+	workspaceSymbol := &protocol.WorkspaceSymbol{}
+	workspaceSymbol.Tags = []protocol.SymbolTag{protocol.DeprecatedSymbol}
+	assert.True(t, isDeprecatedSymbol(workspaceSymbol))
+
+	assert.Equal(t, "[DEPRECATED] Function Old L1:C1", describeSymbol(&tagged, fileColumns{}))
+	assert.Equal(t, "Function New L3:C1", describeSymbol(&current, fileColumns{}))
+}
+
+func TestFormatCompletions(t *testing.T) {
+	items := []protocol.CompletionItem{
+		{Label: "Title", Kind: protocol.FunctionCompletion, Detail: "func(s string) string", SortText: "0001", Tags: []protocol.CompletionItemTag{protocol.ComplDeprecated}},
+		{Label: "ToUpper", Kind: protocol.FunctionCompletion, Detail: "func(s string) string", SortText: "0003"},
+		{Label: "Trim", Kind: protocol.FunctionCompletion, SortText: "0002"},
+		{Label: "OldReader", Deprecated: true, SortText: "0000"},
+	}
+
+	expected := "Completions at L4:C10: 4\n" +
+		"Trim (Function)\n" +
+		"ToUpper (Function): func(s string) string\n" +
+		"[DEPRECATED] OldReader\n" +
+		"[DEPRECATED] Title (Function): func(s string) string\n"
+	assert.Equal(t, expected, formatCompletions(items, false, 4, 10, 50))
+
+	limited := formatCompletions(items, true, 4, 10, 1)
+	assert.Equal(t, "Completions at L4:C10: 4, showing 1 (incomplete, type more characters to narrow the list)\nTrim (Function)\n", limited)
+
+	assert.Equal(t, "No completions available at L1:C1", formatCompletions(nil, false, 1, 1, 50))
+}
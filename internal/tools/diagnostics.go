@@ -3,27 +3,31 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
 )
 
 // GetDiagnosticsForFile retrieves diagnostics for a specific file from the language server
 func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath string, contextLines int, showLineNumbers bool) (string, error) {
-	// Override with environment variable if specified
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
+	doc, err := GetDiagnosticsDocument(ctx, client, filePath, contextLines, showLineNumbers, false)
+	if err != nil {
+		return "", err
 	}
+	return renderPlain(doc), nil
+}
 
+// GetDiagnosticsDocument retrieves diagnostics for a file and, when includeQuickFixes
+// is set, lists the titles of the quick fixes available for each diagnostic
+func GetDiagnosticsDocument(ctx context.Context, client *lsp.Client, filePath string, contextLines int, showLineNumbers bool, includeQuickFixes bool) (format.Document, error) {
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return format.Document{}, fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Wait for diagnostics
@@ -31,7 +35,7 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	time.Sleep(time.Second * 3)
 
 	// Convert the file path to URI format
-	uri := protocol.DocumentUri("file://" + filePath)
+	uri := protocol.URIFromPath(filePath)
 
 	// Request fresh diagnostics
 	diagParams := protocol.DocumentDiagnosticParams{
@@ -45,43 +49,38 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	// Get diagnostics from the cache
 	diagnostics := client.GetFileDiagnostics(uri)
 
+	doc := format.Document{Empty: "No diagnostics found for " + filePath}
 	if len(diagnostics) == 0 {
-		return "No diagnostics found for " + filePath, nil
+		return doc, nil
 	}
 
 	// Format file header
-	fileInfo := fmt.Sprintf("%s\nDiagnostics in File: %d\n",
-		filePath,
-		len(diagnostics),
-	)
+	section := format.Section{Path: filePath}
+	section.AddField("Diagnostics in File", strconv.Itoa(len(diagnostics)))
+
+	// Look up quick fixes for all diagnostics with a single request
+	var fixes map[int][]string
+	if includeQuickFixes {
+		fixes, err = quickFixTitles(ctx, client, uri, diagnostics)
+		if err != nil {
+			toolsLogger.Error("Failed to get quick fixes: %v", err)
+		}
+	}
 
 	// Create a summary of all the diagnostics
-	var diagSummaries []string
 	var diagLocations []protocol.Location
 
-	for _, diag := range diagnostics {
-		severity := getSeverityString(diag.Severity)
-		location := fmt.Sprintf("L%d:C%d",
-			diag.Range.Start.Line+1,
-			diag.Range.Start.Character+1)
-
-		summary := fmt.Sprintf("%s at %s: %s",
-			severity,
-			location,
-			diag.Message)
-
-		// Add source and code if available
-		if diag.Source != "" {
-			summary += fmt.Sprintf(" (Source: %s", diag.Source)
-			if diag.Code != nil {
-				summary += fmt.Sprintf(", Code: %v", diag.Code)
+	columns := readColumns(filePath, client.PositionEncoding())
+	for i, diag := range diagnostics {
+		summary := formatDiagnostic(diag, columns)
+		if includeQuickFixes {
+			if titles := fixes[i]; len(titles) > 0 {
+				summary += "\n  Quick fixes: " + strings.Join(titles, "; ")
+			} else {
+				summary += "\n  Quick fixes: none"
 			}
-			summary += ")"
-		} else if diag.Code != nil {
-			summary += fmt.Sprintf(" (Code: %v)", diag.Code)
 		}
-
-		diagSummaries = append(diagSummaries, summary)
+		section.Notes = append(section.Notes, summary)
 
 		// Create a location for this diagnostic to use with line ranges
 		diagLocations = append(diagLocations, protocol.Location{
@@ -90,13 +89,19 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 		})
 	}
 
+	if titles := fixes[unattachedQuickFixes]; len(titles) > 0 {
+		section.Notes = append(section.Notes, "Other quick fixes: "+strings.Join(titles, "; "))
+	}
+
 	// Format content with context
-	fileContent, err := os.ReadFile(filePath)
+	fileContent, err := textenc.ReadFile(filePath)
 	if err != nil {
-		return fileInfo + "\nError reading file: " + err.Error(), nil
+		section.Error = err.Error()
+		doc.Sections = append(doc.Sections, section)
+		return doc, nil
 	}
 
-	lines := strings.Split(string(fileContent), "\n")
+	lines := strings.Split(fileContent, "\n")
 
 	// Collect lines to display
 	var linesToShow map[int]bool
@@ -121,18 +126,148 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	// Convert to line ranges
 	lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
 
-	// Format with diagnostics summary in header
-	result := fileInfo
-	if len(diagSummaries) > 0 {
-		result += strings.Join(diagSummaries, "\n") + "\n"
-	}
-
 	// Format the content with ranges
 	if showLineNumbers {
-		result += "\n" + FormatLinesWithRanges(lines, lineRanges)
+		section.Snippets = format.SnippetsFromRanges(lines, lineRanges)
+		section.Focus = focusLines(diagLocations)
+		section.Marks = rangeMarks(lines, diagLocations, client.PositionEncoding())
+	}
+
+	doc.Sections = append(doc.Sections, section)
+	return doc, nil
+}
+
+// unattachedQuickFixes is the key for quick fixes that do not name the diagnostics they fix
+const unattachedQuickFixes = -1
+
+// quickFixTitles requests quick fixes for all diagnostics of a file in one
+// codeAction request spanning every diagnostic range
+func quickFixTitles(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) (map[int][]string, error) {
+	if len(diagnostics) == 0 {
+		return nil, nil
+	}
+
+	span := diagnostics[0].Range
+	for _, diag := range diagnostics[1:] {
+		if comparePositions(diag.Range.Start, span.Start) < 0 {
+			span.Start = diag.Range.Start
+		}
+		if comparePositions(diag.Range.End, span.End) > 0 {
+			span.End = diag.Range.End
+		}
+	}
+
+	actions, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        span,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        []protocol.CodeActionKind{protocol.QuickFix},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code actions: %w", err)
+	}
+
+	return assignQuickFixes(diagnostics, actions), nil
+}
+
+// assignQuickFixes maps code actions to the index of the diagnostics they fix.
+// Actions that do not list their diagnostics are keyed by unattachedQuickFixes.
+func assignQuickFixes(diagnostics []protocol.Diagnostic, actions []protocol.Or_Result_textDocument_codeAction_Item0_Elem) map[int][]string {
+	fixes := make(map[int][]string)
+	for _, item := range actions {
+		switch action := item.Value.(type) {
+		case protocol.CodeAction:
+			if action.Disabled != nil {
+				continue
+			}
+			if action.Kind != "" && !strings.HasPrefix(string(action.Kind), string(protocol.QuickFix)) {
+				continue
+			}
+			attached := false
+			for _, fixed := range action.Diagnostics {
+				for i, diag := range diagnostics {
+					if diag.Range == fixed.Range && diag.Message == fixed.Message {
+						fixes[i] = append(fixes[i], action.Title)
+						attached = true
+						break
+					}
+				}
+			}
+			if !attached {
+				fixes[unattachedQuickFixes] = append(fixes[unattachedQuickFixes], action.Title)
+			}
+		case protocol.Command:
+			fixes[unattachedQuickFixes] = append(fixes[unattachedQuickFixes], action.Title)
+		}
+	}
+	return fixes
+}
+
+// comparePositions orders two positions, returning -1, 0 or 1
+func comparePositions(a, b protocol.Position) int {
+	switch {
+	case a.Line < b.Line:
+		return -1
+	case a.Line > b.Line:
+		return 1
+	case a.Character < b.Character:
+		return -1
+	case a.Character > b.Character:
+		return 1
+	}
+	return 0
+}
+
+// diagnosticPuller requests the diagnostics of a document
+type diagnosticPuller interface {
+	Diagnostic(ctx context.Context, params protocol.DocumentDiagnosticParams) (protocol.DocumentDiagnosticReport, error)
+}
+
+// pullDiagnostics requests the diagnostics of a document from a server that
+// supports pull diagnostics. ok is false when the server does not support them
+// or answered that nothing changed, leaving the published diagnostics as the
+// only source.
+func pullDiagnostics(ctx context.Context, client diagnosticPuller, uri protocol.DocumentUri) (diagnostics []protocol.Diagnostic, ok bool) {
+	report, err := client.Diagnostic(ctx, protocol.DocumentDiagnosticParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		toolsLogger.Debug("Pull diagnostics not available for %s: %v", uri, err)
+		return nil, false
+	}
+	switch full := report.Value.(type) {
+	case protocol.RelatedFullDocumentDiagnosticReport:
+		return full.Items, true
+	case protocol.FullDocumentDiagnosticReport:
+		return full.Items, true
+	}
+	return nil, false
+}
+
+// formatDiagnostic renders a one line summary of a diagnostic in a file
+func formatDiagnostic(diag protocol.Diagnostic, columns fileColumns) string {
+	severity := getSeverityString(diag.Severity)
+	location := columns.format(diag.Range.Start)
+
+	summary := fmt.Sprintf("%s at %s: %s",
+		severity,
+		location,
+		diag.Message)
+
+	// Add source and code if available
+	if diag.Source != "" {
+		summary += fmt.Sprintf(" (Source: %s", diag.Source)
+		if diag.Code != nil {
+			summary += fmt.Sprintf(", Code: %v", diag.Code)
+		}
+		summary += ")"
+	} else if diag.Code != nil {
+		summary += fmt.Sprintf(" (Code: %v)", diag.Code)
 	}
 
-	return result, nil
+	return summary
 }
 
 func getSeverityString(severity protocol.DiagnosticSeverity) string {
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func diagAt(line uint32, message string) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: 0},
+			End:   protocol.Position{Line: line, Character: 5},
+		},
+		Severity: protocol.SeverityError,
+		Message:  message,
+	}
+}
+
+func TestAssignQuickFixes(t *testing.T) {
+	unused := diagAt(3, "declared and not used: x")
+	missing := diagAt(7, "undefined: fmt")
+	diagnostics := []protocol.Diagnostic{unused, missing}
+
+	actions := []protocol.Or_Result_textDocument_codeAction_Item0_Elem{
+		{Value: protocol.CodeAction{Title: "Remove variable x", Kind: protocol.QuickFix, Diagnostics: []protocol.Diagnostic{unused}}},
+		{Value: protocol.CodeAction{Title: "Add import: \"fmt\"", Kind: "quickfix.import", Diagnostics: []protocol.Diagnostic{missing}}},
+		{Value: protocol.CodeAction{Title: "Disabled fix", Kind: protocol.QuickFix, Diagnostics: []protocol.Diagnostic{missing}, Disabled: &protocol.CodeActionDisabled{Reason: "no"}}},
+		{Value: protocol.CodeAction{Title: "Extract function", Kind: protocol.RefactorExtract}},
+		{Value: protocol.CodeAction{Title: "Fix all in file", Kind: protocol.QuickFix}},
+		{Value: protocol.Command{Title: "Organize imports", Command: "organize"}},
+	}
+
+	fixes := assignQuickFixes(diagnostics, actions)
+	assert.Equal(t, []string{"Remove variable x"}, fixes[0])
+	assert.Equal(t, []string{"Add import: \"fmt\""}, fixes[1])
+	assert.Equal(t, []string{"Fix all in file", "Organize imports"}, fixes[unattachedQuickFixes])
+}
+
+func TestComparePositions(t *testing.T) {
+	assert.Equal(t, -1, comparePositions(protocol.Position{Line: 1, Character: 9}, protocol.Position{Line: 2, Character: 0}))
+	assert.Equal(t, 1, comparePositions(protocol.Position{Line: 2, Character: 1}, protocol.Position{Line: 2, Character: 0}))
+	assert.Equal(t, 0, comparePositions(protocol.Position{Line: 2, Character: 1}, protocol.Position{Line: 2, Character: 1}))
+}
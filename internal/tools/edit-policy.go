@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+)
+
+// parseSeverity converts a severity name from the settings file to an LSP severity
+func parseSeverity(name string) (protocol.DiagnosticSeverity, error) {
+	switch strings.ToLower(name) {
+	case "", "error":
+		return protocol.SeverityError, nil
+	case "warning", "warn":
+		return protocol.SeverityWarning, nil
+	case "info", "information":
+		return protocol.SeverityInformation, nil
+	case "hint":
+		return protocol.SeverityHint, nil
+	default:
+		return 0, fmt.Errorf("unknown severity: %s", name)
+	}
+}
+
+// countBlockingDiagnostics counts diagnostics at least as severe as minSeverity.
+// Lower severity values are more severe in LSP.
+func countBlockingDiagnostics(diagnostics []protocol.Diagnostic, minSeverity protocol.DiagnosticSeverity) int {
+	count := 0
+	for _, diag := range diagnostics {
+		if diag.Severity != 0 && diag.Severity <= minSeverity {
+			count++
+		}
+	}
+	return count
+}
+
+// editPolicyDiagnosticsTimeout is how long the edit policy waits in total for
+// the first diagnostics of files the server has not analyzed yet
+const editPolicyDiagnosticsTimeout = 5 * time.Second
+
+// editPolicyClient is the part of the LSP client the edit policy uses
+type editPolicyClient interface {
+	diagnosticPuller
+	OpenFile(ctx context.Context, filepath string) error
+	HasDiagnostics(uri protocol.DocumentUri) bool
+	GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic
+	SubscribeDiagnostics(listener lsp.DiagnosticsListener) func()
+}
+
+// currentDiagnostics returns the diagnostics of a file for the edit policy.
+// The file is opened, and servers that support pull diagnostics are asked for
+// them. For the others, when nothing was published for the file yet, the first
+// publication is awaited until the deadline.
+func currentDiagnostics(ctx context.Context, client editPolicyClient, filePath string, deadline time.Time) []protocol.Diagnostic {
+	uri := protocol.URIFromPath(filePath)
+
+	// Subscribe before opening so the first publication is not missed
+	published := make(chan struct{}, 1)
+	unsubscribe := client.SubscribeDiagnostics(func(publishedURI protocol.DocumentUri, _ []protocol.Diagnostic) {
+		if publishedURI != uri {
+			return
+		}
+		select {
+		case published <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		// Files that do not exist yet have no diagnostics
+		toolsLogger.Debug("Edit policy could not open %s: %v", filePath, err)
+		return nil
+	}
+
+	if diagnostics, ok := pullDiagnostics(ctx, client, uri); ok {
+		return diagnostics
+	}
+	if !client.HasDiagnostics(uri) {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		select {
+		case <-published:
+		case <-timer.C:
+			toolsLogger.Debug("No diagnostics published for %s in time for the edit policy", filePath)
+		case <-ctx.Done():
+		}
+	}
+	return client.GetFileDiagnostics(uri)
+}
+
+// CheckEditPolicy returns an error when the edit policy is enabled and any of the
+// target files currently has more diagnostics than allowed. Files are opened
+// and their diagnostics pulled or awaited first, so that edits to files the
+// server has not analyzed yet are judged too.
+func CheckEditPolicy(ctx context.Context, client editPolicyClient, policy settings.EditPolicySettings, filePaths []string) error {
+	if !policy.Enabled {
+		return nil
+	}
+
+	minSeverity, err := parseSeverity(policy.MinSeverity)
+	if err != nil {
+		return fmt.Errorf("invalid edit policy: %w", err)
+	}
+
+	var blocked []string
+	deadline := time.Now().Add(editPolicyDiagnosticsTimeout)
+	for _, filePath := range filePaths {
+		diagnostics := currentDiagnostics(ctx, client, filePath, deadline)
+		if count := countBlockingDiagnostics(diagnostics, minSeverity); count > policy.MaxDiagnostics {
+			blocked = append(blocked, fmt.Sprintf("%s (%d)", filePath, count))
+		}
+	}
+
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	sort.Strings(blocked)
+	return fmt.Errorf("edit blocked by policy: files have more than %d diagnostics of severity %s or worse: %s. Fix the existing problems first or pass force: true",
+		policy.MaxDiagnostics, strings.ToLower(getSeverityString(minSeverity)), strings.Join(blocked, ", "))
+}
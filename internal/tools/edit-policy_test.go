@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := parseSeverity("")
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.SeverityError, severity)
+
+	severity, err = parseSeverity("Warning")
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.SeverityWarning, severity)
+
+	_, err = parseSeverity("fatal")
+	assert.Error(t, err)
+}
+
+func TestCountBlockingDiagnostics(t *testing.T) {
+	diagnostics := []protocol.Diagnostic{
+		{Severity: protocol.SeverityError},
+		{Severity: protocol.SeverityWarning},
+		{Severity: protocol.SeverityHint},
+		{Severity: 0}, // unspecified severity is never counted
+	}
+
+	assert.Equal(t, 1, countBlockingDiagnostics(diagnostics, protocol.SeverityError))
+	assert.Equal(t, 2, countBlockingDiagnostics(diagnostics, protocol.SeverityWarning))
+	assert.Equal(t, 3, countBlockingDiagnostics(diagnostics, protocol.SeverityHint))
+	assert.Equal(t, 0, countBlockingDiagnostics(nil, protocol.SeverityHint))
+}
+
+// policyWorkspace writes a Go file with an error the server has not seen yet
+// and a clean one
+func policyWorkspace(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.go"), []byte("package main\n\nfunc Greet() string {\n\treturn BROKEN\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "clean.go"), []byte("package main\n\nfunc Farewell() string {\n\treturn \"bye\"\n}\n"), 0644))
+	return dir
+}
+
+func TestCheckEditPolicyUnopenedFiles(t *testing.T) {
+	policy := settings.EditPolicySettings{Enabled: true, MinSeverity: "error"}
+	for _, mode := range []string{"push", "pull"} {
+		t.Run(mode, func(t *testing.T) {
+			dir := policyWorkspace(t)
+			client := startFakeServer(t, mode, dir)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			broken := filepath.Join(dir, "broken.go")
+			err := CheckEditPolicy(ctx, client, policy, []string{broken})
+			assert.ErrorContains(t, err, "edit blocked by policy")
+			assert.ErrorContains(t, err, broken+" (1)")
+			assert.True(t, client.IsFileOpen(broken))
+
+			assert.NoError(t, CheckEditPolicy(ctx, client, policy, []string{filepath.Join(dir, "clean.go")}))
+			// Files that do not exist yet are not blocked
+			assert.NoError(t, CheckEditPolicy(ctx, client, policy, []string{filepath.Join(dir, "new.go")}))
+		})
+	}
+}
+
+func TestReplaceSymbolEditPolicy(t *testing.T) {
+	for _, mode := range []string{"push", "pull"} {
+		t.Run(mode, func(t *testing.T) {
+			dir := policyWorkspace(t)
+			client := startFakeServer(t, mode, dir)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			cfg := settings.Default()
+			cfg.EditPolicy = settings.EditPolicySettings{Enabled: true, MinSeverity: "error"}
+			tc := &ToolContext{Client: client, WorkspaceDir: dir, Settings: cfg, Resolver: resolve.New(cfg.SymbolMatch), ContextLines: -1}
+			broken := filepath.Join(dir, "broken.go")
+
+			// The server has not analyzed the file yet, its error still
+			// blocks the edit
+			_, err := ReplaceSymbol(ctx, tc, "Greet", "func Greet() string {\n\treturn \"hi\"\n}", "", false)
+			assert.ErrorContains(t, err, "edit blocked by policy")
+			content, err := os.ReadFile(broken)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "BROKEN")
+
+			_, err = ReplaceSymbol(ctx, tc, "Farewell", "func Farewell() string {\n\treturn \"later\"\n}", "", false)
+			assert.NoError(t, err)
+
+			_, err = ReplaceSymbol(ctx, tc, "Greet", "func Greet() string {\n\treturn \"hi\"\n}", "", true)
+			require.NoError(t, err)
+			content, err = os.ReadFile(broken)
+			require.NoError(t, err)
+			assert.Equal(t, "package main\n\nfunc Greet() string {\n\treturn \"hi\"\n}\n", string(content))
+		})
+	}
+}
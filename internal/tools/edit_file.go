@@ -1,15 +1,14 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
 	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
@@ -20,9 +19,13 @@ type TextEdit struct {
 }
 
 func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, edits []TextEdit) (string, error) {
+	if source, ok := ExternalSource(filePath); ok {
+		return "", errorf(KindWorkspaceViolation, "%s is dependency source (%s) and is read-only", filePath, source)
+	}
+
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Create a sorted copy of edits for reporting
@@ -62,7 +65,7 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 		// Get the range covering the requested lines
 		rng, err := getRange(edit.StartLine, edit.EndLine, filePath)
 		if err != nil {
-			return "", fmt.Errorf("invalid position: %v", err)
+			return "", fmt.Errorf("invalid position: %w", err)
 		}
 
 		// Always do a replacement
@@ -79,7 +82,7 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 	}
 
 	if err := utilities.ApplyWorkspaceEdit(edit); err != nil {
-		return "", fmt.Errorf("failed to apply text edits: %v", err)
+		return "", fmt.Errorf("failed to apply text edits: %w", err)
 	}
 
 	return fmt.Sprintf("Successfully applied text edits. %d lines removed, %d lines added.", linesRemovedSorted, linesAddedSorted), nil
@@ -87,21 +90,21 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 
 // getRange creates a protocol.Range that covers the specified start and end lines
 func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
-	content, err := os.ReadFile(filePath)
+	content, err := textenc.ReadFile(filePath)
 	if err != nil {
 		return protocol.Range{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Detect line ending style
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// Split lines without the line endings
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// Handle start line positioning
 	if startLine < 1 {
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// entryPointHeadings titles the groups of entry points
+var entryPointHeadings = map[resolve.EntryPointKind]string{
+	resolve.EntryMain:        "Main functions",
+	resolve.EntryCommand:     "CLI commands",
+	resolve.EntryHTTPHandler: "HTTP handlers",
+	resolve.EntryAPI:         "Exported API",
+}
+
+// FindEntryPoints lists the probable entry points of the workspace grouped by
+// kind: main functions, CLI commands, HTTP handlers and exported library API.
+// At most limit symbols are listed for each kind.
+func FindEntryPoints(ctx context.Context, tc *ToolContext, limit int) (string, error) {
+	entryPoints, err := tc.Resolver.EntryPoints(ctx, tc.Client)
+	if err != nil {
+		return "", err
+	}
+	if len(entryPoints) == 0 {
+		return "No entry points found", nil
+	}
+
+	byKind := make(map[resolve.EntryPointKind][]resolve.EntryPoint)
+	for _, entryPoint := range entryPoints {
+		byKind[entryPoint.Kind] = append(byKind[entryPoint.Kind], entryPoint)
+	}
+
+	var counts []string
+	for _, kind := range resolve.EntryPointKinds {
+		if n := len(byKind[kind]); n > 0 {
+			counts = append(counts, pluralize(n, string(kind)))
+		}
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %s: %s\n", pluralize(len(entryPoints), "probable entry point"), strings.Join(counts, ", ")))
+	for _, kind := range resolve.EntryPointKinds {
+		group := byKind[kind]
+		if len(group) == 0 {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("\n%s:\n", entryPointHeadings[kind]))
+		for i, entryPoint := range group {
+			if limit > 0 && i == limit {
+				result.WriteString(fmt.Sprintf("  ... %d more, raise limit to see them\n", len(group)-limit))
+				break
+			}
+			loc := entryPoint.Symbol.GetLocation()
+			result.WriteString(fmt.Sprintf("  %s %s:L%d\n", entryPoint.Symbol.GetName(), protocol.PathFromURI(string(loc.URI)), loc.Range.Start.Line+1))
+		}
+	}
+	return result.String(), nil
+}
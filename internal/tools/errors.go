@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// ErrorKind classifies why a tool failed, so that clients can branch on the
+// kind of failure instead of parsing messages
+type ErrorKind string
+
+const (
+	// KindSymbolNotFound means no symbol matched the name or position given
+	KindSymbolNotFound ErrorKind = "symbol_not_found"
+
+	// KindServerNotReady means the language server has exited, is still
+	// starting or has not caught up with the latest changes
+	KindServerNotReady ErrorKind = "server_not_ready"
+
+	// KindCapabilityMissing means the language server does not support the
+	// request the tool needs
+	KindCapabilityMissing ErrorKind = "capability_missing"
+
+	// KindTimeout means the tool ran out of time
+	KindTimeout ErrorKind = "timeout"
+
+	// KindWorkspaceViolation means the tool was asked to change files it may
+	// not change, such as read-only dependency sources
+	KindWorkspaceViolation ErrorKind = "workspace_violation"
+
+	// KindOther is every failure not classified above
+	KindOther ErrorKind = "other"
+)
+
+// Error is a tool failure of a known kind
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError classifies err as a failure of a kind
+func NewError(kind ErrorKind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// errorf formats a failure of a kind like fmt.Errorf
+func errorf(kind ErrorKind, format string, args ...any) error {
+	return NewError(kind, fmt.Errorf(format, args...))
+}
+
+// KindOf returns the kind of a tool failure. Errors wrapped with %w keep their
+// kind; errors from the language server are classified by their code.
+func KindOf(err error) ErrorKind {
+	var toolErr *Error
+	if errors.As(err, &toolErr) {
+		return toolErr.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+	if errors.Is(err, lsp.ErrConnectionClosed) {
+		return KindServerNotReady
+	}
+	var responseErr *lsp.ResponseError
+	if errors.As(err, &responseErr) {
+		switch responseErr.Code {
+		case int(protocol.MethodNotFound):
+			return KindCapabilityMissing
+		case int(protocol.ServerNotInitialized), int(protocol.ContentModified):
+			return KindServerNotReady
+		}
+	}
+	return KindOther
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorKind
+	}{
+		{"classified", errorf(KindSymbolNotFound, "Foo not found"), KindSymbolNotFound},
+		{"wrapped", fmt.Errorf("failed to replace symbol: %w", errorf(KindWorkspaceViolation, "read-only")), KindWorkspaceViolation},
+		{"deadline", fmt.Errorf("textDocument/references request cancelled: %w", context.DeadlineExceeded), KindTimeout},
+		{"connection closed", fmt.Errorf("textDocument/hover request failed: %w", lsp.ErrConnectionClosed), KindServerNotReady},
+		{"method not found", fmt.Errorf("failed to prepare call hierarchy: %w", &lsp.ResponseError{Code: -32601, Message: "unhandled method"}), KindCapabilityMissing},
+		{"not initialized", &lsp.ResponseError{Code: -32002, Message: "not initialized"}, KindServerNotReady},
+		{"content modified", &lsp.ResponseError{Code: -32801, Message: "content modified"}, KindServerNotReady},
+		{"other response", &lsp.ResponseError{Code: -32602, Message: "No references found at position"}, KindOther},
+		{"unclassified", errors.New("could not open file"), KindOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, KindOf(tt.err))
+		})
+	}
+}
+
+func TestErrorKeepsMessage(t *testing.T) {
+	err := fmt.Errorf("failed to rename symbol: %w", &lsp.ResponseError{Code: -32602, Message: "No references found at position"})
+	assert.Equal(t, "failed to rename symbol: request failed: No references found at position (code: -32602)", err.Error())
+	assert.Equal(t, "Foo not found", errorf(KindSymbolNotFound, "Foo not found").Error())
+}
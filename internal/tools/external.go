@@ -0,0 +1,382 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+const (
+	// maxUsageFiles bounds how many workspace files are searched for a usage of
+	// an external symbol
+	maxUsageFiles = 5000
+
+	// maxUsageAttempts bounds how many usages are followed with go to definition
+	maxUsageAttempts = 20
+)
+
+// Values of externalSources.prefer
+const (
+	PreferCache  = "cache"
+	PreferVendor = "vendor"
+	PreferOff    = "off"
+)
+
+// ValidateExternalSources checks the settings for reading definitions in
+// dependencies
+func ValidateExternalSources(cfg settings.ExternalSourceSettings) error {
+	switch cfg.Prefer {
+	case PreferCache, PreferVendor, PreferOff:
+		return nil
+	}
+	return fmt.Errorf("invalid externalSources.prefer %q, expected %s, %s or %s", cfg.Prefer, PreferCache, PreferVendor, PreferOff)
+}
+
+// errExternalDisabled is returned for dependency definitions when reading
+// them is turned off
+var errExternalDisabled = errors.New("reading dependency sources is disabled")
+
+// locateDefinition applies the external source settings to a definition. It
+// returns the location to read, which may be a vendored copy, and a label for
+// definitions outside the workspace's own code. An error means the definition
+// is not read.
+func locateDefinition(workspaceDir string, loc protocol.Location, cfg settings.ExternalSourceSettings) (protocol.Location, string, error) {
+	path := protocol.PathFromURI(string(loc.URI))
+	source, external := ExternalSource(path)
+	if !external {
+		if workspaceDir != "" && !insideWorkspace(workspaceDir, path) {
+			return loc, "outside the workspace", nil
+		}
+		return loc, "", nil
+	}
+	if cfg.Prefer == PreferOff {
+		return loc, "", errExternalDisabled
+	}
+
+	label := source + " (read-only)"
+	if cfg.Prefer == PreferVendor {
+		if vendored, ok := vendoredCopy(workspaceDir, path); ok {
+			loc.URI = protocol.URIFromPath(vendored)
+			path = vendored
+			label = "vendored copy of " + source
+		}
+	}
+
+	if cfg.MaxFileBytes > 0 {
+		if info, err := os.Stat(path); err == nil && info.Size() > int64(cfg.MaxFileBytes) {
+			return loc, label, fmt.Errorf("%s is %d bytes, over the %d byte limit for dependency files (externalSources.maxFileBytes)", path, info.Size(), cfg.MaxFileBytes)
+		}
+	}
+	return loc, label, nil
+}
+
+// vendoredCopy finds the copy of a dependency file in the workspace's vendor
+// directory, e.g. vendor/github.com/google/uuid/uuid.go for
+// pkg/mod/github.com/google/uuid@v1.6.0/uuid.go
+func vendoredCopy(workspaceDir, path string) (string, bool) {
+	if workspaceDir == "" {
+		return "", false
+	}
+	slashed := filepath.ToSlash(path)
+
+	var rel string
+	switch {
+	case strings.Contains(slashed, "/pkg/mod/"):
+		rest := slashed[strings.LastIndex(slashed, "/pkg/mod/")+len("/pkg/mod/"):]
+		parts := strings.Split(rest, "/")
+		for i, part := range parts {
+			if at := strings.Index(part, "@"); at >= 0 {
+				parts[i] = part[:at]
+				break
+			}
+		}
+		rel = unescapeModulePath(strings.Join(parts, "/"))
+	case strings.Contains(slashed, "/.cargo/registry/src/"):
+		rest := slashed[strings.LastIndex(slashed, "/.cargo/registry/src/")+len("/.cargo/registry/src/"):]
+		parts := strings.Split(rest, "/")
+		if len(parts) < 3 {
+			return "", false
+		}
+		// registry/crate-1.2.3/... is vendored as crate/...
+		crate := parts[1]
+		for i := 1; i+1 < len(crate); i++ {
+			if crate[i] == '-' && crate[i+1] >= '0' && crate[i+1] <= '9' {
+				crate = crate[:i]
+				break
+			}
+		}
+		rel = strings.Join(append([]string{crate}, parts[2:]...), "/")
+	case strings.Contains(slashed, "/site-packages/"):
+		rel = slashed[strings.LastIndex(slashed, "/site-packages/")+len("/site-packages/"):]
+	default:
+		return "", false
+	}
+
+	vendored := filepath.Join(workspaceDir, "vendor", filepath.FromSlash(rel))
+	if _, err := os.Stat(vendored); err != nil {
+		return "", false
+	}
+	return vendored, true
+}
+
+// unescapeModulePath undoes the module cache's case encoding, in which
+// upper case letters are written as ! followed by the lower case letter
+func unescapeModulePath(path string) string {
+	var unescaped strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '!' && i+1 < len(path) {
+			i++
+			unescaped.WriteString(strings.ToUpper(string(path[i])))
+			continue
+		}
+		unescaped.WriteByte(path[i])
+	}
+	return unescaped.String()
+}
+
+// ExternalSource describes where a file outside the workspace's own code comes
+// from, e.g. "Go module cache: github.com/google/uuid@v1.6.0". It returns false
+// for files that belong to the workspace. Dependency sources are read-only.
+func ExternalSource(path string) (string, bool) {
+	slashed := filepath.ToSlash(path)
+	if rest, ok := goStandardLibrary(slashed); ok {
+		return "Go standard library: " + pathDir(rest), true
+	}
+
+	stores := []struct {
+		marker string
+		label  string
+		// skip is how many path components after the marker precede the
+		// dependency, e.g. the registry index of cargo
+		skip int
+		// components is how many path components name the dependency
+		components int
+	}{
+		{"/pkg/mod/", "Go module cache", 0, 0},
+		{"/site-packages/", "Python site-packages", 0, 1},
+		{"/dist-packages/", "Python dist-packages", 0, 1},
+		{"/node_modules/", "node_modules", 0, 1},
+		{"/.cargo/registry/src/", "Cargo registry", 1, 1},
+		{"/.cargo/git/checkouts/", "Cargo git checkout", 0, 1},
+		{"/.rustup/toolchains/", "Rust toolchain", 0, 1},
+	}
+	for _, store := range stores {
+		index := strings.LastIndex(slashed, store.marker)
+		if index < 0 {
+			continue
+		}
+		rest := slashed[index+len(store.marker):]
+		for i := 0; i < store.skip; i++ {
+			if _, after, ok := strings.Cut(rest, "/"); ok {
+				rest = after
+			}
+		}
+		return store.label + ": " + dependencyName(rest, store.components), true
+	}
+	return "", false
+}
+
+// goStandardLibrary returns the path of a file relative to GOROOT/src when it is
+// in a Go installation, recognized by GOROOT/src/runtime/runtime.go
+func goStandardLibrary(slashed string) (string, bool) {
+	for index := strings.Index(slashed, "/src/"); index >= 0; {
+		root := slashed[:index]
+		if _, err := os.Stat(filepath.FromSlash(root + "/src/runtime/runtime.go")); err == nil {
+			return slashed[index+len("/src/"):], true
+		}
+		next := strings.Index(slashed[index+1:], "/src/")
+		if next < 0 {
+			break
+		}
+		index += next + 1
+	}
+	return "", false
+}
+
+// dependencyName extracts the name of a dependency from a path inside a
+// dependency store. Zero components means up to the component holding the
+// module version, e.g. github.com/google/uuid@v1.6.0.
+func dependencyName(rest string, components int) string {
+	parts := strings.Split(rest, "/")
+	if len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	if components == 0 {
+		for i, part := range parts {
+			if strings.Contains(part, "@") {
+				return strings.Join(parts[:i+1], "/")
+			}
+		}
+		return strings.Join(parts, "/")
+	}
+	// Scoped npm packages have two components, e.g. @types/node
+	if strings.HasPrefix(parts[0], "@") {
+		components++
+	}
+	if components > len(parts) {
+		components = len(parts)
+	}
+	return strings.Join(parts[:components], "/")
+}
+
+// pathDir returns the directory of a slash separated path
+func pathDir(path string) string {
+	if index := strings.LastIndex(path, "/"); index >= 0 {
+		return path[:index]
+	}
+	return path
+}
+
+// insideWorkspace reports whether path is in the workspace and not in one of
+// its dependency directories, e.g. node_modules or a virtualenv
+func insideWorkspace(workspaceDir, path string) bool {
+	rel, err := filepath.Rel(workspaceDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	_, external := ExternalSource(path)
+	return !external
+}
+
+// externalDefinitionClient is the part of the LSP client used to follow usages
+// of a symbol into dependency sources
+type externalDefinitionClient interface {
+	OpenFile(ctx context.Context, path string) error
+	Definition(ctx context.Context, params protocol.DefinitionParams) (protocol.Or_Result_textDocument_definition, error)
+	LanguageID(path string) protocol.LanguageKind
+	PositionEncoding() protocol.PositionEncodingKind
+}
+
+// findExternalDefinitions finds the definitions of a symbol that workspace/symbol
+// does not know because it is defined in a dependency. Most servers only index
+// the workspace, so the workspace is searched for a usage of the symbol, e.g.
+// "http.Client", and go to definition is run on it.
+func findExternalDefinitions(ctx context.Context, client externalDefinitionClient, workspaceDir, symbolName string) []protocol.Location {
+	usage, err := usagePattern(symbolName)
+	if err != nil {
+		return nil
+	}
+	nameOffset := len(symbolName) - len(lastComponent(symbolName))
+
+	var found []protocol.Location
+	attempts, files := 0, 0
+	_ = filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != workspaceDir && skipWalkDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if client.LanguageID(path) == "" {
+			return nil
+		}
+		if files++; files > maxUsageFiles {
+			return filepath.SkipAll
+		}
+
+		for _, position := range usagePositions(path, usage, nameOffset, client.PositionEncoding()) {
+			if attempts++; attempts > maxUsageAttempts {
+				return filepath.SkipAll
+			}
+			if err := client.OpenFile(ctx, path); err != nil {
+				toolsLogger.Debug("Could not open %s: %v", path, err)
+				return nil
+			}
+			result, err := client.Definition(ctx, protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+					Position:     position,
+				},
+			})
+			if err != nil {
+				toolsLogger.Debug("Definition failed at %s:%d: %v", path, position.Line+1, err)
+				continue
+			}
+			for _, loc := range definitionLocations(result) {
+				if !insideWorkspace(workspaceDir, protocol.PathFromURI(string(loc.URI))) {
+					found = append(found, loc)
+				}
+			}
+			if len(found) > 0 {
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// usagePattern matches the symbol name as a whole identifier
+func usagePattern(symbolName string) (*regexp.Regexp, error) {
+	return regexp.Compile(`(^|[^\w$])` + regexp.QuoteMeta(symbolName) + `($|[^\w$])`)
+}
+
+// lastComponent returns the unqualified name of a symbol, e.g. Client for
+// http.Client or Serialize for serde::Serialize
+func lastComponent(symbolName string) string {
+	if index := strings.LastIndexAny(symbolName, ".:"); index >= 0 {
+		return symbolName[index+1:]
+	}
+	return symbolName
+}
+
+// usagePositions returns the positions of the unqualified name in every usage
+// of the symbol in a file, with characters counted in the given position
+// encoding
+func usagePositions(path string, usage *regexp.Regexp, nameOffset int, encoding protocol.PositionEncodingKind) []protocol.Position {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var positions []protocol.Position
+	scanner := bufio.NewScanner(file)
+	for line := 0; scanner.Scan(); line++ {
+		text := scanner.Text()
+		for _, match := range usage.FindAllStringSubmatchIndex(text, -1) {
+			// match[3] is the end of the leading boundary group
+			positions = append(positions, protocol.Position{
+				Line:      uint32(line),
+				Character: utilities.CharacterOffset(text, match[3]+nameOffset, encoding),
+			})
+		}
+	}
+	return positions
+}
+
+// definitionLocations flattens the result of textDocument/definition
+func definitionLocations(result protocol.Or_Result_textDocument_definition) []protocol.Location {
+	switch v := result.Value.(type) {
+	case protocol.Definition:
+		switch d := v.Value.(type) {
+		case protocol.Location:
+			return []protocol.Location{d}
+		case []protocol.Location:
+			return d
+		}
+	case []protocol.DefinitionLink:
+		locations := make([]protocol.Location, 0, len(v))
+		for _, link := range v {
+			locations = append(locations, protocol.Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+		}
+		return locations
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalSource(t *testing.T) {
+	goroot := t.TempDir()
+	writeFile(t, filepath.Join(goroot, "src", "runtime", "runtime.go"), "package runtime\n")
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/home/u/go/pkg/mod/github.com/google/uuid@v1.6.0/uuid.go", "Go module cache: github.com/google/uuid@v1.6.0"},
+		{"/home/u/go/pkg/mod/golang.org/x/text@v0.25.0/encoding/charmap/charmap.go", "Go module cache: golang.org/x/text@v0.25.0"},
+		{filepath.Join(goroot, "src", "net", "http", "client.go"), "Go standard library: net/http"},
+		{"/repo/.venv/lib/python3.12/site-packages/requests/sessions.py", "Python site-packages: requests"},
+		{"/repo/node_modules/express/lib/router/index.js", "node_modules: express"},
+		{"/repo/node_modules/@types/node/fs.d.ts", "node_modules: @types/node"},
+		{"/home/u/.cargo/registry/src/index.crates.io-6f17d22bba15001f/serde-1.0.219/src/ser/mod.rs", "Cargo registry: serde-1.0.219"},
+		{"/repo/src/server.go", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			source, ok := ExternalSource(tt.path)
+			assert.Equal(t, tt.expected, source)
+			assert.Equal(t, tt.expected != "", ok)
+		})
+	}
+}
+
+func TestInsideWorkspace(t *testing.T) {
+	assert.True(t, insideWorkspace("/repo", "/repo/cmd/main.go"))
+	assert.False(t, insideWorkspace("/repo", "/repo/node_modules/express/index.js"))
+	assert.False(t, insideWorkspace("/repo", "/repository/main.go"))
+	assert.False(t, insideWorkspace("/repo", "/usr/lib/go/src/fmt/print.go"))
+}
+
+// fakeDefinitionClient resolves every position to the same definition
+type fakeDefinitionClient struct {
+	definition protocol.Or_Result_textDocument_definition
+	asked      []protocol.TextDocumentPositionParams
+}
+
+func (f *fakeDefinitionClient) OpenFile(ctx context.Context, path string) error {
+	return nil
+}
+
+func (f *fakeDefinitionClient) Definition(ctx context.Context, params protocol.DefinitionParams) (protocol.Or_Result_textDocument_definition, error) {
+	f.asked = append(f.asked, params.TextDocumentPositionParams)
+	return f.definition, nil
+}
+
+func (f *fakeDefinitionClient) LanguageID(path string) protocol.LanguageKind {
+	if strings.HasSuffix(path, ".go") {
+		return "go"
+	}
+	return ""
+}
+
+func (f *fakeDefinitionClient) PositionEncoding() protocol.PositionEncodingKind {
+	return protocol.UTF16
+}
+
+func TestUsagePositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	writeFile(t, path, "package main\n\nvar s = \"é\" + http.Client{}.Name\n")
+	usage, err := usagePattern("http.Client")
+	assert.NoError(t, err)
+
+	// The name is found after the qualifier, and characters before it are
+	// counted in the encoding of the server
+	assert.Equal(t, []protocol.Position{{Line: 2, Character: 19}}, usagePositions(path, usage, len("http."), protocol.UTF16))
+	assert.Equal(t, []protocol.Position{{Line: 2, Character: 20}}, usagePositions(path, usage, len("http."), protocol.UTF8))
+}
+
+func TestFindExternalDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "README.md"), "Uses http.Client\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nvar c = &http.Client{}\nvar d = myhttp.Client{}\n")
+
+	external := protocol.Location{
+		URI:   protocol.URIFromPath("/home/u/go/pkg/mod/example.com/http@v1.0.0/client.go"),
+		Range: protocol.Range{Start: protocol.Position{Line: 10}},
+	}
+	client := &fakeDefinitionClient{
+		definition: protocol.Or_Result_textDocument_definition{Value: []protocol.DefinitionLink{{
+			TargetURI:            external.URI,
+			TargetSelectionRange: external.Range,
+		}}},
+	}
+
+	found := findExternalDefinitions(context.Background(), client, dir, "http.Client")
+	assert.Equal(t, []protocol.Location{external}, found)
+
+	// Only the usage in main.go is followed, at the start of Client
+	assert.Len(t, client.asked, 1)
+	assert.Equal(t, protocol.URIFromPath(filepath.Join(dir, "main.go")), client.asked[0].TextDocument.URI)
+	assert.Equal(t, protocol.Position{Line: 2, Character: 14}, client.asked[0].Position)
+}
+
+func TestFindExternalDefinitionsIgnoresWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc run() { helper() }\n")
+
+	client := &fakeDefinitionClient{
+		definition: protocol.Or_Result_textDocument_definition{Value: protocol.Definition{Value: protocol.Location{
+			URI: protocol.URIFromPath(filepath.Join(dir, "helper.go")),
+		}}},
+	}
+
+	assert.Empty(t, findExternalDefinitions(context.Background(), client, dir, "helper"))
+	assert.Len(t, client.asked, 1)
+}
+
+func TestValidateExternalSources(t *testing.T) {
+	assert.NoError(t, ValidateExternalSources(settings.Default().ExternalSources))
+	assert.NoError(t, ValidateExternalSources(settings.ExternalSourceSettings{Prefer: PreferVendor}))
+
+	err := ValidateExternalSources(settings.ExternalSourceSettings{Prefer: "both"})
+	assert.ErrorContains(t, err, `invalid externalSources.prefer "both"`)
+}
+
+func TestVendoredCopy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "vendor", "github.com", "BurntSushi", "toml", "decode.go"), "package toml\n")
+	writeFile(t, filepath.Join(dir, "vendor", "serde_json", "src", "lib.rs"), "")
+	writeFile(t, filepath.Join(dir, "vendor", "requests", "sessions.py"), "")
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/home/u/go/pkg/mod/github.com/!burnt!sushi/toml@v1.4.1/decode.go", "vendor/github.com/BurntSushi/toml/decode.go"},
+		{"/home/u/.cargo/registry/src/index.crates.io-6f17d22bba15001f/serde_json-1.0.140/src/lib.rs", "vendor/serde_json/src/lib.rs"},
+		{"/usr/lib/python3/site-packages/requests/sessions.py", "vendor/requests/sessions.py"},
+		{"/usr/lib/python3/site-packages/urllib3/util.py", ""},
+		{"/repo/node_modules/express/index.js", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			vendored, ok := vendoredCopy(dir, tt.path)
+			if tt.expected == "" {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, filepath.Join(dir, filepath.FromSlash(tt.expected)), vendored)
+		})
+	}
+}
+
+func TestLocateDefinition(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(t.TempDir(), "pkg", "mod", "example.com", "lib@v1.0.0", "lib.go")
+	writeFile(t, cache, "package lib\n\nfunc Big() {}\n")
+	writeFile(t, filepath.Join(dir, "vendor", "example.com", "lib", "lib.go"), "package lib\n\nfunc Big() {}\n")
+	own := protocol.Location{URI: protocol.URIFromPath(filepath.Join(dir, "main.go"))}
+	dependency := protocol.Location{URI: protocol.URIFromPath(cache)}
+
+	loc, source, err := locateDefinition(dir, own, settings.ExternalSourceSettings{Prefer: PreferOff})
+	assert.NoError(t, err)
+	assert.Equal(t, own, loc)
+	assert.Empty(t, source)
+
+	_, _, err = locateDefinition(dir, dependency, settings.ExternalSourceSettings{Prefer: PreferOff})
+	assert.ErrorIs(t, err, errExternalDisabled)
+
+	loc, source, err = locateDefinition(dir, dependency, settings.ExternalSourceSettings{Prefer: PreferCache})
+	assert.NoError(t, err)
+	assert.Equal(t, dependency, loc)
+	assert.Equal(t, "Go module cache: example.com/lib@v1.0.0 (read-only)", source)
+
+	loc, source, err = locateDefinition(dir, dependency, settings.ExternalSourceSettings{Prefer: PreferVendor})
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.URIFromPath(filepath.Join(dir, "vendor", "example.com", "lib", "lib.go")), loc.URI)
+	assert.Equal(t, "vendored copy of Go module cache: example.com/lib@v1.0.0", source)
+
+	_, _, err = locateDefinition(dir, dependency, settings.ExternalSourceSettings{Prefer: PreferCache, MaxFileBytes: 10})
+	assert.ErrorContains(t, err, "over the 10 byte limit for dependency files")
+}
+
+func TestAddDefinitionRangeCutsExternalDefinitions(t *testing.T) {
+	loc := protocol.Location{Range: protocol.Range{Start: protocol.Position{Line: 4}, End: protocol.Position{Line: 7}}}
+	definition := "type T struct {\n\tA int\n\tB int\n}"
+
+	var own format.Section
+	addDefinitionRange(&own, "", loc, definition, 2, protocol.UTF16)
+	assert.Len(t, own.Snippets[0].Lines, 4)
+	assert.Empty(t, own.Notes)
+
+	var external format.Section
+	addDefinitionRange(&external, "node_modules: express (read-only)", loc, definition, 2, protocol.UTF16)
+	assert.Equal(t, []string{"type T struct {", "\tA int"}, external.Snippets[0].Lines)
+	assert.Equal(t, []string{"Definition cut to 2 of 4 lines (externalSources.maxLines)"}, external.Notes)
+}
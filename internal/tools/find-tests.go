@@ -0,0 +1,470 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// maxFileSymbols bounds the symbols of a file whose references are searched
+// when finding the tests of a whole file
+const maxFileSymbols = 30
+
+// testFinderClient is the part of the LSP client used to find tests
+type testFinderClient interface {
+	resolve.SymbolSearcher
+	OpenFile(ctx context.Context, filepath string) error
+	DocumentSymbol(ctx context.Context, params protocol.DocumentSymbolParams) (protocol.Or_Result_textDocument_documentSymbol, error)
+	References(ctx context.Context, params protocol.ReferenceParams) ([]protocol.Location, error)
+	CodeLens(ctx context.Context, params protocol.CodeLensParams) ([]protocol.CodeLens, error)
+}
+
+// testCase is a test function found in a test file
+type testCase struct {
+	Name       string
+	Line       int // 1-indexed
+	NamedAfter bool
+	References int
+	Lens       bool
+}
+
+// testFile collects the tests of one file that exercise the target
+type testFile struct {
+	Path    string
+	Reasons []string
+	Lines   []string
+	Tests   map[int]*testCase
+
+	// Unattributed counts references outside any test function, e.g. in helpers
+	Unattributed int
+}
+
+// testDeclarations match the declaration of a test in each language, capturing its name
+var testDeclarations = map[string]*regexp.Regexp{
+	".go": regexp.MustCompile(`^func ((?:Test|Benchmark|Fuzz|Example)\w*)\(`),
+	".py": regexp.MustCompile(`^\s*(?:async\s+)?def (test\w*)\(`),
+	".js": regexp.MustCompile("^\\s*(?:it|test)(?:\\.\\w+)?\\(\\s*['\"`](.+?)['\"`]"),
+	".rs": regexp.MustCompile(`^\s*(?:pub\s+)?(?:async\s+)?fn (\w+)\(`),
+}
+
+// testDeclaration returns the declaration pattern for the language of a file
+func testDeclaration(path string) *regexp.Regexp {
+	switch ext := filepath.Ext(path); ext {
+	case ".ts", ".tsx", ".jsx", ".mjs", ".cjs":
+		return testDeclarations[".js"]
+	default:
+		return testDeclarations[ext]
+	}
+}
+
+// testAt returns the name of the test declared on a 0-indexed line, if any.
+// Rust functions only count as tests when a #[test] attribute precedes them.
+func testAt(path string, lines []string, line int) (string, bool) {
+	declaration := testDeclaration(path)
+	if declaration == nil || line < 0 || line >= len(lines) {
+		return "", false
+	}
+	match := declaration.FindStringSubmatch(lines[line])
+	if match == nil {
+		return "", false
+	}
+	if filepath.Ext(path) == ".rs" && (line == 0 || !strings.Contains(lines[line-1], "test]")) {
+		return "", false
+	}
+	return match[1], true
+}
+
+// enclosingTest returns the 0-indexed line of the closest test declared at or
+// above a line, or -1 when there is none
+func enclosingTest(path string, lines []string, line int) int {
+	for i := line; i >= 0; i-- {
+		if _, ok := testAt(path, lines, i); ok {
+			return i
+		}
+		// A Go function that is not a test ends the search
+		if filepath.Ext(path) == ".go" && i < line && strings.HasPrefix(lines[i], "func ") {
+			return -1
+		}
+	}
+	return -1
+}
+
+// companionTestFiles returns the existing test files that by naming convention
+// test a source file, e.g. config_test.go for config.go, test_config.py for
+// config.py or config.test.ts for config.ts. Rust files are their own
+// companion when they contain a #[cfg(test)] module.
+func companionTestFiles(path string) []string {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+
+	var candidates []string
+	switch ext {
+	case ".go":
+		candidates = []string{filepath.Join(dir, stem+"_test.go")}
+	case ".py":
+		candidates = []string{
+			filepath.Join(dir, "test_"+stem+".py"),
+			filepath.Join(dir, stem+"_test.py"),
+			filepath.Join(dir, "tests", "test_"+stem+".py"),
+			filepath.Join(filepath.Dir(dir), "tests", "test_"+stem+".py"),
+		}
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		candidates = []string{
+			filepath.Join(dir, stem+".test"+ext),
+			filepath.Join(dir, stem+".spec"+ext),
+			filepath.Join(dir, "__tests__", stem+".test"+ext),
+			filepath.Join(dir, "__tests__", stem+ext),
+		}
+	case ".rs":
+		if content, err := textenc.ReadFile(path); err == nil && strings.Contains(content, "#[cfg(test)]") {
+			candidates = append(candidates, path)
+		}
+		candidates = append(candidates, filepath.Join(filepath.Dir(dir), "tests", stem+".rs"))
+	}
+
+	var existing []string
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			existing = append(existing, candidate)
+		}
+	}
+	return existing
+}
+
+// isNamedAfter reports whether a test name mentions a symbol, e.g.
+// TestParseConfig or test_parse_config for ParseConfig and parse_config
+func isNamedAfter(testName, symbolName string) bool {
+	if symbolName == "" {
+		return false
+	}
+	simplify := func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	}
+	return strings.Contains(simplify(testName), simplify(symbolName))
+}
+
+// FindTests finds the tests that exercise a symbol, or every symbol of a file
+// when target is a path to an existing file. Tests are found by combining
+// references from test files, test file and test naming conventions, and the
+// "run test" code lenses of the language server. The result lists the tests
+// per file with the evidence for each and commands to run them.
+func FindTests(ctx context.Context, tc *ToolContext, target string) (string, error) {
+	return findTests(ctx, tc.Client, tc.Resolver, tc.WorkspaceDir, target)
+}
+
+func findTests(ctx context.Context, client testFinderClient, resolver *resolve.Resolver, workspaceDir, target string) (string, error) {
+	files := make(map[string]*testFile)
+	addFile := func(path, reason string) *testFile {
+		file, ok := files[path]
+		if !ok {
+			file = &testFile{Path: path, Tests: make(map[int]*testCase)}
+			if content, err := textenc.ReadFile(path); err == nil {
+				file.Lines = strings.Split(content, "\n")
+			}
+			files[path] = file
+		}
+		for _, existing := range file.Reasons {
+			if existing == reason {
+				return file
+			}
+		}
+		file.Reasons = append(file.Reasons, reason)
+		return file
+	}
+
+	// The symbols whose references are searched, and the name tests are matched against
+	var title, sourcePath, testedName string
+	var locations []protocol.Location
+	fileMode := false
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		fileMode = true
+		sourcePath = target
+		testedName = strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+		title = fmt.Sprintf("Tests for %s", target)
+		locations, err = fileSymbolLocations(ctx, client, target)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		matches, err := resolver.Lookup(ctx, client, target)
+		if err != nil {
+			return "", err
+		}
+		if len(matches) == 0 {
+			return fmt.Sprintf("%s not found", target), nil
+		}
+		symbol := matches[0].Symbol
+		loc := symbol.GetLocation()
+		sourcePath = protocol.PathFromURI(string(loc.URI))
+		_, testedName, _ = resolve.SplitQualified(resolve.DefaultStrategy{}.Normalize(symbol.GetName()))
+		title = fmt.Sprintf("Tests for %s (%s)", symbol.GetName(), sourcePath)
+		if err := client.OpenFile(ctx, sourcePath); err != nil {
+			return "", fmt.Errorf("could not open file: %w", err)
+		}
+		locations = []protocol.Location{loc}
+	}
+
+	// References from test files
+	for _, loc := range locations {
+		refs, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+				Position:     loc.Range.Start,
+			},
+			Context: protocol.ReferenceContext{IncludeDeclaration: false},
+		})
+		if err != nil {
+			toolsLogger.Debug("References failed while finding tests: %v", err)
+			continue
+		}
+		for _, ref := range refs {
+			path := protocol.PathFromURI(string(ref.URI))
+			if !resolve.StrategyFor(path).IsTestFile(path) && !hasTests(path) {
+				continue
+			}
+			file := addFile(path, "references")
+			line := enclosingTest(path, file.Lines, int(ref.Range.Start.Line))
+			if line < 0 {
+				file.Unattributed++
+				continue
+			}
+			file.test(line).References++
+		}
+	}
+
+	// Test files and tests named after the target
+	for _, path := range companionTestFiles(sourcePath) {
+		file := addFile(path, "test file naming")
+		for i := range file.Lines {
+			name, ok := testAt(path, file.Lines, i)
+			if !ok {
+				continue
+			}
+			if isNamedAfter(name, testedName) {
+				file.test(i).NamedAfter = true
+			} else if fileMode {
+				file.test(i)
+			}
+		}
+	}
+	for _, file := range files {
+		for i := range file.Lines {
+			if name, ok := testAt(file.Path, file.Lines, i); ok && isNamedAfter(name, testedName) {
+				file.test(i).NamedAfter = true
+			}
+		}
+	}
+
+	// Run test code lenses confirm which tests the server can run
+	for _, file := range files {
+		if err := client.OpenFile(ctx, file.Path); err != nil {
+			toolsLogger.Debug("Could not open %s for code lens: %v", file.Path, err)
+			continue
+		}
+		lenses, err := client.CodeLens(ctx, protocol.CodeLensParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(file.Path)},
+		})
+		if err != nil {
+			toolsLogger.Debug("Code lens not available for %s: %v", file.Path, err)
+			continue
+		}
+		for _, lens := range lenses {
+			if lens.Command == nil || !strings.Contains(strings.ToLower(lens.Command.Title), "run test") {
+				continue
+			}
+			if test, ok := file.Tests[int(lens.Range.Start.Line)]; ok {
+				test.Lens = true
+			}
+		}
+	}
+
+	return formatTests(title, testedName, workspaceDir, files), nil
+}
+
+// test returns the test declared on a 0-indexed line, recording it if needed
+func (f *testFile) test(line int) *testCase {
+	if test, ok := f.Tests[line]; ok {
+		return test
+	}
+	name, _ := testAt(f.Path, f.Lines, line)
+	test := &testCase{Name: name, Line: line + 1}
+	f.Tests[line] = test
+	return test
+}
+
+// hasTests reports whether a file declares tests even though its name does
+// not follow a test file convention, such as a Rust file with unit tests
+func hasTests(path string) bool {
+	if filepath.Ext(path) != ".rs" {
+		return false
+	}
+	content, err := textenc.ReadFile(path)
+	return err == nil && strings.Contains(content, "#[cfg(test)]")
+}
+
+// fileSymbolLocations returns the locations of the top level symbols of a file
+func fileSymbolLocations(ctx context.Context, client testFinderClient, path string) ([]protocol.Location, error) {
+	if err := client.OpenFile(ctx, path); err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	uri := protocol.URIFromPath(path)
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document symbols: %w", err)
+	}
+	symbols, err := symResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to process document symbols: %w", err)
+	}
+
+	var locations []protocol.Location
+	for _, symbol := range symbols {
+		var kind protocol.SymbolKind
+		var loc protocol.Location
+		switch v := symbol.(type) {
+		case *protocol.DocumentSymbol:
+			kind, loc = v.Kind, protocol.Location{URI: uri, Range: v.SelectionRange}
+		case *protocol.SymbolInformation:
+			kind, loc = v.Kind, v.Location
+		}
+		switch kind {
+		case protocol.Function, protocol.Method, protocol.Class, protocol.Struct, protocol.Interface:
+		default:
+			continue
+		}
+		locations = append(locations, loc)
+		if len(locations) == maxFileSymbols {
+			break
+		}
+	}
+	return locations, nil
+}
+
+// formatTests lists the tests of each file with their evidence, followed by
+// commands that run them
+func formatTests(title, testedName, workspaceDir string, files map[string]*testFile) string {
+	paths := make([]string, 0, len(files))
+	total := 0
+	for path, file := range files {
+		if len(file.Tests) == 0 && file.Unattributed == 0 {
+			continue
+		}
+		paths = append(paths, path)
+		total += len(file.Tests)
+	}
+	sort.Strings(paths)
+
+	var result strings.Builder
+	result.WriteString(title + "\n")
+	if len(paths) == 0 {
+		result.WriteString("No tests found\n")
+		return result.String()
+	}
+	result.WriteString(fmt.Sprintf("Found %s in %s\n", pluralize(total, "test"), pluralize(len(paths), "file")))
+
+	runnable := make(map[string][]string)
+	for _, path := range paths {
+		file := files[path]
+		result.WriteString(fmt.Sprintf("\n%s (%s)\n", path, strings.Join(file.Reasons, ", ")))
+
+		lines := make([]int, 0, len(file.Tests))
+		for line := range file.Tests {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		for _, line := range lines {
+			test := file.Tests[line]
+			var evidence []string
+			if test.NamedAfter {
+				evidence = append(evidence, "named after "+testedName)
+			}
+			if test.References > 0 {
+				evidence = append(evidence, pluralize(test.References, "reference"))
+			}
+			if test.Lens {
+				evidence = append(evidence, "run test lens")
+			}
+			entry := fmt.Sprintf("  %s L%d", test.Name, test.Line)
+			if len(evidence) > 0 {
+				entry += " [" + strings.Join(evidence, ", ") + "]"
+			}
+			result.WriteString(entry + "\n")
+			runnable[path] = append(runnable[path], test.Name)
+		}
+		if file.Unattributed > 0 {
+			result.WriteString(fmt.Sprintf("  %s outside test functions, e.g. in helpers\n", pluralize(file.Unattributed, "reference")))
+		}
+	}
+
+	if commands := testCommands(workspaceDir, paths, runnable); len(commands) > 0 {
+		result.WriteString("\nRun:\n")
+		for _, command := range commands {
+			result.WriteString("  " + command + "\n")
+		}
+	}
+	return result.String()
+}
+
+// testCommands suggests commands running the given tests for languages with a
+// standard test runner: go test, pytest and cargo test
+func testCommands(workspaceDir string, paths []string, tests map[string][]string) []string {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(workspaceDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return path
+	}
+
+	var commands []string
+	goPackages := make(map[string][]string)
+	var goDirs []string
+	for _, path := range paths {
+		switch filepath.Ext(path) {
+		case ".go":
+			dir := relative(filepath.Dir(path))
+			if dir != "." && !filepath.IsAbs(dir) {
+				dir = "./" + dir
+			}
+			if _, ok := goPackages[dir]; !ok {
+				goDirs = append(goDirs, dir)
+			}
+			for _, name := range tests[path] {
+				if strings.HasPrefix(name, "Test") {
+					goPackages[dir] = append(goPackages[dir], name)
+				}
+			}
+		case ".py":
+			var ids []string
+			for _, name := range tests[path] {
+				ids = append(ids, relative(path)+"::"+name)
+			}
+			if len(ids) > 0 {
+				commands = append(commands, "pytest "+strings.Join(ids, " "))
+			}
+		case ".rs":
+			for _, name := range tests[path] {
+				commands = append(commands, "cargo test "+name)
+			}
+		}
+	}
+	for _, dir := range goDirs {
+		names := goPackages[dir]
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		commands = append(commands, fmt.Sprintf("go test %s -run '^(%s)$'", dir, strings.Join(names, "|")))
+	}
+	return commands
+}
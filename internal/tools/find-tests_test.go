@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTestFinder serves a single symbol, its references and code lenses
+type fakeTestFinder struct {
+	symbol protocol.SymbolInformation
+	refs   []protocol.Location
+	lenses map[protocol.DocumentUri][]protocol.CodeLens
+}
+
+func (f *fakeTestFinder) Symbol(ctx context.Context, params protocol.WorkspaceSymbolParams) (protocol.Or_Result_workspace_symbol, error) {
+	return protocol.Or_Result_workspace_symbol{Value: []protocol.SymbolInformation{f.symbol}}, nil
+}
+
+func (f *fakeTestFinder) OpenFile(ctx context.Context, filepath string) error {
+	return nil
+}
+
+func (f *fakeTestFinder) DocumentSymbol(ctx context.Context, params protocol.DocumentSymbolParams) (protocol.Or_Result_textDocument_documentSymbol, error) {
+	return protocol.Or_Result_textDocument_documentSymbol{Value: []protocol.SymbolInformation{f.symbol}}, nil
+}
+
+func (f *fakeTestFinder) References(ctx context.Context, params protocol.ReferenceParams) ([]protocol.Location, error) {
+	return f.refs, nil
+}
+
+func (f *fakeTestFinder) CodeLens(ctx context.Context, params protocol.CodeLensParams) ([]protocol.CodeLens, error) {
+	return f.lenses[params.TextDocument.URI], nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestFindTests(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "config", "parse.go")
+	companion := filepath.Join(dir, "config", "parse_test.go")
+	other := filepath.Join(dir, "server", "server_test.go")
+
+	writeFile(t, source, "package config\n\nfunc ParseConfig() {}\n")
+	writeFile(t, companion, `package config
+
+func TestParseConfig(t *testing.T) {
+	ParseConfig()
+}
+
+func TestDefaults(t *testing.T) {
+	ParseConfig()
+}
+
+func TestUnrelated(t *testing.T) {}
+
+func helper() {
+	ParseConfig()
+}
+`)
+	writeFile(t, other, `package server
+
+func TestServerStart(t *testing.T) {
+	config.ParseConfig()
+}
+`)
+
+	client := &fakeTestFinder{
+		symbol: symbolAt("ParseConfig", protocol.Function, source, 2),
+		refs: []protocol.Location{
+			refAt(string(protocol.URIFromPath(companion)), 3),
+			refAt(string(protocol.URIFromPath(companion)), 7),
+			refAt(string(protocol.URIFromPath(companion)), 13),
+			refAt(string(protocol.URIFromPath(other)), 3),
+			refAt(string(protocol.URIFromPath(source)), 2),
+		},
+		lenses: map[protocol.DocumentUri][]protocol.CodeLens{
+			protocol.URIFromPath(companion): {{
+				Range:   protocol.Range{Start: protocol.Position{Line: 2}},
+				Command: &protocol.Command{Title: "run test"},
+			}},
+		},
+	}
+
+	output, err := findTests(context.Background(), client, resolve.New(settings.Default().SymbolMatch), dir, "ParseConfig")
+	assert.NoError(t, err)
+	assert.Equal(t, "Tests for ParseConfig ("+source+`)
+Found 3 tests in 2 files
+
+`+companion+` (references, test file naming)
+  TestParseConfig L3 [named after ParseConfig, 1 reference, run test lens]
+  TestDefaults L7 [1 reference]
+  1 reference outside test functions, e.g. in helpers
+
+`+other+` (references)
+  TestServerStart L3 [1 reference]
+
+Run:
+  go test ./config -run '^(TestDefaults|TestParseConfig)$'
+  go test ./server -run '^(TestServerStart)$'
+`, output)
+
+	// A file lists every test of its companion test file
+	output, err = findTests(context.Background(), client, resolve.New(settings.Default().SymbolMatch), dir, source)
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Found 4 tests in 2 files")
+	assert.Contains(t, output, "  TestUnrelated L11\n")
+}
+
+func TestCompanionTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app", "config.py"), "")
+	writeFile(t, filepath.Join(dir, "tests", "test_config.py"), "")
+	writeFile(t, filepath.Join(dir, "web", "users.ts"), "")
+	writeFile(t, filepath.Join(dir, "web", "users.spec.ts"), "")
+	writeFile(t, filepath.Join(dir, "src", "lib.rs"), "fn a() {}\n#[cfg(test)]\nmod tests {}\n")
+
+	assert.Equal(t, []string{filepath.Join(dir, "tests", "test_config.py")}, companionTestFiles(filepath.Join(dir, "app", "config.py")))
+	assert.Equal(t, []string{filepath.Join(dir, "web", "users.spec.ts")}, companionTestFiles(filepath.Join(dir, "web", "users.ts")))
+	assert.Equal(t, []string{filepath.Join(dir, "src", "lib.rs")}, companionTestFiles(filepath.Join(dir, "src", "lib.rs")))
+	assert.Empty(t, companionTestFiles(filepath.Join(dir, "web", "missing.go")))
+
+	// Test files in legacy encodings are decoded like every other file
+	utf16 := []byte{0xff, 0xfe}
+	for _, r := range "fn b() {}\n#[cfg(test)]\nmod tests {}\n" {
+		utf16 = append(utf16, byte(r), 0)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "wide.rs"), utf16, 0644))
+	assert.True(t, hasTests(filepath.Join(dir, "src", "wide.rs")))
+}
+
+func TestEnclosingTest(t *testing.T) {
+	rust := []string{"#[test]", "fn parses() {", "    parse();", "}"}
+	assert.Equal(t, 1, enclosingTest("lib.rs", rust, 2))
+
+	js := []string{"describe('parse', () => {", "  it('handles empty input', () => {", "    parse('')", "  })", "})"}
+	assert.Equal(t, 1, enclosingTest("parse.test.ts", js, 2))
+	name, _ := testAt("parse.test.ts", js, 1)
+	assert.Equal(t, "handles empty input", name)
+
+	python := []string{"def test_parse():", "    parse()"}
+	assert.Equal(t, 0, enclosingTest("test_parse.py", python, 1))
+	assert.Equal(t, -1, enclosingTest("test_parse.py", python[1:], 0))
+}
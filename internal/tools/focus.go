@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveFocus resolves a focus directory, given relative to the workspace or
+// absolute. An empty path or the workspace root means no focus and gives "".
+func ResolveFocus(workspaceDir, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	dir := path
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workspaceDir, dir)
+	}
+	dir = filepath.Clean(dir)
+
+	rel, err := filepath.Rel(workspaceDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errorf(KindWorkspaceViolation, "focus %s is outside the workspace", path)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("focus %s does not exist: %w", path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("focus %s is not a directory", path)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return dir, nil
+}
+
+// inFocus reports whether a path is inside the focus directory, which every
+// path is when there is none
+func (tc *ToolContext) inFocus(path string) bool {
+	if tc.FocusDir == "" {
+		return true
+	}
+	rel, err := filepath.Rel(tc.FocusDir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// focusNote tells how many results the focus hid, or "" when it hid none
+func (tc *ToolContext) focusNote(hidden int, noun string) string {
+	if hidden == 0 {
+		return ""
+	}
+	dir := tc.FocusDir
+	if rel, err := filepath.Rel(tc.WorkspaceDir, dir); err == nil {
+		dir = rel
+	}
+	return fmt.Sprintf("Focused on %s: %s outside it hidden, pass focus \".\" to include them\n", dir, pluralize(hidden, noun))
+}
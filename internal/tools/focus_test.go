@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testContext returns a tool context with the default settings and no client
+func testContext() *ToolContext {
+	return &ToolContext{Resolver: resolve.New(settings.Default().SymbolMatch), ContextLines: -1}
+}
+
+func TestResolveFocus(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "services", "api"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), nil, 0644))
+
+	focus, err := ResolveFocus(dir, "services/api")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "services", "api"), focus)
+
+	focus, err = ResolveFocus(dir, filepath.Join(dir, "services"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "services"), focus)
+
+	// The workspace root is no focus at all
+	for _, path := range []string{"", ".", dir} {
+		focus, err = ResolveFocus(dir, path)
+		require.NoError(t, err)
+		assert.Empty(t, focus)
+	}
+
+	_, err = ResolveFocus(dir, "../other")
+	assert.Equal(t, KindWorkspaceViolation, KindOf(err))
+	_, err = ResolveFocus(dir, "go.mod")
+	assert.EqualError(t, err, "focus go.mod is not a directory")
+	_, err = ResolveFocus(dir, "missing")
+	assert.Error(t, err)
+}
+
+func TestInFocus(t *testing.T) {
+	tc := &ToolContext{WorkspaceDir: "/ws"}
+	assert.True(t, tc.inFocus("/ws/lib/util.go"))
+
+	tc.FocusDir = "/ws/services/api"
+	assert.True(t, tc.inFocus("/ws/services/api/main.go"))
+	assert.True(t, tc.inFocus("/ws/services/api/handlers/user.go"))
+	assert.False(t, tc.inFocus("/ws/services/api-gateway/main.go"))
+	assert.False(t, tc.inFocus("/ws/lib/util.go"))
+
+	assert.Empty(t, tc.focusNote(0, "reference"))
+	assert.Equal(t, "Focused on services/api: 2 references outside it hidden, pass focus \".\" to include them\n", tc.focusNote(2, "reference"))
+}
+
+func TestSearchSymbolsFocus(t *testing.T) {
+	symbols := fakeSymbolSearcher{
+		symbolAt("Handler", protocol.Struct, "/ws/services/api/handler.go", 3),
+		symbolAt("Handler", protocol.Struct, "/ws/services/billing/handler.go", 3),
+		symbolAt("HandlerFunc", protocol.Function, "/ws/lib/http.go", 10),
+	}
+	tc := testContext()
+	tc.WorkspaceDir = "/ws"
+	tc.FocusDir = "/ws/services/api"
+
+	doc, err := searchSymbols(context.Background(), tc, symbols, "Handler", 50)
+	require.NoError(t, err)
+	require.Len(t, doc.Sections, 1)
+	assert.Equal(t, "/ws/services/api/handler.go", doc.Sections[0].Path)
+	assert.Contains(t, doc.Preamble, "Focused on services/api: 2 symbols outside it hidden")
+	assert.Contains(t, doc.Preamble, `Found 1 symbol matching "Handler"`)
+
+	tc.FocusDir = "/ws/services/web"
+	doc, err = searchSymbols(context.Background(), tc, symbols, "Handler", 50)
+	require.NoError(t, err)
+	assert.Empty(t, doc.Sections)
+	assert.Contains(t, renderPlain(doc), "No symbols found for query: Handler\nFocused on services/web: 3 symbols outside it hidden")
+}
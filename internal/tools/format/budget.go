@@ -0,0 +1,148 @@
+package format
+
+import "fmt"
+
+// CharsPerToken is a rough estimate used to turn a token budget into a character budget
+const CharsPerToken = 4
+
+// Budget limits the size of a rendered document. Documents over budget shrink in
+// stages that keep the most useful information longest: context lines around the
+// focus lines go first, then whole snippets, then section notes, and finally
+// trailing sections are replaced by a count. Output is never cut mid-line.
+type Budget struct {
+	// MaxTokens is the estimated size limit. Zero means no limit.
+	MaxTokens int
+
+	// MinContext is the fewest context lines kept around focus lines before
+	// snippets are dropped entirely
+	MinContext int
+
+	// KeepNotes keeps section notes until sections are omitted, for tools whose
+	// notes are the result itself, such as diagnostics
+	KeepNotes bool
+}
+
+// Fit renders a document, shrinking it until the output fits the budget or
+// nothing more can be dropped
+func Fit(doc Document, renderer Renderer, budget Budget) string {
+	out := renderer.Render(doc)
+	if budget.MaxTokens <= 0 {
+		return out
+	}
+	maxChars := budget.MaxTokens * CharsPerToken
+	if len(out) <= maxChars {
+		return out
+	}
+
+	// Work on a copy so the caller's sections are left alone
+	doc.Sections = append([]Section(nil), doc.Sections...)
+	fits := func() bool {
+		out = renderer.Render(doc)
+		return len(out) <= maxChars
+	}
+
+	// Less context around the lines the sections are about
+	for context := maxContext(doc) - 1; context >= budget.MinContext; context-- {
+		for i := range doc.Sections {
+			doc.Sections[i] = trimContext(doc.Sections[i], context)
+		}
+		if fits() {
+			return out
+		}
+	}
+
+	// No snippets, starting with the last sections
+	for i := len(doc.Sections) - 1; i >= 0; i-- {
+		if len(doc.Sections[i].Snippets) == 0 {
+			continue
+		}
+		doc.Sections[i].Snippets = nil
+		if fits() {
+			return out
+		}
+	}
+
+	// Sections collapsed to their header fields and counts
+	if !budget.KeepNotes {
+		for i := len(doc.Sections) - 1; i >= 0; i-- {
+			if len(doc.Sections[i].Notes) == 0 {
+				continue
+			}
+			doc.Sections[i].Notes = nil
+			if fits() {
+				return out
+			}
+		}
+	}
+
+	// Trailing sections replaced by a count, always keeping the first
+	footer := doc.Footer
+	for omitted := 1; len(doc.Sections) > 1; omitted++ {
+		doc.Sections = doc.Sections[:len(doc.Sections)-1]
+		doc.Footer = footer + fmt.Sprintf("\n... %d more results omitted to fit max_tokens\n", omitted)
+		if fits() {
+			return out
+		}
+	}
+	return out
+}
+
+// maxContext returns the largest distance between a snippet line and the
+// nearest focus line of its section
+func maxContext(doc Document) int {
+	largest := 0
+	for _, section := range doc.Sections {
+		if len(section.Focus) == 0 {
+			continue
+		}
+		for _, snippet := range section.Snippets {
+			for i := range snippet.Lines {
+				largest = max(largest, distanceToFocus(snippet.StartLine+i, section.Focus))
+			}
+		}
+	}
+	return largest
+}
+
+// trimContext keeps only the snippet lines within context lines of a focus
+// line, splitting snippets where lines are dropped. Sections without focus
+// lines are returned unchanged.
+func trimContext(section Section, context int) Section {
+	if len(section.Focus) == 0 {
+		return section
+	}
+
+	var snippets []Snippet
+	for _, snippet := range section.Snippets {
+		current := -1
+		for i, line := range snippet.Lines {
+			number := snippet.StartLine + i
+			if distanceToFocus(number, section.Focus) > context {
+				current = -1
+				continue
+			}
+			if current < 0 {
+				snippets = append(snippets, Snippet{StartLine: number})
+				current = len(snippets) - 1
+			}
+			snippets[current].Lines = append(snippets[current].Lines, line)
+		}
+	}
+	section.Snippets = snippets
+	return section
+}
+
+// distanceToFocus returns how many lines away the nearest focus line is
+func distanceToFocus(line int, focus []int) int {
+	distance := -1
+	for _, f := range focus {
+		d := line - f
+		if d < 0 {
+			d = -d
+		}
+		if distance < 0 || d < distance {
+			distance = d
+		}
+	}
+	return distance
+}
@@ -0,0 +1,101 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// budgetDocument has files with a reference on line 10, shown with three lines
+// of context on each side, and a note per file
+func budgetDocument(files int) Document {
+	doc := Document{Banner: "---\n\n", Separator: "\n"}
+	for i := 0; i < files; i++ {
+		section := Section{
+			Path:  fmt.Sprintf("/ws/file%d.go", i),
+			Notes: []string{"a note that only some tools need"},
+			Focus: []int{10},
+		}
+		section.AddField("References in File", "1")
+		var lines []string
+		for line := 7; line <= 13; line++ {
+			lines = append(lines, fmt.Sprintf("line %d of file %d", line, i))
+		}
+		section.Snippets = []Snippet{{StartLine: 7, Lines: lines}}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc
+}
+
+func tokens(out string) int {
+	return (len(out) + CharsPerToken - 1) / CharsPerToken
+}
+
+func TestFitWithinBudgetIsUnchanged(t *testing.T) {
+	renderer, _ := Get(Plain)
+	doc := budgetDocument(2)
+	assert.Equal(t, renderer.Render(doc), Fit(doc, renderer, Budget{}))
+	assert.Equal(t, renderer.Render(doc), Fit(doc, renderer, Budget{MaxTokens: 10000}))
+}
+
+func TestFitDropsContextFirst(t *testing.T) {
+	renderer, _ := Get(Plain)
+	doc := budgetDocument(2)
+	full := renderer.Render(doc)
+
+	// Enough room for one line of context around each reference
+	reduced := doc
+	reduced.Sections = []Section{trimContext(doc.Sections[0], 1), trimContext(doc.Sections[1], 1)}
+	out := Fit(doc, renderer, Budget{MaxTokens: tokens(renderer.Render(reduced))})
+
+	assert.Less(t, len(out), len(full))
+	assert.Contains(t, out, "9|line 9 of file 0")
+	assert.Contains(t, out, "10|line 10 of file 1")
+	assert.NotContains(t, out, "8|line 8")
+	assert.Contains(t, out, "a note that only some tools need")
+
+	// The caller's document is left alone
+	assert.Equal(t, full, renderer.Render(doc))
+}
+
+func TestFitRespectsMinContext(t *testing.T) {
+	renderer, _ := Get(Plain)
+	doc := budgetDocument(1)
+
+	// Too small for any snippet, so the snippet is dropped rather than trimmed below MinContext
+	out := Fit(doc, renderer, Budget{MaxTokens: 30, MinContext: 2})
+	assert.NotContains(t, out, "|line")
+	assert.Contains(t, out, "/ws/file0.go")
+}
+
+func TestFitCollapsesAndOmitsSections(t *testing.T) {
+	renderer, _ := Get(Plain)
+	doc := budgetDocument(20)
+
+	out := Fit(doc, renderer, Budget{MaxTokens: 60})
+	assert.NotContains(t, out, "|line")
+	assert.NotContains(t, out, "a note")
+	assert.Contains(t, out, "/ws/file0.go\nReferences in File: 1\n")
+	assert.Regexp(t, `\.\.\. \d+ more results omitted to fit max_tokens`, out)
+	assert.LessOrEqual(t, len(out), 60*CharsPerToken)
+
+	// Tools whose notes are the result keep them while sections are omitted
+	out = Fit(doc, renderer, Budget{MaxTokens: 60, KeepNotes: true})
+	assert.Contains(t, out, "a note that only some tools need")
+	assert.Equal(t, strings.Count(out, "---"), strings.Count(out, "a note"))
+}
+
+func TestTrimContextSplitsSnippets(t *testing.T) {
+	section := Section{
+		Focus:    []int{2, 8},
+		Snippets: []Snippet{{StartLine: 1, Lines: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}}},
+	}
+	trimmed := trimContext(section, 1)
+	assert.Equal(t, []Snippet{
+		{StartLine: 1, Lines: []string{"1", "2", "3"}},
+		{StartLine: 7, Lines: []string{"7", "8", "9"}},
+	}, trimmed.Snippets)
+	assert.Len(t, section.Snippets[0].Lines, 9)
+}
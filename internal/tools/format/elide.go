@@ -0,0 +1,102 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+)
+
+// elideLine shortens a line longer than maxLength characters by replacing
+// what lies outside a window of maxLength characters with a count of the
+// characters left out. The window is centered on the marked ranges so that
+// the match stays visible, or starts the line when it has no marks. Marks are
+// moved to where their characters are in the shortened line, and marks
+// outside the window are dropped. A maxLength of zero keeps lines whole.
+func elideLine(line string, marks []Mark, maxLength int) (string, []Mark) {
+	runes := []rune(line)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return line, marks
+	}
+
+	start, end := 0, maxLength
+	if len(marks) > 0 {
+		spanStart, spanEnd := len(runes), 0
+		for _, mark := range marks {
+			markStart, markEnd := clampMark(mark, len(runes))
+			spanStart = min(spanStart, markStart)
+			spanEnd = max(spanEnd, markEnd)
+		}
+		spanEnd = max(spanEnd, spanStart)
+		if spanEnd-spanStart >= maxLength {
+			start, end = spanStart, spanStart+maxLength
+		} else {
+			extra := maxLength - (spanEnd - spanStart)
+			start = spanStart - extra/2
+			end = spanEnd + extra - extra/2
+		}
+		// Slide the window back inside the line
+		if start < 0 {
+			end -= start
+			start = 0
+		}
+		if end > len(runes) {
+			start -= end - len(runes)
+			end = len(runes)
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = fmt.Sprintf("…%d chars…", start)
+	}
+	if end < len(runes) {
+		suffix = fmt.Sprintf("…%d chars…", len(runes)-end)
+	}
+	offset := len([]rune(prefix)) - start
+
+	var moved []Mark
+	for _, mark := range marks {
+		markStart, markEnd := clampMark(mark, len(runes))
+		if markStart < start || markStart > end || (markStart == end && end < len(runes)) {
+			continue
+		}
+		moved = append(moved, Mark{Line: mark.Line, Start: markStart + offset, End: min(markEnd, end) + offset})
+	}
+	return prefix + string(runes[start:end]) + suffix, moved
+}
+
+// elideSnippets shortens the long lines of snippets and moves the marks on
+// them. Marks on lines the snippets do not show are kept as they are.
+func elideSnippets(snippets []Snippet, marks []Mark, maxLength int) ([]Snippet, []Mark) {
+	if maxLength <= 0 {
+		return snippets, marks
+	}
+	byLine := marksByLine(marks)
+	elided := make([]Snippet, len(snippets))
+	for i, snippet := range snippets {
+		elided[i] = Snippet{StartLine: snippet.StartLine, Lines: make([]string, len(snippet.Lines))}
+		for j, line := range snippet.Lines {
+			number := snippet.StartLine + j
+			elided[i].Lines[j], byLine[number] = elideLine(line, byLine[number], maxLength)
+		}
+	}
+
+	lines := make([]int, 0, len(byLine))
+	for line := range byLine {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	var moved []Mark
+	for _, line := range lines {
+		moved = append(moved, byLine[line]...)
+	}
+	return elided, moved
+}
+
+// marksByLine groups marks by their line
+func marksByLine(marks []Mark) map[int][]Mark {
+	byLine := make(map[int][]Mark)
+	for _, mark := range marks {
+		byLine[mark.Line] = append(byLine[mark.Line], mark)
+	}
+	return byLine
+}
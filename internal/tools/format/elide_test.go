@@ -0,0 +1,77 @@
+package format
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElideLine(t *testing.T) {
+	line := strings.Repeat("a", 50) + "target" + strings.Repeat("b", 50)
+
+	// Short lines and a zero limit keep the line whole
+	short, marks := elideLine("short", []Mark{{Line: 1, Start: 0, End: 5}}, 10)
+	assert.Equal(t, "short", short)
+	assert.Equal(t, []Mark{{Line: 1, Start: 0, End: 5}}, marks)
+	whole, _ := elideLine(line, nil, 0)
+	assert.Equal(t, line, whole)
+
+	// Without marks the start of the line is kept
+	elided, marks := elideLine(line, nil, 10)
+	assert.Equal(t, "aaaaaaaaaa…96 chars…", elided)
+	assert.Empty(t, marks)
+
+	// The window is centered on the marks, which move with the text
+	elided, marks = elideLine(line, []Mark{{Line: 3, Start: 50, End: 56}}, 10)
+	assert.Equal(t, "…48 chars…aatargetbb…48 chars…", elided)
+	assert.Equal(t, []Mark{{Line: 3, Start: 12, End: 18}}, marks)
+	assert.Equal(t, "target", string([]rune(elided)[marks[0].Start:marks[0].End]))
+
+	// Near the end of the line the window slides back inside it
+	elided, marks = elideLine(line, []Mark{{Line: 3, Start: 104, End: 106}}, 10)
+	assert.Equal(t, "…96 chars…bbbbbbbbbb", elided)
+	assert.Equal(t, []Mark{{Line: 3, Start: 18, End: 20}}, marks)
+
+	// A span longer than the limit keeps its start, and marks past the
+	// window are dropped
+	elided, marks = elideLine(line, []Mark{{Line: 3, Start: 40, End: 56}, {Line: 3, Start: 90, End: 95}}, 10)
+	assert.Equal(t, "…40 chars…aaaaaaaaaa…56 chars…", elided)
+	assert.Equal(t, []Mark{{Line: 3, Start: 10, End: 20}}, marks)
+}
+
+func TestFormatMarkedSnippetsElided(t *testing.T) {
+	line := "var x=" + strings.Repeat("1,", 100) + "target();"
+	snippets := []Snippet{{StartLine: 1, Lines: []string{"// minified", line}}}
+	marks := []Mark{{Line: 2, Start: 206, End: 212}}
+
+	expected := "1|// minified\n" +
+		"2|…195 chars…,1,1,1,1,1,target();\n" +
+		" |                      ^^^^^^\n"
+	assert.Equal(t, expected, FormatMarkedSnippets(snippets, marks, HighlightUnderline, 20))
+
+	// Elision alone keeps the marked range in view
+	assert.Equal(t, "1|// minified\n2|…195 chars…,1,1,1,1,1,target();\n", FormatMarkedSnippets(snippets, marks, "", 20))
+}
+
+func TestRenderJSONElided(t *testing.T) {
+	line := strings.Repeat("x", 30) + "target" + strings.Repeat("y", 30)
+	doc := Document{
+		Highlight:     HighlightBrackets,
+		MaxLineLength: 10,
+		Sections: []Section{{
+			Path:     "/ws/app.min.js",
+			Snippets: []Snippet{{StartLine: 1, Lines: []string{line}}},
+			Marks:    []Mark{{Line: 1, Start: 30, End: 36}, {Line: 5, Start: 0, End: 2}},
+		}},
+	}
+
+	var out jsonDocument
+	assert.NoError(t, json.Unmarshal([]byte(renderJSON(doc)), &out))
+	assert.Equal(t, []string{"…28 chars…xxtargetyy…28 chars…"}, out.Sections[0].Snippets[0].Lines)
+	assert.Equal(t, []jsonMark{{Line: 1, Start: 12, End: 18}, {Line: 5, Start: 0, End: 2}}, out.Sections[0].Marks)
+
+	// The section itself is left alone
+	assert.Equal(t, line, doc.Sections[0].Snippets[0].Lines[0])
+}
@@ -0,0 +1,187 @@
+// Package format renders tool results. Tools describe their result as a Document
+// made of per-file Sections and a Renderer turns it into text, so every tool
+// supports the same output formats with the same layout.
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Document is the result of a tool call
+type Document struct {
+	// Preamble is printed before the sections
+	Preamble string
+
+	// Sections are usually one per file
+	Sections []Section
+
+	// Streamed counts the sections that were sent to the client one by one
+	// while the document was produced. They are not in Sections.
+	Streamed int
+
+	// StreamOmitted counts the sections that were not streamed because the
+	// streamed output reached its token limit
+	StreamOmitted int
+
+	// Banner is printed before each section in plain output, e.g. "---\n\n"
+	Banner string
+
+	// Separator is printed between sections in plain output
+	Separator string
+
+	// Footer is printed after the sections, unless there are none
+	Footer string
+
+	// Empty is printed instead of the sections when there are none
+	Empty string
+
+	// EmptyKind classifies an empty result in structured output, e.g.
+	// symbol_not_found, so that clients need not parse Empty
+	EmptyKind string
+
+	// ResultID identifies the result for diff_results. Only structured output
+	// shows it, so that the text formats stay as they were.
+	ResultID string
+
+	// Highlight is the style marks are shown in, HighlightUnderline or
+	// HighlightBrackets. Marks are not shown when it is empty.
+	Highlight string
+
+	// MaxLineLength is the most characters of a snippet line shown. Longer
+	// lines, such as minified code, are shortened in the middle around their
+	// marks. Zero shows lines whole.
+	MaxLineLength int
+}
+
+// Section is a block of results, typically for a single file
+type Section struct {
+	// Path is the file the section is about, if any
+	Path string
+
+	// Language is the languageId of the file, used to highlight snippets
+	Language string
+
+	// Fields are "Name: Value" header lines
+	Fields []Field
+
+	// Notes are free form lines printed after the fields, e.g. diagnostic summaries
+	Notes []string
+
+	// Error is set when the file content could not be read
+	Error string
+
+	// Snippets are numbered excerpts of the file
+	Snippets []Snippet
+
+	// Focus holds the 1-indexed lines the section is about, such as reference
+	// or diagnostic lines. The other snippet lines are context, which is the
+	// first thing dropped when the document has to fit a Budget.
+	Focus []int
+
+	// Marks are the exact ranges of the focus lines the section is about,
+	// shown when the document has a Highlight style
+	Marks []Mark
+}
+
+// Field is a named header value of a section
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Snippet is a contiguous excerpt of a file
+type Snippet struct {
+	// StartLine is the 1-indexed line number of the first line
+	StartLine int
+	Lines     []string
+}
+
+// fenceTags maps languageIds to the tags highlighters expect on markdown
+// code fences where the two differ
+var fenceTags = map[string]string{
+	"typescriptreact": "tsx",
+	"javascriptreact": "jsx",
+	"shellscript":     "bash",
+	"plaintext":       "text",
+	"objective-c":     "objc",
+	"objective-cpp":   "objcpp",
+	"dockerfile":      "docker",
+}
+
+// FenceTag returns the markdown code fence tag for a languageId
+func FenceTag(languageID string) string {
+	if tag, ok := fenceTags[languageID]; ok {
+		return tag
+	}
+	return languageID
+}
+
+// AddField appends a header field to the section
+func (s *Section) AddField(name, value string) {
+	s.Fields = append(s.Fields, Field{Name: name, Value: value})
+}
+
+// Renderer turns a document into text
+type Renderer interface {
+	Render(doc Document) string
+}
+
+// RendererFunc adapts a function to the Renderer interface
+type RendererFunc func(doc Document) string
+
+// Render calls f(doc)
+func (f RendererFunc) Render(doc Document) string {
+	return f(doc)
+}
+
+// Plain is the name of the default renderer, which produces the original text output
+const Plain = "plain"
+
+var (
+	renderers = map[string]Renderer{
+		Plain:      RendererFunc(renderPlain),
+		"markdown": RendererFunc(renderMarkdown),
+		"json":     RendererFunc(renderJSON),
+	}
+	renderersMu sync.RWMutex
+)
+
+// Register adds a renderer under a name, replacing any existing one
+func Register(name string, renderer Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = renderer
+}
+
+// Get returns the renderer registered under a name. An empty name selects Plain.
+func Get(name string) (Renderer, error) {
+	if name == "" {
+		name = Plain
+	}
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	renderer, ok := renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, expected one of: %s", name, strings.Join(namesLocked(), ", "))
+	}
+	return renderer, nil
+}
+
+// Names returns the names of all registered renderers
+func Names() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
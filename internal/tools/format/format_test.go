@@ -0,0 +1,161 @@
+package format
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func referencesDocument() Document {
+	section := Section{Path: "/ws/main.go", Language: "go"}
+	section.AddField("References in File", "2")
+	section.AddField("At", "L3:C2, L10:C5")
+	section.Snippets = []Snippet{
+		{StartLine: 2, Lines: []string{"func main() {", "\tfoo()"}},
+		{StartLine: 10, Lines: []string{"\tfoo()"}},
+	}
+
+	failed := Section{Path: "/ws/gone.go", Error: "no such file"}
+	failed.AddField("References in File", "1")
+
+	return Document{
+		Banner:    "---\n\n",
+		Separator: "\n",
+		Empty:     "No references found",
+		Sections:  []Section{section, failed},
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	expected := "---\n\n" +
+		"/ws/main.go\n" +
+		"References in File: 2\n" +
+		"At: L3:C2, L10:C5\n" +
+		"\n" +
+		"2|func main() {\n" +
+		"3|\tfoo()\n" +
+		"...\n" +
+		"10|\tfoo()\n" +
+		"\n" +
+		"---\n\n" +
+		"/ws/gone.go\n" +
+		"References in File: 1\n" +
+		"\nError reading file: no such file"
+
+	renderer, err := Get("")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, renderer.Render(referencesDocument()))
+
+	assert.Equal(t, "No references found", renderer.Render(Document{Empty: "No references found", Footer: "\n"}))
+}
+
+func TestRenderPlainWithPreambleAndNotes(t *testing.T) {
+	section := Section{Path: "/ws/main.go", Notes: []string{"ERROR at L1:C1: broken"}}
+	section.AddField("Diagnostics in File", "1")
+	doc := Document{
+		Preamble: "Command: make\n",
+		Sections: []Section{section},
+		Footer:   "done\n",
+	}
+
+	expected := "Command: make\n/ws/main.go\nDiagnostics in File: 1\nERROR at L1:C1: broken\ndone\n"
+	assert.Equal(t, expected, renderPlain(doc))
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	renderer, err := Get("markdown")
+	assert.NoError(t, err)
+
+	expected := "### `/ws/main.go`\n\n" +
+		"- **References in File**: 2\n" +
+		"- **At**: L3:C2, L10:C5\n" +
+		"\n```go\n" +
+		"2|func main() {\n" +
+		"3|\tfoo()\n" +
+		"...\n" +
+		"10|\tfoo()\n" +
+		"```\n" +
+		"\n" +
+		"### `/ws/gone.go`\n\n" +
+		"- **References in File**: 1\n" +
+		"\n> Error reading file: no such file\n"
+
+	assert.Equal(t, expected, renderer.Render(referencesDocument()))
+}
+
+func TestRenderJSON(t *testing.T) {
+	renderer, err := Get("json")
+	assert.NoError(t, err)
+
+	var out jsonDocument
+	assert.NoError(t, json.Unmarshal([]byte(renderer.Render(referencesDocument())), &out))
+	assert.Equal(t, JSONSchemaVersion, out.SchemaVersion)
+	assert.Len(t, out.Sections, 2)
+	assert.Equal(t, "/ws/main.go", out.Sections[0].Path)
+	assert.Equal(t, []jsonField{{Name: "References in File", Value: "2"}, {Name: "At", Value: "L3:C2, L10:C5"}}, out.Sections[0].Fields)
+	assert.Equal(t, []jsonSnippet{{StartLine: 2, Lines: []string{"func main() {", "\tfoo()"}, Language: "go"}, {StartLine: 10, Lines: []string{"\tfoo()"}, Language: "go"}}, out.Sections[0].Snippets)
+	assert.Equal(t, "no such file", out.Sections[1].Error)
+
+	assert.NoError(t, json.Unmarshal([]byte(renderer.Render(Document{Empty: "nothing"})), &out))
+	assert.Equal(t, "nothing", out.Message)
+	assert.Empty(t, out.Sections)
+
+	out = jsonDocument{}
+	assert.NoError(t, json.Unmarshal([]byte(renderer.Render(Document{Empty: "Foo not found", EmptyKind: "symbol_not_found"})), &out))
+	assert.Equal(t, "symbol_not_found", out.Kind)
+}
+
+func TestResultIDOnlyInJSON(t *testing.T) {
+	doc := referencesDocument()
+	withID := doc
+	withID.ResultID = "r3"
+
+	for _, name := range []string{Plain, "markdown"} {
+		renderer, err := Get(name)
+		assert.NoError(t, err)
+		assert.Equal(t, renderer.Render(doc), renderer.Render(withID), name)
+	}
+
+	renderer, err := Get("json")
+	assert.NoError(t, err)
+	var out jsonDocument
+	assert.NoError(t, json.Unmarshal([]byte(renderer.Render(withID)), &out))
+	assert.Equal(t, "r3", out.ResultID)
+	assert.NotContains(t, renderer.Render(doc), "resultId")
+}
+
+func TestRenderErrorJSON(t *testing.T) {
+	var out jsonDocument
+	assert.NoError(t, json.Unmarshal([]byte(RenderErrorJSON("timeout", "definition timed out after 10ms")), &out))
+	assert.Equal(t, JSONSchemaVersion, out.SchemaVersion)
+	assert.Equal(t, &jsonError{Kind: "timeout", Message: "definition timed out after 10ms"}, out.Error)
+	assert.Empty(t, out.Sections)
+}
+
+func TestFenceTag(t *testing.T) {
+	assert.Equal(t, "go", FenceTag("go"))
+	assert.Equal(t, "tsx", FenceTag("typescriptreact"))
+	assert.Equal(t, "bash", FenceTag("shellscript"))
+	assert.Equal(t, "", FenceTag(""))
+}
+
+func TestGetUnknownRenderer(t *testing.T) {
+	_, err := Get("yaml")
+	assert.ErrorContains(t, err, "json, markdown, plain")
+}
+
+func TestRegister(t *testing.T) {
+	Register("count", RendererFunc(func(doc Document) string {
+		return string(rune('0' + len(doc.Sections)))
+	}))
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "count")
+		renderersMu.Unlock()
+	}()
+
+	renderer, err := Get("count")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", renderer.Render(referencesDocument()))
+}
@@ -0,0 +1,117 @@
+package format
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// JSONSchemaVersion is the version of the structured output contract. It is
+// bumped whenever fields are renamed or removed, never for additions.
+const JSONSchemaVersion = 2
+
+type jsonDocument struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	Preamble      string        `json:"preamble,omitempty"`
+	Sections      []jsonSection `json:"sections"`
+	Footer        string        `json:"footer,omitempty"`
+	Message       string        `json:"message,omitempty"`
+	Kind          string        `json:"kind,omitempty"`
+	Error         *jsonError    `json:"error,omitempty"`
+	ResultID      string        `json:"resultId,omitempty"`
+}
+
+type jsonError struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+type jsonSection struct {
+	Path     string        `json:"path,omitempty"`
+	Fields   []jsonField   `json:"fields,omitempty"`
+	Notes    []string      `json:"notes,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Snippets []jsonSnippet `json:"snippets,omitempty"`
+	Focus    []int         `json:"focus,omitempty"`
+	Marks    []jsonMark    `json:"marks,omitempty"`
+}
+
+type jsonField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type jsonMark struct {
+	Line  int `json:"line"`
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type jsonSnippet struct {
+	StartLine int      `json:"startLine"`
+	Lines     []string `json:"lines"`
+	Language  string   `json:"language,omitempty"`
+}
+
+// renderJSON renders the document as a JSON object for programmatic consumers
+func renderJSON(doc Document) string {
+	out := jsonDocument{
+		SchemaVersion: JSONSchemaVersion,
+		Preamble:      strings.TrimSpace(doc.Preamble),
+		Sections:      make([]jsonSection, 0, len(doc.Sections)),
+		ResultID:      doc.ResultID,
+	}
+	if len(doc.Sections) == 0 {
+		out.Message = strings.TrimSpace(doc.Empty)
+		out.Kind = doc.EmptyKind
+	} else {
+		out.Footer = strings.TrimSpace(doc.Footer)
+	}
+
+	for _, section := range doc.Sections {
+		s := jsonSection{
+			Path:  section.Path,
+			Notes: section.Notes,
+			Error: section.Error,
+			Focus: section.Focus,
+		}
+		for _, field := range section.Fields {
+			s.Fields = append(s.Fields, jsonField(field))
+		}
+		snippets, marks := elideSnippets(section.Snippets, section.Marks, doc.MaxLineLength)
+		if doc.Highlight != "" {
+			for _, mark := range marks {
+				s.Marks = append(s.Marks, jsonMark(mark))
+			}
+		}
+		for _, snippet := range snippets {
+			s.Snippets = append(s.Snippets, jsonSnippet{
+				StartLine: snippet.StartLine,
+				Lines:     snippet.Lines,
+				Language:  section.Language,
+			})
+		}
+		out.Sections = append(out.Sections, s)
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		// Only strings and ints are marshalled so this cannot happen
+		return "{}"
+	}
+	return string(data)
+}
+
+// RenderErrorJSON renders a failed tool call as a JSON object with the kind of
+// failure, e.g. symbol_not_found, and its message
+func RenderErrorJSON(kind, message string) string {
+	out := jsonDocument{
+		SchemaVersion: JSONSchemaVersion,
+		Sections:      []jsonSection{},
+		Error:         &jsonError{Kind: kind, Message: message},
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,101 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LineRange represents a continuous range of lines to display
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ConvertLinesToRanges converts a set of lines to continuous ranges
+func ConvertLinesToRanges(linesToShow map[int]bool, totalLines int) []LineRange {
+	// Convert map to sorted slice
+	lineNumbers := make([]int, 0, len(linesToShow))
+	for line := range linesToShow {
+		if line >= 0 && line < totalLines {
+			lineNumbers = append(lineNumbers, line)
+		}
+	}
+	sort.Ints(lineNumbers)
+
+	// Group into ranges
+	var ranges []LineRange
+	if len(lineNumbers) == 0 {
+		return ranges
+	}
+
+	currentRange := LineRange{Start: lineNumbers[0], End: lineNumbers[0]}
+
+	for i := 1; i < len(lineNumbers); i++ {
+		if lineNumbers[i] == currentRange.End+1 {
+			// Extend current range
+			currentRange.End = lineNumbers[i]
+		} else {
+			// Start new range
+			ranges = append(ranges, currentRange)
+			currentRange = LineRange{Start: lineNumbers[i], End: lineNumbers[i]}
+		}
+	}
+
+	// Add the last range
+	ranges = append(ranges, currentRange)
+	return ranges
+}
+
+// SnippetsFromRanges cuts the given 0-indexed line ranges out of file lines
+func SnippetsFromRanges(lines []string, ranges []LineRange) []Snippet {
+	snippets := make([]Snippet, 0, len(ranges))
+	for _, r := range ranges {
+		snippets = append(snippets, Snippet{
+			StartLine: r.Start + 1,
+			Lines:     lines[r.Start : r.End+1],
+		})
+	}
+	return snippets
+}
+
+// FormatLinesWithRanges formats file content using line ranges
+func FormatLinesWithRanges(lines []string, ranges []LineRange) string {
+	return FormatSnippets(SnippetsFromRanges(lines, ranges))
+}
+
+// FormatSnippets numbers snippet lines, separating snippets that are not adjacent with "..."
+func FormatSnippets(snippets []Snippet) string {
+	var result strings.Builder
+	lastEnd := -1
+
+	for _, snippet := range snippets {
+		// Add skipped lines indicator
+		if lastEnd != -1 && snippet.StartLine > lastEnd+1 {
+			result.WriteString("...\n")
+		}
+
+		result.WriteString(AddLineNumbers(strings.Join(snippet.Lines, "\n"), snippet.StartLine))
+		lastEnd = snippet.StartLine + len(snippet.Lines) - 1
+	}
+
+	return result.String()
+}
+
+// AddLineNumbers adds line numbers to each line of text with proper padding, starting from startLine
+func AddLineNumbers(text string, startLine int) string {
+	lines := strings.Split(text, "\n")
+	// Calculate padding width based on the number of digits in the last line number
+	lastLineNum := startLine + len(lines)
+	padding := len(strconv.Itoa(lastLineNum))
+
+	var result strings.Builder
+	for i, line := range lines {
+		// Format line number with padding and separator
+		lineNum := strconv.Itoa(startLine + i)
+		linePadding := strings.Repeat(" ", padding-len(lineNum))
+		result.WriteString(fmt.Sprintf("%s%s|%s\n", linePadding, lineNum, line))
+	}
+	return result.String()
+}
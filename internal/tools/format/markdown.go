@@ -0,0 +1,46 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderMarkdown renders sections under headings with snippets in fenced code blocks
+func renderMarkdown(doc Document) string {
+	var result strings.Builder
+	if preamble := strings.TrimSpace(doc.Preamble); preamble != "" {
+		result.WriteString(preamble + "\n\n")
+	}
+
+	if len(doc.Sections) == 0 {
+		result.WriteString(strings.TrimSpace(doc.Empty) + "\n")
+		return result.String()
+	}
+
+	for i, section := range doc.Sections {
+		if i > 0 {
+			result.WriteString("\n")
+		}
+		if section.Path != "" {
+			result.WriteString(fmt.Sprintf("### `%s`\n\n", section.Path))
+		}
+		for _, field := range section.Fields {
+			result.WriteString(fmt.Sprintf("- **%s**: %s\n", field.Name, field.Value))
+		}
+		for _, note := range section.Notes {
+			result.WriteString("- " + strings.ReplaceAll(note, "\n", "\n  ") + "\n")
+		}
+		if section.Error != "" {
+			result.WriteString("\n> Error reading file: " + section.Error + "\n")
+			continue
+		}
+		if len(section.Snippets) > 0 {
+			result.WriteString("\n```" + FenceTag(section.Language) + "\n" + FormatMarkedSnippets(section.Snippets, section.Marks, doc.Highlight, doc.MaxLineLength) + "```\n")
+		}
+	}
+
+	if footer := strings.TrimSpace(doc.Footer); footer != "" {
+		result.WriteString("\n" + footer + "\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,144 @@
+package format
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Highlight styles mark the exact ranges a section is about within its snippet
+// lines
+const (
+	// HighlightUnderline prints a line of ^ under each marked range
+	HighlightUnderline = "underline"
+
+	// HighlightBrackets wraps each marked range in [[ and ]]
+	HighlightBrackets = "brackets"
+)
+
+// HighlightStyles returns the names of the highlight styles
+func HighlightStyles() []string {
+	return []string{HighlightUnderline, HighlightBrackets}
+}
+
+// Mark is a range of a single line that a section is about, such as the token
+// a reference points at
+type Mark struct {
+	// Line is the 1-indexed line number
+	Line int
+
+	// Start and End are 0-indexed character offsets in the line, End excluded.
+	// An empty range marks the position of Start.
+	Start int
+	End   int
+}
+
+// FormatMarkedSnippets numbers snippet lines like FormatSnippets and highlights
+// the marked ranges in the given style. Marks on lines the snippets do not
+// show are ignored. Lines longer than maxLineLength characters are shortened
+// around their marks, unless maxLineLength is zero.
+func FormatMarkedSnippets(snippets []Snippet, marks []Mark, style string, maxLineLength int) string {
+	if style != HighlightUnderline && style != HighlightBrackets {
+		style = ""
+	}
+	if maxLineLength <= 0 && (len(marks) == 0 || style == "") {
+		return FormatSnippets(snippets)
+	}
+	snippets, marks = elideSnippets(snippets, marks, maxLineLength)
+	byLine := marksByLine(marks)
+
+	var result strings.Builder
+	lastEnd := -1
+	for _, snippet := range snippets {
+		if lastEnd != -1 && snippet.StartLine > lastEnd+1 {
+			result.WriteString("...\n")
+		}
+
+		// Same padding as AddLineNumbers
+		padding := len(strconv.Itoa(snippet.StartLine + len(snippet.Lines)))
+		for i, line := range snippet.Lines {
+			lineNum := strconv.Itoa(snippet.StartLine + i)
+			lineMarks := byLine[snippet.StartLine+i]
+			if style == HighlightBrackets {
+				line = bracketMarks(line, lineMarks)
+			}
+			result.WriteString(strings.Repeat(" ", padding-len(lineNum)) + lineNum + "|" + line + "\n")
+			if style == HighlightUnderline && len(lineMarks) > 0 {
+				result.WriteString(strings.Repeat(" ", padding) + "|" + underlineMarks(line, lineMarks) + "\n")
+			}
+		}
+		lastEnd = snippet.StartLine + len(snippet.Lines) - 1
+	}
+	return result.String()
+}
+
+// underlineMarks returns the line to print under a line with ^ below each
+// marked character. Tabs before a mark are kept so that the ^ line up with
+// the marked characters however wide tabs are shown.
+func underlineMarks(line string, marks []Mark) string {
+	length := utf8.RuneCountInString(line)
+	marked := make(map[int]bool)
+	last := -1
+	for _, mark := range marks {
+		start, end := clampMark(mark, length)
+		if end == start {
+			end = start + 1
+		}
+		for i := start; i < end; i++ {
+			marked[i] = true
+		}
+		last = max(last, end-1)
+	}
+
+	var b strings.Builder
+	i := 0
+	for _, r := range line {
+		if i > last {
+			break
+		}
+		switch {
+		case marked[i]:
+			b.WriteByte('^')
+		case r == '\t':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(' ')
+		}
+		i++
+	}
+	// A mark at the end of the line points just past its last character
+	for ; i <= last; i++ {
+		b.WriteByte('^')
+	}
+	return b.String()
+}
+
+// bracketMarks wraps the marked ranges of a line in [[ and ]]. Marks that
+// overlap an earlier one are left out.
+func bracketMarks(line string, marks []Mark) string {
+	runes := []rune(line)
+	sorted := append([]Mark(nil), marks...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	next := 0
+	for _, mark := range sorted {
+		start, end := clampMark(mark, len(runes))
+		if start < next {
+			continue
+		}
+		b.WriteString(string(runes[next:start]) + "[[" + string(runes[start:end]) + "]]")
+		next = end
+	}
+	b.WriteString(string(runes[next:]))
+	return b.String()
+}
+
+// clampMark limits the range of a mark to a line of the given length in
+// characters
+func clampMark(mark Mark, length int) (int, int) {
+	start := min(max(mark.Start, 0), length)
+	end := min(max(mark.End, start), length)
+	return start, end
+}
@@ -0,0 +1,66 @@
+package format
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func markedSnippets() ([]Snippet, []Mark) {
+	snippets := []Snippet{{StartLine: 9, Lines: []string{"func run() {", "\tx := foo(foo(1))", "}"}}}
+	marks := []Mark{
+		{Line: 10, Start: 6, End: 9},
+		{Line: 10, Start: 10, End: 13},
+		// Not shown by the snippets
+		{Line: 20, Start: 0, End: 3},
+	}
+	return snippets, marks
+}
+
+func TestFormatMarkedSnippetsUnderline(t *testing.T) {
+	snippets, marks := markedSnippets()
+	expected := " 9|func run() {\n" +
+		"10|\tx := foo(foo(1))\n" +
+		"  |\t     ^^^ ^^^\n" +
+		"11|}\n"
+	assert.Equal(t, expected, FormatMarkedSnippets(snippets, marks, HighlightUnderline, 0))
+}
+
+func TestFormatMarkedSnippetsBrackets(t *testing.T) {
+	snippets, marks := markedSnippets()
+	expected := " 9|func run() {\n" +
+		"10|\tx := [[foo]]([[foo]](1))\n" +
+		"11|}\n"
+	assert.Equal(t, expected, FormatMarkedSnippets(snippets, marks, HighlightBrackets, 0))
+
+	// Without a style the snippets are formatted as usual
+	assert.Equal(t, FormatSnippets(snippets), FormatMarkedSnippets(snippets, marks, "", 0))
+}
+
+func TestFormatMarkedSnippetsEdgeCases(t *testing.T) {
+	snippets := []Snippet{{StartLine: 1, Lines: []string{"héllo wörld"}}}
+
+	// Overlapping marks keep the first, ranges are clamped to the line
+	marks := []Mark{{Line: 1, Start: 6, End: 11}, {Line: 1, Start: 8, End: 9}, {Line: 1, Start: 0, End: 1}}
+	assert.Equal(t, "1|[[h]]éllo [[wörld]]\n", FormatMarkedSnippets(snippets, marks, HighlightBrackets, 0))
+	marks = []Mark{{Line: 1, Start: 6, End: 50}}
+	assert.Equal(t, "1|héllo [[wörld]]\n", FormatMarkedSnippets(snippets, marks, HighlightBrackets, 0))
+
+	// An empty range marks a position, including the end of the line
+	marks = []Mark{{Line: 1, Start: 1, End: 1}, {Line: 1, Start: 11, End: 11}}
+	assert.Equal(t, "1|héllo wörld\n | ^         ^\n", FormatMarkedSnippets(snippets, marks, HighlightUnderline, 0))
+}
+
+func TestRenderJSONMarks(t *testing.T) {
+	snippets, marks := markedSnippets()
+	doc := Document{Sections: []Section{{Path: "/ws/main.go", Snippets: snippets, Marks: marks[:1]}}}
+
+	var out jsonDocument
+	assert.NoError(t, json.Unmarshal([]byte(renderJSON(doc)), &out))
+	assert.Empty(t, out.Sections[0].Marks)
+
+	doc.Highlight = HighlightUnderline
+	assert.NoError(t, json.Unmarshal([]byte(renderJSON(doc)), &out))
+	assert.Equal(t, []jsonMark{{Line: 10, Start: 6, End: 9}}, out.Sections[0].Marks)
+}
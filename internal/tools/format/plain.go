@@ -0,0 +1,40 @@
+package format
+
+import "strings"
+
+// renderPlain produces the original plain text output of the tools
+func renderPlain(doc Document) string {
+	var result strings.Builder
+	result.WriteString(doc.Preamble)
+
+	if len(doc.Sections) == 0 {
+		result.WriteString(doc.Empty)
+		return result.String()
+	}
+
+	for i, section := range doc.Sections {
+		if i > 0 {
+			result.WriteString(doc.Separator)
+		}
+		result.WriteString(doc.Banner)
+		if section.Path != "" {
+			result.WriteString(section.Path + "\n")
+		}
+		for _, field := range section.Fields {
+			result.WriteString(field.Name + ": " + field.Value + "\n")
+		}
+		for _, note := range section.Notes {
+			result.WriteString(note + "\n")
+		}
+		if section.Error != "" {
+			result.WriteString("\nError reading file: " + section.Error)
+			continue
+		}
+		if len(section.Snippets) > 0 {
+			result.WriteString("\n" + FormatMarkedSnippets(section.Snippets, section.Marks, doc.Highlight, doc.MaxLineLength))
+		}
+	}
+
+	result.WriteString(doc.Footer)
+	return result.String()
+}
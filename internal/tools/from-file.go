@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// lookup resolves a symbol name to workspace symbols like Resolver.Lookup.
+// When the caller names the file it is reading in FromFile, an ambiguous name
+// resolves to the symbol that file uses.
+func (tc *ToolContext) lookup(ctx context.Context, symbolName string) ([]resolve.Match, error) {
+	matches, err := tc.Resolver.Lookup(ctx, tc.Client, symbolName)
+	if err != nil || tc.FromFile == "" {
+		return matches, err
+	}
+	path := tc.FromFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(tc.WorkspaceDir, path)
+	}
+	return preferUsedIn(ctx, tc.Client, path, symbolName, matches), nil
+}
+
+// preferUsedIn narrows matches to the symbols a file means by a name: go to
+// definition is run on the first usage of the name in the file, through the
+// file's imports and scope. Matches are kept as they are when there is nothing
+// to choose from or the file does not lead to any of them.
+func preferUsedIn(ctx context.Context, client externalDefinitionClient, path, symbolName string, matches []resolve.Match) []resolve.Match {
+	if len(matches) < 2 {
+		return matches
+	}
+	definitions := definitionsInFile(ctx, client, path, symbolName)
+	if len(definitions) == 0 {
+		return matches
+	}
+
+	var used []resolve.Match
+	for _, match := range matches {
+		for _, definition := range definitions {
+			if definedAt(match.Symbol.GetLocation(), definition) {
+				used = append(used, match)
+				break
+			}
+		}
+	}
+	if len(used) == 0 {
+		toolsLogger.Debug("None of the matches for %s is the one used in %s", symbolName, path)
+		return matches
+	}
+	return used
+}
+
+// definitionsInFile runs go to definition on the first usage of a symbol in a
+// file. A qualified name that the file does not spell out, e.g. api.Client in
+// a file that imports the package under another name, is looked for by its
+// unqualified name.
+func definitionsInFile(ctx context.Context, client externalDefinitionClient, path, symbolName string) []protocol.Location {
+	for _, name := range []string{symbolName, lastComponent(symbolName)} {
+		usage, err := usagePattern(name)
+		if err != nil {
+			return nil
+		}
+		positions := usagePositions(path, usage, len(name)-len(lastComponent(name)), client.PositionEncoding())
+		if len(positions) == 0 {
+			continue
+		}
+
+		if err := client.OpenFile(ctx, path); err != nil {
+			toolsLogger.Debug("Could not open %s: %v", path, err)
+			return nil
+		}
+		result, err := client.Definition(ctx, protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+				Position:     positions[0],
+			},
+		})
+		if err != nil {
+			toolsLogger.Debug("Definition failed at %s:%d: %v", path, positions[0].Line+1, err)
+			return nil
+		}
+		return definitionLocations(result)
+	}
+	return nil
+}
+
+// definedAt reports whether a workspace symbol is the one a definition points
+// at: the definition starts within the symbol's range, or on its first line
+// for servers that only report where the symbol starts
+func definedAt(symbol, definition protocol.Location) bool {
+	if symbol.URI != definition.URI {
+		return false
+	}
+	line := definition.Range.Start.Line
+	if line == symbol.Range.Start.Line {
+		return true
+	}
+	return line > symbol.Range.Start.Line && line <= symbol.Range.End.Line
+}
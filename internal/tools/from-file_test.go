@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/stretchr/testify/assert"
+)
+
+func matchAt(name, path string, start, end uint32) resolve.Match {
+	symbol := symbolAt(name, protocol.Struct, path, start)
+	symbol.Location.Range.End.Line = end
+	return resolve.Match{Symbol: &symbol}
+}
+
+func definitionAt(path string, line uint32) protocol.Or_Result_textDocument_definition {
+	return protocol.Or_Result_textDocument_definition{Value: []protocol.DefinitionLink{{
+		TargetURI:            protocol.URIFromPath(path),
+		TargetSelectionRange: protocol.Range{Start: protocol.Position{Line: line, Character: 5}},
+	}}}
+}
+
+func TestPreferUsedIn(t *testing.T) {
+	dir := t.TempDir()
+	reading := filepath.Join(dir, "cmd", "main.go")
+	writeFile(t, reading, "package main\n\nimport \"example.com/api\"\n\nvar c = &api.Client{}\n")
+
+	apiClient := matchAt("Client", filepath.Join(dir, "api", "client.go"), 10, 20)
+	dbClient := matchAt("Client", filepath.Join(dir, "db", "client.go"), 4, 8)
+	client := &fakeDefinitionClient{definition: definitionAt(filepath.Join(dir, "api", "client.go"), 10)}
+
+	matches := preferUsedIn(context.Background(), client, reading, "Client", []resolve.Match{dbClient, apiClient})
+	assert.Equal(t, []resolve.Match{apiClient}, matches)
+
+	// Go to definition runs at the usage in the file being read
+	assert.Len(t, client.asked, 1)
+	assert.Equal(t, protocol.Position{Line: 4, Character: 13}, client.asked[0].Position)
+}
+
+func TestPreferUsedInKeepsMatches(t *testing.T) {
+	dir := t.TempDir()
+	reading := filepath.Join(dir, "main.go")
+	writeFile(t, reading, "package main\n\nfunc main() {}\n")
+
+	apiClient := matchAt("Client", filepath.Join(dir, "api", "client.go"), 10, 20)
+	dbClient := matchAt("Client", filepath.Join(dir, "db", "client.go"), 4, 8)
+	all := []resolve.Match{dbClient, apiClient}
+	client := &fakeDefinitionClient{definition: definitionAt(filepath.Join(dir, "api", "client.go"), 10)}
+
+	// The file does not use the name
+	assert.Equal(t, all, preferUsedIn(context.Background(), client, reading, "Client", all))
+	assert.Empty(t, client.asked)
+
+	// A single match is not narrowed further
+	assert.Equal(t, all[:1], preferUsedIn(context.Background(), client, reading, "Client", all[:1]))
+
+	// The usage leads to none of the matches
+	writeFile(t, reading, "package main\n\nvar c = Client{}\n")
+	client.definition = definitionAt(filepath.Join(dir, "other", "client.go"), 3)
+	assert.Equal(t, all, preferUsedIn(context.Background(), client, reading, "Client", all))
+}
+
+func TestDefinedAt(t *testing.T) {
+	symbol := protocol.Location{
+		URI:   protocol.URIFromPath("/ws/api/client.go"),
+		Range: protocol.Range{Start: protocol.Position{Line: 10}, End: protocol.Position{Line: 20}},
+	}
+	at := func(path string, line uint32) protocol.Location {
+		return protocol.Location{URI: protocol.URIFromPath(path), Range: protocol.Range{Start: protocol.Position{Line: line}}}
+	}
+
+	assert.True(t, definedAt(symbol, at("/ws/api/client.go", 10)))
+	assert.True(t, definedAt(symbol, at("/ws/api/client.go", 15)))
+	assert.False(t, definedAt(symbol, at("/ws/api/client.go", 21)))
+	assert.False(t, definedAt(symbol, at("/ws/db/client.go", 10)))
+
+	// Servers that only report where a symbol starts
+	symbol.Range.End = symbol.Range.Start
+	assert.True(t, definedAt(symbol, at("/ws/api/client.go", 10)))
+	assert.False(t, definedAt(symbol, at("/ws/api/client.go", 11)))
+}
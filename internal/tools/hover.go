@@ -14,17 +14,14 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 	// Open the file if not already open
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	params := protocol.HoverParams{}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	position := protocol.Position{
-		Line:      uint32(line - 1),
-		Character: uint32(column - 1),
-	}
-	uri := protocol.DocumentUri("file://" + filePath)
+	position := readColumns(filePath, client.PositionEncoding()).at(line, column)
+	uri := protocol.URIFromPath(filePath)
 	params.TextDocument = protocol.TextDocumentIdentifier{
 		URI: uri,
 	}
@@ -33,7 +30,7 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 	// Execute the hover request
 	hoverResult, err := client.Hover(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to get hover information: %v", err)
+		return "", fmt.Errorf("failed to get hover information: %w", err)
 	}
 
 	var result strings.Builder
@@ -53,7 +50,7 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 					Character: 0,
 				},
 			},
-		})
+		}, client.PositionEncoding())
 		if err != nil {
 			toolsLogger.Warn("failed to extract line at position: %v", err)
 		}
@@ -3,54 +3,77 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
 )
 
-func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
-	// Get context lines from environment variable
-	contextLines := 5
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
+func FindIncomingCalls(ctx context.Context, tc *ToolContext, symbolName string) (string, error) {
+	doc, err := FindIncomingCallsDocument(ctx, tc, symbolName)
+	if err != nil {
+		return "", err
 	}
+	return tc.render(doc)
+}
 
-	// First get the symbol location like ReadDefinition does
-	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
-		Query: symbolName,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch symbol: %v", err)
+// FindIncomingCallsDocument finds the callers of a symbol, grouped into one section per file
+func FindIncomingCallsDocument(ctx context.Context, tc *ToolContext, symbolName string) (format.Document, error) {
+	return FindFilteredIncomingCallsDocument(ctx, tc, symbolName, CallerFilter{})
+}
+
+// FindFilteredIncomingCallsDocument finds the callers of a symbol that the
+// filter keeps. The number of callers it hides is noted in the preamble.
+func FindFilteredIncomingCallsDocument(ctx context.Context, tc *ToolContext, symbolName string, filter CallerFilter) (format.Document, error) {
+	client := tc.Client
+	contextLines := tc.contextLines(5)
+	if err := filter.validate(); err != nil {
+		return format.Document{}, err
 	}
 
-	results, err := symbolResult.Results()
+	matches, err := tc.lookup(ctx, symbolName)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse results: %v", err)
+		return format.Document{}, err
 	}
 
-	var allIncomingCalls []string
-	for _, symbol := range results {
-		// Handle different matching strategies based on the search term
-		if strings.Contains(symbolName, ".") {
-			// For qualified names like "Type.Method", check for various matches
-			parts := strings.Split(symbolName, ".")
-			methodName := parts[len(parts)-1]
-
-			// Try matching the unqualified method name for languages that don't use qualified names in symbols
-			if symbol.GetName() != symbolName && symbol.GetName() != methodName {
-				continue
-			}
-		} else if symbol.GetName() != symbolName {
-			// For unqualified names, exact match only
+	doc := format.Document{
+		Banner:    "---\n\n",
+		Separator: "\n",
+		Empty:     fmt.Sprintf("No incoming calls found for symbol: %s", symbolName),
+	}
+	if len(matches) == 0 {
+		doc.EmptyKind = string(KindSymbolNotFound)
+	}
+	// Types are not called, their constructors are, so asking about a type
+	// shows the calls to its constructors instead
+	var targets []protocol.WorkspaceSymbolResult
+	for _, match := range matches {
+		constructors, err := tc.Resolver.Constructors(ctx, client, match.Symbol)
+		if err != nil {
+			toolsLogger.Debug("Constructor lookup failed for %s: %v", match.Symbol.GetName(), err)
+		}
+		if len(constructors) == 0 {
+			targets = append(targets, match.Symbol)
 			continue
 		}
 
+		names := make([]string, 0, len(constructors))
+		for _, constructor := range constructors {
+			names = append(names, constructor.GetName())
+		}
+		doc.Preamble += fmt.Sprintf("%s is a type, showing calls to its constructors: %s\n", match.Symbol.GetName(), strings.Join(names, ", "))
+		targets = append(targets, constructors...)
+	}
+	if doc.Preamble != "" {
+		doc.Preamble += "\n"
+	}
+
+	hidden, filtered := 0, 0
+	callerLines := fileLines()
+	for _, symbol := range targets {
 		// Get the location of the symbol
 		loc := symbol.GetLocation()
 
@@ -73,7 +96,7 @@ func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName strin
 
 		items, err := client.PrepareCallHierarchy(ctx, prepareParams)
 		if err != nil {
-			return "", fmt.Errorf("failed to prepare call hierarchy: %v", err)
+			return format.Document{}, fmt.Errorf("failed to prepare call hierarchy: %w", err)
 		}
 
 		if len(items) == 0 {
@@ -88,7 +111,7 @@ func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName strin
 
 			incomingCalls, err := client.IncomingCalls(ctx, incomingCallsParams)
 			if err != nil {
-				return "", fmt.Errorf("failed to get incoming calls: %v", err)
+				return format.Document{}, fmt.Errorf("failed to get incoming calls: %w", err)
 			}
 
 			if len(incomingCalls) == 0 {
@@ -98,6 +121,14 @@ func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName strin
 			// Group calls by file
 			callsByFile := make(map[protocol.DocumentUri][]protocol.CallHierarchyIncomingCall)
 			for _, call := range incomingCalls {
+				if !tc.inFocus(call.From.URI.Path()) {
+					hidden++
+					continue
+				}
+				if filter.active() && !filter.keeps(call.From, callerLines) {
+					filtered++
+					continue
+				}
 				callsByFile[call.From.URI] = append(callsByFile[call.From.URI], call)
 			}
 
@@ -112,27 +143,27 @@ func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName strin
 			for _, uriStr := range uris {
 				uri := protocol.DocumentUri(uriStr)
 				fileCalls := callsByFile[uri]
-				filePath := strings.TrimPrefix(uriStr, "file://")
+				filePath := protocol.PathFromURI(uriStr)
 
 				// Format file header
-				fileInfo := fmt.Sprintf("---\n\n%s\nIncoming Calls in File: %d\n",
-					filePath,
-					len(fileCalls),
-				)
+				section := format.Section{Path: filePath}
+				section.AddField("Incoming Calls in File", strconv.Itoa(len(fileCalls)))
 
 				// Format locations with context
-				fileContent, err := os.ReadFile(filePath)
+				fileContent, err := textenc.ReadFile(filePath)
 				if err != nil {
 					// Log error but continue with other files
-					allIncomingCalls = append(allIncomingCalls, fileInfo+"\nError reading file: "+err.Error())
+					section.Error = err.Error()
+					tc.addSection(&doc, section)
 					continue
 				}
 
-				lines := strings.Split(string(fileContent), "\n")
+				lines := strings.Split(fileContent, "\n")
 
 				// Track call locations for header display
 				var locStrings []string
-				var locations []protocol.Location
+				var locations, callSites []protocol.Location
+				columns := fileColumns{lines: lines, encoding: client.PositionEncoding()}
 				for _, call := range fileCalls {
 					// Add the caller location
 					loc := protocol.Location{
@@ -140,11 +171,11 @@ func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName strin
 						Range: call.From.SelectionRange,
 					}
 					locations = append(locations, loc)
+					for _, rng := range call.FromRanges {
+						callSites = append(callSites, protocol.Location{URI: call.From.URI, Range: rng})
+					}
 
-					locStr := fmt.Sprintf("L%d:C%d (%s)",
-						call.From.SelectionRange.Start.Line+1,
-						call.From.SelectionRange.Start.Character+1,
-						call.From.Name)
+					locStr := fmt.Sprintf("%s (%s)", columns.format(call.From.SelectionRange.Start), call.From.Name)
 					locStrings = append(locStrings, locStr)
 				}
 
@@ -159,21 +190,23 @@ func FindIncomingCalls(ctx context.Context, client *lsp.Client, symbolName strin
 				lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
 
 				// Format with locations in header
-				formattedOutput := fileInfo
 				if len(locStrings) > 0 {
-					formattedOutput += "Callers: " + strings.Join(locStrings, ", ") + "\n"
+					section.AddField("Callers", strings.Join(locStrings, ", "))
 				}
 
-				// Format the content with ranges
-				formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
-				allIncomingCalls = append(allIncomingCalls, formattedOutput)
+				section.Snippets = format.SnippetsFromRanges(lines, lineRanges)
+				section.Focus = focusLines(locations)
+				section.Marks = rangeMarks(lines, append(locations, callSites...), client.PositionEncoding())
+				tc.addSection(&doc, section)
 			}
 		}
 	}
 
-	if len(allIncomingCalls) == 0 {
-		return fmt.Sprintf("No incoming calls found for symbol: %s", symbolName), nil
+	for _, note := range []string{tc.focusNote(hidden, "incoming call"), filter.note(filtered)} {
+		if note != "" {
+			doc.Preamble = strings.TrimSuffix(doc.Preamble, "\n") + note + "\n"
+			doc.Empty += "\n" + strings.TrimSuffix(note, "\n")
+		}
 	}
-
-	return strings.Join(allIncomingCalls, "\n"), nil
+	return doc, nil
 }
@@ -3,12 +3,12 @@ package tools
 import (
 	"context"
 	"fmt"
-	"net/url"
-	"os"
 	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
 // Gets the full code block surrounding the start of the input location
@@ -60,19 +60,16 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 
 	if found {
 		// Convert URI to filesystem path
-		filePath, err := url.PathUnescape(strings.TrimPrefix(string(startLocation.URI), "file://"))
-		if err != nil {
-			return "", protocol.Location{}, fmt.Errorf("failed to unescape URI: %w", err)
-		}
+		filePath := protocol.PathFromURI(string(startLocation.URI))
 
 		// Read the file to get the full lines of the definition
 		// because we may have a start and end column
-		content, err := os.ReadFile(filePath)
+		content, err := textenc.ReadFile(filePath)
 		if err != nil {
 			return "", protocol.Location{}, fmt.Errorf("failed to read file: %w", err)
 		}
 
-		lines := strings.Split(string(content), "\n")
+		lines := strings.Split(content, "\n")
 
 		// Extend start to beginning of line
 		symbolRange.Start.Character = 0
@@ -110,7 +107,7 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 									if len(bracketStack) == 0 {
 										// Found matching bracket - update range
 										symbolRange.End.Line = lineNum
-										symbolRange.End.Character = uint32(pos + 1)
+										symbolRange.End.Character = utilities.CharacterOffset(line, pos+1, client.PositionEncoding())
 										goto foundClosing
 									}
 								}
@@ -135,7 +132,7 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 		return strings.Join(selectedLines, "\n"), startLocation, nil
 	}
 
-	return "", protocol.Location{}, fmt.Errorf("symbol not found")
+	return "", protocol.Location{}, errorf(KindSymbolNotFound, "symbol not found")
 }
 
 // GetLineRangesToDisplay determines which lines should be displayed for a set of locations
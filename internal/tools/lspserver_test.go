@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServerEnv makes the test binary run as a fake language server. Its value
+// is how the server reports diagnostics: "push" publishes them after every
+// open and change, "pull" answers textDocument/diagnostic instead.
+const fakeServerEnv = "MCP_LANGUAGE_SERVER_FAKE_LSP"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeServerEnv); mode != "" {
+		serveFakeLanguageServer(mode, os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// startFakeServer starts the test binary as a language server for a workspace
+// of Go files and returns an initialized client
+func startFakeServer(t *testing.T, mode, workspaceDir string) *lsp.Client {
+	t.Setenv(fakeServerEnv, mode)
+	client, err := lsp.NewClient(os.Args[0])
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.InitializeLSPClient(ctx, workspaceDir)
+	require.NoError(t, err)
+	return client
+}
+
+// fakeFunc matches the Go function declarations the fake server knows
+var fakeFunc = regexp.MustCompile(`^func (\w+)\(`)
+
+// fakeLanguageServer understands just enough Go to find functions, and
+// reports an error for every BROKEN in a document
+type fakeLanguageServer struct {
+	mode      string
+	root      string
+	documents map[protocol.DocumentUri]string
+	out       io.Writer
+	writeMu   sync.Mutex
+}
+
+func serveFakeLanguageServer(mode string, in io.Reader, out io.Writer) {
+	server := &fakeLanguageServer{mode: mode, documents: make(map[protocol.DocumentUri]string), out: out}
+	reader := bufio.NewReader(in)
+	for {
+		msg, err := lsp.ReadMessage(reader)
+		if err != nil || msg.Method == "exit" {
+			return
+		}
+		server.handle(msg)
+	}
+}
+
+func (s *fakeLanguageServer) write(msg *lsp.Message) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = lsp.WriteMessage(s.out, msg)
+}
+
+func (s *fakeLanguageServer) handle(msg *lsp.Message) {
+	var result any
+	var respErr *lsp.ResponseError
+	switch msg.Method {
+	case "initialize":
+		var params protocol.InitializeParams
+		_ = json.Unmarshal(msg.Params, &params)
+		s.root = params.RootPath
+		result = map[string]any{"capabilities": map[string]any{}}
+	case "textDocument/didOpen":
+		var params protocol.DidOpenTextDocumentParams
+		_ = json.Unmarshal(msg.Params, &params)
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params protocol.DidChangeTextDocumentParams
+		_ = json.Unmarshal(msg.Params, &params)
+		for _, change := range params.ContentChanges {
+			if whole, ok := change.Value.(protocol.TextDocumentContentChangeWholeDocument); ok {
+				s.update(params.TextDocument.URI, whole.Text)
+			}
+		}
+	case "textDocument/didClose":
+		var params protocol.DidCloseTextDocumentParams
+		_ = json.Unmarshal(msg.Params, &params)
+		delete(s.documents, params.TextDocument.URI)
+	case "textDocument/diagnostic":
+		if s.mode != "pull" {
+			respErr = &lsp.ResponseError{Code: -32601, Message: "method not found"}
+			break
+		}
+		var params protocol.DocumentDiagnosticParams
+		_ = json.Unmarshal(msg.Params, &params)
+		result = map[string]any{"kind": "full", "items": fakeDiagnostics(s.documents[params.TextDocument.URI])}
+	case "workspace/symbol":
+		var params protocol.WorkspaceSymbolParams
+		_ = json.Unmarshal(msg.Params, &params)
+		result = s.workspaceSymbols(params.Query)
+	case "textDocument/documentSymbol":
+		var params protocol.DocumentSymbolParams
+		_ = json.Unmarshal(msg.Params, &params)
+		result = fakeDocumentSymbols(s.text(params.TextDocument.URI))
+	}
+
+	if msg.ID == nil {
+		return
+	}
+	response := &lsp.Message{JSONRPC: "2.0", ID: msg.ID, Error: respErr}
+	if respErr == nil {
+		response.Result, _ = json.Marshal(result)
+	}
+	s.write(response)
+}
+
+// update stores the content of a document and, in push mode, publishes its
+// diagnostics a little later, the way servers analyze in the background
+func (s *fakeLanguageServer) update(uri protocol.DocumentUri, text string) {
+	s.documents[uri] = text
+	if s.mode != "push" {
+		return
+	}
+	params, _ := json.Marshal(protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: fakeDiagnostics(text)})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.write(&lsp.Message{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: params})
+	}()
+}
+
+// text returns the content of an open document or the file on disk
+func (s *fakeLanguageServer) text(uri protocol.DocumentUri) string {
+	if text, ok := s.documents[uri]; ok {
+		return text
+	}
+	content, _ := os.ReadFile(uri.Path())
+	return string(content)
+}
+
+func fakeDiagnostics(text string) []protocol.Diagnostic {
+	diagnostics := []protocol.Diagnostic{}
+	for i, line := range strings.Split(text, "\n") {
+		if column := strings.Index(line, "BROKEN"); column >= 0 {
+			diagnostics = append(diagnostics, protocol.Diagnostic{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(i), Character: uint32(column)},
+					End:   protocol.Position{Line: uint32(i), Character: uint32(column + len("BROKEN"))},
+				},
+				Severity: protocol.SeverityError,
+				Message:  "undefined: BROKEN",
+			})
+		}
+	}
+	return diagnostics
+}
+
+func (s *fakeLanguageServer) workspaceSymbols(query string) []protocol.SymbolInformation {
+	symbols := []protocol.SymbolInformation{}
+	paths, _ := filepath.Glob(filepath.Join(s.root, "*.go"))
+	for _, path := range paths {
+		uri := protocol.URIFromPath(path)
+		for _, symbol := range fakeDocumentSymbols(s.text(uri)) {
+			if strings.Contains(symbol.Name, query) {
+				symbols = append(symbols, protocol.SymbolInformation{
+					Name:     symbol.Name,
+					Kind:     symbol.Kind,
+					Location: protocol.Location{URI: uri, Range: symbol.SelectionRange},
+				})
+			}
+		}
+	}
+	return symbols
+}
+
+func fakeDocumentSymbols(text string) []protocol.DocumentSymbol {
+	symbols := []protocol.DocumentSymbol{}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		match := fakeFunc.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		end := i
+		for end < len(lines)-1 && lines[end] != "}" {
+			end++
+		}
+		symbols = append(symbols, protocol.DocumentSymbol{
+			Name: match[1],
+			Kind: protocol.Function,
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(i)},
+				End:   protocol.Position{Line: uint32(end), Character: uint32(len(lines[end]))},
+			},
+			SelectionRange: protocol.Range{
+				Start: protocol.Position{Line: uint32(i), Character: 5},
+				End:   protocol.Position{Line: uint32(i), Character: uint32(5 + len(match[1]))},
+			},
+		})
+	}
+	return symbols
+}
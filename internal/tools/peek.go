@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+)
+
+// PeekSymbol returns the definition, hover documentation and the top references of a
+// symbol in one response. The output is kept within maxTokens (estimated), giving
+// the definition and hover docs a fixed share of the budget and the rest to references.
+func PeekSymbol(ctx context.Context, tc *ToolContext, symbolName string, maxReferences, maxTokens int) (string, error) {
+	client := tc.Client
+	matches, err := tc.lookup(ctx, symbolName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return fmt.Sprintf("%s not found", symbolName), nil
+	}
+	symbol := matches[0].Symbol
+
+	budget := maxTokens * format.CharsPerToken
+	loc := symbol.GetLocation()
+	if err := client.OpenFile(ctx, loc.URI.Path()); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Symbol: %s\nFile: %s\n", symbol.GetName(), loc.URI.Path()))
+	if v, ok := symbol.(*protocol.SymbolInformation); ok {
+		result.WriteString(fmt.Sprintf("Kind: %s\n", protocol.TableKindMap[v.Kind]))
+		if v.ContainerName != "" {
+			result.WriteString(fmt.Sprintf("Container Name: %s\n", v.ContainerName))
+		}
+	}
+	if isDeprecatedSymbol(symbol) {
+		result.WriteString("Deprecated: yes, avoid new uses of this symbol\n")
+	}
+	if len(matches) > 1 {
+		result.WriteString(fmt.Sprintf("Other matches: %d (use definition to see all)\n", len(matches)-1))
+	}
+
+	// Hover docs
+	hoverParams := protocol.HoverParams{}
+	hoverParams.TextDocument = protocol.TextDocumentIdentifier{URI: loc.URI}
+	hoverParams.Position = loc.Range.Start
+	hover, err := client.Hover(ctx, hoverParams)
+	if err != nil {
+		toolsLogger.Debug("Hover failed for %s: %v", symbolName, err)
+	} else if hover.Contents.Value != "" {
+		result.WriteString("\nHover:\n")
+		result.WriteString(truncateToBudget(hover.Contents.Value, budget/4) + "\n")
+	}
+
+	// Definition
+	definition, defLoc, err := GetFullDefinition(ctx, client, loc)
+	if err != nil {
+		toolsLogger.Error("Error getting definition: %v", err)
+	} else {
+		columns := readColumns(defLoc.URI.Path(), client.PositionEncoding())
+		result.WriteString(fmt.Sprintf("\nDefinition: %s - %s\n", columns.format(defLoc.Range.Start), columns.format(defLoc.Range.End)))
+		definition = addLineNumbers(definition, int(defLoc.Range.Start.Line)+1)
+		result.WriteString(truncateToBudget(definition, budget*2/5) + "\n")
+	}
+
+	// References
+	refs, err := client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+			Position:     loc.Range.Start,
+		},
+		Context: protocol.ReferenceContext{IncludeDeclaration: false},
+	})
+	if err != nil {
+		toolsLogger.Debug("References failed for %s: %v", symbolName, err)
+	}
+
+	result.WriteString(fmt.Sprintf("\nReferences: %d\n", len(refs)))
+	top := selectTopReferences(refs, maxReferences)
+	fileLines := make(map[protocol.DocumentUri][]string)
+	for i, ref := range top {
+		lines, ok := fileLines[ref.URI]
+		if !ok {
+			content, err := textenc.ReadFile(ref.URI.Path())
+			if err == nil {
+				lines = strings.Split(content, "\n")
+			}
+			fileLines[ref.URI] = lines
+		}
+
+		lineText := ""
+		if int(ref.Range.Start.Line) < len(lines) {
+			lineText = strings.TrimSpace(lines[ref.Range.Start.Line])
+		}
+		columns := fileColumns{lines: lines, encoding: client.PositionEncoding()}
+		entry := fmt.Sprintf("%s:%s: %s\n", ref.URI.Path(), columns.format(ref.Range.Start), lineText)
+		if result.Len()+len(entry) > budget {
+			result.WriteString(fmt.Sprintf("... %d more references omitted (token budget)\n", len(refs)-i))
+			return result.String(), nil
+		}
+		result.WriteString(entry)
+	}
+	if len(refs) > len(top) {
+		result.WriteString(fmt.Sprintf("... %d more references omitted\n", len(refs)-len(top)))
+	}
+
+	return result.String(), nil
+}
+
+// selectTopReferences picks up to n references, taking one reference from each file
+// in turn so that the selection shows how the symbol is used across the codebase
+// rather than many uses in a single file
+func selectTopReferences(refs []protocol.Location, n int) []protocol.Location {
+	if n <= 0 || len(refs) == 0 {
+		return nil
+	}
+
+	byFile := make(map[protocol.DocumentUri][]protocol.Location)
+	var uris []string
+	for _, ref := range refs {
+		if _, ok := byFile[ref.URI]; !ok {
+			uris = append(uris, string(ref.URI))
+		}
+		byFile[ref.URI] = append(byFile[ref.URI], ref)
+	}
+	sort.Strings(uris)
+	for _, fileRefs := range byFile {
+		sort.Slice(fileRefs, func(i, j int) bool {
+			if fileRefs[i].Range.Start.Line != fileRefs[j].Range.Start.Line {
+				return fileRefs[i].Range.Start.Line < fileRefs[j].Range.Start.Line
+			}
+			return fileRefs[i].Range.Start.Character < fileRefs[j].Range.Start.Character
+		})
+	}
+
+	var selected []protocol.Location
+	for round := 0; len(selected) < n; round++ {
+		added := false
+		for _, uri := range uris {
+			fileRefs := byFile[protocol.DocumentUri(uri)]
+			if round < len(fileRefs) && len(selected) < n {
+				selected = append(selected, fileRefs[round])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return selected
+}
+
+// truncateToBudget cuts text at a line boundary so that it fits in maxChars,
+// noting how many lines were dropped
+func truncateToBudget(text string, maxChars int) string {
+	text = strings.TrimRight(text, "\n")
+	if len(text) <= maxChars {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	var kept strings.Builder
+	for i, line := range lines {
+		if kept.Len()+len(line)+1 > maxChars {
+			kept.WriteString(fmt.Sprintf("... (%d more lines)", len(lines)-i))
+			return kept.String()
+		}
+		kept.WriteString(line + "\n")
+	}
+	return strings.TrimRight(kept.String(), "\n")
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func refAt(uri string, line uint32) protocol.Location {
+	return protocol.Location{
+		URI: protocol.DocumentUri(uri),
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line},
+			End:   protocol.Position{Line: line},
+		},
+	}
+}
+
+func TestSelectTopReferences(t *testing.T) {
+	refs := []protocol.Location{
+		refAt("file:///b.go", 30),
+		refAt("file:///a.go", 20),
+		refAt("file:///a.go", 10),
+		refAt("file:///a.go", 5),
+		refAt("file:///c.go", 1),
+	}
+
+	// One reference from each file before a second from any file
+	selected := selectTopReferences(refs, 4)
+	assert.Equal(t, []protocol.Location{
+		refAt("file:///a.go", 5),
+		refAt("file:///b.go", 30),
+		refAt("file:///c.go", 1),
+		refAt("file:///a.go", 10),
+	}, selected)
+
+	assert.Len(t, selectTopReferences(refs, 10), 5)
+	assert.Nil(t, selectTopReferences(refs, 0))
+	assert.Nil(t, selectTopReferences(nil, 3))
+}
+
+func TestTruncateToBudget(t *testing.T) {
+	text := "line one\nline two\nline three\nline four\n"
+
+	assert.Equal(t, strings.TrimRight(text, "\n"), truncateToBudget(text, 100))
+
+	truncated := truncateToBudget(text, 20)
+	assert.Equal(t, "line one\nline two\n... (2 more lines)", truncated)
+
+	assert.Equal(t, "... (4 more lines)", truncateToBudget(text, 3))
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+)
+
+// Steps of a pipeline
+const (
+	StepDefinition    = "definition"
+	StepReferences    = "references"
+	StepIncomingCalls = "incoming_calls"
+	StepDiagnostics   = "diagnostics"
+)
+
+const (
+	// maxPipelineSteps bounds the steps of one pipeline
+	maxPipelineSteps = 8
+
+	// defaultPipelineFiles is how many files a file step runs on by default
+	defaultPipelineFiles = 10
+
+	// pipelineWorkers is how many files a file step works on at once
+	pipelineWorkers = 4
+)
+
+// PipelineSpec chains tools in one call. Symbol steps run on the symbol, file
+// steps run on each file the previous step found something in, or on Files
+// when they come first.
+type PipelineSpec struct {
+	Symbol   string
+	Files    []string
+	Steps    []string
+	MaxFiles int
+}
+
+// pipelineStep runs a tool as a step of a pipeline
+type pipelineStep struct {
+	// perFile steps run once per file, the others once on the symbol
+	perFile bool
+	run     func(ctx context.Context, tc *ToolContext, arg string) (format.Document, error)
+}
+
+// pipelineSteps are the tools pipelines can chain
+var pipelineSteps = map[string]pipelineStep{
+	StepDefinition:    {run: ReadDefinitionDocument},
+	StepReferences:    {run: FindReferencesDocument},
+	StepIncomingCalls: {run: FindIncomingCallsDocument},
+	StepDiagnostics: {perFile: true, run: func(ctx context.Context, tc *ToolContext, path string) (format.Document, error) {
+		return GetDiagnosticsDocument(ctx, tc.Client, path, tc.contextLines(5), true, false)
+	}},
+}
+
+// PipelineStepNames returns the steps pipelines can chain, in order
+func PipelineStepNames() []string {
+	return stepNames(pipelineSteps)
+}
+
+func stepNames(steps map[string]pipelineStep) []string {
+	names := make([]string, 0, len(steps))
+	for name := range steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunPipeline runs the steps of a pipeline server-side and returns their
+// results as one document, saving the round trips of a common investigation
+// such as definition, then references, then diagnostics of the referencing
+// files. Sections carry the step they came from.
+func RunPipeline(ctx context.Context, tc *ToolContext, spec PipelineSpec) (format.Document, error) {
+	return runPipeline(ctx, tc, pipelineSteps, spec)
+}
+
+func runPipeline(ctx context.Context, tc *ToolContext, steps map[string]pipelineStep, spec PipelineSpec) (format.Document, error) {
+	if len(spec.Steps) == 0 {
+		return format.Document{}, fmt.Errorf("a pipeline needs at least one step")
+	}
+	if len(spec.Steps) > maxPipelineSteps {
+		return format.Document{}, fmt.Errorf("a pipeline has at most %d steps, got %d", maxPipelineSteps, len(spec.Steps))
+	}
+	for _, name := range spec.Steps {
+		step, ok := steps[name]
+		if !ok {
+			return format.Document{}, fmt.Errorf("unknown pipeline step %q, expected one of %s", name, strings.Join(stepNames(steps), ", "))
+		}
+		if !step.perFile && spec.Symbol == "" {
+			return format.Document{}, fmt.Errorf("the %s step needs a symbol", name)
+		}
+	}
+	if !steps[spec.Steps[0]].perFile && len(spec.Files) > 0 {
+		return format.Document{}, fmt.Errorf("files are only used when the pipeline starts with a file step")
+	}
+	if steps[spec.Steps[0]].perFile && len(spec.Files) == 0 {
+		return format.Document{}, fmt.Errorf("the %s step needs files when it comes first", spec.Steps[0])
+	}
+	maxFiles := spec.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultPipelineFiles
+	}
+
+	doc := format.Document{
+		Banner:    "---\n\n",
+		Separator: "\n",
+	}
+	var preamble strings.Builder
+	if spec.Symbol != "" {
+		preamble.WriteString(fmt.Sprintf("Pipeline for %s: %s\n", spec.Symbol, strings.Join(spec.Steps, " -> ")))
+	} else {
+		preamble.WriteString(fmt.Sprintf("Pipeline for %s: %s\n", pluralize(len(spec.Files), "file"), strings.Join(spec.Steps, " -> ")))
+	}
+
+	files := spec.Files
+	for i, name := range spec.Steps {
+		if err := ctx.Err(); err != nil {
+			return format.Document{}, err
+		}
+		step := steps[name]
+
+		var results []format.Document
+		var note string
+		if step.perFile {
+			targets := files
+			if len(targets) > maxFiles {
+				note = fmt.Sprintf(", first %d of %d files (maxFiles)", maxFiles, len(targets))
+				targets = targets[:maxFiles]
+			}
+			results = runPerFile(ctx, tc, step, targets)
+		} else {
+			result, err := step.run(ctx, tc, spec.Symbol)
+			if err != nil {
+				return format.Document{}, fmt.Errorf("%s step failed: %w", name, err)
+			}
+			results = []format.Document{result}
+		}
+
+		// Files the step found something in feed the next step
+		files = nil
+		seen := make(map[string]bool)
+		var sections []format.Section
+		for _, result := range results {
+			for _, section := range result.Sections {
+				section.Fields = append([]format.Field{{Name: "Step", Value: name}}, section.Fields...)
+				sections = append(sections, section)
+				if section.Path != "" && !seen[section.Path] {
+					seen[section.Path] = true
+					files = append(files, section.Path)
+				}
+			}
+		}
+		doc.Sections = append(doc.Sections, sections...)
+
+		summary := pluralize(len(files), "file")
+		if len(sections) == 0 {
+			summary = "nothing found"
+			if len(results) == 1 && results[0].Empty != "" {
+				summary = results[0].Empty
+			}
+		}
+		preamble.WriteString(fmt.Sprintf("%d. %s: %s%s\n", i+1, name, summary, note))
+
+		if len(files) == 0 && i < len(spec.Steps)-1 && steps[spec.Steps[i+1]].perFile {
+			preamble.WriteString(fmt.Sprintf("Stopped: %s found no files for the next step\n", name))
+			break
+		}
+	}
+	preamble.WriteString("\n")
+	doc.Preamble = preamble.String()
+	doc.Empty = "Nothing found"
+	return doc, nil
+}
+
+// runPerFile runs a file step on files, a few at a time, keeping their order.
+// A file that fails gets a section with the error.
+func runPerFile(ctx context.Context, tc *ToolContext, step pipelineStep, files []string) []format.Document {
+	results := make([]format.Document, len(files))
+	sem := make(chan struct{}, pipelineWorkers)
+	var wg sync.WaitGroup
+	for i, path := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result, err := step.run(ctx, tc, path)
+			if err != nil {
+				result = format.Document{Sections: []format.Section{{Path: path, Error: err.Error()}}}
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	return results
+}
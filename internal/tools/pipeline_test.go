@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSteps returns pipeline steps that find a fixed set of files, recording
+// what each step was run on
+func fakeSteps(ran *[]string) map[string]pipelineStep {
+	symbolStep := func(paths ...string) pipelineStep {
+		return pipelineStep{run: func(ctx context.Context, tc *ToolContext, symbol string) (format.Document, error) {
+			*ran = append(*ran, symbol)
+			doc := format.Document{Empty: "No references found for symbol: " + symbol}
+			for _, path := range paths {
+				doc.Sections = append(doc.Sections, format.Section{Path: path})
+			}
+			return doc, nil
+		}}
+	}
+	return map[string]pipelineStep{
+		"definition": symbolStep("/ws/server.go"),
+		"references": symbolStep("/ws/a.go", "/ws/b.go", "/ws/a.go", "/ws/c.go"),
+		"callers":    symbolStep(),
+		"diagnostics": {perFile: true, run: func(ctx context.Context, tc *ToolContext, path string) (format.Document, error) {
+			switch path {
+			case "/ws/a.go":
+				section := format.Section{Path: path}
+				section.AddField("Diagnostics in File", "1")
+				return format.Document{Sections: []format.Section{section}}, nil
+			case "/ws/b.go":
+				return format.Document{}, errors.New("could not open file")
+			}
+			return format.Document{Empty: "No diagnostics found for " + path}, nil
+		}},
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	var ran []string
+	doc, err := runPipeline(context.Background(), &ToolContext{}, fakeSteps(&ran), PipelineSpec{
+		Symbol: "Start",
+		Steps:  []string{"definition", "references", "diagnostics"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Start", "Start"}, ran)
+
+	assert.Equal(t, "Pipeline for Start: definition -> references -> diagnostics\n"+
+		"1. definition: 1 file\n"+
+		"2. references: 3 files\n"+
+		"3. diagnostics: 2 files\n\n", doc.Preamble)
+
+	var steps, paths []string
+	for _, section := range doc.Sections {
+		steps = append(steps, section.Fields[0].Value)
+		paths = append(paths, section.Path)
+	}
+	assert.Equal(t, []string{"definition", "references", "references", "references", "references", "diagnostics", "diagnostics"}, steps)
+	assert.Equal(t, []string{"/ws/server.go", "/ws/a.go", "/ws/b.go", "/ws/a.go", "/ws/c.go", "/ws/a.go", "/ws/b.go"}, paths)
+	assert.Equal(t, []format.Field{{Name: "Step", Value: "diagnostics"}, {Name: "Diagnostics in File", Value: "1"}}, doc.Sections[5].Fields)
+	assert.Equal(t, "could not open file", doc.Sections[6].Error)
+}
+
+func TestRunPipelineLimitsFiles(t *testing.T) {
+	var ran []string
+	doc, err := runPipeline(context.Background(), &ToolContext{}, fakeSteps(&ran), PipelineSpec{
+		Symbol:   "Start",
+		Steps:    []string{"references", "diagnostics"},
+		MaxFiles: 1,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, doc.Preamble, "2. diagnostics: 1 file, first 1 of 3 files (maxFiles)\n")
+}
+
+func TestRunPipelineStopsWithoutFiles(t *testing.T) {
+	var ran []string
+	doc, err := runPipeline(context.Background(), &ToolContext{}, fakeSteps(&ran), PipelineSpec{
+		Symbol: "Start",
+		Steps:  []string{"callers", "diagnostics"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Pipeline for Start: callers -> diagnostics\n"+
+		"1. callers: No references found for symbol: Start\n"+
+		"Stopped: callers found no files for the next step\n\n", doc.Preamble)
+	assert.Empty(t, doc.Sections)
+}
+
+func TestRunPipelineValidatesSpec(t *testing.T) {
+	var ran []string
+	steps := fakeSteps(&ran)
+	tests := []struct {
+		spec     PipelineSpec
+		expected string
+	}{
+		{PipelineSpec{Symbol: "Start"}, "a pipeline needs at least one step"},
+		{PipelineSpec{Symbol: "Start", Steps: []string{"hover"}}, `unknown pipeline step "hover", expected one of callers, definition, diagnostics, references`},
+		{PipelineSpec{Steps: []string{"references"}}, "the references step needs a symbol"},
+		{PipelineSpec{Steps: []string{"diagnostics"}}, "the diagnostics step needs files when it comes first"},
+		{PipelineSpec{Symbol: "Start", Files: []string{"/ws/a.go"}, Steps: []string{"references"}}, "files are only used when the pipeline starts with a file step"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			_, err := runPipeline(context.Background(), &ToolContext{}, steps, tt.spec)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+	assert.Empty(t, ran)
+}
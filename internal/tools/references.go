@@ -3,53 +3,46 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
 )
 
-func FindReferences(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
-	// Get context lines from environment variable
-	contextLines := 5
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
-	}
-
-	// First get the symbol location like ReadDefinition does
-	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
-		Query: symbolName,
-	})
+func FindReferences(ctx context.Context, tc *ToolContext, symbolName string) (string, error) {
+	doc, err := FindReferencesDocument(ctx, tc, symbolName)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch symbol: %v", err)
+		return "", err
 	}
+	return tc.render(doc)
+}
 
-	results, err := symbolResult.Results()
+// FindReferencesDocument finds the references to a symbol, grouped into one section per file
+func FindReferencesDocument(ctx context.Context, tc *ToolContext, symbolName string) (format.Document, error) {
+	client := tc.Client
+	contextLines := tc.contextLines(5)
+
+	matches, err := tc.lookup(ctx, symbolName)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse results: %v", err)
+		return format.Document{}, err
 	}
+	matches = resolve.TopTier(matches)
 
-	var allReferences []string
-	for _, symbol := range results {
-		// Handle different matching strategies based on the search term
-		if strings.Contains(symbolName, ".") {
-			// For qualified names like "Type.Method", check for various matches
-			parts := strings.Split(symbolName, ".")
-			methodName := parts[len(parts)-1]
-
-			// Try matching the unqualified method name for languages that don't use qualified names in symbols
-			if symbol.GetName() != symbolName && symbol.GetName() != methodName {
-				continue
-			}
-		} else if symbol.GetName() != symbolName {
-			// For unqualified names, exact match only
-			continue
-		}
+	doc := format.Document{
+		Banner:    "---\n\n",
+		Separator: "\n",
+		Empty:     fmt.Sprintf("No references found for symbol: %s", symbolName),
+	}
+	if len(matches) == 0 {
+		doc.EmptyKind = string(KindSymbolNotFound)
+	}
+	hidden := 0
+	for _, match := range matches {
+		symbol := match.Symbol
 
 		// Get the location of the symbol
 		loc := symbol.GetLocation()
@@ -74,12 +67,16 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 		}
 		refs, err := client.References(ctx, refsParams)
 		if err != nil {
-			return "", fmt.Errorf("failed to get references: %v", err)
+			return format.Document{}, fmt.Errorf("failed to get references: %w", err)
 		}
 
 		// Group references by file
 		refsByFile := make(map[protocol.DocumentUri][]protocol.Location)
 		for _, ref := range refs {
+			if !tc.inFocus(ref.URI.Path()) {
+				hidden++
+				continue
+			}
 			refsByFile[ref.URI] = append(refsByFile[ref.URI], ref)
 		}
 
@@ -94,31 +91,28 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 		for _, uriStr := range uris {
 			uri := protocol.DocumentUri(uriStr)
 			fileRefs := refsByFile[uri]
-			filePath := strings.TrimPrefix(uriStr, "file://")
+			filePath := protocol.PathFromURI(uriStr)
 
 			// Format file header
-			fileInfo := fmt.Sprintf("---\n\n%s\nReferences in File: %d\n",
-				filePath,
-				len(fileRefs),
-			)
+			section := format.Section{Path: filePath}
+			section.AddField("References in File", strconv.Itoa(len(fileRefs)))
 
 			// Format locations with context
-			fileContent, err := os.ReadFile(filePath)
+			fileContent, err := textenc.ReadFile(filePath)
 			if err != nil {
 				// Log error but continue with other files
-				allReferences = append(allReferences, fileInfo+"\nError reading file: "+err.Error())
+				section.Error = err.Error()
+				tc.addSection(&doc, section)
 				continue
 			}
 
-			lines := strings.Split(string(fileContent), "\n")
+			lines := strings.Split(fileContent, "\n")
 
 			// Track reference locations for header display
 			var locStrings []string
+			columns := fileColumns{lines: lines, encoding: client.PositionEncoding()}
 			for _, ref := range fileRefs {
-				locStr := fmt.Sprintf("L%d:C%d",
-					ref.Range.Start.Line+1,
-					ref.Range.Start.Character+1)
-				locStrings = append(locStrings, locStr)
+				locStrings = append(locStrings, columns.format(ref.Range.Start))
 			}
 
 			// Collect lines to display using the utility function
@@ -132,20 +126,20 @@ func FindReferences(ctx context.Context, client *lsp.Client, symbolName string)
 			lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
 
 			// Format with locations in header
-			formattedOutput := fileInfo
 			if len(locStrings) > 0 {
-				formattedOutput += "At: " + strings.Join(locStrings, ", ") + "\n"
+				section.AddField("At", strings.Join(locStrings, ", "))
 			}
 
-			// Format the content with ranges
-			formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
-			allReferences = append(allReferences, formattedOutput)
+			section.Snippets = format.SnippetsFromRanges(lines, lineRanges)
+			section.Focus = focusLines(fileRefs)
+			section.Marks = rangeMarks(lines, fileRefs, client.PositionEncoding())
+			tc.addSection(&doc, section)
 		}
 	}
 
-	if len(allReferences) == 0 {
-		return fmt.Sprintf("No references found for symbol: %s", symbolName), nil
+	if note := tc.focusNote(hidden, "reference"); note != "" {
+		doc.Preamble = note + "\n"
+		doc.Empty += "\n" + strings.TrimSuffix(note, "\n")
 	}
-
-	return strings.Join(allReferences, "\n"), nil
+	return doc, nil
 }
@@ -8,24 +8,40 @@ import (
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
 	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
 // RenameSymbol renames a symbol (variable, function, class, etc.) at the specified position
 // It uses the LSP rename functionality to handle all references across files
 func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int, newName string) (string, error) {
+	return RenameSymbolWithPolicy(ctx, client, filePath, line, column, newName, settings.EditPolicySettings{}, false)
+}
+
+// RenameSymbolWithPolicy renames a symbol like RenameSymbol, but refuses to apply
+// the rename when any file it touches is blocked by the edit policy, unless force is set
+func RenameSymbolWithPolicy(ctx context.Context, client *lsp.Client, filePath string, line, column int, newName string, policy settings.EditPolicySettings, force bool) (string, error) {
+	tc := &ToolContext{Client: client, Settings: &settings.Settings{EditPolicy: policy}}
+	return RenameSymbolAcrossServers(ctx, tc, filePath, line, column, newName, force)
+}
+
+// RenameSymbolAcrossServers renames a symbol like RenameSymbolWithPolicy and also
+// asks peer language servers to rename every symbol they know by the same name,
+// for symbols that cross languages such as Go types mirrored in generated
+// TypeScript bindings. The edits of all servers are merged, and nothing is
+// applied when edits from different servers conflict.
+func RenameSymbolAcrossServers(ctx context.Context, tc *ToolContext, filePath string, line, column int, newName string, force bool) (string, error) {
+	client, peers := tc.Client, tc.Peers
 	// Open the file if not already open
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 
 	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	uri := protocol.DocumentUri("file://" + filePath)
-	position := protocol.Position{
-		Line:      uint32(line - 1),
-		Character: uint32(column - 1),
-	}
+	uri := protocol.URIFromPath(filePath)
+	position := readColumns(filePath, client.PositionEncoding()).at(line, column)
 
 	// Create the rename parameters
 	params := protocol.RenameParams{
@@ -42,7 +58,33 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 	// Execute the rename operation
 	workspaceEdit, err := client.Rename(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to rename symbol: %v", err)
+		return "", fmt.Errorf("failed to rename symbol: %w", err)
+	}
+	workspaceEdit, err = utilities.WorkspaceEditToUTF8(workspaceEdit, client.PositionEncoding())
+	if err != nil {
+		return "", fmt.Errorf("failed to rename symbol: %w", err)
+	}
+
+	if len(peers) > 0 {
+		oldName, err := identifierAt(filePath, line, column)
+		if err != nil {
+			return "", err
+		}
+
+		edits := []protocol.WorkspaceEdit{workspaceEdit}
+		for _, peer := range peers {
+			peerEdits, err := renameInPeer(ctx, peer, oldName, newName)
+			if err != nil {
+				toolsLogger.Warn("Peer language server could not rename %s: %v", oldName, err)
+				continue
+			}
+			edits = append(edits, peerEdits...)
+		}
+
+		workspaceEdit, err = utilities.MergeWorkspaceEdits(edits...)
+		if err != nil {
+			return "", fmt.Errorf("rename not applied: %w", err)
+		}
 	}
 
 	// Count the changes that will be made
@@ -65,10 +107,10 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		for uri, edits := range workspaceEdit.Changes {
 			changeCount += len(edits)
 			var locs strings.Builder
+			// The edit was converted to utf-8 above
+			columns := readColumns(protocol.PathFromURI(string(uri)), protocol.UTF8)
 			for i, change := range edits {
-				locs.WriteString(
-					fmt.Sprintf("L%d:C%d", change.Range.Start.Line+1, change.Range.Start.Character+1),
-				)
+				locs.WriteString(columns.format(change.Range.Start))
 				if i != len(edits)-1 {
 					locs.WriteString(", ")
 				}
@@ -84,10 +126,11 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 	for _, change := range workspaceEdit.DocumentChanges {
 		if change.TextDocumentEdit != nil {
 			var locs strings.Builder
+			columns := readColumns(protocol.PathFromURI(string(change.TextDocumentEdit.TextDocument.URI)), protocol.UTF8)
 			for i, edit := range change.TextDocumentEdit.Edits {
 				textEdit, err := edit.AsTextEdit()
 				if err == nil {
-					locs.WriteString(fmt.Sprintf("L%d:C%d", textEdit.Range.Start.Line+1, textEdit.Range.Start.Character+1))
+					locs.WriteString(columns.format(textEdit.Range.Start))
 					if i != len(change.TextDocumentEdit.Edits)-1 {
 						locs.WriteString(", ")
 					}
@@ -112,9 +155,19 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		locationsBuilder.WriteString(fmt.Sprintf("%s: %s\n", change.URI, change.Locations))
 	}
 
+	if !force {
+		var touchedFiles []string
+		for _, change := range allChanges {
+			touchedFiles = append(touchedFiles, protocol.PathFromURI(string(change.URI)))
+		}
+		if err := CheckEditPolicy(ctx, client, tc.Settings.EditPolicy, touchedFiles); err != nil {
+			return "", err
+		}
+	}
+
 	// Apply the workspace edit to files:workspaceEdit
 	if err := utilities.ApplyWorkspaceEdit(workspaceEdit); err != nil {
-		return "", fmt.Errorf("failed to apply changes: %v", err)
+		return "", fmt.Errorf("failed to apply changes: %w", err)
 	}
 
 	if fileCount == 0 || changeCount == 0 {
@@ -125,3 +178,95 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 	return fmt.Sprintf("Successfully renamed symbol to '%s'.\nUpdated %d occurrences across %d files:\n%s",
 		newName, changeCount, fileCount, locationsBuilder.String()), nil
 }
+
+// renameInPeer renames every workspace symbol a peer server knows as oldName
+func renameInPeer(ctx context.Context, peer *lsp.Client, oldName, newName string) ([]protocol.WorkspaceEdit, error) {
+	symbolResult, err := peer.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: oldName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch symbol: %w", err)
+	}
+	results, err := symbolResult.Results()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results: %w", err)
+	}
+
+	var edits []protocol.WorkspaceEdit
+	for _, symbol := range results {
+		if symbol.GetName() != oldName {
+			continue
+		}
+		loc := symbol.GetLocation()
+		path := protocol.PathFromURI(string(loc.URI))
+		if err := peer.OpenFile(ctx, path); err != nil {
+			return nil, fmt.Errorf("could not open file: %w", err)
+		}
+
+		edit, err := peer.Rename(ctx, protocol.RenameParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+			Position:     namePosition(path, loc.Range.Start, oldName, peer.PositionEncoding()),
+			NewName:      newName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to rename symbol in %s: %w", path, err)
+		}
+		// Peers may count characters differently, so their edits are merged in utf-8
+		edit, err = utilities.WorkspaceEditToUTF8(edit, peer.PositionEncoding())
+		if err != nil {
+			return nil, fmt.Errorf("failed to rename symbol in %s: %w", path, err)
+		}
+		edits = append(edits, edit)
+	}
+	return edits, nil
+}
+
+// namePosition moves a symbol position to the symbol's name on the same line,
+// because some servers report where the declaration starts, e.g. at "export".
+// Character offsets are counted in the given position encoding.
+func namePosition(path string, start protocol.Position, name string, encoding protocol.PositionEncodingKind) protocol.Position {
+	content, err := textenc.ReadFile(path)
+	if err != nil {
+		return start
+	}
+	lines := strings.Split(content, "\n")
+	if int(start.Line) >= len(lines) {
+		return start
+	}
+	line := lines[start.Line]
+	if start.Character > utilities.CharacterOffset(line, len(line), encoding) {
+		return start
+	}
+	from := utilities.ByteOffset(line, start.Character, encoding)
+	if offset := strings.Index(line[from:], name); offset >= 0 {
+		start.Character = utilities.CharacterOffset(line, from+offset, encoding)
+	}
+	return start
+}
+
+// identifierAt returns the identifier at a 1-indexed position in a file
+func identifierAt(filePath string, line, column int) (string, error) {
+	content, err := textenc.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %w", err)
+	}
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", fmt.Errorf("line %d is out of range (1-%d)", line, len(lines))
+	}
+
+	text := lines[line-1]
+	isIdentifier := func(b byte) bool {
+		return b == '_' || b == '$' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+	}
+	start := int(utilities.ColumnCharacter(text, column, protocol.UTF8))
+	if column < 1 || start >= len(text) || !isIdentifier(text[start]) {
+		return "", errorf(KindSymbolNotFound, "no identifier at L%d:C%d", line, column)
+	}
+	end := start
+	for start > 0 && isIdentifier(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isIdentifier(text[end]) {
+		end++
+	}
+	return text[start:end], nil
+}
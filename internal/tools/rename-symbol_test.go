@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifierAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "types.go")
+	assert.NoError(t, os.WriteFile(path, []byte("package api\n\ntype UserID string\n"), 0644))
+
+	name, err := identifierAt(path, 3, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, "UserID", name)
+
+	name, err = identifierAt(path, 3, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "UserID", name, "the first character of the name")
+
+	_, err = identifierAt(path, 3, 5)
+	assert.ErrorContains(t, err, "no identifier at L3:C5")
+	_, err = identifierAt(path, 10, 1)
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestNamePosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bindings.ts")
+	assert.NoError(t, os.WriteFile(path, []byte("// generated\nexport interface UserID {}\n"), 0644))
+
+	// Servers may point at the start of the declaration rather than the name
+	assert.Equal(t, protocol.Position{Line: 1, Character: 17}, namePosition(path, protocol.Position{Line: 1}, "UserID", protocol.UTF16))
+	assert.Equal(t, protocol.Position{Line: 0, Character: 3}, namePosition(path, protocol.Position{Line: 0, Character: 3}, "UserID", protocol.UTF16))
+	assert.Equal(t, protocol.Position{Line: 7}, namePosition(path, protocol.Position{Line: 7}, "UserID", protocol.UTF16))
+
+	// Characters before the name count as many units as the encoding says
+	assert.NoError(t, os.WriteFile(path, []byte("export /* 🚀 */ interface UserID {}\n"), 0644))
+	assert.Equal(t, protocol.Position{Character: 26}, namePosition(path, protocol.Position{}, "UserID", protocol.UTF16))
+	assert.Equal(t, protocol.Position{Character: 28}, namePosition(path, protocol.Position{}, "UserID", protocol.UTF8))
+}
@@ -0,0 +1,361 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+)
+
+// Values of the doc_comment argument of replace_symbol
+const (
+	// DocCommentKeep keeps the existing doc comment unless the new text
+	// brings its own
+	DocCommentKeep = "keep"
+
+	// DocCommentReplace uses the new text as given, removing the existing doc
+	// comment when the new text has none
+	DocCommentReplace = "replace"
+)
+
+// docStyle describes how a language writes documentation
+type docStyle struct {
+	// linePrefixes start line comments, e.g. "//" or "#"
+	linePrefixes []string
+	// block is set for languages with /* */ comments
+	block bool
+	// attributes start lines that may sit between a doc comment and the
+	// declaration, e.g. "@" for decorators or "#[" for Rust attributes
+	attributes []string
+	// docstrings is set for Python, which documents in the body
+	docstrings bool
+}
+
+// docStyleFor returns the documentation style for a file
+func docStyleFor(path string) docStyle {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return docStyle{linePrefixes: []string{"//"}, block: true}
+	case ".rs":
+		return docStyle{linePrefixes: []string{"//"}, block: true, attributes: []string{"#["}}
+	case ".cs":
+		return docStyle{linePrefixes: []string{"//"}, block: true, attributes: []string{"["}}
+	case ".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh", ".m", ".mm":
+		return docStyle{linePrefixes: []string{"//"}, block: true}
+	case ".java", ".kt", ".kts", ".scala", ".swift", ".dart", ".php",
+		".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts":
+		return docStyle{linePrefixes: []string{"//"}, block: true, attributes: []string{"@"}}
+	case ".py", ".pyi":
+		return docStyle{linePrefixes: []string{"#"}, attributes: []string{"@"}, docstrings: true}
+	case ".rb", ".sh", ".bash", ".zsh", ".pl", ".r", ".ex", ".exs", ".nim", ".cr":
+		return docStyle{linePrefixes: []string{"#"}}
+	case ".lua", ".sql", ".hs", ".elm":
+		return docStyle{linePrefixes: []string{"--"}}
+	default:
+		return docStyle{linePrefixes: []string{"//", "#"}, block: true}
+	}
+}
+
+// isLineComment reports whether a trimmed line is a line comment
+func (s docStyle) isLineComment(trimmed string) bool {
+	for _, prefix := range s.linePrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAttribute reports whether a trimmed line is an attribute or decorator
+func (s docStyle) isAttribute(trimmed string) bool {
+	for _, prefix := range s.attributes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentLines returns how many lines starting at lines[from] are comments
+func (s docStyle) commentLines(lines []string, from int) int {
+	i := from
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case s.isLineComment(trimmed):
+			i++
+		case s.block && strings.HasPrefix(trimmed, "/*"):
+			end := i
+			for end < len(lines) && !strings.Contains(lines[end], "*/") {
+				end++
+			}
+			if end == len(lines) {
+				return i - from
+			}
+			i = end + 1
+		default:
+			return i - from
+		}
+	}
+	return i - from
+}
+
+// docAbove finds the doc comment above the declaration at line decl. Attribute
+// lines between the comment and the declaration are skipped. It returns the
+// first and last line of the comment.
+func (s docStyle) docAbove(lines []string, decl int) (int, int, bool) {
+	i := decl - 1
+	for i >= 0 && s.isAttribute(strings.TrimSpace(lines[i])) {
+		i--
+	}
+	if i < 0 {
+		return 0, 0, false
+	}
+	last := i
+
+	trimmed := strings.TrimSpace(lines[i])
+	if s.block && strings.HasSuffix(trimmed, "*/") && !s.isLineComment(trimmed) {
+		for i >= 0 && !strings.Contains(lines[i], "/*") {
+			i--
+		}
+		if i < 0 {
+			return 0, 0, false
+		}
+		return i, last, true
+	}
+
+	for i >= 0 && s.isLineComment(strings.TrimSpace(lines[i])) {
+		i--
+	}
+	if i == last {
+		return 0, 0, false
+	}
+	return i + 1, last, true
+}
+
+// docstringQuotes are the quotes that open a Python docstring
+var docstringQuotes = []string{`"""`, `'''`}
+
+// docstring finds the docstring of the Python def or class whose header
+// starts at lines[0]. It returns the line after the header and the first and
+// last line of the docstring.
+func docstring(lines []string) (body, from, to int, ok bool) {
+	header := 0
+	for header < len(lines) && !strings.HasSuffix(stripPythonComment(lines[header]), ":") {
+		header++
+	}
+	if header == len(lines) {
+		return 0, 0, 0, false
+	}
+	body = header + 1
+
+	from = body
+	for from < len(lines) && strings.TrimSpace(lines[from]) == "" {
+		from++
+	}
+	if from == len(lines) {
+		return body, 0, 0, false
+	}
+
+	opening := strings.TrimLeft(strings.TrimSpace(lines[from]), "rRuUbB")
+	for _, quote := range docstringQuotes {
+		if !strings.HasPrefix(opening, quote) {
+			continue
+		}
+		if strings.Contains(opening[len(quote):], quote) {
+			return body, from, from, true
+		}
+		for to = from + 1; to < len(lines); to++ {
+			if strings.Contains(lines[to], quote) {
+				return body, from, to, true
+			}
+		}
+	}
+	return body, 0, 0, false
+}
+
+// stripPythonComment removes a trailing comment and whitespace from a line
+func stripPythonComment(line string) string {
+	if index := strings.Index(line, "#"); index >= 0 {
+		line = line[:index]
+	}
+	return strings.TrimSpace(line)
+}
+
+// leadingIndent returns the indentation of a line
+func leadingIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// reindent moves lines from one indentation to another
+func reindent(lines []string, from, to string) []string {
+	moved := make([]string, len(lines))
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if trimmed, ok := strings.CutPrefix(line, from); ok {
+			moved[i] = to + trimmed
+		} else {
+			moved[i] = to + strings.TrimLeft(line, " \t")
+		}
+	}
+	return moved
+}
+
+// planSymbolReplacement returns the edits replacing the definition spanning
+// lines[start:end+1] with newText, keeping or replacing the doc comment as
+// the mode asks, and a note describing what happened to the doc comment
+func planSymbolReplacement(path string, lines []string, start, end int, newText, mode string) ([]TextEdit, string) {
+	style := docStyleFor(path)
+	oldLines := lines[start : end+1]
+	newLines := strings.Split(strings.TrimRight(newText, "\n"), "\n")
+
+	firstNew := 0
+	for firstNew < len(newLines) && strings.TrimSpace(newLines[firstNew]) == "" {
+		firstNew++
+	}
+	newDoc := style.commentLines(newLines, firstNew) > 0
+	inRange := style.commentLines(oldLines, 0)
+	aboveStart, aboveEnd, above := style.docAbove(lines, start)
+
+	var edits []TextEdit
+	var notes []string
+	removeAbove := func() {
+		edits = append(edits, TextEdit{StartLine: aboveStart + 1, EndLine: aboveEnd + 1})
+	}
+
+	switch {
+	case mode == DocCommentReplace:
+		if above {
+			removeAbove()
+		}
+		if above || inRange > 0 {
+			if newDoc {
+				notes = append(notes, "Replaced the doc comment")
+			} else {
+				notes = append(notes, "Removed the doc comment")
+			}
+		}
+	case newDoc:
+		if above {
+			removeAbove()
+		}
+		if above || inRange > 0 {
+			notes = append(notes, "Replaced the doc comment with the one in the new text")
+		}
+	case inRange > 0:
+		newLines = append(append([]string{}, oldLines[:inRange]...), newLines...)
+		notes = append(notes, fmt.Sprintf("Kept the existing doc comment (%s)", pluralize(inRange, "line")))
+	case above:
+		notes = append(notes, fmt.Sprintf("Kept the existing doc comment (%s)", pluralize(aboveEnd-aboveStart+1, "line")))
+	}
+
+	if style.docstrings {
+		_, oldFrom, oldTo, oldOK := docstring(oldLines)
+		newBody, _, _, newOK := docstring(newLines)
+		switch {
+		case oldOK && newOK:
+			notes = append(notes, "Replaced the docstring with the one in the new text")
+		case oldOK && mode == DocCommentReplace:
+			notes = append(notes, "Removed the docstring")
+		case oldOK && newBody > 0:
+			indent := leadingIndent(newLines[newBody-1]) + "    "
+			for _, line := range newLines[newBody:] {
+				if strings.TrimSpace(line) != "" {
+					indent = leadingIndent(line)
+					break
+				}
+			}
+			kept := reindent(oldLines[oldFrom:oldTo+1], leadingIndent(oldLines[oldFrom]), indent)
+			newLines = append(append(append([]string{}, newLines[:newBody]...), kept...), newLines[newBody:]...)
+			notes = append(notes, fmt.Sprintf("Kept the existing docstring (%s)", pluralize(len(kept), "line")))
+		}
+	}
+
+	edits = append(edits, TextEdit{
+		StartLine: start + 1,
+		EndLine:   end + 1,
+		NewText:   strings.Join(newLines, "\n"),
+	})
+	return edits, strings.Join(notes, "\n")
+}
+
+// ReplaceSymbol replaces the whole definition of a symbol with new text. Unless
+// the mode is DocCommentReplace, the existing doc comment or docstring is kept
+// when the new text has none, so rewriting a function body does not silently
+// delete its documentation. The edit is refused when the file is blocked by the
+// edit policy, unless force is set.
+func ReplaceSymbol(ctx context.Context, tc *ToolContext, symbolName, newText, mode string, force bool) (string, error) {
+	client := tc.Client
+	if mode == "" {
+		mode = DocCommentKeep
+	}
+	if mode != DocCommentKeep && mode != DocCommentReplace {
+		return "", fmt.Errorf("invalid doc_comment %q, expected %s or %s", mode, DocCommentKeep, DocCommentReplace)
+	}
+
+	matches, err := tc.lookup(ctx, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", errorf(KindSymbolNotFound, "%s not found", symbolName)
+	}
+	// Editing the wrong one of several equally good matches is worse than
+	// asking for a qualified name
+	var candidates []string
+	seen := make(map[protocol.Location]bool)
+	for _, match := range matches {
+		loc := match.Symbol.GetLocation()
+		if match.Score != matches[0].Score || seen[loc] {
+			continue
+		}
+		seen[loc] = true
+		candidates = append(candidates, fmt.Sprintf("%s (%s:L%d)", match.Symbol.GetName(), loc.URI.Path(), loc.Range.Start.Line+1))
+	}
+	if len(candidates) > 1 {
+		return "", fmt.Errorf("%s is ambiguous, qualify the name or pass from_file to pick one of: %s", symbolName, strings.Join(candidates, ", "))
+	}
+
+	loc := matches[0].Symbol.GetLocation()
+	if err := client.OpenFile(ctx, loc.URI.Path()); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	_, defLoc, err := GetFullDefinition(ctx, client, loc)
+	if err != nil {
+		return "", fmt.Errorf("could not find the definition of %s: %w", symbolName, err)
+	}
+
+	path := protocol.PathFromURI(string(defLoc.URI))
+	if !force {
+		if err := CheckEditPolicy(ctx, client, tc.Settings.EditPolicy, []string{path}); err != nil {
+			return "", err
+		}
+	}
+
+	content, err := textenc.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %w", err)
+	}
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	start, end := int(defLoc.Range.Start.Line), int(defLoc.Range.End.Line)
+	if end >= len(lines) || start > end {
+		return "", fmt.Errorf("definition range L%d-L%d is outside %s", start+1, end+1, path)
+	}
+
+	edits, note := planSymbolReplacement(path, lines, start, end, newText, mode)
+	result, err := ApplyTextEdits(ctx, client, path, edits)
+	if err != nil {
+		return "", err
+	}
+
+	result = fmt.Sprintf("Replaced %s in %s (L%d-L%d). %s", matches[0].Symbol.GetName(), path, start+1, end+1, result)
+	if note != "" {
+		result += "\n" + note
+	}
+	return result, nil
+}
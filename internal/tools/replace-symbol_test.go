@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSymbolReplacement(t *testing.T) {
+	goFile := strings.Split(`package server
+
+// Start starts the server.
+// It blocks until ctx is done.
+func Start(ctx context.Context) error {
+	return nil
+}`, "\n")
+
+	tests := []struct {
+		name     string
+		path     string
+		lines    []string
+		start    int
+		end      int
+		newText  string
+		mode     string
+		expected []TextEdit
+		note     string
+	}{
+		{
+			name:     "doc above is kept",
+			path:     "server.go",
+			lines:    goFile,
+			start:    4,
+			end:      6,
+			newText:  "func Start(ctx context.Context) error {\n\treturn run(ctx)\n}",
+			mode:     DocCommentKeep,
+			expected: []TextEdit{{StartLine: 5, EndLine: 7, NewText: "func Start(ctx context.Context) error {\n\treturn run(ctx)\n}"}},
+			note:     "Kept the existing doc comment (2 lines)",
+		},
+		{
+			name:    "doc in the new text replaces the old one",
+			path:    "server.go",
+			lines:   goFile,
+			start:   4,
+			end:     6,
+			newText: "// Start runs the server.\nfunc Start(ctx context.Context) error {\n\treturn run(ctx)\n}\n",
+			mode:    DocCommentKeep,
+			expected: []TextEdit{
+				{StartLine: 3, EndLine: 4},
+				{StartLine: 5, EndLine: 7, NewText: "// Start runs the server.\nfunc Start(ctx context.Context) error {\n\treturn run(ctx)\n}"},
+			},
+			note: "Replaced the doc comment with the one in the new text",
+		},
+		{
+			name:    "replace removes the doc",
+			path:    "server.go",
+			lines:   goFile,
+			start:   4,
+			end:     6,
+			newText: "func Start(ctx context.Context) error {\n\treturn nil\n}",
+			mode:    DocCommentReplace,
+			expected: []TextEdit{
+				{StartLine: 3, EndLine: 4},
+				{StartLine: 5, EndLine: 7, NewText: "func Start(ctx context.Context) error {\n\treturn nil\n}"},
+			},
+			note: "Removed the doc comment",
+		},
+		{
+			name: "doc inside the symbol range is kept",
+			path: "lib.rs",
+			lines: strings.Split(`/// Adds two numbers.
+#[inline]
+pub fn add(a: i32, b: i32) -> i32 {
+    a + b
+}`, "\n"),
+			start:    0,
+			end:      4,
+			newText:  "#[inline]\npub fn add(a: i32, b: i32) -> i32 {\n    a.wrapping_add(b)\n}",
+			mode:     DocCommentKeep,
+			expected: []TextEdit{{StartLine: 1, EndLine: 5, NewText: "/// Adds two numbers.\n#[inline]\npub fn add(a: i32, b: i32) -> i32 {\n    a.wrapping_add(b)\n}"}},
+			note:     "Kept the existing doc comment (1 line)",
+		},
+		{
+			name: "block doc above annotations is found",
+			path: "Service.java",
+			lines: strings.Split(`class Service {
+    /**
+     * Handles a request.
+     */
+    @Override
+    public void handle() {
+    }
+}`, "\n"),
+			start:   5,
+			end:     6,
+			newText: "    /** Handles a request quickly. */\n    public void handle() {\n        fast();\n    }",
+			mode:    DocCommentKeep,
+			expected: []TextEdit{
+				{StartLine: 2, EndLine: 4},
+				{StartLine: 6, EndLine: 7, NewText: "    /** Handles a request quickly. */\n    public void handle() {\n        fast();\n    }"},
+			},
+			note: "Replaced the doc comment with the one in the new text",
+		},
+		{
+			name: "python docstring is kept",
+			path: "app.py",
+			lines: strings.Split(`class App:
+    def run(self, port):
+        """Run the app.
+
+        Blocks forever.
+        """
+        serve(port)`, "\n"),
+			start:   1,
+			end:     6,
+			newText: "def run(self, port):\n    serve(port, reload=True)",
+			mode:    DocCommentKeep,
+			expected: []TextEdit{{StartLine: 2, EndLine: 7, NewText: `def run(self, port):
+    """Run the app.
+
+    Blocks forever.
+    """
+    serve(port, reload=True)`}},
+			note: "Kept the existing docstring (4 lines)",
+		},
+		{
+			name: "python docstring in the new text wins",
+			path: "app.py",
+			lines: strings.Split(`def run(port):
+    """Run the app."""
+    serve(port)`, "\n"),
+			start:    0,
+			end:      2,
+			newText:  "def run(port):\n    \"\"\"Serve forever.\"\"\"\n    serve(port)",
+			mode:     DocCommentKeep,
+			expected: []TextEdit{{StartLine: 1, EndLine: 3, NewText: "def run(port):\n    \"\"\"Serve forever.\"\"\"\n    serve(port)"}},
+			note:     "Replaced the docstring with the one in the new text",
+		},
+		{
+			name:     "no doc",
+			path:     "main.go",
+			lines:    []string{"package main", "", "func main() {", "}"},
+			start:    2,
+			end:      3,
+			newText:  "func main() {\n\trun()\n}",
+			mode:     DocCommentKeep,
+			expected: []TextEdit{{StartLine: 3, EndLine: 4, NewText: "func main() {\n\trun()\n}"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits, note := planSymbolReplacement(tt.path, tt.lines, tt.start, tt.end, tt.newText, tt.mode)
+			assert.Equal(t, tt.expected, edits)
+			assert.Equal(t, tt.note, note)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+// querySearcher answers workspace symbol queries from a fixed table
+type querySearcher map[string][]protocol.SymbolInformation
+
+func (q querySearcher) Symbol(ctx context.Context, params protocol.WorkspaceSymbolParams) (protocol.Or_Result_workspace_symbol, error) {
+	return protocol.Or_Result_workspace_symbol{Value: q[params.Query]}, nil
+}
+
+func info(name, container string, kind protocol.SymbolKind, path string) protocol.SymbolInformation {
+	return *symbolAt(name, container, kind, path).(*protocol.SymbolInformation)
+}
+
+func names(symbols []protocol.WorkspaceSymbolResult) []string {
+	var result []string
+	for _, symbol := range symbols {
+		result = append(result, symbol.GetName()+" "+FilePath(symbol.GetLocation().URI))
+	}
+	return result
+}
+
+func TestConstructors(t *testing.T) {
+	resolver := New(settings.Default().SymbolMatch)
+	searcher := querySearcher{
+		"NewConfig": {
+			info("NewConfig", "settings", protocol.Function, "/ws/settings/new.go"),
+			info("NewConfig", "other", protocol.Function, "/ws/other/config.go"),
+			info("NewConfigLoader", "settings", protocol.Function, "/ws/settings/loader.go"),
+		},
+		"__init__": {
+			info("__init__", "Config", protocol.Method, "/ws/app/config.py"),
+			info("__init__", "Loader", protocol.Method, "/ws/app/config.py"),
+		},
+		"new": {
+			info("new", "impl Config", protocol.Function, "/ws/src/config.rs"),
+			info("new", "impl Loader", protocol.Function, "/ws/src/config.rs"),
+		},
+		"constructor": {
+			info("constructor", "Config", protocol.Constructor, "/ws/src/config.ts"),
+		},
+		"Config": {
+			info("Config", "Config", protocol.Constructor, "/ws/src/Config.java"),
+			info("Config", "", protocol.Class, "/ws/src/Config.java"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		typeSym  protocol.WorkspaceSymbolResult
+		expected []string
+	}{
+		{"go NewX in the same package", symbolAt("Config", "settings", protocol.Struct, "/ws/settings/config.go"), []string{"NewConfig /ws/settings/new.go"}},
+		{"python __init__", symbolAt("Config", "", protocol.Class, "/ws/app/config.py"), []string{"__init__ /ws/app/config.py"}},
+		{"rust ::new", symbolAt("Config", "", protocol.Struct, "/ws/src/config.rs"), []string{"new /ws/src/config.rs"}},
+		{"typescript constructor", symbolAt("Config", "", protocol.Class, "/ws/src/config.ts"), []string{"constructor /ws/src/config.ts"}},
+		{"java constructor named after the type", symbolAt("Config", "", protocol.Class, "/ws/src/Config.java"), []string{"Config /ws/src/Config.java"}},
+		{"functions have no constructors", symbolAt("Load", "", protocol.Function, "/ws/settings/config.go"), nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			constructors, err := resolver.Constructors(context.Background(), searcher, tc.typeSym)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, names(constructors))
+		})
+	}
+}
+
+func TestContainerIs(t *testing.T) {
+	assert.True(t, containerIs("Config", "Config"))
+	assert.True(t, containerIs("settings.Config", "Config"))
+	assert.True(t, containerIs("crate::Config", "Config"))
+	assert.True(t, containerIs("impl Config", "Config"))
+	assert.False(t, containerIs("MyConfig", "Config"))
+}
@@ -0,0 +1,255 @@
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// EntryPointKind describes how execution enters a workspace through a symbol
+type EntryPointKind string
+
+const (
+	EntryMain        EntryPointKind = "main function"
+	EntryCommand     EntryPointKind = "CLI command"
+	EntryHTTPHandler EntryPointKind = "HTTP handler"
+	EntryAPI         EntryPointKind = "exported API symbol"
+)
+
+// EntryPointKinds lists the kinds of entry points in the order they are reported
+var EntryPointKinds = []EntryPointKind{EntryMain, EntryCommand, EntryHTTPHandler, EntryAPI}
+
+// EntryPoint is a workspace symbol that probably is an entry point
+type EntryPoint struct {
+	Symbol protocol.WorkspaceSymbolResult
+	Kind   EntryPointKind
+}
+
+// EntryPoints finds the probable entry points of the workspace. The queries of
+// every language strategy are sent to the server and each result is classified
+// by the strategy of the file it is defined in. Symbols in test and vendored
+// files are left out. Results are ordered by kind, then path and line.
+func (r *Resolver) EntryPoints(ctx context.Context, client SymbolSearcher) ([]EntryPoint, error) {
+	var entryPoints []EntryPoint
+	seen := make(map[protocol.Location]bool)
+	for _, query := range entryPointQueries() {
+		symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch symbol: %w", err)
+		}
+		results, err := symbolResult.Results()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse results: %w", err)
+		}
+		for _, symbol := range results {
+			loc := symbol.GetLocation()
+			path := FilePath(loc.URI)
+			strategy := StrategyFor(path)
+			if seen[loc] || path == "" || strategy.IsTestFile(path) || strategy.IsVendored(path) {
+				continue
+			}
+			seen[loc] = true
+			if kind := strategy.EntryPoint(symbol); kind != "" {
+				entryPoints = append(entryPoints, EntryPoint{Symbol: symbol, Kind: kind})
+			}
+		}
+	}
+
+	order := make(map[EntryPointKind]int, len(EntryPointKinds))
+	for i, kind := range EntryPointKinds {
+		order[kind] = i
+	}
+	sort.SliceStable(entryPoints, func(i, j int) bool {
+		a, b := entryPoints[i], entryPoints[j]
+		if a.Kind != b.Kind {
+			return order[a.Kind] < order[b.Kind]
+		}
+		pathA, pathB := FilePath(a.Symbol.GetLocation().URI), FilePath(b.Symbol.GetLocation().URI)
+		if pathA != pathB {
+			return pathA < pathB
+		}
+		return a.Symbol.GetLocation().Range.Start.Line < b.Symbol.GetLocation().Range.Start.Line
+	})
+	return entryPoints, nil
+}
+
+// entryPointQueries collects the entry point queries of all strategies, as the
+// languages used in the workspace are not known up front
+func entryPointQueries() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var queries []string
+	add := func(strategy Strategy) {
+		for _, query := range strategy.EntryPointQueries() {
+			if !seen[query] {
+				seen[query] = true
+				queries = append(queries, query)
+			}
+		}
+	}
+	add(DefaultStrategy{})
+	for _, strategy := range strategies {
+		add(strategy)
+	}
+	sort.Strings(queries)
+	return queries
+}
+
+// EntryPointQueries looks for main functions, handlers and commands by name
+func (DefaultStrategy) EntryPointQueries() []string {
+	return []string{"main", "handle", "handler", "command", "cli"}
+}
+
+func (DefaultStrategy) EntryPoint(symbol protocol.WorkspaceSymbolResult) EntryPointKind {
+	kind, _ := kindAndContainer(symbol)
+	name := shortName(symbol)
+	switch {
+	case name == "main" && kind == protocol.Function:
+		return EntryMain
+	case isCallable(kind) && isHandlerName(name):
+		return EntryHTTPHandler
+	case isCommandName(name):
+		return EntryCommand
+	}
+	return ""
+}
+
+// EntryPointQueries adds http.Handler implementations, cobra and urfave/cli
+// command variables and NewX constructors, the usual surface of a Go library
+func (goStrategy) EntryPointQueries() []string {
+	return []string{"main", "ServeHTTP", "Handle", "Handler", "Cmd", "Command", "New"}
+}
+
+func (goStrategy) EntryPoint(symbol protocol.WorkspaceSymbolResult) EntryPointKind {
+	kind, container := kindAndContainer(symbol)
+	name := shortName(symbol)
+	path := FilePath(symbol.GetLocation().URI)
+	switch {
+	case name == "main" && kind == protocol.Function:
+		return EntryMain
+	case kind == protocol.Method && name == "ServeHTTP", isCallable(kind) && isHandlerName(name):
+		return EntryHTTPHandler
+	case kind == protocol.Variable && (strings.HasSuffix(name, "Cmd") || strings.HasSuffix(name, "Command")):
+		return EntryCommand
+	case isExported(name) && (kind == protocol.Function || IsType(symbol) || kind == protocol.Interface) &&
+		container != "main" && !inDirectory(path, "internal", "cmd"):
+		return EntryAPI
+	}
+	return ""
+}
+
+// EntryPointQueries adds web framework views and routes and click or argparse commands
+func (pythonStrategy) EntryPointQueries() []string {
+	return []string{"main", "handler", "view", "route", "cli", "command"}
+}
+
+func (pythonStrategy) EntryPoint(symbol protocol.WorkspaceSymbolResult) EntryPointKind {
+	kind, _ := kindAndContainer(symbol)
+	name := shortName(symbol)
+	path := FilePath(symbol.GetLocation().URI)
+	base := filepath.Base(path)
+	switch {
+	case name == "main" && kind == protocol.Function:
+		return EntryMain
+	case isCallable(kind) && (isHandlerName(name) || base == "views.py" || base == "routes.py" || inDirectory(path, "views", "routes")):
+		return EntryHTTPHandler
+	case isCommandName(name) || (kind == protocol.Function && (base == "cli.py" || inDirectory(path, "commands"))):
+		return EntryCommand
+	case base == "__init__.py" && !strings.HasPrefix(name, "_") && (kind == protocol.Function || kind == protocol.Class):
+		return EntryAPI
+	}
+	return ""
+}
+
+// EntryPointQueries adds route handlers and commander or yargs commands
+func (javaScriptStrategy) EntryPointQueries() []string {
+	return []string{"main", "handler", "route", "router", "command", "program"}
+}
+
+func (javaScriptStrategy) EntryPoint(symbol protocol.WorkspaceSymbolResult) EntryPointKind {
+	kind, _ := kindAndContainer(symbol)
+	name := shortName(symbol)
+	path := FilePath(symbol.GetLocation().URI)
+	switch {
+	case name == "main" && kind == protocol.Function:
+		return EntryMain
+	case isCallable(kind) && (isHandlerName(name) || inDirectory(path, "routes", "handlers", "api")):
+		return EntryHTTPHandler
+	case isCommandName(name) || name == "program" || (kind == protocol.Function && inDirectory(path, "commands", "bin")):
+		return EntryCommand
+	case strings.HasPrefix(filepath.Base(path), "index.") && !strings.HasPrefix(name, "_") &&
+		(kind == protocol.Function || kind == protocol.Class):
+		return EntryAPI
+	}
+	return ""
+}
+
+// EntryPointQueries adds clap argument types and request handlers
+func (rustStrategy) EntryPointQueries() []string {
+	return []string{"main", "handler", "Cli", "Args", "Command", "Commands"}
+}
+
+func (rustStrategy) EntryPoint(symbol protocol.WorkspaceSymbolResult) EntryPointKind {
+	kind, _ := kindAndContainer(symbol)
+	name := shortName(symbol)
+	path := FilePath(symbol.GetLocation().URI)
+	switch {
+	case name == "main" && kind == protocol.Function:
+		return EntryMain
+	case isCallable(kind) && isHandlerName(name):
+		return EntryHTTPHandler
+	case (IsType(symbol) || kind == protocol.Enum) && (name == "Cli" || name == "Args" || isCommandName(name)):
+		return EntryCommand
+	case filepath.Base(path) == "lib.rs" && (kind == protocol.Function || IsType(symbol) || kind == protocol.Enum || kind == protocol.Interface):
+		return EntryAPI
+	}
+	return ""
+}
+
+// shortName returns the last component of a symbol name, e.g. "ServeHTTP" for
+// "server.Server.ServeHTTP"
+func shortName(symbol protocol.WorkspaceSymbolResult) string {
+	_, name, _ := SplitQualified(DefaultStrategy{}.Normalize(symbol.GetName()))
+	return name
+}
+
+// isCallable reports whether symbols of a kind can be called
+func isCallable(kind protocol.SymbolKind) bool {
+	return kind == protocol.Function || kind == protocol.Method
+}
+
+// isHandlerName reports whether a name follows request handler naming, e.g.
+// handleLogin, HandleLogin, loginHandler or login_handler
+func isHandlerName(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "handle") && len(name) > len("handle") {
+		next := rune(name[len("handle")])
+		if unicode.IsUpper(next) || next == '_' {
+			return true
+		}
+	}
+	return strings.HasSuffix(lower, "handler") && lower != "handler"
+}
+
+// isCommandName reports whether a name follows command naming, e.g. rootCmd,
+// ServeCommand or deploy_command
+func isCommandName(name string) bool {
+	lower := strings.ToLower(name)
+	return lower == "cli" || (strings.HasSuffix(lower, "command") && lower != "command") ||
+		(strings.HasSuffix(name, "Cmd") && name != "Cmd")
+}
+
+// isExported reports whether a Go name is exported
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryPoints(t *testing.T) {
+	resolver := New(settings.Default().SymbolMatch)
+	searcher := querySearcher{
+		"main": {
+			info("main", "main", protocol.Function, "/ws/cmd/server/main.go"),
+			info("main", "", protocol.Function, "/ws/tools/gen.py"),
+			info("mainLoop", "worker", protocol.Function, "/ws/worker/loop.go"),
+		},
+		"ServeHTTP": {
+			info("Server.ServeHTTP", "api", protocol.Method, "/ws/internal/api/server.go"),
+			info("fakeHandler.ServeHTTP", "api", protocol.Method, "/ws/internal/api/server_test.go"),
+		},
+		"Cmd": {
+			info("rootCmd", "main", protocol.Variable, "/ws/cmd/server/root.go"),
+			info("Cmd", "exec", protocol.Struct, "/ws/vendor/os/exec/exec.go"),
+		},
+		"New": {
+			info("NewClient", "client", protocol.Function, "/ws/client/client.go"),
+			info("newRouter", "api", protocol.Function, "/ws/internal/api/router.go"),
+			info("NewServer", "api", protocol.Function, "/ws/internal/api/new.go"),
+		},
+		"handler": {
+			info("login_handler", "", protocol.Function, "/ws/app/auth.py"),
+			info("index", "", protocol.Function, "/ws/app/views.py"),
+		},
+		"route": {
+			info("index", "", protocol.Function, "/ws/app/views.py"),
+			info("listUsers", "", protocol.Function, "/web/src/routes/users.ts"),
+		},
+		"Cli": {
+			info("Cli", "", protocol.Struct, "/rs/src/main.rs"),
+			info("parse_config", "", protocol.Function, "/rs/src/lib.rs"),
+		},
+	}
+
+	entryPoints, err := resolver.EntryPoints(context.Background(), searcher)
+	assert.NoError(t, err)
+
+	var got []string
+	for _, entryPoint := range entryPoints {
+		got = append(got, string(entryPoint.Kind)+": "+entryPoint.Symbol.GetName()+" "+FilePath(entryPoint.Symbol.GetLocation().URI))
+	}
+	assert.Equal(t, []string{
+		"main function: main /ws/cmd/server/main.go",
+		"main function: main /ws/tools/gen.py",
+		"CLI command: Cli /rs/src/main.rs",
+		"CLI command: rootCmd /ws/cmd/server/root.go",
+		"HTTP handler: listUsers /web/src/routes/users.ts",
+		"HTTP handler: login_handler /ws/app/auth.py",
+		"HTTP handler: index /ws/app/views.py",
+		"HTTP handler: Server.ServeHTTP /ws/internal/api/server.go",
+		"exported API symbol: parse_config /rs/src/lib.rs",
+		"exported API symbol: NewClient /ws/client/client.go",
+	}, got)
+}
+
+func TestHandlerAndCommandNames(t *testing.T) {
+	for _, name := range []string{"handleLogin", "HandleLogin", "handle_login", "loginHandler", "login_handler"} {
+		assert.True(t, isHandlerName(name), name)
+	}
+	for _, name := range []string{"Handler", "handler", "manageUsers"} {
+		assert.False(t, isHandlerName(name), name)
+	}
+
+	for _, name := range []string{"rootCmd", "ServeCommand", "deploy_command", "cli"} {
+		assert.True(t, isCommandName(name), name)
+	}
+	for _, name := range []string{"Cmd", "command", "cmdline"} {
+		assert.False(t, isCommandName(name), name)
+	}
+}
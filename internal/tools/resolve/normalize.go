@@ -0,0 +1,98 @@
+package resolve
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+)
+
+// maxRewrites bounds how often a rule is applied to one name, for patterns
+// whose replacement keeps matching
+const maxRewrites = 10
+
+// rule is a compiled settings.NormalizeRule
+type rule struct {
+	pattern   *regexp.Regexp
+	replace   string
+	languages map[protocol.LanguageKind]bool
+}
+
+// ValidateRules checks the normalize rules of the settings
+func ValidateRules(rules []settings.NormalizeRule) error {
+	for i, r := range rules {
+		if r.Pattern == "" {
+			return fmt.Errorf("symbolMatch.normalize[%d] has no pattern", i)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("symbolMatch.normalize[%d] has an invalid pattern: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// compileRules compiles normalize rules, skipping invalid ones. Settings are
+// checked with ValidateRules before a Resolver is created.
+func compileRules(rules []settings.NormalizeRule) []rule {
+	var compiled []rule
+	for _, r := range rules {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil || r.Pattern == "" {
+			continue
+		}
+		c := rule{pattern: pattern, replace: r.Replace}
+		if len(r.Languages) > 0 {
+			c.languages = make(map[protocol.LanguageKind]bool)
+			for _, lang := range r.Languages {
+				c.languages[protocol.LanguageKind(lang)] = true
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled
+}
+
+// normalize rewrites a name with the rules that apply to a language. An empty
+// language applies only the rules for every language; a name the rules rewrite
+// to nothing is kept as it was.
+func normalize(rules []rule, name string, lang protocol.LanguageKind) string {
+	normalized := name
+	for _, r := range rules {
+		if r.languages != nil && !r.languages[lang] {
+			continue
+		}
+		for i := 0; i < maxRewrites; i++ {
+			next := r.pattern.ReplaceAllString(normalized, r.replace)
+			if next == normalized {
+				break
+			}
+			normalized = next
+		}
+	}
+	if normalized == "" {
+		return name
+	}
+	return normalized
+}
+
+// queries returns the distinct forms of a query to send to the language
+// server: the query normalized for every language, and for each language with
+// rules of its own
+func queries(rules []rule, query string) []string {
+	base := normalize(rules, query, "")
+	seen := map[string]bool{base: true}
+	var others []string
+	for _, r := range rules {
+		for lang := range r.languages {
+			form := normalize(rules, query, lang)
+			if !seen[form] {
+				seen[form] = true
+				others = append(others, form)
+			}
+		}
+	}
+	sort.Strings(others)
+	return append([]string{base}, others...)
+}
@@ -0,0 +1,76 @@
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSearcher answers workspace symbol queries from fixed results, recording
+// the queries it was sent
+type fakeSearcher struct {
+	results map[string][]protocol.SymbolInformation
+	queries []string
+}
+
+func (f *fakeSearcher) Symbol(ctx context.Context, params protocol.WorkspaceSymbolParams) (protocol.Or_Result_workspace_symbol, error) {
+	f.queries = append(f.queries, params.Query)
+	return protocol.Or_Result_workspace_symbol{Value: f.results[params.Query]}, nil
+}
+
+func TestNormalize(t *testing.T) {
+	r := New(settings.Default().SymbolMatch)
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"Foo<T>", "", "Foo"},
+		{"Map<K, List<V>>.get", "/ws/Map.java", "Map.get"},
+		{"Set[T].Add", "/ws/set.go", "Set.Add"},
+		{"Set[T].Add", "", "Set[T].Add"},
+		{"Shape.area(self)", "/ws/shape.py", "Shape.area"},
+		{"run()", "", "run"},
+		{"List#add", "", "List.add"},
+		{"pkg::Type::method", "/ws/src/lib.rs", "pkg::Type::method"},
+		{"()", "", "()"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, r.Normalize(tt.name, tt.path))
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	assert.NoError(t, ValidateRules(settings.Default().SymbolMatch.Normalize))
+	assert.EqualError(t, ValidateRules([]settings.NormalizeRule{{Pattern: "("}}),
+		"symbolMatch.normalize[0] has an invalid pattern: error parsing regexp: missing closing ): `(`")
+	assert.EqualError(t, ValidateRules([]settings.NormalizeRule{{Replace: "."}}), "symbolMatch.normalize[0] has no pattern")
+}
+
+func TestLookupNormalizesQuery(t *testing.T) {
+	goSet := protocol.SymbolInformation{Name: "Set.Add", Kind: protocol.Method, Location: protocol.Location{URI: "file:///ws/set.go"}}
+	pySet := protocol.SymbolInformation{Name: "add", Kind: protocol.Method, ContainerName: "Set", Location: protocol.Location{URI: "file:///ws/set.py"}}
+	searcher := &fakeSearcher{results: map[string][]protocol.SymbolInformation{
+		"Set[T].add": {pySet},
+		"Set.Add":    {goSet},
+	}}
+
+	weights := settings.Default().SymbolMatch
+	weights.Normalize = append(weights.Normalize, settings.NormalizeRule{Pattern: `\.add$`, Replace: ".Add", Languages: []string{"go"}})
+	matches, err := New(weights).Lookup(context.Background(), searcher, "Set<T>[T].add()")
+	require.NoError(t, err)
+
+	// One query for every language, one with the go and python rules
+	assert.Equal(t, []string{"Set[T].add", "Set.Add", "Set.add"}, searcher.queries)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "Set.Add", matches[0].Symbol.GetName())
+	assert.Equal(t, weights.Exact, matches[0].Score)
+	assert.Equal(t, "add", matches[1].Symbol.GetName())
+	assert.Equal(t, weights.SamePackage, matches[1].Score)
+}
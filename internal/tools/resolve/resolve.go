@@ -0,0 +1,317 @@
+// Package resolve turns the symbol names tools are called with into workspace
+// symbols. Every tool that takes a symbol name goes through a Resolver so that
+// matching behaves the same everywhere.
+//
+// A Resolver asks the language server for workspace symbols matching a query,
+// scores each result (see Score) and returns the results reaching the minimum
+// score, best first. Language specific naming and file layout conventions are
+// handled by a Strategy chosen from the file a symbol is defined in.
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+)
+
+// SymbolSearcher is the part of the LSP client needed to look up workspace symbols
+type SymbolSearcher interface {
+	Symbol(ctx context.Context, params protocol.WorkspaceSymbolParams) (protocol.Or_Result_workspace_symbol, error)
+}
+
+// Match is a workspace symbol with its score against a query
+type Match struct {
+	Symbol protocol.WorkspaceSymbolResult
+	Score  int
+
+	// Tier is the weight of the tier the symbol matched, before penalties
+	Tier int
+}
+
+// Resolver resolves symbol names using configurable weights and normalize
+// rules
+type Resolver struct {
+	weights settings.SymbolMatchSettings
+	rules   []rule
+	mu      sync.RWMutex
+}
+
+// New creates a resolver with the given weights
+func New(weights settings.SymbolMatchSettings) *Resolver {
+	return &Resolver{weights: weights, rules: compileRules(weights.Normalize)}
+}
+
+// Weights returns the weights used by the resolver
+func (r *Resolver) Weights() settings.SymbolMatchSettings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.weights
+}
+
+// SetWeights replaces the weights used by the resolver
+func (r *Resolver) SetWeights(weights settings.SymbolMatchSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.weights = weights
+	r.rules = compileRules(weights.Normalize)
+}
+
+// Normalize rewrites a symbol name with the normalize rules for the language
+// of a file. An empty path applies the rules for every language.
+func (r *Resolver) Normalize(name, path string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return normalize(r.rules, name, languageOf(path))
+}
+
+func languageOf(path string) protocol.LanguageKind {
+	if path == "" {
+		return ""
+	}
+	return lsp.DetectLanguageID(path)
+}
+
+// Lookup queries the language server for workspace symbols matching query,
+// rewritten by the normalize rules, and ranks them against it. Rules for
+// particular languages can give other forms of the query, each of which is
+// looked up.
+func (r *Resolver) Lookup(ctx context.Context, client SymbolSearcher, query string) ([]Match, error) {
+	r.mu.RLock()
+	forms := queries(r.rules, query)
+	r.mu.RUnlock()
+
+	var results []protocol.WorkspaceSymbolResult
+	seen := make(map[string]bool)
+	for _, form := range forms {
+		symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
+			Query: form,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch symbol: %w", err)
+		}
+
+		formResults, err := symbolResult.Results()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse results: %w", err)
+		}
+		for _, symbol := range formResults {
+			loc := symbol.GetLocation()
+			key := fmt.Sprintf("%s\x00%s:%d:%d", symbol.GetName(), loc.URI, loc.Range.Start.Line, loc.Range.Start.Character)
+			if !seen[key] {
+				seen[key] = true
+				results = append(results, symbol)
+			}
+		}
+	}
+
+	return r.Rank(results, query), nil
+}
+
+// IsType reports whether a symbol is a type whose instances are constructed,
+// such as a class or struct
+func IsType(symbol protocol.WorkspaceSymbolResult) bool {
+	kind, _ := kindAndContainer(symbol)
+	return kind == protocol.Class || kind == protocol.Struct
+}
+
+// Constructors finds the constructors of a type symbol using the conventions of
+// the language it is defined in, e.g. NewConfig for a Go struct Config or
+// __init__ for a Python class. Symbols that are not types have none.
+func (r *Resolver) Constructors(ctx context.Context, client SymbolSearcher, typeSymbol protocol.WorkspaceSymbolResult) ([]protocol.WorkspaceSymbolResult, error) {
+	if !IsType(typeSymbol) {
+		return nil, nil
+	}
+
+	strategy := StrategyFor(FilePath(typeSymbol.GetLocation().URI))
+	var constructors []protocol.WorkspaceSymbolResult
+	seen := make(map[protocol.Location]bool)
+	for _, query := range strategy.ConstructorQueries(typeSymbol.GetName()) {
+		symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch symbol: %w", err)
+		}
+		results, err := symbolResult.Results()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse results: %w", err)
+		}
+		for _, candidate := range results {
+			loc := candidate.GetLocation()
+			if seen[loc] || !strategy.IsConstructor(candidate, typeSymbol) {
+				continue
+			}
+			seen[loc] = true
+			constructors = append(constructors, candidate)
+		}
+	}
+	return constructors, nil
+}
+
+// Rank scores workspace symbols against a query, normalized for the language
+// of each symbol, and returns those reaching the minimum score, best first. Ties prefer symbols closer to the workspace root
+// and otherwise keep the order the server returned.
+func (r *Resolver) Rank(results []protocol.WorkspaceSymbolResult, query string) []Match {
+	weights := r.Weights()
+
+	var matches []Match
+	for _, symbol := range results {
+		tier, score := scoreTier(symbol, r.Normalize(query, FilePath(symbol.GetLocation().URI)), weights)
+		if score <= 0 || score < weights.MinScore {
+			continue
+		}
+		matches = append(matches, Match{Symbol: symbol, Score: score, Tier: tier})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return pathDepth(matches[i].Symbol) < pathDepth(matches[j].Symbol)
+	})
+	return matches
+}
+
+// TopTier returns the matches of the best tier among ranked matches, so that
+// an exact match hides symbols that only share a prefix or the last component
+// of a qualified name. Penalties for test and vendored files do not count, so
+// exact matches in tests are kept next to the code they test.
+func TopTier(matches []Match) []Match {
+	best := 0
+	for _, match := range matches {
+		best = max(best, match.Tier)
+	}
+	var top []Match
+	for _, match := range matches {
+		if match.Tier == best {
+			top = append(top, match)
+		}
+	}
+	return top
+}
+
+// Score scores a single workspace symbol against a query. Each symbol gets the
+// weight of the best tier it matches, from exact names down to fuzzy matches,
+// minus penalties for test and vendored files. Zero means no match at all.
+func Score(symbol protocol.WorkspaceSymbolResult, query string, weights settings.SymbolMatchSettings) int {
+	_, score := scoreTier(symbol, query, weights)
+	return score
+}
+
+// scoreTier returns the weight of the tier a symbol matches and its score
+func scoreTier(symbol protocol.WorkspaceSymbolResult, query string, weights settings.SymbolMatchSettings) (int, int) {
+	container := ""
+	if v, ok := symbol.(*protocol.SymbolInformation); ok {
+		container = v.ContainerName
+	}
+	path := FilePath(symbol.GetLocation().URI)
+	strategy := StrategyFor(path)
+
+	tier := matchTier(strategy, symbol.GetName(), container, path, query, weights)
+	if tier == 0 {
+		return 0, 0
+	}
+	score := tier
+	if strategy.IsTestFile(path) {
+		score -= weights.TestPenalty
+	}
+	if strategy.IsVendored(path) {
+		score -= weights.VendorPenalty
+	}
+	return tier, score
+}
+
+// matchTier returns the weight of the best tier a symbol name matches
+func matchTier(strategy Strategy, name, container, path, query string, weights settings.SymbolMatchSettings) int {
+	name = strategy.Normalize(name)
+	query = strategy.Normalize(query)
+	if name == "" || query == "" {
+		return 0
+	}
+	if name == query {
+		return weights.Exact
+	}
+
+	qualifier, last, qualified := SplitQualified(query)
+	if qualified {
+		// pkg.Type.Method for Type.Method
+		if strings.HasSuffix(name, "."+query) {
+			return weights.SamePackage
+		}
+		// Languages that do not qualify symbol names report Method for
+		// Type.Method. A container that differs from the qualifier is a
+		// different symbol, which scores below MinScore by default.
+		if name == last {
+			if qualifierMatches(qualifier, strategy.Normalize(container), path) {
+				return weights.SamePackage
+			}
+			return weights.Qualified
+		}
+	} else if strings.HasSuffix(name, "."+query) {
+		// Type.Method or module::function for Method
+		return weights.SamePackage
+	}
+
+	if strings.HasPrefix(name, query) || (qualified && strings.HasPrefix(name, last)) {
+		return weights.Prefix
+	}
+	if isSubsequence(strings.ToLower(last), strings.ToLower(name)) {
+		return weights.Fuzzy
+	}
+	return 0
+}
+
+// SplitQualified splits a normalized name like "pkg.Type.Method" into "pkg.Type"
+// and "Method". Unqualified names are returned as the last component.
+func SplitQualified(name string) (string, string, bool) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		return "", name, false
+	}
+	return name[:i], name[i+1:], true
+}
+
+// qualifierMatches reports whether the qualifier of a query names the container
+// of a symbol or the package directory it is defined in
+func qualifierMatches(qualifier, container, path string) bool {
+	if container != "" && (container == qualifier || strings.HasSuffix(container, "."+qualifier) || strings.HasSuffix(qualifier, "."+container)) {
+		return true
+	}
+	_, lastQualifier, _ := SplitQualified(qualifier)
+	return path != "" && filepath.Base(filepath.Dir(path)) == lastQualifier
+}
+
+// isSubsequence reports whether the characters of needle appear in order in haystack
+func isSubsequence(needle, haystack string) bool {
+	if needle == "" {
+		return false
+	}
+	rest := []rune(needle)
+	for _, r := range haystack {
+		if r == rest[0] {
+			rest = rest[1:]
+			if len(rest) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pathDepth is the number of directories above the file defining a symbol
+func pathDepth(symbol protocol.WorkspaceSymbolResult) int {
+	return strings.Count(filepath.ToSlash(FilePath(symbol.GetLocation().URI)), "/")
+}
+
+// FilePath returns the path of a file URI, or an empty string for other schemes
+func FilePath(uri protocol.DocumentUri) string {
+	if !strings.HasPrefix(string(uri), "file://") {
+		return ""
+	}
+	return protocol.PathFromURI(string(uri))
+}
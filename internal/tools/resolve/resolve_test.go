@@ -0,0 +1,139 @@
+package resolve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func symbolAt(name, container string, kind protocol.SymbolKind, path string) protocol.WorkspaceSymbolResult {
+	return &protocol.SymbolInformation{
+		Name:          name,
+		Kind:          kind,
+		ContainerName: container,
+		Location:      protocol.Location{URI: protocol.DocumentUri("file://" + path)},
+	}
+}
+
+func TestScore(t *testing.T) {
+	weights := settings.Default().SymbolMatch
+
+	tests := []struct {
+		name     string
+		symbol   protocol.WorkspaceSymbolResult
+		query    string
+		expected int
+	}{
+		{"exact", symbolAt("FooBar", "", protocol.Function, "/ws/main.go"), "FooBar", weights.Exact},
+		{"rust path separator", symbolAt("Shape::area", "", protocol.Method, "/ws/src/lib.rs"), "Shape.area", weights.Exact},
+		{"method of type for unqualified query", symbolAt("Shape.Area", "", protocol.Method, "/ws/shape.go"), "Area", weights.SamePackage},
+		{"function of module for unqualified query", symbolAt("shapes::area", "", protocol.Function, "/ws/src/lib.rs"), "area", weights.SamePackage},
+		{"qualified query with matching container", symbolAt("area", "Shape", protocol.Method, "/ws/shape.py"), "Shape.area", weights.SamePackage},
+		{"qualified query with matching package directory", symbolAt("Run", "", protocol.Function, "/ws/server/run.go"), "server.Run", weights.SamePackage},
+		{"qualified query in unrelated container", symbolAt("area", "Circle", protocol.Method, "/ws/shape.py"), "Shape.area", weights.Qualified},
+		{"prefix", symbolAt("FooBarBaz", "", protocol.Function, "/ws/main.go"), "FooBar", weights.Prefix},
+		{"fuzzy", symbolAt("FormatOutputBuffer", "", protocol.Function, "/ws/main.go"), "fob", weights.Fuzzy},
+		{"no match", symbolAt("Other", "", protocol.Function, "/ws/main.go"), "FooBar", 0},
+		{"test file penalty", symbolAt("FooBar", "", protocol.Function, "/ws/main_test.go"), "FooBar", weights.Exact - weights.TestPenalty},
+		{"vendored penalty", symbolAt("FooBar", "", protocol.Function, "/ws/vendor/lib/foo.go"), "FooBar", weights.Exact - weights.VendorPenalty},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Score(tc.symbol, tc.query, weights))
+		})
+	}
+}
+
+func TestRankSymbols(t *testing.T) {
+	weights := settings.Default().SymbolMatch
+
+	vendored := symbolAt("Parse", "", protocol.Function, "/ws/vendor/x/parse.go")
+	inTest := symbolAt("Parse", "", protocol.Function, "/ws/parse_test.go")
+	nested := symbolAt("Parse", "", protocol.Function, "/ws/internal/a/b/parse.go")
+	shallow := symbolAt("Parse", "", protocol.Function, "/ws/parse.go")
+	prefixOnly := symbolAt("ParseAll", "", protocol.Function, "/ws/parse.go")
+
+	ranked := New(weights).Rank([]protocol.WorkspaceSymbolResult{vendored, inTest, prefixOnly, nested, shallow}, "Parse")
+
+	var order []protocol.WorkspaceSymbolResult
+	for _, match := range ranked {
+		order = append(order, match.Symbol)
+	}
+	// Prefix matches fall below the minimum score
+	assert.Equal(t, []protocol.WorkspaceSymbolResult{shallow, nested, inTest, vendored}, order)
+
+	// Lowering the minimum score admits weaker matches
+	weights.MinScore = 1
+	assert.Len(t, New(weights).Rank([]protocol.WorkspaceSymbolResult{prefixOnly}, "Parse"), 1)
+}
+
+func TestRankQualifierMismatch(t *testing.T) {
+	weights := settings.Default().SymbolMatch
+	method := symbolAt("Method", "Type", protocol.Method, "/ws/type.py")
+	other := symbolAt("Method", "Other", protocol.Method, "/ws/other.py")
+
+	ranked := New(weights).Rank([]protocol.WorkspaceSymbolResult{other, method}, "Type.Method")
+	assert.Len(t, ranked, 1)
+	assert.Equal(t, method, ranked[0].Symbol)
+
+	// Other.Method does not match Type.Method even when it is the only candidate
+	assert.Empty(t, New(weights).Rank([]protocol.WorkspaceSymbolResult{other}, "Type.Method"))
+}
+
+func TestTopTier(t *testing.T) {
+	weights := settings.Default().SymbolMatch
+	weights.MinScore = 1
+
+	exact := symbolAt("Parse", "", protocol.Function, "/ws/parse.go")
+	inTest := symbolAt("Parse", "", protocol.Function, "/ws/parse_test.go")
+	prefixOnly := symbolAt("ParseAll", "", protocol.Function, "/ws/parse.go")
+	fuzzy := symbolAt("PrepareAllRowsSafely", "", protocol.Function, "/ws/rows.go")
+
+	resolver := New(weights)
+	ranked := resolver.Rank([]protocol.WorkspaceSymbolResult{prefixOnly, fuzzy, inTest, exact}, "Parse")
+	assert.Len(t, ranked, 4)
+
+	// The test file penalty does not move an exact match out of the best tier
+	var top []protocol.WorkspaceSymbolResult
+	for _, match := range TopTier(ranked) {
+		top = append(top, match.Symbol)
+	}
+	assert.Equal(t, []protocol.WorkspaceSymbolResult{exact, inTest}, top)
+
+	// Without exact matches the prefix matches are the best tier
+	ranked = resolver.Rank([]protocol.WorkspaceSymbolResult{fuzzy, prefixOnly}, "Parse")
+	assert.Len(t, TopTier(ranked), 1)
+	assert.Equal(t, prefixOnly, TopTier(ranked)[0].Symbol)
+	assert.Empty(t, TopTier(nil))
+}
+
+func TestStrategyFor(t *testing.T) {
+	assert.True(t, StrategyFor("/ws/pkg/parse_test.go").IsTestFile("/ws/pkg/parse_test.go"))
+	assert.False(t, StrategyFor("/ws/tests/parse.go").IsTestFile("/ws/tests/parse.go"))
+	assert.True(t, StrategyFor("/ws/app/test_parse.py").IsTestFile("/ws/app/test_parse.py"))
+	assert.True(t, StrategyFor("/ws/src/parse.spec.ts").IsTestFile("/ws/src/parse.spec.ts"))
+	assert.True(t, StrategyFor("/ws/tests/integration.rs").IsTestFile("/ws/tests/integration.rs"))
+	assert.True(t, StrategyFor("/ws/.venv/lib/site-packages/x.py").IsVendored("/ws/.venv/lib/site-packages/x.py"))
+
+	// Unknown languages combine the conventions of all languages
+	assert.Equal(t, DefaultStrategy{}, StrategyFor("/ws/notes.txt"))
+	assert.True(t, StrategyFor("/ws/tests/notes.txt").IsTestFile("/ws/tests/notes.txt"))
+}
+
+type upperStrategy struct{ DefaultStrategy }
+
+func (upperStrategy) Normalize(name string) string {
+	return strings.ToUpper(DefaultStrategy{}.Normalize(name))
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	RegisterStrategy(protocol.LangLua, upperStrategy{})
+	defer RegisterStrategy(protocol.LangLua, DefaultStrategy{})
+
+	symbol := symbolAt("compute-total", "", protocol.Function, "/ws/prog.lua")
+	assert.Equal(t, settings.Default().SymbolMatch.Exact, Score(symbol, "COMPUTE-TOTAL", settings.Default().SymbolMatch))
+}
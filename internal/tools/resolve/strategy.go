@@ -0,0 +1,253 @@
+package resolve
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Strategy adapts symbol resolution to the conventions of a language
+type Strategy interface {
+	// Normalize rewrites a symbol name or query so that qualified names use "."
+	// as the separator, e.g. "Shape::area" becomes "Shape.area"
+	Normalize(name string) string
+
+	// IsTestFile reports whether a file holds tests
+	IsTestFile(path string) bool
+
+	// IsVendored reports whether a file belongs to vendored or third party code
+	IsVendored(path string) bool
+
+	// ConstructorQueries returns the workspace symbol queries that find the
+	// constructors of a type, e.g. "NewConfig" for a Go type named Config
+	ConstructorQueries(typeName string) []string
+
+	// IsConstructor reports whether candidate constructs the type typeSymbol
+	IsConstructor(candidate, typeSymbol protocol.WorkspaceSymbolResult) bool
+
+	// EntryPointQueries returns the workspace symbol queries that find probable
+	// entry points, e.g. "main" and "ServeHTTP" for Go
+	EntryPointQueries() []string
+
+	// EntryPoint classifies a symbol as a kind of entry point, or returns ""
+	// when it does not look like one
+	EntryPoint(symbol protocol.WorkspaceSymbolResult) EntryPointKind
+
+	// IsExported reports whether a function or method can be called from
+	// outside its package or module, given its name, kind and the line of
+	// source it is declared on
+	IsExported(name string, kind protocol.SymbolKind, declaration string) bool
+}
+
+var (
+	strategies   = make(map[protocol.LanguageKind]Strategy)
+	strategiesMu sync.RWMutex
+)
+
+func init() {
+	RegisterStrategy(protocol.LangGo, goStrategy{})
+	RegisterStrategy(protocol.LangPython, pythonStrategy{})
+	RegisterStrategy(protocol.LangRust, rustStrategy{})
+	for _, lang := range []protocol.LanguageKind{
+		protocol.LangJavaScript,
+		protocol.LangJavaScriptReact,
+		protocol.LangTypeScript,
+		protocol.LangTypeScriptReact,
+	} {
+		RegisterStrategy(lang, javaScriptStrategy{})
+	}
+}
+
+// RegisterStrategy sets the strategy used for files of a language
+func RegisterStrategy(lang protocol.LanguageKind, strategy Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[lang] = strategy
+}
+
+// StrategyFor returns the strategy for the language of a file, falling back to
+// a generic strategy that combines the conventions of all languages
+func StrategyFor(path string) Strategy {
+	if path == "" {
+		return DefaultStrategy{}
+	}
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	if strategy, ok := strategies[lsp.DetectLanguageID(path)]; ok {
+		return strategy
+	}
+	return DefaultStrategy{}
+}
+
+// DefaultStrategy handles "::" separators and recognizes test and vendored
+// files using the layouts of all supported languages
+type DefaultStrategy struct{}
+
+func (DefaultStrategy) Normalize(name string) string {
+	return strings.ReplaceAll(strings.TrimSpace(name), "::", ".")
+}
+
+func (DefaultStrategy) IsTestFile(path string) bool {
+	return goStrategy{}.IsTestFile(path) ||
+		pythonStrategy{}.IsTestFile(path) ||
+		javaScriptStrategy{}.IsTestFile(path) ||
+		inDirectory(path, "test", "tests")
+}
+
+func (DefaultStrategy) IsVendored(path string) bool {
+	return inDirectory(path, "vendor", "node_modules", "third_party", "site-packages", ".cargo") ||
+		strings.Contains(filepath.ToSlash(path), "/pkg/mod/")
+}
+
+// ConstructorQueries looks for symbols named after the type, as constructors
+// are in Java, C# and C++
+func (DefaultStrategy) ConstructorQueries(typeName string) []string {
+	return []string{typeName}
+}
+
+func (DefaultStrategy) IsConstructor(candidate, typeSymbol protocol.WorkspaceSymbolResult) bool {
+	kind, container := kindAndContainer(candidate)
+	return kind == protocol.Constructor &&
+		(candidate.GetName() == typeSymbol.GetName() || containerIs(container, typeSymbol.GetName()))
+}
+
+type goStrategy struct{ DefaultStrategy }
+
+// ConstructorQueries looks for the NewX convention
+func (goStrategy) ConstructorQueries(typeName string) []string {
+	return []string{"New" + typeName}
+}
+
+// IsConstructor accepts NewX and newX functions in the package of the type
+func (goStrategy) IsConstructor(candidate, typeSymbol protocol.WorkspaceSymbolResult) bool {
+	typeName := typeSymbol.GetName()
+	name := candidate.GetName()
+	if name != "New"+typeName && name != "new"+typeName {
+		return false
+	}
+	return sameDirectory(candidate, typeSymbol)
+}
+
+func (goStrategy) IsTestFile(path string) bool {
+	return strings.HasSuffix(filepath.Base(path), "_test.go")
+}
+
+func (goStrategy) IsVendored(path string) bool {
+	return inDirectory(path, "vendor", "third_party") || strings.Contains(filepath.ToSlash(path), "/pkg/mod/")
+}
+
+type pythonStrategy struct{ DefaultStrategy }
+
+func (pythonStrategy) IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	return (strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py")) ||
+		strings.HasSuffix(base, "_test.py") ||
+		base == "conftest.py" ||
+		inDirectory(path, "tests")
+}
+
+func (pythonStrategy) IsVendored(path string) bool {
+	return inDirectory(path, "site-packages", "dist-packages", ".venv", "venv", "third_party")
+}
+
+// ConstructorQueries looks for __init__ methods
+func (pythonStrategy) ConstructorQueries(typeName string) []string {
+	return []string{"__init__"}
+}
+
+func (pythonStrategy) IsConstructor(candidate, typeSymbol protocol.WorkspaceSymbolResult) bool {
+	_, container := kindAndContainer(candidate)
+	return candidate.GetName() == "__init__" && containerIs(container, typeSymbol.GetName()) && sameFile(candidate, typeSymbol)
+}
+
+type javaScriptStrategy struct{ DefaultStrategy }
+
+// ConstructorQueries looks for constructor methods of classes
+func (javaScriptStrategy) ConstructorQueries(typeName string) []string {
+	return []string{"constructor"}
+}
+
+func (javaScriptStrategy) IsConstructor(candidate, typeSymbol protocol.WorkspaceSymbolResult) bool {
+	_, container := kindAndContainer(candidate)
+	return candidate.GetName() == "constructor" && containerIs(container, typeSymbol.GetName()) && sameFile(candidate, typeSymbol)
+}
+
+func (javaScriptStrategy) IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") || inDirectory(path, "__tests__")
+}
+
+func (javaScriptStrategy) IsVendored(path string) bool {
+	return inDirectory(path, "node_modules", "vendor", "third_party")
+}
+
+type rustStrategy struct{ DefaultStrategy }
+
+func (rustStrategy) IsTestFile(path string) bool {
+	return inDirectory(path, "tests", "benches")
+}
+
+func (rustStrategy) IsVendored(path string) bool {
+	return inDirectory(path, "vendor", ".cargo", "third_party")
+}
+
+// ConstructorQueries looks for the ::new convention
+func (rustStrategy) ConstructorQueries(typeName string) []string {
+	return []string{"new"}
+}
+
+// IsConstructor accepts new associated functions in an impl block of the type
+func (rustStrategy) IsConstructor(candidate, typeSymbol protocol.WorkspaceSymbolResult) bool {
+	_, container := kindAndContainer(candidate)
+	return candidate.GetName() == "new" && containerIs(container, typeSymbol.GetName())
+}
+
+// kindAndContainer returns the kind and container name reported for a symbol
+func kindAndContainer(symbol protocol.WorkspaceSymbolResult) (protocol.SymbolKind, string) {
+	switch v := symbol.(type) {
+	case *protocol.SymbolInformation:
+		return v.Kind, v.ContainerName
+	case *protocol.WorkspaceSymbol:
+		return v.Kind, v.ContainerName
+	}
+	return 0, ""
+}
+
+// containerIs reports whether a container name refers to the named type, e.g.
+// "Config", "settings.Config", "crate::Config" or "impl Config"
+func containerIs(container, typeName string) bool {
+	if container == typeName {
+		return true
+	}
+	for _, separator := range []string{".", "::", " "} {
+		if strings.HasSuffix(container, separator+typeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFile reports whether two symbols are defined in the same file
+func sameFile(a, b protocol.WorkspaceSymbolResult) bool {
+	return a.GetLocation().URI == b.GetLocation().URI
+}
+
+// sameDirectory reports whether two symbols are defined in the same directory
+func sameDirectory(a, b protocol.WorkspaceSymbolResult) bool {
+	return filepath.Dir(FilePath(a.GetLocation().URI)) == filepath.Dir(FilePath(b.GetLocation().URI))
+}
+
+// inDirectory reports whether any directory above path has one of the given names
+func inDirectory(path string, names ...string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		for _, name := range names {
+			if dir == name {
+				return true
+			}
+		}
+	}
+	return false
+}
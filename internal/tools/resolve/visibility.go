@@ -0,0 +1,89 @@
+package resolve
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// IsExported looks for access modifiers before the name, e.g. public in Java
+// and C# or static in C, and otherwise treats names without a leading
+// underscore as exported
+func (DefaultStrategy) IsExported(name string, kind protocol.SymbolKind, declaration string) bool {
+	name = lastName(name)
+	for _, word := range wordsBefore(declaration, name) {
+		switch word {
+		case "public", "export", "pub":
+			return true
+		case "private", "protected", "internal", "fileprivate", "static":
+			return false
+		}
+	}
+	return !strings.HasPrefix(name, "_")
+}
+
+// IsExported follows the capitalization rule
+func (goStrategy) IsExported(name string, kind protocol.SymbolKind, declaration string) bool {
+	return isExported(lastName(name))
+}
+
+// IsExported treats names without a leading underscore as exported, including
+// dunder methods such as __call__
+func (pythonStrategy) IsExported(name string, kind protocol.SymbolKind, declaration string) bool {
+	name = lastName(name)
+	return !strings.HasPrefix(name, "_") || (strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+}
+
+// IsExported accepts exported functions and class members that are not
+// private. Whether the class itself is exported is not checked.
+func (javaScriptStrategy) IsExported(name string, kind protocol.SymbolKind, declaration string) bool {
+	name = lastName(name)
+	if strings.HasPrefix(name, "#") || strings.HasPrefix(name, "_") {
+		return false
+	}
+	for _, word := range wordsBefore(declaration, name) {
+		switch word {
+		case "export":
+			return true
+		case "private", "protected":
+			return false
+		}
+	}
+	return kind == protocol.Method || kind == protocol.Constructor
+}
+
+// IsExported accepts pub items, but not pub(crate) or pub(super) ones
+func (rustStrategy) IsExported(name string, kind protocol.SymbolKind, declaration string) bool {
+	for _, restricted := range []string{"pub(crate)", "pub(super)", "pub(in", "pub(self)"} {
+		if strings.Contains(declaration, restricted) {
+			return false
+		}
+	}
+	for _, word := range wordsBefore(declaration, lastName(name)) {
+		if word == "pub" {
+			return true
+		}
+	}
+	return false
+}
+
+// lastName returns the last component of a possibly qualified name
+func lastName(name string) string {
+	_, last, _ := SplitQualified(DefaultStrategy{}.Normalize(name))
+	return last
+}
+
+// wordsBefore returns the words of a declaration that come before the name it
+// declares, which is where modifiers are
+func wordsBefore(declaration, name string) []string {
+	words := strings.FieldsFunc(declaration, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '#'
+	})
+	for i, word := range words {
+		if word == name {
+			return words[:i]
+		}
+	}
+	return words
+}
@@ -0,0 +1,41 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsExported(t *testing.T) {
+	tests := []struct {
+		path        string
+		name        string
+		kind        protocol.SymbolKind
+		declaration string
+		expected    bool
+	}{
+		{"/ws/api/client.go", "Do", protocol.Method, "func (c *Client) Do(req *Request) error {", true},
+		{"/ws/api/client.go", "Client.send", protocol.Method, "func (c *Client) send() error {", false},
+		{"/ws/app/client.py", "send", protocol.Method, "    def send(self):", true},
+		{"/ws/app/client.py", "_send", protocol.Method, "    def _send(self):", false},
+		{"/ws/app/client.py", "__call__", protocol.Method, "    def __call__(self):", true},
+		{"/ws/web/client.ts", "send", protocol.Function, "export function send(req: Request) {", true},
+		{"/ws/web/client.ts", "send", protocol.Function, "function send(req: Request) {", false},
+		{"/ws/web/client.ts", "send", protocol.Method, "  send(req: Request) {", true},
+		{"/ws/web/client.ts", "send", protocol.Method, "  private send(req: Request) {", false},
+		{"/ws/web/client.ts", "#send", protocol.Method, "  #send(req) {", false},
+		{"/ws/src/client.rs", "send", protocol.Function, "pub fn send(req: Request) -> Result<()> {", true},
+		{"/ws/src/client.rs", "send", protocol.Function, "pub(crate) fn send(req: Request) {", false},
+		{"/ws/src/client.rs", "send", protocol.Function, "fn send(req: Request) {", false},
+		{"/ws/src/Client.java", "send", protocol.Method, "    public static void send(Request req) {", true},
+		{"/ws/src/Client.java", "send", protocol.Method, "    private void send(Request req) {", false},
+		{"/ws/src/client.c", "send", protocol.Function, "static int send(struct request *req) {", false},
+		{"/ws/src/client.c", "send", protocol.Function, "int send(struct request *req) {", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.declaration, func(t *testing.T) {
+			assert.Equal(t, tt.expected, StrategyFor(tt.path).IsExported(tt.name, tt.kind, tt.declaration))
+		})
+	}
+}
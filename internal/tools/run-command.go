@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+)
+
+// CommandFinding is a file:line location reported in build or test output
+type CommandFinding struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+var findingPatterns = []*regexp.Regexp{
+	// Python tracebacks: File "app/main.py", line 12, in handler
+	regexp.MustCompile(`File "([^"]+)", line (\d+)()(?:, (.*))?`),
+	// tsc: src/index.ts(12,5): error TS2322: ...
+	regexp.MustCompile(`([^\s():"']+\.[A-Za-z0-9]+)\((\d+),(\d+)\):?\s*(.*)`),
+	// go, gcc, clang, rustc, eslint, jest: path/file.go:12:5: message
+	regexp.MustCompile(`([^\s():"']+\.[A-Za-z0-9]+):(\d+)(?::(\d+))?:?\s*(.*)`),
+}
+
+// IsCommandAllowed reports whether command exactly matches an allowlist entry,
+// ignoring differences in whitespace
+func IsCommandAllowed(allowlist []string, command string) bool {
+	normalized := strings.Join(strings.Fields(command), " ")
+	if normalized == "" {
+		return false
+	}
+	for _, allowed := range allowlist {
+		if strings.Join(strings.Fields(allowed), " ") == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCommandFindings extracts file:line locations from command output
+func ParseCommandFindings(output string) []CommandFinding {
+	var findings []CommandFinding
+	for _, line := range strings.Split(output, "\n") {
+		for _, pattern := range findingPatterns {
+			match := pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			lineNum, err := strconv.Atoi(match[2])
+			if err != nil || lineNum < 1 {
+				continue
+			}
+			column, _ := strconv.Atoi(match[3])
+			findings = append(findings, CommandFinding{
+				Path:    match[1],
+				Line:    lineNum,
+				Column:  column,
+				Message: strings.TrimSpace(match[4]),
+			})
+			break
+		}
+	}
+	return findings
+}
+
+// resolveFindingPath maps a path printed by a command to a file in the workspace.
+// Tools such as `go test` print paths relative to the package directory, so when
+// the path does not exist relative to the workspace root, fall back to a unique
+// file in the workspace with the same trailing path.
+func resolveFindingPath(workspaceDir, path string, index map[string][]string) (string, bool) {
+	candidate := path
+	if !filepath.IsAbs(candidate) {
+		candidate = filepath.Join(workspaceDir, candidate)
+	}
+	candidate = filepath.Clean(candidate)
+	if rel, err := filepath.Rel(workspaceDir, candidate); err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+		return candidate, true
+	}
+
+	var matches []string
+	suffix := string(filepath.Separator) + filepath.Clean(path)
+	for _, file := range index[filepath.Base(path)] {
+		if strings.HasSuffix(file, suffix) {
+			matches = append(matches, file)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+	return "", false
+}
+
+// indexWorkspaceFiles maps base names to absolute paths of workspace files
+func indexWorkspaceFiles(workspaceDir string) map[string][]string {
+	index := make(map[string][]string)
+	_ = filepath.WalkDir(workspaceDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != workspaceDir && skipWalkDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		index[d.Name()] = append(index[d.Name()], path)
+		return nil
+	})
+	return index
+}
+
+// RunCommand executes an allowlisted build or test command in the workspace and
+// returns its output together with the file:line findings it reported, each
+// shown in the context of the surrounding code
+func RunCommand(ctx context.Context, tc *ToolContext, command string) (string, error) {
+	doc, err := RunCommandDocument(ctx, tc, command)
+	if err != nil {
+		return "", err
+	}
+	return tc.render(doc)
+}
+
+// RunCommandDocument runs an allowlisted command like RunCommand, returning the
+// command output as the preamble and one section per file with findings
+func RunCommandDocument(ctx context.Context, tc *ToolContext, command string) (format.Document, error) {
+	client, workspaceDir, cfg := tc.Client, tc.WorkspaceDir, tc.Settings.RunCommand
+	if !IsCommandAllowed(cfg.Allowlist, command) {
+		return format.Document{}, fmt.Errorf("command is not in the allowlist: %q. Allowed commands: %s", command, strings.Join(cfg.Allowlist, ", "))
+	}
+
+	contextLines := tc.contextLines(5)
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fields := strings.Fields(command)
+	cmd := exec.CommandContext(cmdCtx, fields[0], fields[1:]...)
+	cmd.Dir = workspaceDir
+	cmd.Env = os.Environ()
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	toolsLogger.Debug("Running command: %s", command)
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start)
+
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case cmdCtx.Err() == context.DeadlineExceeded:
+			return format.Document{}, errorf(KindTimeout, "command timed out after %s", timeout)
+		case errors.As(runErr, &exitErr):
+			exitCode = exitErr.ExitCode()
+		default:
+			return format.Document{}, fmt.Errorf("failed to run command: %w", runErr)
+		}
+	}
+
+	outText := output.String()
+	var preamble strings.Builder
+	preamble.WriteString(fmt.Sprintf("Command: %s\nExit Code: %d\nDuration: %.2fs\n", command, exitCode, elapsed.Seconds()))
+
+	displayed := outText
+	if cfg.MaxOutputBytes > 0 && len(displayed) > cfg.MaxOutputBytes {
+		displayed = displayed[len(displayed)-cfg.MaxOutputBytes:]
+		preamble.WriteString(fmt.Sprintf("Output truncated to the last %d bytes\n", cfg.MaxOutputBytes))
+	}
+	preamble.WriteString("\nOutput:\n" + displayed)
+	if !strings.HasSuffix(displayed, "\n") {
+		preamble.WriteString("\n")
+	}
+
+	doc := format.Document{
+		Preamble: preamble.String(),
+		Banner:   "\n---\n\n",
+		Empty:    "\nNo file locations found in output\n",
+	}
+
+	// Group findings by the workspace file they refer to
+	index := indexWorkspaceFiles(workspaceDir)
+	findingsByFile := make(map[string][]CommandFinding)
+	seen := make(map[string]bool)
+	hidden := 0
+	for _, finding := range ParseCommandFindings(outText) {
+		path, ok := resolveFindingPath(workspaceDir, finding.Path, index)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d:%d", path, finding.Line, finding.Column)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if !tc.inFocus(path) {
+			hidden++
+			continue
+		}
+		finding.Path = path
+		findingsByFile[path] = append(findingsByFile[path], finding)
+	}
+
+	if note := tc.focusNote(hidden, "finding"); note != "" {
+		doc.Preamble += "\n" + note
+	}
+
+	paths := make([]string, 0, len(findingsByFile))
+	for path := range findingsByFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fileFindings := findingsByFile[path]
+		sort.Slice(fileFindings, func(i, j int) bool {
+			return fileFindings[i].Line < fileFindings[j].Line
+		})
+
+		section := format.Section{Path: path}
+		section.AddField("Findings in File", strconv.Itoa(len(fileFindings)))
+
+		var locations []protocol.Location
+		columns := readColumns(path, client.PositionEncoding())
+		for _, finding := range fileFindings {
+			column := finding.Column
+			if column < 1 {
+				column = 1
+			}
+			section.Notes = append(section.Notes, fmt.Sprintf("L%d:C%d: %s", finding.Line, column, finding.Message))
+			position := columns.at(finding.Line, column)
+			locations = append(locations, protocol.Location{
+				URI:   protocol.URIFromPath(path),
+				Range: protocol.Range{Start: position, End: position},
+			})
+		}
+
+		fileContent, err := textenc.ReadFile(path)
+		if err != nil {
+			section.Error = err.Error()
+			doc.Sections = append(doc.Sections, section)
+			continue
+		}
+		lines := strings.Split(fileContent, "\n")
+
+		// Open the file so the container of each finding can be resolved
+		if err := client.OpenFile(ctx, path); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+		}
+
+		linesToShow, err := GetLineRangesToDisplay(ctx, client, locations, len(lines), contextLines)
+		if err == nil {
+			section.Snippets = format.SnippetsFromRanges(lines, ConvertLinesToRanges(linesToShow, len(lines)))
+			section.Focus = focusLines(locations)
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	return doc, nil
+}
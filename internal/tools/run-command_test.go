@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCommandAllowed(t *testing.T) {
+	allowlist := []string{"go test ./...", "npm test"}
+
+	assert.True(t, IsCommandAllowed(allowlist, "go test ./..."))
+	assert.True(t, IsCommandAllowed(allowlist, "  go   test ./...  "))
+	assert.True(t, IsCommandAllowed(allowlist, "npm test"))
+	assert.False(t, IsCommandAllowed(allowlist, "go test ./... && rm -rf /"))
+	assert.False(t, IsCommandAllowed(allowlist, "go vet ./..."))
+	assert.False(t, IsCommandAllowed(allowlist, ""))
+	assert.False(t, IsCommandAllowed(nil, "go test ./..."))
+}
+
+func TestParseCommandFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []CommandFinding
+	}{
+		{
+			name:   "go compiler error",
+			output: "# example.com/pkg\n./main.go:12:5: undefined: foo\n",
+			expected: []CommandFinding{
+				{Path: "./main.go", Line: 12, Column: 5, Message: "undefined: foo"},
+			},
+		},
+		{
+			name:   "go test failure without column",
+			output: "--- FAIL: TestThing (0.00s)\n    thing_test.go:27: expected 1, got 2\nFAIL\n",
+			expected: []CommandFinding{
+				{Path: "thing_test.go", Line: 27, Column: 0, Message: "expected 1, got 2"},
+			},
+		},
+		{
+			name:   "typescript compiler error",
+			output: "src/index.ts(3,10): error TS2322: Type 'string' is not assignable to type 'number'.\n",
+			expected: []CommandFinding{
+				{Path: "src/index.ts", Line: 3, Column: 10, Message: "error TS2322: Type 'string' is not assignable to type 'number'."},
+			},
+		},
+		{
+			name:   "python traceback",
+			output: "Traceback (most recent call last):\n  File \"app/main.py\", line 8, in <module>\n    run()\n",
+			expected: []CommandFinding{
+				{Path: "app/main.py", Line: 8, Column: 0, Message: "in <module>"},
+			},
+		},
+		{
+			name:   "rust error arrow",
+			output: "error[E0425]: cannot find value `x`\n --> src/main.rs:4:13\n",
+			expected: []CommandFinding{
+				{Path: "src/main.rs", Line: 4, Column: 13, Message: ""},
+			},
+		},
+		{
+			name:     "no locations",
+			output:   "ok  \texample.com/pkg\t0.012s\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseCommandFindings(tc.output))
+		})
+	}
+}
+
+func TestResolveFindingPath(t *testing.T) {
+	workspace := t.TempDir()
+	pkgDir := filepath.Join(workspace, "pkg", "thing")
+	assert.NoError(t, os.MkdirAll(pkgDir, 0755))
+	testFile := filepath.Join(pkgDir, "thing_test.go")
+	assert.NoError(t, os.WriteFile(testFile, []byte("package thing\n"), 0644))
+	mainFile := filepath.Join(workspace, "main.go")
+	assert.NoError(t, os.WriteFile(mainFile, []byte("package main\n"), 0644))
+
+	index := indexWorkspaceFiles(workspace)
+
+	path, ok := resolveFindingPath(workspace, "./main.go", index)
+	assert.True(t, ok)
+	assert.Equal(t, mainFile, path)
+
+	// Relative to the package directory rather than the workspace root
+	path, ok = resolveFindingPath(workspace, "thing_test.go", index)
+	assert.True(t, ok)
+	assert.Equal(t, testFile, path)
+
+	// Outside the workspace
+	_, ok = resolveFindingPath(workspace, "../../etc/passwd", index)
+	assert.False(t, ok)
+
+	// Unknown file
+	_, ok = resolveFindingPath(workspace, "missing.go", index)
+	assert.False(t, ok)
+}
@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// scratchDocument is an in-memory document opened with an untitled: URI
+type scratchDocument struct {
+	URI        protocol.DocumentUri
+	LanguageID protocol.LanguageKind
+	Content    string
+	// Written is when the content was last sent to the server
+	Written time.Time
+	// Published is when the server last published diagnostics for the document
+	Published time.Time
+}
+
+// scratchClient is the part of the LSP client scratch documents use
+type scratchClient interface {
+	diagnosticPuller
+	LanguageID(path string) protocol.LanguageKind
+	OpenDocument(ctx context.Context, uri protocol.DocumentUri, languageID protocol.LanguageKind, text string) error
+	ChangeDocument(ctx context.Context, uri protocol.DocumentUri, text string) error
+	CloseDocument(ctx context.Context, uri protocol.DocumentUri) error
+	GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic
+	SubscribeDiagnostics(listener lsp.DiagnosticsListener) func()
+	Hover(ctx context.Context, params protocol.HoverParams) (protocol.Hover, error)
+	PositionEncoding() protocol.PositionEncodingKind
+}
+
+// ScratchStore tracks scratch documents so that agents can analyze candidate
+// code before writing anything to disk
+type ScratchStore struct {
+	client scratchClient
+	docs   map[string]*scratchDocument
+	mu     sync.Mutex
+	// writeMu serializes opening, changing and closing documents. It is held
+	// while notifying the server, unlike mu, which the diagnostics listener
+	// takes.
+	writeMu sync.Mutex
+}
+
+// NewScratchStore creates a scratch store for the given client
+func NewScratchStore(client *lsp.Client) *ScratchStore {
+	store := &ScratchStore{
+		docs: make(map[string]*scratchDocument),
+	}
+	store.Reset(client)
+	return store
+}
+
+// Reset forgets all scratch documents and switches to a new client, used when
+// the language server is replaced
+func (s *ScratchStore) Reset(client *lsp.Client) {
+	s.reset(client)
+}
+
+func (s *ScratchStore) reset(client scratchClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = client
+	s.docs = make(map[string]*scratchDocument)
+
+	client.SubscribeDiagnostics(func(uri protocol.DocumentUri, _ []protocol.Diagnostic) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.client != client {
+			return
+		}
+		for _, doc := range s.docs {
+			if doc.URI == uri {
+				doc.Published = time.Now()
+			}
+		}
+	})
+}
+
+// scratchURI builds the untitled: URI for a scratch document name
+func scratchURI(name string) protocol.DocumentUri {
+	return protocol.DocumentUri(protocol.UntitledScheme + ":" + name)
+}
+
+// activeClient returns the client scratch documents are opened in
+func (s *ScratchStore) activeClient() scratchClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// get returns a copy of the named scratch document
+func (s *ScratchStore) get(name string) (scratchDocument, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.docs[name]
+	if !ok {
+		return scratchDocument{}, fmt.Errorf("no scratch document named %q", name)
+	}
+	return *doc, nil
+}
+
+// update changes the named scratch document, unless it was closed or the
+// store was reset in the meantime
+func (s *ScratchStore) update(name string, change func(doc *scratchDocument)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if doc, ok := s.docs[name]; ok {
+		change(doc)
+	}
+}
+
+// WriteScratch creates a scratch document or replaces the content of an existing one.
+// The languageId is detected from the name when it is not given.
+func WriteScratch(ctx context.Context, store *ScratchStore, name, languageID, content string) (string, error) {
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return "", fmt.Errorf("scratch document name must be a non-empty file name, e.g. candidate.go")
+	}
+
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	client := store.activeClient()
+	if doc, err := store.get(name); err == nil {
+		// Taken before notifying, so that publications for the new content
+		// are never older than it
+		written := time.Now()
+		if err := client.ChangeDocument(ctx, doc.URI, content); err != nil {
+			return "", fmt.Errorf("failed to update scratch document: %w", err)
+		}
+		store.update(name, func(doc *scratchDocument) {
+			doc.Content = content
+			doc.Written = written
+		})
+		return fmt.Sprintf("Updated scratch document %s (%d lines)", doc.URI, strings.Count(content, "\n")+1), nil
+	}
+
+	lang := protocol.LanguageKind(languageID)
+	if lang == "" {
+		lang = client.LanguageID(name)
+	}
+	if lang == "" {
+		return "", fmt.Errorf("could not detect the language of %s, pass languageId", name)
+	}
+
+	// Add the document first so that publications sent while it is opened
+	// are recorded
+	uri := scratchURI(name)
+	store.mu.Lock()
+	store.docs[name] = &scratchDocument{
+		URI:        uri,
+		LanguageID: lang,
+		Content:    content,
+		Written:    time.Now(),
+	}
+	store.mu.Unlock()
+	if err := client.OpenDocument(ctx, uri, lang, content); err != nil {
+		store.mu.Lock()
+		delete(store.docs, name)
+		store.mu.Unlock()
+		return "", fmt.Errorf("failed to open scratch document: %w", err)
+	}
+
+	return fmt.Sprintf("Created scratch document %s (%s, %d lines)", uri, lang, strings.Count(content, "\n")+1), nil
+}
+
+// ScratchDiagnostics returns diagnostics for a scratch document, waiting up to
+// the given timeout for the server to publish diagnostics for its latest content
+func ScratchDiagnostics(ctx context.Context, tc *ToolContext, store *ScratchStore, name string, timeout time.Duration) (string, error) {
+	doc, err := store.get(name)
+	if err != nil {
+		return "", err
+	}
+
+	contextLines := tc.contextLines(2)
+	client := store.activeClient()
+
+	// Servers that support pull diagnostics answer for the current content,
+	// for the others wait for a publication
+	diagnostics, pulled := pullDiagnostics(ctx, client, doc.URI)
+	if !pulled {
+		deadline := time.Now().Add(timeout)
+		for doc.Published.Before(doc.Written) && time.Now().Before(deadline) {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+			}
+			doc, err = store.get(name)
+			if err != nil {
+				return "", err
+			}
+		}
+		diagnostics = client.GetFileDiagnostics(doc.URI)
+	}
+	if len(diagnostics) == 0 {
+		if !pulled && doc.Published.Before(doc.Written) {
+			return fmt.Sprintf("No diagnostics published for %s within %s", doc.URI, timeout), nil
+		}
+		return "No diagnostics found for " + string(doc.URI), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s\nDiagnostics in File: %d\n", doc.URI, len(diagnostics)))
+
+	lines := strings.Split(doc.Content, "\n")
+	columns := fileColumns{lines: lines, encoding: client.PositionEncoding()}
+	linesToShow := make(map[int]bool)
+	for _, diag := range diagnostics {
+		result.WriteString(formatDiagnostic(diag, columns) + "\n")
+		line := int(diag.Range.Start.Line)
+		for i := line - contextLines; i <= line+contextLines; i++ {
+			linesToShow[i] = true
+		}
+	}
+
+	result.WriteString("\n" + FormatLinesWithRanges(lines, ConvertLinesToRanges(linesToShow, len(lines))))
+	return result.String(), nil
+}
+
+// ScratchHover returns hover information at a position in a scratch document
+func ScratchHover(ctx context.Context, store *ScratchStore, name string, line, column int) (string, error) {
+	doc, err := store.get(name)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(doc.Content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", fmt.Errorf("line %d is out of range (1-%d)", line, len(lines))
+	}
+
+	client := store.activeClient()
+	params := protocol.HoverParams{}
+	params.TextDocument = protocol.TextDocumentIdentifier{URI: doc.URI}
+	params.Position = fileColumns{lines: lines, encoding: client.PositionEncoding()}.at(line, column)
+
+	hoverResult, err := client.Hover(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get hover information: %w", err)
+	}
+
+	if hoverResult.Contents.Value == "" {
+		return fmt.Sprintf("No hover information available for this position on the following line:\n%s", lines[line-1]), nil
+	}
+	return hoverResult.Contents.Value, nil
+}
+
+// CloseScratch closes a scratch document and forgets its content
+func CloseScratch(ctx context.Context, store *ScratchStore, name string) (string, error) {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	doc, err := store.get(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := store.activeClient().CloseDocument(ctx, doc.URI); err != nil {
+		return "", fmt.Errorf("failed to close scratch document: %w", err)
+	}
+
+	store.mu.Lock()
+	delete(store.docs, name)
+	store.mu.Unlock()
+
+	return fmt.Sprintf("Closed scratch document %s", doc.URI), nil
+}
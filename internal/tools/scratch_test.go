@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScratchClient records the documents it is sent. Servers with push
+// diagnostics publish while handling the notification, servers with pull
+// diagnostics answer textDocument/diagnostic.
+type fakeScratchClient struct {
+	mu        sync.Mutex
+	documents map[protocol.DocumentUri]string
+	closed    []protocol.DocumentUri
+	published map[protocol.DocumentUri][]protocol.Diagnostic
+	listener  lsp.DiagnosticsListener
+
+	// push is published for every change when set
+	push []protocol.Diagnostic
+	// pull is the answer to textDocument/diagnostic when set
+	pull []protocol.Diagnostic
+}
+
+func newFakeScratchClient() *fakeScratchClient {
+	return &fakeScratchClient{
+		documents: make(map[protocol.DocumentUri]string),
+		published: make(map[protocol.DocumentUri][]protocol.Diagnostic),
+	}
+}
+
+func (f *fakeScratchClient) LanguageID(path string) protocol.LanguageKind {
+	if len(path) > 3 && path[len(path)-3:] == ".go" {
+		return protocol.LangGo
+	}
+	return ""
+}
+
+func (f *fakeScratchClient) OpenDocument(ctx context.Context, uri protocol.DocumentUri, languageID protocol.LanguageKind, text string) error {
+	return f.ChangeDocument(ctx, uri, text)
+}
+
+func (f *fakeScratchClient) ChangeDocument(ctx context.Context, uri protocol.DocumentUri, text string) error {
+	f.mu.Lock()
+	f.documents[uri] = text
+	push, listener := f.push, f.listener
+	if push != nil {
+		f.published[uri] = push
+	}
+	f.mu.Unlock()
+	if push != nil && listener != nil {
+		listener(uri, push)
+	}
+	return nil
+}
+
+func (f *fakeScratchClient) CloseDocument(ctx context.Context, uri protocol.DocumentUri) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.documents, uri)
+	f.closed = append(f.closed, uri)
+	return nil
+}
+
+func (f *fakeScratchClient) Diagnostic(ctx context.Context, params protocol.DocumentDiagnosticParams) (protocol.DocumentDiagnosticReport, error) {
+	if f.pull == nil {
+		return protocol.DocumentDiagnosticReport{}, errors.New("method not found")
+	}
+	report := protocol.RelatedFullDocumentDiagnosticReport{}
+	report.Kind = "full"
+	report.Items = f.pull
+	return protocol.DocumentDiagnosticReport{Value: report}, nil
+}
+
+func (f *fakeScratchClient) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.published[uri]
+}
+
+func (f *fakeScratchClient) SubscribeDiagnostics(listener lsp.DiagnosticsListener) func() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listener = listener
+	return func() {}
+}
+
+func (f *fakeScratchClient) Hover(ctx context.Context, params protocol.HoverParams) (protocol.Hover, error) {
+	return protocol.Hover{}, nil
+}
+
+func (f *fakeScratchClient) PositionEncoding() protocol.PositionEncodingKind {
+	return protocol.UTF16
+}
+
+func newTestScratchStore(client scratchClient) *ScratchStore {
+	store := &ScratchStore{docs: make(map[string]*scratchDocument)}
+	store.reset(client)
+	return store
+}
+
+func scratchDiagnostic(line uint32, message string) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Range:    protocol.Range{Start: protocol.Position{Line: line, Character: 4}, End: protocol.Position{Line: line, Character: 8}},
+		Severity: protocol.SeverityError,
+		Message:  message,
+	}
+}
+
+func TestWriteScratch(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScratchClient()
+	store := newTestScratchStore(client)
+
+	result, err := WriteScratch(ctx, store, "candidate.go", "", "package main\n")
+	require.NoError(t, err)
+	assert.Equal(t, "Created scratch document untitled:candidate.go (go, 2 lines)", result)
+	assert.Equal(t, "package main\n", client.documents["untitled:candidate.go"])
+
+	result, err = WriteScratch(ctx, store, "candidate.go", "", "package main\n\nfunc main() {}\n")
+	require.NoError(t, err)
+	assert.Equal(t, "Updated scratch document untitled:candidate.go (4 lines)", result)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", client.documents["untitled:candidate.go"])
+	doc, err := store.get("candidate.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", doc.Content)
+
+	_, err = WriteScratch(ctx, store, "dir/candidate.go", "", "package main")
+	assert.ErrorContains(t, err, "must be a non-empty file name")
+	_, err = WriteScratch(ctx, store, "notes.txt", "", "text")
+	assert.ErrorContains(t, err, "pass languageId")
+	_, err = WriteScratch(ctx, store, "notes.txt", "plaintext", "text")
+	assert.NoError(t, err)
+}
+
+func TestWriteScratchPublishingDuringChange(t *testing.T) {
+	// Servers may publish diagnostics before the notification returns, which
+	// must neither block nor count as older than the content
+	client := newFakeScratchClient()
+	client.push = []protocol.Diagnostic{scratchDiagnostic(0, "expected package")}
+	store := newTestScratchStore(client)
+
+	done := make(chan error)
+	go func() {
+		_, err := WriteScratch(context.Background(), store, "candidate.go", "", "pakage main")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("WriteScratch blocked on a publication")
+	}
+
+	doc, err := store.get("candidate.go")
+	require.NoError(t, err)
+	assert.False(t, doc.Published.Before(doc.Written))
+}
+
+func TestCloseScratch(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScratchClient()
+	store := newTestScratchStore(client)
+
+	_, err := WriteScratch(ctx, store, "candidate.go", "", "package main")
+	require.NoError(t, err)
+
+	result, err := CloseScratch(ctx, store, "candidate.go")
+	require.NoError(t, err)
+	assert.Equal(t, "Closed scratch document untitled:candidate.go", result)
+	assert.Equal(t, []protocol.DocumentUri{"untitled:candidate.go"}, client.closed)
+	assert.NotContains(t, client.documents, protocol.DocumentUri("untitled:candidate.go"))
+
+	_, err = store.get("candidate.go")
+	assert.Error(t, err)
+	_, err = CloseScratch(ctx, store, "candidate.go")
+	assert.ErrorContains(t, err, `no scratch document named "candidate.go"`)
+}
+
+func TestScratchDiagnosticsPull(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScratchClient()
+	client.pull = []protocol.Diagnostic{scratchDiagnostic(1, "undefined: x")}
+	store := newTestScratchStore(client)
+
+	_, err := WriteScratch(ctx, store, "candidate.go", "", "package main\nvar y = x\n")
+	require.NoError(t, err)
+
+	// Nothing is ever published, the pulled report is used without waiting
+	start := time.Now()
+	result, err := ScratchDiagnostics(ctx, testContext(), store, "candidate.go", 5*time.Second)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, result, "Diagnostics in File: 1")
+	assert.Contains(t, result, "ERROR at L2:C5: undefined: x")
+
+	client.pull = []protocol.Diagnostic{}
+	result, err = ScratchDiagnostics(ctx, testContext(), store, "candidate.go", 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "No diagnostics found for untitled:candidate.go", result)
+}
+
+func TestScratchDiagnosticsPush(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeScratchClient()
+	client.push = []protocol.Diagnostic{scratchDiagnostic(0, "expected 'package'")}
+	store := newTestScratchStore(client)
+
+	_, err := WriteScratch(ctx, store, "candidate.go", "", "pakage main\n")
+	require.NoError(t, err)
+	result, err := ScratchDiagnostics(ctx, testContext(), store, "candidate.go", time.Second)
+	require.NoError(t, err)
+	assert.Contains(t, result, "ERROR at L1:C5: expected 'package'")
+
+	// Without a publication for the latest content the wait times out
+	client.push = nil
+	_, err = WriteScratch(ctx, store, "other.go", "", "package main\n")
+	require.NoError(t, err)
+	result, err = ScratchDiagnostics(ctx, testContext(), store, "other.go", 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "No diagnostics published for untitled:other.go within 200ms", result)
+}
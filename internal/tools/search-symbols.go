@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// symbolSummaryThreshold is the number of hits above which search results
+// start with a breakdown by kind and directory
+const symbolSummaryThreshold = 10
+
+// SearchSymbolsDocument searches workspace symbols, best matches first, grouped
+// into one section per file. At most limit symbols are listed, and large result
+// sets start with a breakdown of all hits so the query can be refined.
+func SearchSymbolsDocument(ctx context.Context, tc *ToolContext, query string, limit int) (format.Document, error) {
+	return searchSymbols(ctx, tc, tc.Client, query, limit)
+}
+
+func searchSymbols(ctx context.Context, tc *ToolContext, client resolve.SymbolSearcher, query string, limit int) (format.Document, error) {
+	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+	if err != nil {
+		return format.Document{}, fmt.Errorf("failed to fetch symbol: %w", err)
+	}
+
+	results, err := symbolResult.Results()
+	if err != nil {
+		return format.Document{}, fmt.Errorf("failed to parse results: %w", err)
+	}
+
+	inFocus := results[:0]
+	for _, symbol := range results {
+		if tc.inFocus(protocol.PathFromURI(string(symbol.GetLocation().URI))) {
+			inFocus = append(inFocus, symbol)
+		}
+	}
+	hidden := len(results) - len(inFocus)
+	results = inFocus
+
+	// Servers match fuzzily, so keep every hit and only use the score for ordering
+	weights := tc.Resolver.Weights()
+	scores := make(map[protocol.WorkspaceSymbolResult]int, len(results))
+	for _, symbol := range results {
+		scores[symbol] = resolve.Score(symbol, query, weights)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return scores[results[i]] > scores[results[j]]
+	})
+
+	doc := format.Document{
+		Banner:    "---\n\n",
+		Separator: "\n",
+		Empty:     fmt.Sprintf("No symbols found for query: %s", query),
+	}
+	if len(results) == 0 {
+		if hidden > 0 {
+			doc.Empty += "\n" + strings.TrimSuffix(tc.focusNote(hidden, "symbol"), "\n")
+		}
+		return doc, nil
+	}
+
+	var preamble strings.Builder
+	preamble.WriteString(tc.focusNote(hidden, "symbol"))
+	preamble.WriteString(fmt.Sprintf("Found %s matching %q", pluralize(len(results), "symbol"), query))
+	if len(results) > symbolSummaryThreshold {
+		preamble.WriteString(": " + summarizeSymbols(results))
+	}
+	preamble.WriteString("\n")
+	shown := results
+	if limit > 0 && len(results) > limit {
+		shown = results[:limit]
+		preamble.WriteString(fmt.Sprintf("Showing the best %d, refine the query or raise limit to see more\n", limit))
+	}
+	doc.Preamble = preamble.String() + "\n"
+
+	var encoding protocol.PositionEncodingKind
+	if encoder, ok := client.(interface {
+		PositionEncoding() protocol.PositionEncodingKind
+	}); ok {
+		encoding = encoder.PositionEncoding()
+	}
+
+	// Group by file, ordering files by their best match
+	sections := make(map[string]*format.Section)
+	columns := make(map[string]fileColumns)
+	var paths []string
+	for _, symbol := range shown {
+		path := protocol.PathFromURI(string(symbol.GetLocation().URI))
+		section, ok := sections[path]
+		if !ok {
+			section = &format.Section{Path: path}
+			sections[path] = section
+			columns[path] = readColumns(path, encoding)
+			paths = append(paths, path)
+		}
+		section.Notes = append(section.Notes, describeSymbol(symbol, columns[path]))
+	}
+	for _, path := range paths {
+		doc.Sections = append(doc.Sections, *sections[path])
+	}
+
+	return doc, nil
+}
+
+// symbolKindName returns the name of a symbol's kind and its container, if the
+// server reported them
+func symbolKindName(symbol protocol.WorkspaceSymbolResult) (string, string) {
+	var kind protocol.SymbolKind
+	var container string
+	switch v := symbol.(type) {
+	case *protocol.SymbolInformation:
+		kind, container = v.Kind, v.ContainerName
+	case *protocol.WorkspaceSymbol:
+		kind, container = v.Kind, v.ContainerName
+	}
+	return protocol.TableKindMap[kind], container
+}
+
+// describeSymbol formats a symbol as "Function Name L12:C6 (in Container)",
+// labeled when it is deprecated
+func describeSymbol(symbol protocol.WorkspaceSymbolResult, columns fileColumns) string {
+	description := symbol.GetName() + " " + columns.format(symbol.GetLocation().Range.Start)
+	kind, container := symbolKindName(symbol)
+	if kind != "" {
+		description = kind + " " + description
+	}
+	if container != "" {
+		description += fmt.Sprintf(" (in %s)", container)
+	}
+	if isDeprecatedSymbol(symbol) {
+		description = deprecatedLabel + " " + description
+	}
+	return description
+}
+
+// summarizeSymbols counts symbols by kind and directory, e.g.
+// "40 functions, 12 methods, 3 structs across 9 directories"
+func summarizeSymbols(symbols []protocol.WorkspaceSymbolResult) string {
+	counts := make(map[string]int)
+	dirs := make(map[string]bool)
+	for _, symbol := range symbols {
+		kind, _ := symbolKindName(symbol)
+		if kind == "" {
+			kind = "Symbol"
+		}
+		counts[kind]++
+		dirs[filepath.Dir(protocol.PathFromURI(string(symbol.GetLocation().URI)))] = true
+	}
+
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if counts[kinds[i]] != counts[kinds[j]] {
+			return counts[kinds[i]] > counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, pluralize(counts[kind], strings.ToLower(kind)))
+	}
+	return fmt.Sprintf("%s across %s", strings.Join(parts, ", "), pluralize(len(dirs), "directory"))
+}
+
+// pluralize formats a count with a noun, e.g. "1 class" or "3 classes"
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	switch {
+	case strings.HasSuffix(noun, "s"):
+		noun += "es"
+	case strings.HasSuffix(noun, "y"):
+		noun = strings.TrimSuffix(noun, "y") + "ies"
+	default:
+		noun += "s"
+	}
+	return fmt.Sprintf("%d %s", count, noun)
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSymbolSearcher []protocol.SymbolInformation
+
+func (f fakeSymbolSearcher) Symbol(ctx context.Context, params protocol.WorkspaceSymbolParams) (protocol.Or_Result_workspace_symbol, error) {
+	return protocol.Or_Result_workspace_symbol{Value: []protocol.SymbolInformation(f)}, nil
+}
+
+func symbolAt(name string, kind protocol.SymbolKind, path string, line uint32) protocol.SymbolInformation {
+	return protocol.SymbolInformation{
+		Name: name,
+		Kind: kind,
+		Location: protocol.Location{
+			URI:   protocol.URIFromPath(path),
+			Range: protocol.Range{Start: protocol.Position{Line: line}},
+		},
+	}
+}
+
+func TestSearchSymbolsBreakdown(t *testing.T) {
+	var symbols fakeSymbolSearcher
+	for i := 0; i < 8; i++ {
+		symbols = append(symbols, symbolAt(fmt.Sprintf("handler%d", i), protocol.Function, fmt.Sprintf("/ws/pkg%d/a.go", i%3), uint32(i)))
+	}
+	symbols = append(symbols,
+		symbolAt("Handler", protocol.Struct, "/ws/pkg0/types.go", 3),
+		symbolAt("ServeHandler", protocol.Method, "/ws/pkg1/a.go", 20),
+		symbolAt("handlerClass", protocol.Class, "/ws/pkg2/a.go", 30),
+	)
+
+	doc, err := searchSymbols(context.Background(), testContext(), symbols, "Handler", 5)
+	assert.NoError(t, err)
+	assert.Contains(t, doc.Preamble, `Found 11 symbols matching "Handler": 8 functions, 1 class, 1 method, 1 struct across 3 directories`)
+	assert.Contains(t, doc.Preamble, "Showing the best 5")
+
+	// The exact match comes first
+	assert.Equal(t, "/ws/pkg0/types.go", doc.Sections[0].Path)
+	assert.Equal(t, []string{"Struct Handler L4:C1"}, doc.Sections[0].Notes)
+
+	listed := 0
+	for _, section := range doc.Sections {
+		listed += len(section.Notes)
+	}
+	assert.Equal(t, 5, listed)
+}
+
+func TestSearchSymbolsFewHits(t *testing.T) {
+	symbols := fakeSymbolSearcher{symbolAt("Open", protocol.Function, "/ws/文件.go", 0)}
+	doc, err := searchSymbols(context.Background(), testContext(), symbols, "Open", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "Found 1 symbol matching \"Open\"\n\n", doc.Preamble)
+	assert.Equal(t, "/ws/文件.go", doc.Sections[0].Path)
+
+	doc, err = searchSymbols(context.Background(), testContext(), fakeSymbolSearcher{}, "Missing", 50)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(renderPlain(doc), "No symbols found"))
+}
+
+func TestPluralize(t *testing.T) {
+	assert.Equal(t, "1 class", pluralize(1, "class"))
+	assert.Equal(t, "2 classes", pluralize(2, "class"))
+	assert.Equal(t, "3 properties", pluralize(3, "property"))
+	assert.Equal(t, "4 functions", pluralize(4, "function"))
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+)
+
+// maxTextMatches bounds how many matches a text search for references returns
+const maxTextMatches = 200
+
+// TextReferencesDocument searches the text of the workspace's source files for
+// the unqualified name of a symbol. It stands in for references when the
+// language server finds none, as it does for projects that do not build. The
+// result says that the matches are text, which includes comments, strings and
+// other symbols with the same name.
+func TextReferencesDocument(ctx context.Context, tc *ToolContext, symbolName string) (format.Document, error) {
+	return textReferences(ctx, tc, symbolName, tc.Client.LanguageID)
+}
+
+func textReferences(ctx context.Context, tc *ToolContext, symbolName string, languageID func(path string) protocol.LanguageKind) (format.Document, error) {
+	name := lastComponent(symbolName)
+	usage, err := usagePattern(name)
+	if err != nil {
+		return format.Document{}, err
+	}
+	contextLines := tc.contextLines(5)
+
+	doc := format.Document{
+		Preamble:  fmt.Sprintf("The language server found no references to %s. These are text matches for %s: they may include comments, strings and other symbols with the same name.\n\n", symbolName, name),
+		Banner:    "---\n\n",
+		Separator: "\n",
+		Empty:     fmt.Sprintf("No references or text matches found for symbol: %s", symbolName),
+		EmptyKind: string(KindSymbolNotFound),
+	}
+	matches, hidden, files := 0, 0, 0
+	err = filepath.WalkDir(tc.WorkspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != tc.WorkspaceDir && skipWalkDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if languageID(path) == "" {
+			return nil
+		}
+		if files++; files > maxUsageFiles {
+			return filepath.SkipAll
+		}
+
+		content, err := textenc.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		lines := strings.Split(content, "\n")
+		var marks []format.Mark
+		for i, line := range lines {
+			for _, match := range usage.FindAllStringSubmatchIndex(line, -1) {
+				// match[3] is the end of the leading boundary group
+				start := len([]rune(line[:match[3]]))
+				marks = append(marks, format.Mark{Line: i + 1, Start: start, End: start + len([]rune(name))})
+			}
+		}
+		if len(marks) == 0 {
+			return nil
+		}
+		if !tc.inFocus(path) {
+			hidden += len(marks)
+			return nil
+		}
+		if matches+len(marks) > maxTextMatches {
+			marks = marks[:maxTextMatches-matches]
+		}
+		matches += len(marks)
+		tc.addSection(&doc, textMatchSection(path, lines, marks, contextLines))
+		if matches >= maxTextMatches {
+			doc.Footer = fmt.Sprintf("\nStopped after %d text matches\n", maxTextMatches)
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return format.Document{}, err
+	}
+
+	if len(doc.Sections) > 0 || doc.Streamed > 0 {
+		doc.EmptyKind = ""
+	} else {
+		doc.Preamble = ""
+	}
+	if note := tc.focusNote(hidden, "text match"); note != "" {
+		doc.Preamble += note + "\n"
+		doc.Empty += "\n" + strings.TrimSuffix(note, "\n")
+	}
+	return doc, nil
+}
+
+// textMatchSection shows the text matches in a file like references
+func textMatchSection(path string, lines []string, marks []format.Mark, contextLines int) format.Section {
+	section := format.Section{Path: path, Marks: marks}
+	section.AddField("Text Matches in File", strconv.Itoa(len(marks)))
+
+	linesToShow := make(map[int]bool)
+	var at []string
+	for _, mark := range marks {
+		at = append(at, fmt.Sprintf("L%d:C%d", mark.Line, mark.Start+1))
+		section.Focus = append(section.Focus, mark.Line)
+		for line := mark.Line - 1 - contextLines; line <= mark.Line-1+contextLines; line++ {
+			linesToShow[line] = true
+		}
+	}
+	section.AddField("At", strings.Join(at, ", "))
+	section.Snippets = format.SnippetsFromRanges(lines, ConvertLinesToRanges(linesToShow, len(lines)))
+	return section
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func goLanguageID(path string) protocol.LanguageKind {
+	if strings.HasSuffix(path, ".go") {
+		return "go"
+	}
+	return ""
+}
+
+func TestTextReferences(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "api", "client.go"), "package api\n\n// Client talks to the API\ntype Client struct{}\n")
+	writeFile(t, filepath.Join(dir, "cmd", "main.go"), "package main\n\nfunc main() {\n\tc := api.Client{} // NewClient is not a match\n\t_ = c\n}\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "Client is documented here\n")
+	writeFile(t, filepath.Join(dir, "vendor", "dep", "dep.go"), "package dep\n\ntype Client struct{}\n")
+
+	tc := testContext()
+	tc.WorkspaceDir = dir
+	tc.ContextLines = 0
+	doc, err := textReferences(context.Background(), tc, "api.Client", goLanguageID)
+	require.NoError(t, err)
+
+	assert.Contains(t, doc.Preamble, "The language server found no references to api.Client. These are text matches for Client")
+	assert.Empty(t, doc.EmptyKind)
+	require.Len(t, doc.Sections, 2)
+
+	client := doc.Sections[0]
+	assert.Equal(t, filepath.Join(dir, "api", "client.go"), client.Path)
+	assert.Equal(t, []format.Field{{Name: "Text Matches in File", Value: "2"}, {Name: "At", Value: "L3:C4, L4:C6"}}, client.Fields)
+	assert.Equal(t, []format.Mark{{Line: 3, Start: 3, End: 9}, {Line: 4, Start: 5, End: 11}}, client.Marks)
+
+	main := doc.Sections[1]
+	assert.Equal(t, []format.Mark{{Line: 4, Start: 10, End: 16}}, main.Marks)
+	assert.Equal(t, []format.Snippet{{StartLine: 4, Lines: []string{"\tc := api.Client{} // NewClient is not a match"}}}, main.Snippets)
+}
+
+func TestTextReferencesStream(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.go"), "package a\n\ntype Client struct{}\n")
+	writeFile(t, filepath.Join(dir, "b.go"), "package a\n\nvar c Client\n")
+
+	tc := testContext()
+	tc.WorkspaceDir = dir
+	var streamed []string
+	tc.Stream = func(doc format.Document, section format.Section) bool {
+		assert.Equal(t, "---\n\n", doc.Banner)
+		streamed = append(streamed, filepath.Base(section.Path))
+		return true
+	}
+	doc, err := textReferences(context.Background(), tc, "Client", goLanguageID)
+	require.NoError(t, err)
+
+	// Sections go to the stream as each file is searched and are only counted
+	assert.Equal(t, []string{"a.go", "b.go"}, streamed)
+	assert.Empty(t, doc.Sections)
+	assert.Equal(t, 2, doc.Streamed)
+	assert.Empty(t, doc.EmptyKind)
+	assert.Contains(t, doc.Preamble, "These are text matches for Client")
+}
+
+func TestTextReferencesNoMatches(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+
+	tc := testContext()
+	tc.WorkspaceDir = dir
+	doc, err := textReferences(context.Background(), tc, "Missing", goLanguageID)
+	require.NoError(t, err)
+	assert.Empty(t, doc.Sections)
+	assert.Empty(t, doc.Preamble)
+	assert.Equal(t, string(KindSymbolNotFound), doc.EmptyKind)
+	assert.Equal(t, "No references or text matches found for symbol: Missing", doc.Empty)
+}
+
+func TestTextReferencesFocus(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "api", "client.go"), "package api\n\ntype Client struct{}\n")
+	writeFile(t, filepath.Join(dir, "web", "client.go"), "package web\n\nvar c Client\n")
+
+	tc := testContext()
+	tc.WorkspaceDir = dir
+	tc.FocusDir = filepath.Join(dir, "api")
+	doc, err := textReferences(context.Background(), tc, "Client", goLanguageID)
+	require.NoError(t, err)
+	require.Len(t, doc.Sections, 1)
+	assert.Contains(t, doc.Preamble, "Focused on api: 1 text match outside it hidden")
+}
@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// callHierarchyClient is the part of the LSP client used to walk callers
+type callHierarchyClient interface {
+	PrepareCallHierarchy(ctx context.Context, params protocol.CallHierarchyPrepareParams) ([]protocol.CallHierarchyItem, error)
+	IncomingCalls(ctx context.Context, params protocol.CallHierarchyIncomingCallsParams) ([]protocol.CallHierarchyIncomingCall, error)
+}
+
+// callerNode is a function in the incoming call tree of a sink
+type callerNode struct {
+	Item    protocol.CallHierarchyItem
+	Callers []*callerNode
+
+	// Note explains why the callers of the node are not listed, or what kind
+	// of entry point a node without callers is
+	Note string
+
+	// Entry is set for nodes without callers that look like entry points
+	Entry bool
+}
+
+// callerWalker builds incoming call trees, bounded in depth and size
+type callerWalker struct {
+	client   callHierarchyClient
+	maxDepth int
+	maxNodes int
+	nodes    int
+	expanded map[string]bool
+}
+
+// TraceSink lists the functions that reach a sink such as exec.Command or
+// db.Query as a tree of incoming calls, up to maxDepth calls away and maxNodes
+// functions in total. Functions without callers are annotated as entry points,
+// and the files and entry points involved are summarized at the end.
+func TraceSink(ctx context.Context, tc *ToolContext, symbolName string, maxDepth, maxNodes int) (string, error) {
+	client := tc.Client
+	matches, err := tc.lookup(ctx, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("%s not found", symbolName), nil
+	}
+
+	loc := matches[0].Symbol.GetLocation()
+	if err := client.OpenFile(ctx, protocol.PathFromURI(string(loc.URI))); err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+
+	items, err := client.PrepareCallHierarchy(ctx, protocol.CallHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+			Position:     loc.Range.Start,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare call hierarchy: %w", err)
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf("No call hierarchy available for %s", symbolName), nil
+	}
+
+	walker := &callerWalker{
+		client:   client,
+		maxDepth: maxDepth,
+		maxNodes: maxNodes,
+		expanded: make(map[string]bool),
+	}
+	var trees []*callerNode
+	for _, item := range items {
+		trees = append(trees, walker.walk(ctx, item, 0, map[string]bool{}))
+	}
+	return formatSinkTrees(trees), nil
+}
+
+// itemKey identifies a call hierarchy item
+func itemKey(item protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+}
+
+// walk builds the caller tree of an item. ancestors holds the items on the
+// path from the sink, to cut recursion; functions already expanded elsewhere
+// in the tree are listed once without their callers.
+func (w *callerWalker) walk(ctx context.Context, item protocol.CallHierarchyItem, depth int, ancestors map[string]bool) *callerNode {
+	node := &callerNode{Item: item}
+	w.nodes++
+	key := itemKey(item)
+
+	switch {
+	case ancestors[key]:
+		node.Note = "recursive call"
+		return node
+	case w.expanded[key]:
+		node.Note = "callers listed above"
+		return node
+	case depth >= w.maxDepth:
+		node.Note = "depth limit reached"
+		return node
+	case ctx.Err() != nil:
+		node.Note = "stopped: " + ctx.Err().Error()
+		return node
+	}
+	w.expanded[key] = true
+
+	calls, err := w.client.IncomingCalls(ctx, protocol.CallHierarchyIncomingCallsParams{Item: item})
+	if err != nil {
+		node.Note = fmt.Sprintf("callers unavailable: %v", err)
+		return node
+	}
+	if len(calls) == 0 {
+		node.Note, node.Entry = entryPointNote(item)
+		return node
+	}
+
+	ancestors[key] = true
+	defer delete(ancestors, key)
+	for i, call := range calls {
+		if w.nodes >= w.maxNodes {
+			node.Note = fmt.Sprintf("node limit reached, %d more callers not shown", len(calls)-i)
+			break
+		}
+		node.Callers = append(node.Callers, w.walk(ctx, call.From, depth+1, ancestors))
+	}
+	return node
+}
+
+// entryPointNote describes a function without callers. Such functions are
+// where execution enters the code: main functions, tests, exported API, or
+// callbacks registered with code outside the workspace.
+func entryPointNote(item protocol.CallHierarchyItem) (string, bool) {
+	path := protocol.PathFromURI(string(item.URI))
+	switch {
+	case item.Name == "main":
+		return "entry point: main", true
+	case item.Name == "init" && filepath.Ext(path) == ".go":
+		return "entry point: package init", true
+	case resolve.StrategyFor(path).IsTestFile(path):
+		return "entry point: test", true
+	default:
+		return "entry point: no callers found (exported API, handler or callback)", true
+	}
+}
+
+// formatSinkTrees renders the caller trees followed by a summary of the entry
+// points and files that reach the sink
+func formatSinkTrees(trees []*callerNode) string {
+	var result strings.Builder
+	entries := make(map[string]*callerNode)
+	functionsByFile := make(map[string]map[string]bool)
+
+	var render func(node *callerNode, depth int)
+	render = func(node *callerNode, depth int) {
+		path := protocol.PathFromURI(string(node.Item.URI))
+		line := fmt.Sprintf("%s %s:L%d", node.Item.Name, path, node.Item.SelectionRange.Start.Line+1)
+		if depth == 0 {
+			result.WriteString("Sink: " + line)
+		} else {
+			result.WriteString(strings.Repeat("  ", depth) + "<- " + line)
+			if functionsByFile[path] == nil {
+				functionsByFile[path] = make(map[string]bool)
+			}
+			functionsByFile[path][node.Item.Name] = true
+		}
+		if node.Note != "" {
+			result.WriteString(" [" + node.Note + "]")
+		}
+		result.WriteString("\n")
+
+		if node.Entry && depth > 0 {
+			entries[itemKey(node.Item)] = node
+		}
+		for _, caller := range node.Callers {
+			render(caller, depth+1)
+		}
+	}
+	for _, tree := range trees {
+		render(tree, 0)
+	}
+
+	functions := 0
+	paths := make([]string, 0, len(functionsByFile))
+	for path, names := range functionsByFile {
+		functions += len(names)
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	result.WriteString(fmt.Sprintf("\nCallers: %s in %s\n", pluralize(functions, "function"), pluralize(len(paths), "file")))
+
+	if len(entries) > 0 {
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result.WriteString("\nEntry points reaching the sink:\n")
+		for _, key := range keys {
+			node := entries[key]
+			result.WriteString(fmt.Sprintf("  %s %s:L%d (%s)\n", node.Item.Name, protocol.PathFromURI(string(node.Item.URI)),
+				node.Item.SelectionRange.Start.Line+1, strings.TrimPrefix(node.Note, "entry point: ")))
+		}
+	}
+
+	if len(paths) > 0 {
+		result.WriteString("\nFiles:\n")
+		for _, path := range paths {
+			result.WriteString(fmt.Sprintf("  %s (%s)\n", path, pluralize(len(functionsByFile[path]), "function")))
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCallHierarchy maps function names to the names of their callers
+type fakeCallHierarchy map[string][]string
+
+func hierarchyItem(name, path string, line uint32) protocol.CallHierarchyItem {
+	r := protocol.Range{Start: protocol.Position{Line: line}}
+	return protocol.CallHierarchyItem{Name: name, URI: protocol.URIFromPath(path), Range: r, SelectionRange: r}
+}
+
+var fakeItems = map[string]protocol.CallHierarchyItem{
+	"Command":     hierarchyItem("Command", "/go/src/os/exec/exec.go", 99),
+	"runTool":     hierarchyItem("runTool", "/ws/internal/run.go", 39),
+	"handleRun":   hierarchyItem("handleRun", "/ws/cmd/server.go", 11),
+	"main":        hierarchyItem("main", "/ws/cmd/main.go", 9),
+	"TestRunTool": hierarchyItem("TestRunTool", "/ws/internal/run_test.go", 4),
+	"retry":       hierarchyItem("retry", "/ws/internal/run.go", 59),
+}
+
+func (f fakeCallHierarchy) PrepareCallHierarchy(ctx context.Context, params protocol.CallHierarchyPrepareParams) ([]protocol.CallHierarchyItem, error) {
+	return nil, nil
+}
+
+func (f fakeCallHierarchy) IncomingCalls(ctx context.Context, params protocol.CallHierarchyIncomingCallsParams) ([]protocol.CallHierarchyIncomingCall, error) {
+	var calls []protocol.CallHierarchyIncomingCall
+	for _, name := range f[params.Item.Name] {
+		calls = append(calls, protocol.CallHierarchyIncomingCall{From: fakeItems[name]})
+	}
+	return calls, nil
+}
+
+func traceFake(hierarchy fakeCallHierarchy, maxDepth, maxNodes int) string {
+	walker := &callerWalker{client: hierarchy, maxDepth: maxDepth, maxNodes: maxNodes, expanded: make(map[string]bool)}
+	tree := walker.walk(context.Background(), fakeItems["Command"], 0, map[string]bool{})
+	return formatSinkTrees([]*callerNode{tree})
+}
+
+func TestTraceSinkTree(t *testing.T) {
+	hierarchy := fakeCallHierarchy{
+		"Command":   {"runTool"},
+		"runTool":   {"handleRun", "TestRunTool", "retry"},
+		"handleRun": {"main"},
+		"retry":     {"runTool"},
+	}
+
+	assert.Equal(t, `Sink: Command /go/src/os/exec/exec.go:L100
+  <- runTool /ws/internal/run.go:L40
+    <- handleRun /ws/cmd/server.go:L12
+      <- main /ws/cmd/main.go:L10 [entry point: main]
+    <- TestRunTool /ws/internal/run_test.go:L5 [entry point: test]
+    <- retry /ws/internal/run.go:L60
+      <- runTool /ws/internal/run.go:L40 [recursive call]
+
+Callers: 5 functions in 4 files
+
+Entry points reaching the sink:
+  main /ws/cmd/main.go:L10 (main)
+  TestRunTool /ws/internal/run_test.go:L5 (test)
+
+Files:
+  /ws/cmd/main.go (1 function)
+  /ws/cmd/server.go (1 function)
+  /ws/internal/run.go (2 functions)
+  /ws/internal/run_test.go (1 function)
+`, traceFake(hierarchy, 5, 100))
+}
+
+func TestTraceSinkLimits(t *testing.T) {
+	hierarchy := fakeCallHierarchy{
+		"Command":   {"runTool"},
+		"runTool":   {"handleRun", "TestRunTool"},
+		"handleRun": {"main"},
+	}
+
+	output := traceFake(hierarchy, 2, 100)
+	assert.Contains(t, output, "<- handleRun /ws/cmd/server.go:L12 [depth limit reached]")
+	assert.NotContains(t, output, "main /ws/cmd/main.go")
+
+	output = traceFake(hierarchy, 5, 3)
+	assert.Contains(t, output, "<- runTool /ws/internal/run.go:L40 [node limit reached, 1 more callers not shown]")
+	assert.NotContains(t, output, "TestRunTool")
+
+	output = traceFake(fakeCallHierarchy{"Command": {"handleRun"}}, 5, 100)
+	assert.Contains(t, output, "<- handleRun /ws/cmd/server.go:L12 [entry point: no callers found (exported API, handler or callback)]")
+}
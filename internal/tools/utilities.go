@@ -2,23 +2,35 @@ package tools
 
 import (
 	"fmt"
-	"os"
-	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
-func ExtractTextFromLocation(loc protocol.Location) (string, error) {
-	path := strings.TrimPrefix(string(loc.URI), "file://")
+// skipWalkDir reports whether a directory below the workspace root is left out
+// when walking the workspace: hidden directories, dependencies and build output
+func skipWalkDir(name string) bool {
+	switch name {
+	case "node_modules", "vendor", "target", "__pycache__":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
 
-	content, err := os.ReadFile(path)
+// ExtractTextFromLocation returns the text of a location, whose character
+// offsets are counted in the given position encoding
+func ExtractTextFromLocation(loc protocol.Location, encoding protocol.PositionEncodingKind) (string, error) {
+	path := protocol.PathFromURI(string(loc.URI))
+
+	content, err := textenc.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
+	lines := strings.Split(content, "\n")
 
 	startLine := int(loc.Range.Start.Line)
 	endLine := int(loc.Range.End.Line)
@@ -26,13 +38,22 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 		return "", fmt.Errorf("invalid Location range: %v", loc.Range)
 	}
 
+	// offset converts a character offset to a byte offset, or -1 when it lies
+	// past the end of the line
+	offset := func(line string, character uint32) int {
+		if character > utilities.CharacterOffset(line, len(line), encoding) {
+			return -1
+		}
+		return utilities.ByteOffset(line, character, encoding)
+	}
+
 	// Handle single-line case
 	if startLine == endLine {
 		line := lines[startLine]
-		startChar := int(loc.Range.Start.Character)
-		endChar := int(loc.Range.End.Character)
+		startChar := offset(line, loc.Range.Start.Character)
+		endChar := offset(line, loc.Range.End.Character)
 
-		if startChar < 0 || startChar > len(line) || endChar < 0 || endChar > len(line) {
+		if startChar < 0 || endChar < 0 || endChar < startChar {
 			return "", fmt.Errorf("invalid character range: %v", loc.Range)
 		}
 
@@ -44,8 +65,8 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 
 	// First line
 	firstLine := lines[startLine]
-	startChar := int(loc.Range.Start.Character)
-	if startChar < 0 || startChar > len(firstLine) {
+	startChar := offset(firstLine, loc.Range.Start.Character)
+	if startChar < 0 {
 		return "", fmt.Errorf("invalid start character: %v", loc.Range.Start)
 	}
 	result.WriteString(firstLine[startChar:])
@@ -58,8 +79,8 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 
 	// Last line
 	lastLine := lines[endLine]
-	endChar := int(loc.Range.End.Character)
-	if endChar < 0 || endChar > len(lastLine) {
+	endChar := offset(lastLine, loc.Range.End.Character)
+	if endChar < 0 {
 		return "", fmt.Errorf("invalid end character: %v", loc.Range.End)
 	}
 	result.WriteString("\n")
@@ -83,87 +104,99 @@ func containsPosition(r protocol.Range, p protocol.Position) bool {
 
 // addLineNumbers adds line numbers to each line of text with proper padding, starting from startLine
 func addLineNumbers(text string, startLine int) string {
-	lines := strings.Split(text, "\n")
-	// Calculate padding width based on the number of digits in the last line number
-	lastLineNum := startLine + len(lines)
-	padding := len(strconv.Itoa(lastLineNum))
-
-	var result strings.Builder
-	for i, line := range lines {
-		// Format line number with padding and separator
-		lineNum := strconv.Itoa(startLine + i)
-		linePadding := strings.Repeat(" ", padding-len(lineNum))
-		result.WriteString(fmt.Sprintf("%s%s|%s\n", linePadding, lineNum, line))
-	}
-	return result.String()
+	return format.AddLineNumbers(text, startLine)
 }
 
 // LineRange represents a continuous range of lines to display
-type LineRange struct {
-	Start int
-	End   int
-}
+type LineRange = format.LineRange
 
 // ConvertLinesToRanges converts a set of lines to continuous ranges
 func ConvertLinesToRanges(linesToShow map[int]bool, totalLines int) []LineRange {
-	// Convert map to sorted slice
-	lineNumbers := make([]int, 0, len(linesToShow))
-	for line := range linesToShow {
-		if line >= 0 && line < totalLines {
-			lineNumbers = append(lineNumbers, line)
-		}
-	}
-	sort.Ints(lineNumbers)
+	return format.ConvertLinesToRanges(linesToShow, totalLines)
+}
+
+// FormatLinesWithRanges formats file content using line ranges
+func FormatLinesWithRanges(lines []string, ranges []LineRange) string {
+	return format.FormatLinesWithRanges(lines, ranges)
+}
 
-	// Group into ranges
-	var ranges []LineRange
-	if len(lineNumbers) == 0 {
-		return ranges
+// focusLines returns the 1-indexed start lines of locations, used as the
+// focus of a section so that context around them can be shrunk
+func focusLines(locations []protocol.Location) []int {
+	lines := make([]int, 0, len(locations))
+	for _, loc := range locations {
+		lines = append(lines, int(loc.Range.Start.Line)+1)
 	}
+	return lines
+}
 
-	currentRange := LineRange{Start: lineNumbers[0], End: lineNumbers[0]}
+// fileColumns converts between the 1-indexed columns shown to and given by
+// users, counted in runes the way editors count them, and the character offsets
+// of the position encoding the language server uses
+type fileColumns struct {
+	lines    []string
+	encoding protocol.PositionEncodingKind
+}
 
-	for i := 1; i < len(lineNumbers); i++ {
-		if lineNumbers[i] == currentRange.End+1 {
-			// Extend current range
-			currentRange.End = lineNumbers[i]
-		} else {
-			// Start new range
-			ranges = append(ranges, currentRange)
-			currentRange = LineRange{Start: lineNumbers[i], End: lineNumbers[i]}
-		}
+// readColumns reads the lines of a file for column conversions. Positions in
+// files that cannot be read keep their character offsets.
+func readColumns(path string, encoding protocol.PositionEncodingKind) fileColumns {
+	columns := fileColumns{encoding: encoding}
+	if content, err := textenc.ReadFile(path); err == nil {
+		columns.lines = strings.Split(content, "\n")
 	}
+	return columns
+}
 
-	// Add the last range
-	ranges = append(ranges, currentRange)
-	return ranges
+// at converts a 1-indexed line and column to a position
+func (c fileColumns) at(line, column int) protocol.Position {
+	position := protocol.Position{Line: uint32(max(line-1, 0)), Character: uint32(max(column-1, 0))}
+	if line >= 1 && line <= len(c.lines) {
+		position.Character = utilities.ColumnCharacter(c.lines[line-1], column, c.encoding)
+	}
+	return position
 }
 
-// FormatLinesWithRanges formats file content using line ranges
-func FormatLinesWithRanges(lines []string, ranges []LineRange) string {
-	if len(ranges) == 0 {
-		return ""
+// column returns the 1-indexed column of a position
+func (c fileColumns) column(pos protocol.Position) int {
+	if int(pos.Line) >= len(c.lines) {
+		return int(pos.Character) + 1
 	}
+	return utilities.CharacterColumn(c.lines[pos.Line], pos.Character, c.encoding)
+}
 
-	var result strings.Builder
-	lastEnd := -1
+// format formats a position as "L12:C6"
+func (c fileColumns) format(pos protocol.Position) string {
+	return fmt.Sprintf("L%d:C%d", pos.Line+1, c.column(pos))
+}
 
-	for _, r := range ranges {
-		// Add skipped lines indicator
-		if lastEnd != -1 && r.Start > lastEnd+1 {
-			// skipped := r.Start - lastEnd - 1
-			result.WriteString("...\n")
+// rangeMarks marks the ranges of locations in the lines of their file, so that
+// a highlight shows which token on a line each location is. A range over
+// several lines is marked to the end of its first line. Character offsets are
+// counted in the given position encoding.
+func rangeMarks(lines []string, locations []protocol.Location, encoding protocol.PositionEncodingKind) []format.Mark {
+	marks := make([]format.Mark, 0, len(locations))
+	for _, loc := range locations {
+		line := int(loc.Range.Start.Line)
+		if line >= len(lines) {
+			continue
 		}
-
-		// Extract lines for this range
-		rangeLines := lines[r.Start : r.End+1]
-
-		// Add line numbers using the existing function
-		numberedText := addLineNumbers(strings.Join(rangeLines, "\n"), r.Start+1)
-		result.WriteString(numberedText)
-
-		lastEnd = r.End
+		text := lines[line]
+		end := len([]rune(text))
+		if loc.Range.End.Line == loc.Range.Start.Line {
+			end = utilities.RuneOffset(text, loc.Range.End.Character, encoding)
+		}
+		marks = append(marks, format.Mark{
+			Line:  line + 1,
+			Start: utilities.RuneOffset(text, loc.Range.Start.Character, encoding),
+			End:   end,
+		})
 	}
+	return marks
+}
 
-	return result.String()
+// renderPlain renders a document in the original plain text format
+func renderPlain(doc format.Document) string {
+	renderer, _ := format.Get(format.Plain)
+	return renderer.Render(doc)
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -454,3 +455,54 @@ func TestFormatLinesWithRanges(t *testing.T) {
 		})
 	}
 }
+
+func TestRangeMarks(t *testing.T) {
+	lines := []string{"s := \"😀\" + name", "call(", "\targ)"}
+	at := func(startLine, startChar, endLine, endChar uint32) protocol.Location {
+		return protocol.Location{Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}}
+	}
+
+	marks := rangeMarks(lines, []protocol.Location{
+		// The emoji is two UTF-16 code units but one character
+		at(0, 11, 0, 15),
+		// A range over several lines is marked to the end of its first line
+		at(1, 0, 2, 4),
+		// Past the end of the file
+		at(7, 0, 7, 1),
+	}, protocol.UTF16)
+	assert.Equal(t, []format.Mark{{Line: 1, Start: 10, End: 14}, {Line: 2, Start: 0, End: 5}}, marks)
+
+	// In utf-8 the emoji is four bytes
+	marks = rangeMarks(lines, []protocol.Location{at(0, 13, 0, 17)}, protocol.UTF8)
+	assert.Equal(t, []format.Mark{{Line: 1, Start: 10, End: 14}}, marks)
+}
+
+func TestFileColumns(t *testing.T) {
+	lines := []string{"s := \"😀\" + name", "x"}
+
+	// Column 11 is the "n" of name, after the emoji
+	utf16 := fileColumns{lines: lines, encoding: protocol.UTF16}
+	assert.Equal(t, protocol.Position{Line: 0, Character: 11}, utf16.at(1, 11))
+	assert.Equal(t, "L1:C11", utf16.format(protocol.Position{Line: 0, Character: 11}))
+
+	utf8 := fileColumns{lines: lines, encoding: protocol.UTF8}
+	assert.Equal(t, protocol.Position{Line: 0, Character: 13}, utf8.at(1, 11))
+	assert.Equal(t, "L1:C11", utf8.format(protocol.Position{Line: 0, Character: 13}))
+
+	// Positions outside the lines keep their character offsets
+	assert.Equal(t, protocol.Position{Line: 4, Character: 2}, utf8.at(5, 3))
+	assert.Equal(t, "L5:C3", utf8.format(protocol.Position{Line: 4, Character: 2}))
+	assert.Equal(t, "L3:C4", fileColumns{}.format(protocol.Position{Line: 2, Character: 3}))
+}
+
+func TestSkipWalkDir(t *testing.T) {
+	for _, name := range []string{".git", ".venv", "node_modules", "vendor", "target", "__pycache__"} {
+		assert.True(t, skipWalkDir(name), name)
+	}
+	for _, name := range []string{"src", "internal", "vendored", "targets"} {
+		assert.False(t, skipWalkDir(name), name)
+	}
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+)
+
+// DefaultWarmupFiles is how many files a warmup opens when not told otherwise
+const DefaultWarmupFiles = 20
+
+// warmupClient is the part of the language server client a warmup uses
+type warmupClient interface {
+	resolve.SymbolSearcher
+	OpenFile(ctx context.Context, path string) error
+	LanguageID(path string) protocol.LanguageKind
+}
+
+// WarmupProgress describes a finished step of a warmup
+type WarmupProgress struct {
+	Step    int
+	Total   int
+	Message string
+}
+
+// Warmup gets the language server to index the workspace before the first
+// real queries: it opens the files of the entry points and the most recently
+// modified source files, up to maxFiles, and runs a broad workspace symbol
+// query. progress is called after each step. The result summarizes what was
+// done and how long it took.
+func Warmup(ctx context.Context, tc *ToolContext, maxFiles int, progress func(WarmupProgress)) (string, error) {
+	return warmup(ctx, tc.Client, tc.Resolver, tc.WorkspaceDir, maxFiles, progress)
+}
+
+func warmup(ctx context.Context, client warmupClient, resolver *resolve.Resolver, workspaceDir string, maxFiles int, progress func(WarmupProgress)) (string, error) {
+	started := time.Now()
+	var result strings.Builder
+	rel := func(path string) string {
+		if r, err := filepath.Rel(workspaceDir, path); err == nil {
+			return r
+		}
+		return path
+	}
+
+	// Finding the entry points already queries the whole workspace
+	var entryFiles []string
+	seen := make(map[string]bool)
+	entryPoints, err := resolver.EntryPoints(ctx, client)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		result.WriteString(fmt.Sprintf("Could not find entry points: %v\n", err))
+	}
+	for _, entryPoint := range entryPoints {
+		path := resolve.FilePath(entryPoint.Symbol.GetLocation().URI)
+		if len(entryFiles) >= (maxFiles+1)/2 {
+			break
+		}
+		if !seen[path] {
+			seen[path] = true
+			entryFiles = append(entryFiles, path)
+		}
+	}
+	recentFiles := recentlyModifiedFiles(workspaceDir, client, maxFiles-len(entryFiles), seen)
+
+	total := len(entryFiles) + len(recentFiles) + 2
+	step := 0
+	report := func(message string) {
+		step++
+		if progress != nil {
+			progress(WarmupProgress{Step: step, Total: total, Message: message})
+		}
+	}
+	report(fmt.Sprintf("Found %s and %s", pluralize(len(entryFiles), "entry point file"), pluralize(len(recentFiles), "recently modified file")))
+
+	opened := 0
+	open := func(label string, paths []string) error {
+		if len(paths) == 0 {
+			return nil
+		}
+		result.WriteString(label + ":\n")
+		for _, path := range paths {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := client.OpenFile(ctx, path); err != nil {
+				result.WriteString(fmt.Sprintf("  %s (could not open: %v)\n", rel(path), err))
+				report("Could not open " + rel(path))
+				continue
+			}
+			opened++
+			result.WriteString("  " + rel(path) + "\n")
+			report("Opened " + rel(path))
+		}
+		return nil
+	}
+	if err := open("Entry point files", entryFiles); err != nil {
+		return warmupStopped(&result, opened, started), nil
+	}
+	if err := open("Recently modified files", recentFiles); err != nil {
+		return warmupStopped(&result, opened, started), nil
+	}
+
+	queryStarted := time.Now()
+	symbols, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: ""})
+	if err != nil {
+		if ctx.Err() != nil {
+			return warmupStopped(&result, opened, started), nil
+		}
+		result.WriteString(fmt.Sprintf("Workspace symbol query failed: %v\n", err))
+		report("Workspace symbol query failed")
+	} else {
+		results, _ := symbols.Results()
+		message := fmt.Sprintf("Workspace symbol query returned %s in %s", pluralize(len(results), "symbol"), time.Since(queryStarted).Round(time.Millisecond))
+		result.WriteString(message + "\n")
+		report(message)
+	}
+
+	return fmt.Sprintf("Warmed up the workspace in %s: opened %s\n\n", time.Since(started).Round(time.Millisecond), pluralize(opened, "file")) + result.String(), nil
+}
+
+// warmupStopped describes a warmup cut short by its context
+func warmupStopped(result *strings.Builder, opened int, started time.Time) string {
+	return fmt.Sprintf("Warmup stopped after %s: opened %s\n\n", time.Since(started).Round(time.Millisecond), pluralize(opened, "file")) + result.String()
+}
+
+// recentlyModifiedFiles returns up to limit source files of the workspace, the
+// most recently modified first. Hidden and dependency directories and the
+// files in skip are left out.
+func recentlyModifiedFiles(workspaceDir string, client warmupClient, limit int, skip map[string]bool) []string {
+	if limit <= 0 {
+		return nil
+	}
+	type file struct {
+		path     string
+		modified time.Time
+	}
+	var files []file
+	_ = filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != workspaceDir && skipWalkDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip[path] || client.LanguageID(path) == "" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, file{path: path, modified: info.ModTime()})
+		return nil
+	})
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modified.After(files[j].modified)
+	})
+	paths := make([]string, 0, min(limit, len(files)))
+	for _, f := range files {
+		if len(paths) == limit {
+			break
+		}
+		paths = append(paths, f.path)
+	}
+	return paths
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWarmupClient answers workspace symbol queries from a map and records
+// the files it opens
+type fakeWarmupClient struct {
+	symbols map[string][]protocol.SymbolInformation
+	opened  []string
+	fail    string
+}
+
+func (f *fakeWarmupClient) Symbol(ctx context.Context, params protocol.WorkspaceSymbolParams) (protocol.Or_Result_workspace_symbol, error) {
+	return protocol.Or_Result_workspace_symbol{Value: f.symbols[params.Query]}, nil
+}
+
+func (f *fakeWarmupClient) OpenFile(ctx context.Context, path string) error {
+	if path == f.fail {
+		return errors.New("permission denied")
+	}
+	f.opened = append(f.opened, path)
+	return nil
+}
+
+func (f *fakeWarmupClient) LanguageID(path string) protocol.LanguageKind {
+	if strings.HasSuffix(path, ".go") {
+		return "go"
+	}
+	return ""
+}
+
+func TestWarmup(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "cmd", "server", "main.go")
+	writeFile(t, main, "package main\n\nfunc main() {}\n")
+	now := time.Now()
+	for i, name := range []string{"old.go", "newer.go", "newest.go", "notes.md", filepath.Join("vendor", "dep.go"), filepath.Join(".git", "hook.go")} {
+		path := filepath.Join(dir, "pkg", name)
+		writeFile(t, path, "package pkg\n")
+		modified := now.Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(path, modified, modified))
+	}
+
+	client := &fakeWarmupClient{
+		symbols: map[string][]protocol.SymbolInformation{
+			"main": {symbolAt("main", protocol.Function, main, 2)},
+			"":     {symbolAt("main", protocol.Function, main, 2), symbolAt("Run", protocol.Function, filepath.Join(dir, "pkg", "newest.go"), 0)},
+		},
+		fail: filepath.Join(dir, "pkg", "newer.go"),
+	}
+	var steps []WarmupProgress
+	text, err := warmup(context.Background(), client, resolve.New(settings.Default().SymbolMatch), dir, 4, func(p WarmupProgress) {
+		steps = append(steps, p)
+	})
+	require.NoError(t, err)
+
+	// The entry point file first, then the most recently modified source files
+	assert.Equal(t, []string{main, filepath.Join(dir, "pkg", "newest.go"), filepath.Join(dir, "pkg", "old.go")}, client.opened)
+	assert.Contains(t, text, "opened 3 files")
+	assert.Contains(t, text, "Entry point files:\n  "+filepath.Join("cmd", "server", "main.go")+"\n")
+	assert.Contains(t, text, filepath.Join("pkg", "newer.go")+" (could not open: permission denied)")
+	assert.Contains(t, text, "Workspace symbol query returned 2 symbols")
+
+	require.Len(t, steps, 6)
+	assert.Equal(t, WarmupProgress{Step: 1, Total: 6, Message: "Found 1 entry point file and 3 recently modified files"}, steps[0])
+	assert.Equal(t, 6, steps[5].Step)
+	assert.Contains(t, steps[5].Message, "Workspace symbol query returned 2 symbols")
+}
+
+func TestWarmupCancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := &fakeWarmupClient{}
+	text, err := warmup(ctx, client, resolve.New(settings.Default().SymbolMatch), dir, 4, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(text, "Warmup stopped after"), text)
+	assert.Empty(t, client.opened)
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DiagnosticsUpdate is a single diagnostics publication for a watched file
+type DiagnosticsUpdate struct {
+	FilePath    string
+	Elapsed     time.Duration
+	Diagnostics []protocol.Diagnostic
+}
+
+// Summary returns a short description of the update, e.g. "2 errors, 1 warning"
+func (u DiagnosticsUpdate) Summary() string {
+	return summarizeSeverities(u.Diagnostics)
+}
+
+// Positions returns the "L12:C6" position of each diagnostic, with columns
+// counted in runes rather than in the given position encoding
+func (u DiagnosticsUpdate) Positions(encoding protocol.PositionEncodingKind) []string {
+	columns := readColumns(u.FilePath, encoding)
+	positions := make([]string, len(u.Diagnostics))
+	for i, diag := range u.Diagnostics {
+		positions[i] = columns.format(diag.Range.Start)
+	}
+	return positions
+}
+
+// summarizeSeverities counts diagnostics by severity
+func summarizeSeverities(diagnostics []protocol.Diagnostic) string {
+	if len(diagnostics) == 0 {
+		return "no diagnostics"
+	}
+
+	counts := make(map[protocol.DiagnosticSeverity]int)
+	for _, diag := range diagnostics {
+		counts[diag.Severity]++
+	}
+
+	var parts []string
+	for _, severity := range []struct {
+		severity protocol.DiagnosticSeverity
+		noun     string
+	}{
+		{protocol.SeverityError, "error"},
+		{protocol.SeverityWarning, "warning"},
+		{protocol.SeverityInformation, "info message"},
+		{protocol.SeverityHint, "hint"},
+	} {
+		if count := counts[severity.severity]; count > 0 {
+			parts = append(parts, pluralize(count, severity.noun))
+		}
+	}
+	if count := counts[0]; count > 0 {
+		parts = append(parts, fmt.Sprintf("%d unknown", count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WatchDiagnostics opens the given files and reports diagnostics as the language
+// server publishes them until the duration elapses or the context is cancelled.
+// emit is called for every update, one at a time and in order, so that clients
+// supporting notifications can show live feedback. The returned text is a timeline of updates followed by the
+// latest diagnostics for each file.
+func WatchDiagnostics(ctx context.Context, client *lsp.Client, filePaths []string, duration time.Duration, emit func(DiagnosticsUpdate)) (string, error) {
+	if len(filePaths) == 0 {
+		return "", fmt.Errorf("at least one file path is required")
+	}
+
+	watched := make(map[protocol.DocumentUri]string, len(filePaths))
+	for _, path := range filePaths {
+		watched[protocol.URIFromPath(path)] = path
+	}
+
+	var mu sync.Mutex
+	var timeline []DiagnosticsUpdate
+	stopped := false
+	start := time.Now()
+
+	// Subscribe before opening files so the initial publication is not missed
+	unsubscribe := client.SubscribeDiagnostics(func(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) {
+		path, ok := watched[uri]
+		if !ok {
+			return
+		}
+		update := DiagnosticsUpdate{
+			FilePath:    path,
+			Elapsed:     time.Since(start),
+			Diagnostics: diagnostics,
+		}
+
+		// Publications are handled concurrently, emit runs under the lock
+		// so that it sees updates one at a time
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		timeline = append(timeline, update)
+		if emit != nil {
+			emit(update)
+		}
+	})
+	defer unsubscribe()
+
+	for _, path := range filePaths {
+		if err := client.OpenFile(ctx, path); err != nil {
+			return "", fmt.Errorf("could not open file %s: %w", path, err)
+		}
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	stopped = true
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Watched %d files for %.1fs\nUpdates received: %d\n", len(filePaths), time.Since(start).Seconds(), len(timeline)))
+
+	if len(timeline) > 0 {
+		result.WriteString("\nTimeline:\n")
+		for _, update := range timeline {
+			result.WriteString(fmt.Sprintf("+%.1fs %s: %s\n", update.Elapsed.Seconds(), update.FilePath, update.Summary()))
+		}
+	}
+
+	sortedPaths := make([]string, len(filePaths))
+	copy(sortedPaths, filePaths)
+	sort.Strings(sortedPaths)
+
+	result.WriteString("\nCurrent diagnostics:\n")
+	for _, path := range sortedPaths {
+		diagnostics := client.GetFileDiagnostics(protocol.URIFromPath(path))
+		result.WriteString(fmt.Sprintf("---\n\n%s\nDiagnostics in File: %d\n", path, len(diagnostics)))
+		columns := readColumns(path, client.PositionEncoding())
+		for _, diag := range diagnostics {
+			result.WriteString(formatDiagnostic(diag, columns) + "\n")
+		}
+	}
+
+	return result.String(), nil
+}
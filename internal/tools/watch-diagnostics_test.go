@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeSeverities(t *testing.T) {
+	assert.Equal(t, "no diagnostics", summarizeSeverities(nil))
+
+	diagnostics := []protocol.Diagnostic{
+		{Severity: protocol.SeverityWarning},
+		{Severity: protocol.SeverityError},
+		{Severity: protocol.SeverityError},
+		{Severity: protocol.SeverityHint},
+		{Severity: protocol.SeverityInformation},
+		{Severity: protocol.SeverityInformation},
+	}
+	assert.Equal(t, "2 errors, 1 warning, 2 info messages, 1 hint", summarizeSeverities(diagnostics))
+}
@@ -6,9 +6,11 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
 )
 
 var (
@@ -20,16 +22,98 @@ var (
 	osRename    = os.Rename
 )
 
-// ApplyTextEdits applies a sequence of text edits to a file specified by URI
+// WriteObserver is told about every file changed by the functions in this file.
+// before is nil for created files and after is nil for deleted files.
+type WriteObserver func(path string, before, after []byte)
+
+var (
+	writeObservers   = make(map[int]WriteObserver)
+	nextObserverID   int
+	writeObserversMu sync.RWMutex
+)
+
+// ObserveWrites registers an observer for file changes and returns a function
+// that unregisters it
+func ObserveWrites(observer WriteObserver) func() {
+	writeObserversMu.Lock()
+	defer writeObserversMu.Unlock()
+	id := nextObserverID
+	nextObserverID++
+	writeObservers[id] = observer
+	return func() {
+		writeObserversMu.Lock()
+		defer writeObserversMu.Unlock()
+		delete(writeObservers, id)
+	}
+}
+
+// observing reports whether any observer is registered, so that content is
+// only read for observers
+func observing() bool {
+	writeObserversMu.RLock()
+	defer writeObserversMu.RUnlock()
+	return len(writeObservers) > 0
+}
+
+// notifyWrite tells the observers about a changed file
+func notifyWrite(path string, before, after []byte) {
+	writeObserversMu.RLock()
+	defer writeObserversMu.RUnlock()
+	for _, observer := range writeObservers {
+		observer(path, before, after)
+	}
+}
+
+// currentContent returns the content of a file, or nil when there is none or
+// nobody is observing writes
+func currentContent(path string) []byte {
+	if !observing() {
+		return nil
+	}
+	content, err := osReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// WriteFile replaces the content of a file, creating it if needed, and tells
+// the write observers
+func WriteFile(path string, content []byte) error {
+	before := currentContent(path)
+	if err := osWriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	notifyWrite(path, before, content)
+	return nil
+}
+
+// RemoveFile deletes a file and tells the write observers
+func RemoveFile(path string) error {
+	before := currentContent(path)
+	if err := osRemove(path); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	notifyWrite(path, before, nil)
+	return nil
+}
+
+// ApplyTextEdits applies a sequence of text edits to a file specified by URI.
+// Character offsets are counted in bytes, as in the utf-8 position encoding.
 func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
-	path := strings.TrimPrefix(string(uri), "file://")
+	path := protocol.PathFromURI(string(uri))
 
 	// Read the file content
-	content, err := osReadFile(path)
+	data, err := osReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// Edit the UTF-8 text the language server saw and write it back in the
+	// file's own encoding
+	text, enc := textenc.Decode(data)
+	content := []byte(text)
+
 	// Detect line ending style
 	var lineEnding string
 	if bytes.Contains(content, []byte("\r\n")) {
@@ -86,9 +170,15 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := osWriteFile(path, []byte(newContent.String()), 0644); err != nil {
+	encoded, err := enc.Encode(newContent.String())
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	if err := osWriteFile(path, encoded, 0644); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
+	notifyWrite(path, data, encoded)
 
 	return nil
 }
@@ -173,7 +263,7 @@ func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([
 // ApplyDocumentChange applies a DocumentChange (create/rename/delete operations)
 func ApplyDocumentChange(change protocol.DocumentChange) error {
 	if change.CreateFile != nil {
-		path := strings.TrimPrefix(string(change.CreateFile.URI), "file://")
+		path := protocol.PathFromURI(string(change.CreateFile.URI))
 		if change.CreateFile.Options != nil {
 			if change.CreateFile.Options.Overwrite {
 				// Proceed with overwrite
@@ -183,13 +273,16 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 				}
 			}
 		}
+		before := currentContent(path)
 		if err := osWriteFile(path, []byte(""), 0644); err != nil {
 			return fmt.Errorf("failed to create file: %w", err)
 		}
+		notifyWrite(path, before, []byte(""))
 	}
 
 	if change.DeleteFile != nil {
-		path := strings.TrimPrefix(string(change.DeleteFile.URI), "file://")
+		path := protocol.PathFromURI(string(change.DeleteFile.URI))
+		before := currentContent(path)
 		if change.DeleteFile.Options != nil && change.DeleteFile.Options.Recursive {
 			if err := osRemoveAll(path); err != nil {
 				return fmt.Errorf("failed to delete directory recursively: %w", err)
@@ -199,11 +292,12 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 				return fmt.Errorf("failed to delete file: %w", err)
 			}
 		}
+		notifyWrite(path, before, nil)
 	}
 
 	if change.RenameFile != nil {
-		oldPath := strings.TrimPrefix(string(change.RenameFile.OldURI), "file://")
-		newPath := strings.TrimPrefix(string(change.RenameFile.NewURI), "file://")
+		oldPath := protocol.PathFromURI(string(change.RenameFile.OldURI))
+		newPath := protocol.PathFromURI(string(change.RenameFile.NewURI))
 		if change.RenameFile.Options != nil {
 			if !change.RenameFile.Options.Overwrite {
 				if _, err := osStat(newPath); err == nil {
@@ -211,9 +305,12 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 				}
 			}
 		}
+		content := currentContent(oldPath)
 		if err := osRename(oldPath, newPath); err != nil {
 			return fmt.Errorf("failed to rename file: %w", err)
 		}
+		notifyWrite(oldPath, content, nil)
+		notifyWrite(newPath, nil, content)
 	}
 
 	if change.TextDocumentEdit != nil {
@@ -231,7 +328,9 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	return nil
 }
 
-// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem
+// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem. Its
+// positions are in utf-8; WorkspaceEditToUTF8 converts edits from servers that
+// use another position encoding.
 func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	// Handle Changes field
 	for uri, textEdits := range edit.Changes {
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"os"
 	"reflect"
-	"strings"
 	"testing"
 	"time"
 
@@ -471,6 +470,68 @@ func TestApplyTextEdits(t *testing.T) {
 		expectErr  bool
 		setupMocks func(*mockFileSystem)
 	}{
+		{
+			name:    "Percent-encoded non-ASCII file name",
+			uri:     "file:///test/%E6%96%87%E4%BB%B6%20%F0%9F%9A%80.txt",
+			content: "This is a test line",
+			edits: []protocol.TextEdit{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 0, Character: 5},
+						End:   protocol.Position{Line: 0, Character: 9},
+					},
+					NewText: "was",
+				},
+			},
+			expected:  "This was test line",
+			expectErr: false,
+			setupMocks: func(mfs *mockFileSystem) {
+				mfs.files = map[string][]byte{
+					"/test/文件 🚀.txt": []byte("This is a test line"),
+				}
+			},
+		},
+		{
+			name:    "Latin-1 file keeps its encoding",
+			uri:     "file:///test/legacy.c",
+			content: "/* caf\xe9 */ int x;",
+			edits: []protocol.TextEdit{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 0, Character: 12},
+						End:   protocol.Position{Line: 0, Character: 18},
+					},
+					NewText: "int résumé;",
+				},
+			},
+			expected:  "/* caf\xe9 */ int r\xe9sum\xe9;",
+			expectErr: false,
+			setupMocks: func(mfs *mockFileSystem) {
+				mfs.files = map[string][]byte{
+					"/test/legacy.c": []byte("/* caf\xe9 */ int x;"),
+				}
+			},
+		},
+		{
+			name:    "Text that cannot be written in the file's encoding",
+			uri:     "file:///test/legacy.c",
+			content: "/* caf\xe9 */",
+			edits: []protocol.TextEdit{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 0, Character: 3},
+						End:   protocol.Position{Line: 0, Character: 8},
+					},
+					NewText: "日本語",
+				},
+			},
+			expectErr: true,
+			setupMocks: func(mfs *mockFileSystem) {
+				mfs.files = map[string][]byte{
+					"/test/legacy.c": []byte("/* caf\xe9 */"),
+				}
+			},
+		},
 		{
 			name:    "Single edit - replace text",
 			uri:     "file:///test/file.txt",
@@ -653,7 +714,7 @@ func TestApplyTextEdits(t *testing.T) {
 				if err != nil {
 					t.Errorf("Unexpected error: %v", err)
 				} else {
-					path := strings.TrimPrefix(string(tt.uri), "file://")
+					path := protocol.PathFromURI(string(tt.uri))
 					if content, ok := mfs.files[path]; ok {
 						if string(content) != tt.expected {
 							t.Errorf("applyTextEdits() result = %q, want %q", string(content), tt.expected)
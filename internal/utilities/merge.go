@@ -0,0 +1,107 @@
+package utilities
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+)
+
+// MergeWorkspaceEdits combines the workspace edits several language servers
+// returned for the same operation into one edit. Text edits are grouped per
+// file and identical edits, e.g. two servers renaming the same occurrence, are
+// kept once. Overlapping edits that differ are conflicts and are reported
+// without merging anything. File operations (create, rename, delete) are only
+// accepted when a single edit is non-empty, because their order relative to
+// the text edits of other servers is unknown.
+func MergeWorkspaceEdits(edits ...protocol.WorkspaceEdit) (protocol.WorkspaceEdit, error) {
+	var nonEmpty []protocol.WorkspaceEdit
+	for _, edit := range edits {
+		if len(edit.Changes) > 0 || len(edit.DocumentChanges) > 0 {
+			nonEmpty = append(nonEmpty, edit)
+		}
+	}
+	switch len(nonEmpty) {
+	case 0:
+		return protocol.WorkspaceEdit{}, nil
+	case 1:
+		return nonEmpty[0], nil
+	}
+
+	merged := make(map[protocol.DocumentUri][]protocol.TextEdit)
+	for _, edit := range nonEmpty {
+		for uri, textEdits := range edit.Changes {
+			for _, textEdit := range textEdits {
+				if err := addTextEdit(merged, uri, textEdit); err != nil {
+					return protocol.WorkspaceEdit{}, err
+				}
+			}
+		}
+		for _, change := range edit.DocumentChanges {
+			if change.TextDocumentEdit == nil {
+				return protocol.WorkspaceEdit{}, fmt.Errorf("cannot merge file operations from several language servers")
+			}
+			uri := change.TextDocumentEdit.TextDocument.URI
+			for _, elem := range change.TextDocumentEdit.Edits {
+				textEdit, err := elem.AsTextEdit()
+				if err != nil {
+					return protocol.WorkspaceEdit{}, fmt.Errorf("unsupported edit in %s: %v", uri, err)
+				}
+				if err := addTextEdit(merged, uri, textEdit); err != nil {
+					return protocol.WorkspaceEdit{}, err
+				}
+			}
+		}
+	}
+
+	return protocol.WorkspaceEdit{Changes: merged}, nil
+}
+
+// addTextEdit adds an edit to a file unless the file already has the same edit,
+// failing when it overlaps a different edit
+func addTextEdit(merged map[protocol.DocumentUri][]protocol.TextEdit, uri protocol.DocumentUri, edit protocol.TextEdit) error {
+	for _, existing := range merged[uri] {
+		if existing.Range == edit.Range && existing.NewText == edit.NewText {
+			return nil
+		}
+		if editsOverlap(existing.Range, edit.Range) {
+			path := protocol.PathFromURI(string(uri))
+			return fmt.Errorf("conflicting edits in %s at %s and %s", path, formatRange(path, existing.Range), formatRange(path, edit.Range))
+		}
+	}
+	merged[uri] = append(merged[uri], edit)
+	return nil
+}
+
+// editsOverlap reports whether two edit ranges touch the same text. Unlike
+// RangesOverlap, ranges that only meet at a position do not overlap, except
+// for two insertions at the same position whose order would be ambiguous.
+func editsOverlap(a, b protocol.Range) bool {
+	if a.Start == b.Start {
+		return true
+	}
+	return comparePosition(a.Start, b.End) < 0 && comparePosition(b.Start, a.End) < 0
+}
+
+func comparePosition(a, b protocol.Position) int {
+	if a.Line != b.Line {
+		return int(a.Line) - int(b.Line)
+	}
+	return int(a.Character) - int(b.Character)
+}
+
+// formatRange formats a utf-8 range of a file with columns counted in runes
+func formatRange(path string, r protocol.Range) string {
+	var lines []string
+	if content, err := textenc.ReadFile(path); err == nil {
+		lines = strings.Split(content, "\n")
+	}
+	column := func(pos protocol.Position) int {
+		if int(pos.Line) >= len(lines) {
+			return int(pos.Character) + 1
+		}
+		return CharacterColumn(lines[pos.Line], pos.Character, protocol.UTF8)
+	}
+	return fmt.Sprintf("L%d:C%d-L%d:C%d", r.Start.Line+1, column(r.Start), r.End.Line+1, column(r.End))
+}
@@ -0,0 +1,76 @@
+package utilities
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func textEdit(line, startChar, endChar uint32, newText string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: startChar},
+			End:   protocol.Position{Line: line, Character: endChar},
+		},
+		NewText: newText,
+	}
+}
+
+func documentEdit(uri protocol.DocumentUri, edits ...protocol.TextEdit) protocol.DocumentChange {
+	change := protocol.DocumentChange{TextDocumentEdit: &protocol.TextDocumentEdit{}}
+	change.TextDocumentEdit.TextDocument.URI = uri
+	for _, edit := range edits {
+		change.TextDocumentEdit.Edits = append(change.TextDocumentEdit.Edits, protocol.Or_TextDocumentEdit_edits_Elem{Value: edit})
+	}
+	return change
+}
+
+func TestMergeWorkspaceEdits(t *testing.T) {
+	goFile := protocol.DocumentUri("file:///ws/api/types.go")
+	tsFile := protocol.DocumentUri("file:///ws/web/bindings.ts")
+
+	fromGo := protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		goFile: {textEdit(4, 5, 11, "Client")},
+	}}
+	fromTS := protocol.WorkspaceEdit{DocumentChanges: []protocol.DocumentChange{
+		documentEdit(tsFile, textEdit(1, 17, 23, "Client"), textEdit(9, 8, 14, "Client")),
+	}}
+
+	merged, err := MergeWorkspaceEdits(fromGo, fromTS)
+	assert.NoError(t, err)
+	assert.Equal(t, []protocol.TextEdit{textEdit(4, 5, 11, "Client")}, merged.Changes[goFile])
+	assert.Len(t, merged.Changes[tsFile], 2)
+
+	// Both servers editing the same occurrence the same way is not a conflict
+	fromBoth := protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		goFile: {textEdit(4, 5, 11, "Client"), textEdit(4, 11, 11, "")},
+	}}
+	merged, err = MergeWorkspaceEdits(fromGo, fromBoth)
+	assert.NoError(t, err)
+	assert.Len(t, merged.Changes[goFile], 2, "an edit starting where another ends does not overlap")
+
+	// Different text for the same range is
+	conflicting := protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		goFile: {textEdit(4, 5, 11, "Customer")},
+	}}
+	_, err = MergeWorkspaceEdits(fromGo, conflicting)
+	assert.ErrorContains(t, err, "conflicting edits in /ws/api/types.go at L5:C6-L5:C12 and L5:C6-L5:C12")
+}
+
+func TestMergeWorkspaceEditsFileOperations(t *testing.T) {
+	rename := protocol.WorkspaceEdit{DocumentChanges: []protocol.DocumentChange{
+		{RenameFile: &protocol.RenameFile{OldURI: "file:///ws/a.go", NewURI: "file:///ws/b.go"}},
+	}}
+
+	// A single edit is returned as it is
+	merged, err := MergeWorkspaceEdits(rename, protocol.WorkspaceEdit{})
+	assert.NoError(t, err)
+	assert.Equal(t, rename, merged)
+
+	other := protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+		"file:///ws/c.ts": {textEdit(0, 0, 1, "x")},
+	}}
+	_, err = MergeWorkspaceEdits(rename, other)
+	assert.ErrorContains(t, err, "cannot merge file operations")
+}
@@ -0,0 +1,164 @@
+package utilities
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/textenc"
+)
+
+// Positions sent to and received from a language server count characters in
+// the position encoding negotiated during initialize: bytes for utf-8, UTF-16
+// code units for utf-16, the LSP default, and runes for utf-32. The text read
+// from files is UTF-8, so offsets into it are converted with these functions.
+
+// unitLength returns how many units of the encoding a rune takes
+func unitLength(r rune, encoding protocol.PositionEncodingKind) int {
+	switch encoding {
+	case protocol.UTF8:
+		return utf8.RuneLen(r)
+	case protocol.UTF32:
+		return 1
+	default:
+		if r >= 0x10000 {
+			return 2
+		}
+		return 1
+	}
+}
+
+// ByteOffset converts a character offset in a line, counted in the given
+// position encoding, to a byte offset in the line. Offsets past the end of the
+// line give its length.
+func ByteOffset(line string, character uint32, encoding protocol.PositionEncodingKind) int {
+	if encoding == protocol.UTF8 {
+		return min(int(character), len(line))
+	}
+	units := 0
+	for i, r := range line {
+		if units >= int(character) {
+			return i
+		}
+		units += unitLength(r, encoding)
+	}
+	return len(line)
+}
+
+// RuneOffset converts a character offset in a line, counted in the given
+// position encoding, to an offset in the runes of the line
+func RuneOffset(line string, character uint32, encoding protocol.PositionEncodingKind) int {
+	return utf8.RuneCountInString(line[:ByteOffset(line, character, encoding)])
+}
+
+// CharacterOffset converts a byte offset in a line to a character offset
+// counted in the given position encoding
+func CharacterOffset(line string, byteOffset int, encoding protocol.PositionEncodingKind) uint32 {
+	byteOffset = min(max(byteOffset, 0), len(line))
+	if encoding == protocol.UTF8 {
+		return uint32(byteOffset)
+	}
+	units := 0
+	for _, r := range line[:byteOffset] {
+		units += unitLength(r, encoding)
+	}
+	return uint32(units)
+}
+
+// ColumnCharacter converts a 1-indexed column, counted in runes the way
+// editors count columns, to a character offset counted in the given position
+// encoding. Columns past the end of the line keep their distance to it.
+func ColumnCharacter(line string, column int, encoding protocol.PositionEncodingKind) uint32 {
+	runes := max(column-1, 0)
+	offset := 0
+	for offset < len(line) && runes > 0 {
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+		runes--
+	}
+	return CharacterOffset(line, offset, encoding) + uint32(runes)
+}
+
+// CharacterColumn converts a character offset counted in the given position
+// encoding to a 1-indexed column counted in runes
+func CharacterColumn(line string, character uint32, encoding protocol.PositionEncodingKind) int {
+	column := RuneOffset(line, character, encoding) + 1
+	if end := CharacterOffset(line, len(line), encoding); character > end {
+		column += int(character - end)
+	}
+	return column
+}
+
+// WorkspaceEditToUTF8 converts the ranges of a workspace edit from the given
+// position encoding to utf-8, the encoding ApplyWorkspaceEdit and
+// MergeWorkspaceEdits work in. Edits to files that cannot be read, such as
+// files the edit creates, are kept as they are.
+func WorkspaceEditToUTF8(edit protocol.WorkspaceEdit, encoding protocol.PositionEncodingKind) (protocol.WorkspaceEdit, error) {
+	if encoding == protocol.UTF8 {
+		return edit, nil
+	}
+	converted := protocol.WorkspaceEdit{ChangeAnnotations: edit.ChangeAnnotations}
+	if edit.Changes != nil {
+		converted.Changes = make(map[protocol.DocumentUri][]protocol.TextEdit, len(edit.Changes))
+		for uri, textEdits := range edit.Changes {
+			lines := fileLines(uri)
+			converted.Changes[uri] = make([]protocol.TextEdit, len(textEdits))
+			for i, textEdit := range textEdits {
+				textEdit.Range = rangeToUTF8(lines, textEdit.Range, encoding)
+				converted.Changes[uri][i] = textEdit
+			}
+		}
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			converted.DocumentChanges = append(converted.DocumentChanges, change)
+			continue
+		}
+		documentEdit := *change.TextDocumentEdit
+		lines := fileLines(documentEdit.TextDocument.URI)
+		documentEdit.Edits = make([]protocol.Or_TextDocumentEdit_edits_Elem, len(change.TextDocumentEdit.Edits))
+		for j, elem := range change.TextDocumentEdit.Edits {
+			switch v := elem.Value.(type) {
+			case protocol.TextEdit:
+				v.Range = rangeToUTF8(lines, v.Range, encoding)
+				elem.Value = v
+			case protocol.AnnotatedTextEdit:
+				v.Range = rangeToUTF8(lines, v.Range, encoding)
+				elem.Value = v
+			case protocol.SnippetTextEdit:
+				v.Range = rangeToUTF8(lines, v.Range, encoding)
+				elem.Value = v
+			default:
+				return protocol.WorkspaceEdit{}, fmt.Errorf("invalid edit type: %T", elem.Value)
+			}
+			documentEdit.Edits[j] = elem
+		}
+		change.TextDocumentEdit = &documentEdit
+		converted.DocumentChanges = append(converted.DocumentChanges, change)
+	}
+	return converted, nil
+}
+
+// fileLines returns the lines of a file as the language server sees them, or
+// nil when it cannot be read
+func fileLines(uri protocol.DocumentUri) []string {
+	content, err := textenc.ReadFile(protocol.PathFromURI(string(uri)))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+}
+
+// rangeToUTF8 converts a range in the lines of a file to utf-8. Positions on
+// lines the file does not have are kept as they are.
+func rangeToUTF8(lines []string, r protocol.Range, encoding protocol.PositionEncodingKind) protocol.Range {
+	convert := func(position protocol.Position) protocol.Position {
+		if int(position.Line) >= len(lines) {
+			return position
+		}
+		position.Character = uint32(ByteOffset(lines[position.Line], position.Character, encoding))
+		return position
+	}
+	return protocol.Range{Start: convert(r.Start), End: convert(r.End)}
+}
@@ -0,0 +1,104 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionOffsets(t *testing.T) {
+	// é is two bytes and one UTF-16 unit, 😀 is four bytes and two UTF-16 units
+	line := "é😀x"
+
+	tests := []struct {
+		encoding  protocol.PositionEncodingKind
+		character uint32
+		byteIdx   int
+		runeIdx   int
+	}{
+		{protocol.UTF8, 0, 0, 0},
+		{protocol.UTF8, 2, 2, 1},
+		{protocol.UTF8, 6, 6, 2},
+		{protocol.UTF8, 99, 7, 3},
+		{protocol.UTF16, 1, 2, 1},
+		{protocol.UTF16, 3, 6, 2},
+		{protocol.UTF16, 4, 7, 3},
+		{protocol.UTF32, 2, 6, 2},
+		// Servers that chose no encoding use utf-16
+		{"", 3, 6, 2},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.byteIdx, ByteOffset(line, tt.character, tt.encoding), "%s %d", tt.encoding, tt.character)
+		assert.Equal(t, tt.runeIdx, RuneOffset(line, tt.character, tt.encoding), "%s %d", tt.encoding, tt.character)
+		if tt.character <= CharacterOffset(line, len(line), tt.encoding) {
+			assert.Equal(t, tt.character, CharacterOffset(line, tt.byteIdx, tt.encoding), "%s %d", tt.encoding, tt.character)
+		}
+	}
+	assert.Equal(t, uint32(4), CharacterOffset(line, 100, protocol.UTF16))
+}
+
+func TestColumns(t *testing.T) {
+	line := "é😀x"
+
+	tests := []struct {
+		encoding  protocol.PositionEncodingKind
+		column    int
+		character uint32
+	}{
+		{protocol.UTF8, 1, 0},
+		{protocol.UTF8, 3, 6},
+		{protocol.UTF8, 4, 7},
+		{protocol.UTF16, 3, 3},
+		{protocol.UTF16, 4, 4},
+		{protocol.UTF32, 3, 2},
+		// Columns past the end of the line keep their distance to it
+		{protocol.UTF8, 6, 9},
+		{protocol.UTF16, 6, 6},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.character, ColumnCharacter(line, tt.column, tt.encoding), "%s %d", tt.encoding, tt.column)
+		assert.Equal(t, tt.column, CharacterColumn(line, tt.character, tt.encoding), "%s %d", tt.encoding, tt.character)
+	}
+	assert.Equal(t, uint32(0), ColumnCharacter(line, 0, protocol.UTF8))
+}
+
+func TestWorkspaceEditToUTF8(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	assert.NoError(t, os.WriteFile(path, []byte("package main\r\n\r\nvar s = \"😀\" + old\r\n"), 0644))
+	uri := protocol.URIFromPath(path)
+	created := protocol.URIFromPath(filepath.Join(dir, "new.go"))
+
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			uri: {textEdit(2, 15, 18, "name")},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{CreateFile: &protocol.CreateFile{Kind: "create", URI: created}},
+			documentEdit(uri, textEdit(2, 15, 18, "name")),
+			documentEdit(created, textEdit(0, 0, 0, "package main\n")),
+		},
+	}
+	converted, err := WorkspaceEditToUTF8(edit, protocol.UTF16)
+	assert.NoError(t, err)
+	assert.Equal(t, []protocol.TextEdit{textEdit(2, 17, 20, "name")}, converted.Changes[uri])
+	assert.Equal(t, edit.DocumentChanges[0], converted.DocumentChanges[0])
+	assert.Equal(t, documentEdit(uri, textEdit(2, 17, 20, "name")), converted.DocumentChanges[1])
+	// Files that do not exist yet are left as they are
+	assert.Equal(t, edit.DocumentChanges[2], converted.DocumentChanges[2])
+	// The edit itself is not changed
+	assert.Equal(t, textEdit(2, 15, 18, "name"), edit.Changes[uri][0])
+
+	same, err := WorkspaceEditToUTF8(edit, protocol.UTF8)
+	assert.NoError(t, err)
+	assert.Equal(t, edit, same)
+
+	// Applying the converted edit replaces the name after the emoji
+	assert.NoError(t, ApplyTextEdits(uri, converted.Changes[uri]))
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\r\n\r\nvar s = \"😀\" + name\r\n", string(content))
+}
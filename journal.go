@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/journal"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionJournals keeps the edit journal of each client session
+type sessionJournals struct {
+	journals map[string]*journal.Journal
+	mu       sync.Mutex
+}
+
+func newSessionJournals() *sessionJournals {
+	return &sessionJournals{journals: make(map[string]*journal.Journal)}
+}
+
+// Get returns the journal of a session, creating it on first use
+func (s *sessionJournals) Get(session string) *journal.Journal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.journals[session]
+	if !ok {
+		j = journal.New()
+		s.journals[session] = j
+	}
+	return j
+}
+
+// Forget drops the journal of a session that has disconnected
+func (s *sessionJournals) Forget(ctx context.Context, session server.ClientSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.journals, session.SessionID())
+}
+
+// Run runs a mutating tool call, recording the files the mutation writes in
+// the session's journal
+func (s *sessionJournals) Run(m *mutation, session, tool string, call func() (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	j := s.Get(session)
+	m.Observe(func(path string, before, after []byte) {
+		j.Record(tool, path, before, after)
+	})
+	return call()
+}
+
+// journalMiddleware records the files changed by mutating tool calls so that
+// sessions can restore them to a snapshot
+func (s *mcpServer) journalMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		m := currentMutation(ctx)
+		if m == nil {
+			return next(ctx, request)
+		}
+		return s.journals.Run(m, sessionID(ctx), request.Params.Name, func() (*mcp.CallToolResult, error) {
+			return next(ctx, request)
+		})
+	}
+}
+
+// relativePath shows a path relative to the workspace when it is inside it
+func relativePath(workspaceDir, path string) string {
+	if rel, err := filepath.Rel(workspaceDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// formatSnapshots lists the snapshots of a session for list_snapshots
+func formatSnapshots(workspaceDir string, snapshots []journal.Snapshot) string {
+	if len(snapshots) == 0 {
+		return "No snapshots in this session. Take one with snapshot_workspace before changing files."
+	}
+	var b strings.Builder
+	for _, snapshot := range snapshots {
+		b.WriteString(fmt.Sprintf("%s (taken %s), files changed since: %d\n", snapshot.Name, snapshot.Time.Format("15:04:05"), len(snapshot.Changed)))
+		for _, path := range snapshot.Changed {
+			b.WriteString("  " + relativePath(workspaceDir, path) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatRestore describes what restoring to a snapshot did
+func formatRestore(workspaceDir, name string, result journal.RestoreResult) string {
+	if len(result.Restored)+len(result.Deleted)+len(result.Skipped) == 0 {
+		return fmt.Sprintf("Nothing to restore: no files changed since snapshot %s", name)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Restored to snapshot %s (files restored: %d, deleted: %d, skipped: %d)\n",
+		name, len(result.Restored), len(result.Deleted), len(result.Skipped)))
+	for _, path := range result.Restored {
+		b.WriteString("  restored " + relativePath(workspaceDir, path) + "\n")
+	}
+	for _, path := range result.Deleted {
+		b.WriteString("  deleted " + relativePath(workspaceDir, path) + "\n")
+	}
+	skipped := make([]string, 0, len(result.Skipped))
+	for path := range result.Skipped {
+		skipped = append(skipped, path)
+	}
+	sort.Strings(skipped)
+	for _, path := range skipped {
+		b.WriteString(fmt.Sprintf("  skipped %s: %s\n", relativePath(workspaceDir, path), result.Skipped[path]))
+	}
+	return b.String()
+}
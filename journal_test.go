@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionJournalsRestoreEdits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package old\n"), 0644))
+
+	journals := newSessionJournals()
+	_, err := journals.Get("s1").Snapshot("checkpoint")
+	require.NoError(t, err)
+
+	_, err = runMutating(func(m *mutation) (*mcp.CallToolResult, error) {
+		return journals.Run(m, "s1", "edit_file", editCall(path, "package new\n"))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, journals.Get("s1").Snapshots()[0].Changed)
+	assert.Empty(t, journals.Get("s2").Snapshots())
+
+	result, err := journals.Get("s1").Restore("checkpoint", false)
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package old\n", string(content))
+
+	assert.Equal(t, "Restored to snapshot checkpoint (files restored: 1, deleted: 0, skipped: 0)\n  restored main.go\n",
+		formatRestore(dir, "checkpoint", result))
+}
@@ -11,34 +11,72 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/isaacphi/mcp-language-server/internal/codeowners"
+	"github.com/isaacphi/mcp-language-server/internal/gitinfo"
 	"github.com/isaacphi/mcp-language-server/internal/logging"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/redact"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
 	"github.com/isaacphi/mcp-language-server/internal/watcher"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// version is reported to MCP clients and written to debug bundles
+const version = "v0.0.2"
+
 // Create a logger for the core component
 var coreLogger = logging.NewLogger(logging.Core)
 
 type config struct {
-	workspaceDir string
-	lspCommand   string
-	lspArgs      []string
+	workspaceDir  string
+	lspCommand    string
+	lspArgs       []string
+	configFile    string
+	outputVersion string
+	warmup        bool
+	settings      *settings.Settings
 }
 
 type mcpServer struct {
 	config           config
-	lspClient        *lsp.Client
+	pool             *clientPool
 	mcpServer        *server.MCPServer
 	ctx              context.Context
 	cancelFunc       context.CancelFunc
 	workspaceWatcher *watcher.WorkspaceWatcher
+	scratchStore     *tools.ScratchStore
+	outputVersions   *outputVersions
+	codeOwners       *codeowners.Cache
+	gitInfo          *gitinfo.Cache
+	scheduler        *scheduler
+	coalescer        *coalescer
+	auditor          *auditor
+	mutations        *mutations
+	resolver         *resolve.Resolver
+	overrides        *sessionOverrides
+	contextLines     int
+	toolNames        map[string]bool
+	pluginMutating   map[string]bool
+	outputBudget     *outputBudget
+	journals         *sessionJournals
+	idle             *idleMonitor
+	results          *resultStore
+	bookmarks        *sessionBookmarks
+	redactor         *redact.Redactor
+
+	// shutdown exits the process, set by main
+	shutdown func()
 }
 
 func parseConfig() (*config, error) {
 	cfg := &config{}
 	flag.StringVar(&cfg.workspaceDir, "workspace", "", "Path to workspace directory")
 	flag.StringVar(&cfg.lspCommand, "lsp", "", "LSP command to run (args should be passed after --)")
+	flag.StringVar(&cfg.configFile, "config", "", "Path to an optional JSON settings file")
+	flag.StringVar(&cfg.outputVersion, "output-version", "", "Default output version for tool results: v1 (text) or v2 (structured)")
+	flag.BoolVar(&cfg.warmup, "warmup", false, "Open key files and query workspace symbols at startup so the first tool calls are fast")
 	flag.Parse()
 
 	// Get remaining args after -- as LSP arguments
@@ -68,6 +106,25 @@ func parseConfig() (*config, error) {
 		return nil, fmt.Errorf("LSP command not found: %s", cfg.lspCommand)
 	}
 
+	cfg.settings, err = settings.Load(cfg.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// The flag takes precedence over the settings file
+	if cfg.outputVersion != "" {
+		cfg.settings.OutputVersion = cfg.outputVersion
+	}
+	if err := validateOutputVersion(cfg.settings.OutputVersion); err != nil {
+		return nil, err
+	}
+	if err := validateIdle(cfg.settings.Idle); err != nil {
+		return nil, err
+	}
+	if err := redact.Validate(cfg.settings.Redaction); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -77,6 +134,7 @@ func newServer(config *config) (*mcpServer, error) {
 		config:     *config,
 		ctx:        ctx,
 		cancelFunc: cancel,
+		redactor:   redact.New(config.settings.Redaction),
 	}, nil
 }
 
@@ -85,22 +143,23 @@ func (s *mcpServer) initializeLSP() error {
 		return fmt.Errorf("failed to change to workspace directory: %v", err)
 	}
 
-	client, err := lsp.NewClient(s.config.lspCommand, s.config.lspArgs...)
-	if err != nil {
-		return fmt.Errorf("failed to create LSP client: %v", err)
-	}
-	s.lspClient = client
-	s.workspaceWatcher = watcher.NewWorkspaceWatcher(client)
+	// The watcher forwards file events through the pool so that they reach
+	// whichever client is active, and the standby if there is one
+	s.pool = newClientPool(s.config)
+	s.workspaceWatcher = watcher.NewWorkspaceWatcher(s.pool)
 
-	initResult, err := client.InitializeLSPClient(s.ctx, s.config.workspaceDir)
-	if err != nil {
-		return fmt.Errorf("initialize failed: %v", err)
+	if err := s.pool.Start(s.ctx); err != nil {
+		return err
 	}
-
-	coreLogger.Debug("Server capabilities: %+v", initResult.Capabilities)
+	go s.pool.StartPeers(s.ctx, s.config.settings.Rename.PeerServers)
 
 	go s.workspaceWatcher.WatchWorkspace(s.ctx, s.config.workspaceDir)
-	return client.WaitForServerReady(s.ctx)
+	return nil
+}
+
+// client returns the active language server client
+func (s *mcpServer) client() *lsp.Client {
+	return s.pool.Active()
 }
 
 func (s *mcpServer) start() error {
@@ -108,17 +167,61 @@ func (s *mcpServer) start() error {
 		return err
 	}
 
+	s.outputVersions = newOutputVersions(s.config.settings.OutputVersion)
+	s.overrides = newSessionOverrides()
+	s.outputBudget = newOutputBudget(s.config.settings.OutputBudget)
+	hooks := &server.Hooks{}
+	s.scheduler = newScheduler(s.config.settings.Scheduler.MaxConcurrent)
+	s.coalescer = newCoalescer()
+	s.idle = newIdleMonitor()
+	s.results = newResultStore()
+	hooks.AddOnUnregisterSession(s.results.Forget)
+	hooks.AddOnUnregisterSession(s.outputVersions.Forget)
+	hooks.AddOnUnregisterSession(s.scheduler.Forget)
+	hooks.AddOnUnregisterSession(s.overrides.Forget)
+	hooks.AddOnUnregisterSession(s.outputBudget.Forget)
+	s.journals = newSessionJournals()
+	hooks.AddOnUnregisterSession(s.journals.Forget)
+	s.bookmarks = newSessionBookmarks()
+	hooks.AddOnUnregisterSession(s.bookmarks.Forget)
+
+	auditLog, err := openAuditLog(s.config.settings.Audit.LogFile)
+	if err != nil {
+		return err
+	}
+	if auditLog != nil {
+		defer auditLog.Close()
+	}
+	s.auditor = newAuditor(s.config.settings.Audit, auditLog)
+	s.mutations = &mutations{}
+	hooks.AddOnUnregisterSession(s.auditor.Forget)
+
 	s.mcpServer = server.NewMCPServer(
 		"MCP Language Server",
-		"v0.0.2",
+		version,
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(s.idleMiddleware),
+		server.WithToolHandlerMiddleware(s.budgetMiddleware),
+		server.WithToolHandlerMiddleware(s.timeoutMiddleware),
+		server.WithToolHandlerMiddleware(s.coalesceMiddleware),
+		server.WithToolHandlerMiddleware(s.scheduleMiddleware),
+		server.WithToolHandlerMiddleware(s.mutationMiddleware),
+		server.WithToolHandlerMiddleware(s.auditMiddleware),
+		server.WithToolHandlerMiddleware(s.journalMiddleware),
+		server.WithToolHandlerMiddleware(s.redactMiddleware),
+		server.WithToolFilter(s.withTimeoutParameter),
+		server.WithToolFilter(s.withRefreshParameter),
+		server.WithHooks(hooks),
 	)
 
-	err := s.registerTools()
-	if err != nil {
+	if err := s.registerTools(); err != nil {
 		return fmt.Errorf("tool registration failed: %v", err)
 	}
+	s.watchIdle(s.ctx)
+	if s.config.warmup {
+		s.warmupOnStart(s.ctx)
+	}
 
 	return server.ServeStdio(s.mcpServer)
 }
@@ -139,6 +242,10 @@ func main() {
 	if err != nil {
 		coreLogger.Fatal("%v", err)
 	}
+	server.shutdown = func() { cleanup(server, done) }
+	if server.redactor != nil {
+		logging.SetRedact(server.redactor.Text)
+	}
 
 	// Parent process monitoring channel
 	parentDeath := make(chan struct{})
@@ -197,40 +304,9 @@ func cleanup(s *mcpServer, done chan struct{}) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if s.lspClient != nil {
-		coreLogger.Info("Closing open files")
-		s.lspClient.CloseAllFiles(ctx)
-
-		// Create a shorter timeout context for the shutdown request
-		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 500*time.Millisecond)
-		defer shutdownCancel()
-
-		// Run shutdown in a goroutine with timeout to avoid blocking if LSP doesn't respond
-		shutdownDone := make(chan struct{})
-		go func() {
-			coreLogger.Info("Sending shutdown request")
-			if err := s.lspClient.Shutdown(shutdownCtx); err != nil {
-				coreLogger.Error("Shutdown request failed: %v", err)
-			}
-			close(shutdownDone)
-		}()
-
-		// Wait for shutdown with timeout
-		select {
-		case <-shutdownDone:
-			coreLogger.Info("Shutdown request completed")
-		case <-time.After(1 * time.Second):
-			coreLogger.Warn("Shutdown request timed out, proceeding with exit")
-		}
-
-		coreLogger.Info("Sending exit notification")
-		if err := s.lspClient.Exit(ctx); err != nil {
-			coreLogger.Error("Exit notification failed: %v", err)
-		}
-
-		coreLogger.Info("Closing LSP client")
-		if err := s.lspClient.Close(); err != nil {
-			coreLogger.Error("Failed to close LSP client: %v", err)
+	if s.pool != nil && !s.pool.Stopped() {
+		for _, client := range s.pool.Clients() {
+			shutdownClient(ctx, client)
 		}
 	}
 
@@ -243,3 +319,42 @@ func cleanup(s *mcpServer, done chan struct{}) {
 
 	coreLogger.Info("Cleanup completed for PID: %d", os.Getpid())
 }
+
+// shutdownClient closes open files and shuts down a language server, killing
+// it if it does not respond in time
+func shutdownClient(ctx context.Context, client *lsp.Client) {
+	coreLogger.Info("Closing open files")
+	client.CloseAllFiles(ctx)
+
+	// Create a shorter timeout context for the shutdown request
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer shutdownCancel()
+
+	// Run shutdown in a goroutine with timeout to avoid blocking if LSP doesn't respond
+	shutdownDone := make(chan struct{})
+	go func() {
+		coreLogger.Info("Sending shutdown request")
+		if err := client.Shutdown(shutdownCtx); err != nil {
+			coreLogger.Error("Shutdown request failed: %v", err)
+		}
+		close(shutdownDone)
+	}()
+
+	// Wait for shutdown with timeout
+	select {
+	case <-shutdownDone:
+		coreLogger.Info("Shutdown request completed")
+	case <-time.After(1 * time.Second):
+		coreLogger.Warn("Shutdown request timed out, proceeding with exit")
+	}
+
+	coreLogger.Info("Sending exit notification")
+	if err := client.Exit(ctx); err != nil {
+		coreLogger.Error("Exit notification failed: %v", err)
+	}
+
+	coreLogger.Info("Closing LSP client")
+	if err := client.Close(); err != nil {
+		coreLogger.Error("Failed to close LSP client: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mutatingTools are the tools that may change files in the workspace
+var mutatingTools = map[string]bool{
+	"edit_file":        true,
+	"rename_symbol":    true,
+	"replace_symbol":   true,
+	"execute_codelens": true,
+	"restore_snapshot": true,
+}
+
+// mutationKey is the context key of the mutating call being run
+type mutationKey struct{}
+
+// mutation is a running mutating tool call. Its observers see the files the
+// call writes.
+type mutation struct {
+	mu        sync.Mutex
+	observers []utilities.WriteObserver
+}
+
+// Observe adds an observer of the files written by the call
+func (m *mutation) Observe(observer utilities.WriteObserver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observers = append(m.observers, observer)
+}
+
+func (m *mutation) notify(path string, before, after []byte) {
+	m.mu.Lock()
+	observers := m.observers
+	m.mu.Unlock()
+	for _, observer := range observers {
+		observer(path, before, after)
+	}
+}
+
+// mutations runs mutating tool calls one at a time, so that every file write
+// can be attributed to the call that made it. It installs the only write
+// observer for tool calls; the audit log and the session journals observe
+// through the mutation of each call.
+type mutations struct {
+	mu sync.Mutex
+}
+
+// Run runs a mutating call with a context carrying its mutation
+func (m *mutations) Run(ctx context.Context, call func(context.Context) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := &mutation{}
+	unobserve := utilities.ObserveWrites(current.notify)
+	defer unobserve()
+	return call(context.WithValue(ctx, mutationKey{}, current))
+}
+
+// currentMutation returns the mutation of a call run by mutations, or nil
+func currentMutation(ctx context.Context) *mutation {
+	m, _ := ctx.Value(mutationKey{}).(*mutation)
+	return m
+}
+
+// mutating reports whether a tool may change files in the workspace
+func (s *mcpServer) mutating(name string) bool {
+	return mutatingTools[name] || s.pluginMutating[name]
+}
+
+// mutationMiddleware serializes mutating tool calls. It runs outside the
+// audit and journal middlewares, which observe the writes of each call.
+func (s *mcpServer) mutationMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !s.mutating(request.Params.Name) {
+			return next(ctx, request)
+		}
+		return s.mutations.Run(ctx, func(ctx context.Context) (*mcp.CallToolResult, error) {
+			return next(ctx, request)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMutating runs a call as a mutating tool call, handing it the mutation
+func runMutating(call func(m *mutation) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	return (&mutations{}).Run(context.Background(), func(ctx context.Context) (*mcp.CallToolResult, error) {
+		return call(currentMutation(ctx))
+	})
+}
+
+func TestMutationMiddlewareSharesWrites(t *testing.T) {
+	dir := t.TempDir()
+	var log bytes.Buffer
+	s := &mcpServer{
+		auditor:   newAuditor(settings.AuditSettings{}, &log),
+		journals:  newSessionJournals(),
+		mutations: &mutations{},
+	}
+
+	// Each call writes its session's file twice, pausing in between
+	edit := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if currentMutation(ctx) == nil {
+			return mcp.NewToolResultText("not mutating"), nil
+		}
+		path := filepath.Join(dir, sessionID(ctx)+".go")
+		if _, err := editCall(path, "package first\n")(); err != nil {
+			return nil, err
+		}
+		time.Sleep(20 * time.Millisecond)
+		return editCall(path, "package second\n")()
+	}
+	handler := s.mutationMiddleware(s.auditMiddleware(s.journalMiddleware(edit)))
+
+	var request mcp.CallToolRequest
+	request.Params.Name = "edit_file"
+	var wg sync.WaitGroup
+	for _, session := range []string{"a", "b"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, session+".go"), []byte("package p\n"), 0644))
+		_, err := s.journals.Get(session).Snapshot("start")
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := handler(sessionContext(session), request)
+			assert.NoError(t, err)
+			assert.False(t, result.IsError)
+		}()
+	}
+	wg.Wait()
+
+	// Both calls ran one at a time, so each saw only its own writes
+	records := auditRecords(t, &log)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		require.Len(t, record.Files, 1, record.Session)
+		assert.Equal(t, filepath.Join(dir, record.Session+".go"), record.Files[0].Path)
+		assert.Equal(t, contentHash([]byte("package second\n")), record.Files[0].After)
+		assert.Equal(t, []string{record.Files[0].Path}, s.journals.Get(record.Session).Snapshots()[0].Changed)
+	}
+
+	request.Params.Name = "hover"
+	result, err := handler(sessionContext("a"), request)
+	require.NoError(t, err)
+	assert.Equal(t, "not mutating", resultText(result))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// outputFormats maps each output contract version to the format tools render
+// documents in when the caller does not pass a format. v1 is the original text
+// output that existing prompt templates are tuned to, v2 is structured JSON.
+var outputFormats = map[string]string{
+	"v1": "plain",
+	"v2": "json",
+}
+
+// outputVersionNames returns the supported output versions in order
+func outputVersionNames() []string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateOutputVersion returns an error for unknown output versions
+func validateOutputVersion(version string) error {
+	if _, ok := outputFormats[version]; !ok {
+		return fmt.Errorf("unknown output version %q, expected one of %v", version, outputVersionNames())
+	}
+	return nil
+}
+
+// outputVersions tracks the output version chosen by each client session,
+// falling back to the configured default for sessions that did not choose one
+type outputVersions struct {
+	defaultVersion string
+	sessions       map[string]string
+	mu             sync.RWMutex
+}
+
+func newOutputVersions(defaultVersion string) *outputVersions {
+	return &outputVersions{
+		defaultVersion: defaultVersion,
+		sessions:       make(map[string]string),
+	}
+}
+
+// sessionID identifies the client session of a request. Requests without a
+// session share the default.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// Get returns the output version for the session of ctx
+func (v *outputVersions) Get(ctx context.Context) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if version, ok := v.sessions[sessionID(ctx)]; ok {
+		return version
+	}
+	return v.defaultVersion
+}
+
+// Set selects the output version for the session of ctx
+func (v *outputVersions) Set(ctx context.Context, version string) error {
+	if err := validateOutputVersion(version); err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sessions[sessionID(ctx)] = version
+	return nil
+}
+
+// Forget drops the choice of a session that has disconnected
+func (v *outputVersions) Forget(ctx context.Context, session server.ClientSession) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.sessions, session.SessionID())
+}
+
+// Format returns the default document format for the session of ctx
+func (v *outputVersions) Format(ctx context.Context) string {
+	return outputFormats[v.Get(ctx)]
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSession struct {
+	id string
+}
+
+func (f fakeSession) SessionID() string                                   { return f.id }
+func (f fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (f fakeSession) Initialize()                                         {}
+func (f fakeSession) Initialized() bool                                   { return true }
+
+func sessionContext(id string) context.Context {
+	srv := server.NewMCPServer("test", "v0")
+	return srv.WithContext(context.Background(), fakeSession{id: id})
+}
+
+func TestOutputVersionsPerSession(t *testing.T) {
+	versions := newOutputVersions("v1")
+	first := sessionContext("first")
+	second := sessionContext("second")
+
+	assert.Equal(t, "plain", versions.Format(first))
+	assert.NoError(t, versions.Set(first, "v2"))
+	assert.Equal(t, "v2", versions.Get(first))
+	assert.Equal(t, "json", versions.Format(first))
+	assert.Equal(t, "v1", versions.Get(second), "other sessions keep the default")
+
+	versions.Forget(context.Background(), fakeSession{id: "first"})
+	assert.Equal(t, "v1", versions.Get(first))
+}
+
+func TestOutputVersionsRejectUnknown(t *testing.T) {
+	versions := newOutputVersions("v1")
+	assert.ErrorContains(t, versions.Set(context.Background(), "v3"), "unknown output version")
+	assert.Equal(t, "v1", versions.Get(context.Background()))
+	assert.Equal(t, []string{"v1", "v2"}, outputVersionNames())
+}
+
+func TestToolErrorFollowsOutputVersion(t *testing.T) {
+	s := &mcpServer{outputVersions: newOutputVersions("v1")}
+	structured := sessionContext("structured")
+	assert.NoError(t, s.outputVersions.Set(structured, "v2"))
+
+	var request mcp.CallToolRequest
+	request.Params.Name = "replace_symbol"
+	err := fmt.Errorf("failed to replace symbol: %w", tools.NewError(tools.KindSymbolNotFound, errors.New("Foo not found")))
+
+	result := s.toolError(sessionContext("text"), request, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "failed to replace symbol: Foo not found\nError kind: symbol_not_found", resultText(result))
+
+	result = s.toolError(structured, request, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(result), `"kind": "symbol_not_found"`)
+	assert.Contains(t, resultText(result), `"message": "failed to replace symbol: Foo not found"`)
+
+	// Unclassified failures keep their message as it was
+	result = s.toolError(sessionContext("text"), request, errors.New("failed to replace symbol: could not open file"))
+	assert.Equal(t, "failed to replace symbol: could not open file", resultText(result))
+}
@@ -0,0 +1,280 @@
+// Package lspbridge lets Go programs use the tools of mcp-language-server
+// directly, without speaking MCP over stdio. A Bridge starts a language server
+// for a workspace and answers the same questions as the MCP tools, returning
+// typed results that can also be rendered as the tools' text, markdown or JSON
+// output.
+//
+// Paths passed to a Bridge should be absolute. Lines and columns are 1-indexed.
+package lspbridge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/redact"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
+	"github.com/isaacphi/mcp-language-server/internal/watcher"
+)
+
+// Options configures the language server a Bridge starts
+type Options struct {
+	// Command is the language server executable, e.g. gopls
+	Command string
+
+	// Args are passed to the language server
+	Args []string
+
+	// WorkspaceDir is the root of the workspace
+	WorkspaceDir string
+
+	// ConfigFile is a settings file in the format of the --config flag. Empty
+	// uses the default settings.
+	ConfigFile string
+
+	// Watch forwards changes to files in the workspace to the language server.
+	// Enable it when files change other than through the Bridge.
+	Watch bool
+}
+
+// Bridge is a running language server with the tools of mcp-language-server.
+// Its methods may be called concurrently.
+type Bridge struct {
+	// source returns the context each tool call runs with
+	source func(ctx context.Context) *tools.ToolContext
+
+	// close stops what Start started, nil for bridges created by New
+	close func() error
+
+	mu   sync.RWMutex
+	base tools.ToolContext
+}
+
+// New returns a Bridge whose tools run with the contexts source returns. The
+// server uses it to give plugin tools the language server of the calling
+// session. The language server belongs to the caller, so Close does nothing.
+func New(source func(ctx context.Context) *tools.ToolContext) *Bridge {
+	return &Bridge{source: source}
+}
+
+// Start starts and initializes the language server for a workspace. Call Close
+// when done with the Bridge.
+func Start(ctx context.Context, opts Options) (*Bridge, error) {
+	if opts.Command == "" {
+		return nil, fmt.Errorf("language server command is required")
+	}
+	workspaceDir, err := filepath.Abs(opts.WorkspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	if info, err := os.Stat(workspaceDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("workspace directory does not exist: %s", workspaceDir)
+	}
+	cfg, err := settings.Load(opts.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := tools.ValidateExternalSources(cfg.ExternalSources); err != nil {
+		return nil, err
+	}
+	if err := resolve.ValidateRules(cfg.SymbolMatch.Normalize); err != nil {
+		return nil, err
+	}
+	if err := redact.Validate(cfg.Redaction); err != nil {
+		return nil, err
+	}
+
+	client, err := lsp.NewClient(opts.Command, opts.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LSP client: %v", err)
+	}
+	var overrides []lsp.LanguageOverride
+	for _, override := range cfg.LanguageOverrides {
+		overrides = append(overrides, lsp.LanguageOverride{
+			Pattern:    override.Pattern,
+			LanguageID: protocol.LanguageKind(override.LanguageID),
+		})
+	}
+	client.SetLanguageOverrides(overrides)
+
+	if _, err := client.InitializeLSPClient(ctx, workspaceDir); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("initialize failed: %v", err)
+	}
+	if err := client.WaitForServerReady(ctx); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	if opts.Watch {
+		go watcher.NewWorkspaceWatcher(client).WatchWorkspace(watchCtx, workspaceDir)
+	}
+
+	b := &Bridge{
+		base: tools.ToolContext{
+			Client:       client,
+			WorkspaceDir: workspaceDir,
+			Settings:     cfg,
+			Resolver:     resolve.New(cfg.SymbolMatch),
+			Redactor:     redact.New(cfg.Redaction),
+			ContextLines: -1,
+		},
+	}
+	b.source = b.ownContext
+	b.close = func() error {
+		cancel()
+		return shutdown(client)
+	}
+	return b, nil
+}
+
+// SetContextLines sets how many lines around each match results show.
+// Negative values use each tool's default. Bridges created by New take the
+// context lines from their source instead.
+func (b *Bridge) SetContextLines(lines int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.base.ContextLines = lines
+}
+
+// ownContext returns a copy of the context of a Bridge created by Start
+func (b *Bridge) ownContext(ctx context.Context) *tools.ToolContext {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	tc := b.base
+	return &tc
+}
+
+// Close shuts the language server down, killing it if it does not respond
+func (b *Bridge) Close() error {
+	if b.close == nil {
+		return nil
+	}
+	return b.close()
+}
+
+// shutdown closes open files and shuts a language server down
+func shutdown(client *lsp.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client.CloseAllFiles(ctx)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, time.Second)
+	defer shutdownCancel()
+	if err := client.Shutdown(shutdownCtx); err == nil {
+		_ = client.Exit(ctx)
+	}
+	return client.Close()
+}
+
+// Definition finds the definitions of a symbol
+func (b *Bridge) Definition(ctx context.Context, symbolName string) (*Result, error) {
+	tc := b.source(ctx)
+	doc, err := tools.ReadDefinitionDocument(ctx, tc, symbolName)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(tc.Redactor.Document(doc)), nil
+}
+
+// References finds the references to a symbol, one file per result file
+func (b *Bridge) References(ctx context.Context, symbolName string) (*Result, error) {
+	tc := b.source(ctx)
+	doc, err := tools.FindReferencesDocument(ctx, tc, symbolName)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(tc.Redactor.Document(doc)), nil
+}
+
+// IncomingCalls finds the callers of a symbol
+func (b *Bridge) IncomingCalls(ctx context.Context, symbolName string) (*Result, error) {
+	tc := b.source(ctx)
+	doc, err := tools.FindIncomingCallsDocument(ctx, tc, symbolName)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(tc.Redactor.Document(doc)), nil
+}
+
+// Diagnostics returns the diagnostics of a file, with the titles of the quick
+// fixes available for each when includeQuickFixes is set
+func (b *Bridge) Diagnostics(ctx context.Context, filePath string, includeQuickFixes bool) (*Result, error) {
+	tc := b.source(ctx)
+	contextLines := tc.ContextLines
+	if contextLines < 0 {
+		contextLines = 5
+	}
+	doc, err := tools.GetDiagnosticsDocument(ctx, tc.Client, filePath, contextLines, true, includeQuickFixes)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(tc.Redactor.Document(doc)), nil
+}
+
+// SearchSymbols searches the workspace symbols, best matches first. At most
+// limit symbols are listed.
+func (b *Bridge) SearchSymbols(ctx context.Context, query string, limit int) (*Result, error) {
+	tc := b.source(ctx)
+	doc, err := tools.SearchSymbolsDocument(ctx, tc, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(tc.Redactor.Document(doc)), nil
+}
+
+// Hover returns the hover information at a position
+func (b *Bridge) Hover(ctx context.Context, filePath string, line, column int) (string, error) {
+	tc := b.source(ctx)
+	text, err := tools.GetHoverInfo(ctx, tc.Client, filePath, line, column)
+	return tc.Redactor.Text(text), err
+}
+
+// TextEdit replaces lines of a file
+type TextEdit struct {
+	// StartLine is the first line to replace, 1-indexed and inclusive
+	StartLine int
+
+	// EndLine is the last line to replace, inclusive
+	EndLine int
+
+	// NewText replaces the lines. Empty removes them.
+	NewText string
+}
+
+// EditFile applies edits to a file. Files blocked by the edit policy are not
+// edited unless force is set.
+func (b *Bridge) EditFile(ctx context.Context, filePath string, edits []TextEdit, force bool) (string, error) {
+	tc := b.source(ctx)
+	if !force {
+		if err := tools.CheckEditPolicy(ctx, tc.Client, tc.Settings.EditPolicy, []string{filePath}); err != nil {
+			return "", err
+		}
+	}
+	converted := make([]tools.TextEdit, len(edits))
+	for i, edit := range edits {
+		converted[i] = tools.TextEdit{StartLine: edit.StartLine, EndLine: edit.EndLine, NewText: edit.NewText}
+	}
+	return tools.ApplyTextEdits(ctx, tc.Client, filePath, converted)
+}
+
+// RenameSymbol renames the symbol at a position and all its references. The
+// rename is refused when it touches files blocked by the edit policy, unless
+// force is set.
+func (b *Bridge) RenameSymbol(ctx context.Context, filePath string, line, column int, newName string, force bool) (string, error) {
+	return tools.RenameSymbolAcrossServers(ctx, b.source(ctx), filePath, line, column, newName, force)
+}
+
+// ReplaceSymbol replaces the definition of a symbol, keeping its doc comment
+// when the new text has none
+func (b *Bridge) ReplaceSymbol(ctx context.Context, symbolName, newText string, force bool) (string, error) {
+	return tools.ReplaceSymbol(ctx, b.source(ctx), symbolName, newText, tools.DocCommentKeep, force)
+}
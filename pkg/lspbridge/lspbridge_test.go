@@ -0,0 +1,57 @@
+package lspbridge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartValidatesOptions(t *testing.T) {
+	_, err := Start(context.Background(), Options{WorkspaceDir: t.TempDir()})
+	assert.ErrorContains(t, err, "language server command is required")
+
+	_, err = Start(context.Background(), Options{Command: "gopls", WorkspaceDir: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorContains(t, err, "workspace directory does not exist")
+
+	_, err = Start(context.Background(), Options{Command: "gopls", WorkspaceDir: t.TempDir(), ConfigFile: filepath.Join(t.TempDir(), "missing.json")})
+	assert.ErrorContains(t, err, "failed to read config file")
+
+	config := filepath.Join(t.TempDir(), "settings.json")
+	require.NoError(t, os.WriteFile(config, []byte(`{"redaction": {"patterns": ["("]}}`), 0644))
+	_, err = Start(context.Background(), Options{Command: "gopls", WorkspaceDir: t.TempDir(), ConfigFile: config})
+	assert.ErrorContains(t, err, "redaction.patterns[0] has an invalid pattern")
+}
+
+func TestNewResult(t *testing.T) {
+	doc := format.Document{
+		Preamble: "Found 1 reference\n",
+		Sections: []format.Section{{
+			Path:     "/ws/main.go",
+			Language: "go",
+			Fields:   []format.Field{{Name: "References in File", Value: "1"}},
+			Snippets: []format.Snippet{{StartLine: 3, Lines: []string{"\trun()"}}},
+		}},
+	}
+
+	result := newResult(doc)
+	assert.Equal(t, "Found 1 reference\n", result.Summary)
+	assert.Equal(t, []File{{
+		Path:     "/ws/main.go",
+		Language: "go",
+		Fields:   []Field{{Name: "References in File", Value: "1"}},
+		Snippets: []Snippet{{StartLine: 3, Lines: []string{"\trun()"}}},
+	}}, result.Files)
+
+	text, err := result.Render("json")
+	assert.NoError(t, err)
+	assert.Contains(t, text, `"path": "/ws/main.go"`)
+	assert.Contains(t, result.String(), "/ws/main.go")
+
+	_, err = result.Render("yaml")
+	assert.Error(t, err)
+}
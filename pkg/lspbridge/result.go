@@ -0,0 +1,88 @@
+package lspbridge
+
+import "github.com/isaacphi/mcp-language-server/internal/tools/format"
+
+// Result is the result of a tool, usually one File per file it found
+// something in
+type Result struct {
+	// Summary is printed before the files, e.g. a count of matches
+	Summary string
+
+	// Files are the per-file results
+	Files []File
+
+	doc format.Document
+}
+
+// File is the part of a result about one file
+type File struct {
+	// Path is the file, empty for results not tied to a file
+	Path string
+
+	// Language is the languageId of the file
+	Language string
+
+	// Fields are named details such as the symbol kind or owners
+	Fields []Field
+
+	// Notes are free form lines, e.g. diagnostic messages
+	Notes []string
+
+	// Error is set when the file could not be read
+	Error string
+
+	// Snippets are excerpts of the file
+	Snippets []Snippet
+}
+
+// Field is a named detail of a file
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Snippet is a contiguous excerpt of a file
+type Snippet struct {
+	// StartLine is the 1-indexed number of the first line
+	StartLine int
+
+	// Lines are the lines of the excerpt
+	Lines []string
+}
+
+// newResult converts a tool document to a result
+func newResult(doc format.Document) *Result {
+	result := &Result{Summary: doc.Preamble, doc: doc}
+	for _, section := range doc.Sections {
+		file := File{
+			Path:     section.Path,
+			Language: section.Language,
+			Notes:    section.Notes,
+			Error:    section.Error,
+		}
+		for _, field := range section.Fields {
+			file.Fields = append(file.Fields, Field{Name: field.Name, Value: field.Value})
+		}
+		for _, snippet := range section.Snippets {
+			file.Snippets = append(file.Snippets, Snippet{StartLine: snippet.StartLine, Lines: snippet.Lines})
+		}
+		result.Files = append(result.Files, file)
+	}
+	return result
+}
+
+// Render renders the result like the MCP tools do: "plain" for the original
+// text output, "markdown" or "json"
+func (r *Result) Render(name string) (string, error) {
+	renderer, err := format.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return renderer.Render(r.doc), nil
+}
+
+// String returns the plain text output of the result
+func (r *Result) String() string {
+	text, _ := r.Render(format.Plain)
+	return text
+}
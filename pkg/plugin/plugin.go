@@ -0,0 +1,148 @@
+// Package plugin registers custom MCP tools that are compiled into the server,
+// such as company-specific codegen or lint integrations. A plugin package
+// registers its tools in an init function:
+//
+//	func init() {
+//		plugin.Register(plugin.Tool{
+//			Name:        "acme_lint",
+//			Description: "Run the acme linter on a file and explain each finding",
+//			Params: []plugin.Param{
+//				{Name: "filePath", Type: plugin.String, Required: true, Description: "File to lint"},
+//			},
+//			Run: func(ctx context.Context, bridge *lspbridge.Bridge, args plugin.Args) (string, error) {
+//				diagnostics, err := bridge.Diagnostics(ctx, args.String("filePath"), false)
+//				...
+//			},
+//		})
+//	}
+//
+// and is compiled in by a blank import from a file added to the server's main
+// package, e.g. plugins_acme.go containing import _ "example.com/acme/lsptools".
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/pkg/lspbridge"
+)
+
+// ParamType is the JSON type of a tool parameter
+type ParamType string
+
+const (
+	String  ParamType = "string"
+	Number  ParamType = "number"
+	Boolean ParamType = "boolean"
+)
+
+// Param is a parameter of a tool
+type Param struct {
+	Name        string
+	Type        ParamType
+	Description string
+	Required    bool
+}
+
+// Tool is a custom MCP tool
+type Tool struct {
+	// Name is the MCP tool name. It may not be the name of a built-in tool.
+	Name string
+
+	// Description tells the model what the tool does
+	Description string
+
+	// Params are the tool's parameters
+	Params []Param
+
+	// Mutating marks tools that change files, so that they are audited and
+	// count towards the file quotas like the built-in editing tools
+	Mutating bool
+
+	// Run handles a call. The bridge uses the language server and settings of
+	// the calling session. A returned error is reported to the model as a tool
+	// error.
+	Run func(ctx context.Context, bridge *lspbridge.Bridge, args Args) (string, error)
+}
+
+// Args are the arguments of a tool call
+type Args map[string]any
+
+// String returns a string argument, or "" if it is missing
+func (a Args) String(name string) string {
+	value, _ := a[name].(string)
+	return value
+}
+
+// Number returns a number argument, or 0 if it is missing
+func (a Args) Number(name string) float64 {
+	switch value := a[name].(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	}
+	return 0
+}
+
+// Bool returns a boolean argument, or false if it is missing
+func (a Args) Bool(name string) bool {
+	value, _ := a[name].(bool)
+	return value
+}
+
+// toolName is the form MCP clients accept for tool names
+var toolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+var (
+	registry   = make(map[string]Tool)
+	registryMu sync.RWMutex
+)
+
+// Register adds a tool. It panics if the tool is invalid or a tool with the
+// same name is already registered, since both are programming errors found
+// at startup.
+func Register(tool Tool) {
+	if err := validate(tool); err != nil {
+		panic(fmt.Sprintf("plugin: %v", err))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[tool.Name]; ok {
+		panic(fmt.Sprintf("plugin: tool %s is registered twice", tool.Name))
+	}
+	registry[tool.Name] = tool
+}
+
+// validate checks a tool before it is registered
+func validate(tool Tool) error {
+	if !toolName.MatchString(tool.Name) {
+		return fmt.Errorf("invalid tool name %q", tool.Name)
+	}
+	if tool.Run == nil {
+		return fmt.Errorf("tool %s has no Run function", tool.Name)
+	}
+	for _, param := range tool.Params {
+		switch param.Type {
+		case String, Number, Boolean:
+		default:
+			return fmt.Errorf("parameter %s of tool %s has invalid type %q", param.Name, tool.Name, param.Type)
+		}
+	}
+	return nil
+}
+
+// Tools returns the registered tools sorted by name
+func Tools() []Tool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	tools := make([]Tool, 0, len(registry))
+	for _, tool := range registry {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/pkg/lspbridge"
+	"github.com/stretchr/testify/assert"
+)
+
+func run(ctx context.Context, bridge *lspbridge.Bridge, args Args) (string, error) {
+	return "ok", nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(Tool{Name: "test_b", Run: run})
+	Register(Tool{Name: "test_a", Run: run, Params: []Param{{Name: "path", Type: String}}})
+
+	var names []string
+	for _, tool := range Tools() {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"test_a", "test_b"}, names)
+
+	assert.PanicsWithValue(t, "plugin: tool test_a is registered twice", func() {
+		Register(Tool{Name: "test_a", Run: run})
+	})
+}
+
+func TestRegisterRejectsInvalidTools(t *testing.T) {
+	assert.PanicsWithValue(t, `plugin: invalid tool name "acme lint"`, func() {
+		Register(Tool{Name: "acme lint", Run: run})
+	})
+	assert.PanicsWithValue(t, "plugin: tool acme_lint has no Run function", func() {
+		Register(Tool{Name: "acme_lint"})
+	})
+	assert.PanicsWithValue(t, `plugin: parameter files of tool acme_lint has invalid type "array"`, func() {
+		Register(Tool{Name: "acme_lint", Run: run, Params: []Param{{Name: "files", Type: "array"}}})
+	})
+}
+
+func TestArgs(t *testing.T) {
+	args := Args{"path": "main.go", "limit": float64(3), "fix": true}
+	assert.Equal(t, "main.go", args.String("path"))
+	assert.Equal(t, float64(3), args.Number("limit"))
+	assert.True(t, args.Bool("fix"))
+
+	assert.Empty(t, args.String("missing"))
+	assert.Zero(t, args.Number("path"))
+	assert.False(t, args.Bool("missing"))
+}
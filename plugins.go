@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/pkg/lspbridge"
+	"github.com/isaacphi/mcp-language-server/pkg/plugin"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// registerPlugins registers the tools of the plugins compiled into the server.
+// Plugin tools run through the same middlewares as built-in tools and reach
+// the language server of the calling session through a bridge.
+func (s *mcpServer) registerPlugins() error {
+	bridge := lspbridge.New(s.toolContext)
+	for _, tool := range plugin.Tools() {
+		if s.toolNames[tool.Name] {
+			return fmt.Errorf("plugin tool %s has the name of a built-in tool", tool.Name)
+		}
+		if tool.Mutating {
+			if s.pluginMutating == nil {
+				s.pluginMutating = make(map[string]bool)
+			}
+			s.pluginMutating[tool.Name] = true
+		}
+		s.mcpServer.AddTool(pluginTool(tool), s.pluginHandler(tool, bridge))
+		coreLogger.Info("Registered plugin tool %s", tool.Name)
+	}
+	return nil
+}
+
+// pluginTool describes a plugin tool to MCP clients
+func pluginTool(tool plugin.Tool) mcp.Tool {
+	options := []mcp.ToolOption{mcp.WithDescription(tool.Description)}
+	for _, param := range tool.Params {
+		propertyOptions := []mcp.PropertyOption{mcp.Description(param.Description)}
+		if param.Required {
+			propertyOptions = append(propertyOptions, mcp.Required())
+		}
+		switch param.Type {
+		case plugin.String:
+			options = append(options, mcp.WithString(param.Name, propertyOptions...))
+		case plugin.Number:
+			options = append(options, mcp.WithNumber(param.Name, propertyOptions...))
+		case plugin.Boolean:
+			options = append(options, mcp.WithBoolean(param.Name, propertyOptions...))
+		}
+	}
+	return mcp.NewTool(tool.Name, options...)
+}
+
+// pluginHandler runs a plugin tool, checking its required parameters first
+func (s *mcpServer) pluginHandler(tool plugin.Tool, bridge *lspbridge.Bridge) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := plugin.Args(request.Params.Arguments)
+		for _, param := range tool.Params {
+			if _, ok := args[param.Name]; param.Required && !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("%s is required", param.Name)), nil
+			}
+		}
+
+		coreLogger.Debug("Executing plugin tool %s", tool.Name)
+		text, err := tool.Run(ctx, bridge, args)
+		if err != nil {
+			coreLogger.Error("Plugin tool %s failed: %v", tool.Name, err)
+			return s.toolError(ctx, request, fmt.Errorf("%s failed: %w", tool.Name, err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	}
+}
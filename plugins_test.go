@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/pkg/lspbridge"
+	"github.com/isaacphi/mcp-language-server/pkg/plugin"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginHandler(t *testing.T) {
+	tool := plugin.Tool{
+		Name:   "acme_lint",
+		Params: []plugin.Param{{Name: "filePath", Type: plugin.String, Required: true}},
+		Run: func(ctx context.Context, bridge *lspbridge.Bridge, args plugin.Args) (string, error) {
+			if args.String("filePath") == "broken.go" {
+				return "", errors.New("linter crashed")
+			}
+			return "linted " + args.String("filePath"), nil
+		},
+	}
+	s := &mcpServer{outputVersions: newOutputVersions("v1")}
+	handler := s.pluginHandler(tool, nil)
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Name = tool.Name
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		assert.NoError(t, err)
+		return result
+	}
+
+	result := call(map[string]any{"filePath": "main.go"})
+	assert.False(t, result.IsError)
+	assert.Equal(t, "linted main.go", resultText(result))
+
+	result = call(map[string]any{})
+	assert.True(t, result.IsError)
+	assert.Equal(t, "filePath is required", resultText(result))
+
+	result = call(map[string]any{"filePath": "broken.go"})
+	assert.True(t, result.IsError)
+	assert.Equal(t, "acme_lint failed: linter crashed", resultText(result))
+}
+
+func TestPluginTool(t *testing.T) {
+	tool := pluginTool(plugin.Tool{
+		Name:        "acme_codegen",
+		Description: "Generate acme stubs",
+		Params: []plugin.Param{
+			{Name: "target", Type: plugin.String, Required: true},
+			{Name: "dryRun", Type: plugin.Boolean},
+		},
+	})
+	assert.Equal(t, "Generate acme stubs", tool.Description)
+	assert.Equal(t, []string{"target"}, tool.InputSchema.Required)
+	assert.Contains(t, tool.InputSchema.Properties, "dryRun")
+}
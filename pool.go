@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+)
+
+// clientPool owns the language server clients. It always has an active client
+// and, when the standby setting is enabled, a second initialized client that
+// replaces the active one if its server exits, so tool calls only pause briefly
+// instead of waiting for a cold start and a full re-index. Peers are servers for
+// other languages that take part in renames.
+type clientPool struct {
+	config config
+
+	active  *lsp.Client
+	standby *lsp.Client
+	peers   []*lsp.Client
+	mu      sync.RWMutex
+
+	// peerServers are the peers to start again when the pool resumes
+	peerServers []settings.ServerCommand
+
+	// parent is the context the pool was started with. run is cancelled by
+	// Stop, ending the standby monitor and servers still starting.
+	parent    context.Context
+	run       context.Context
+	cancelRun context.CancelFunc
+
+	// stopped is set while the servers are shut down for being idle, and
+	// resumeMu makes concurrent calls resume them once
+	stopped  bool
+	resumeMu sync.Mutex
+
+	// onSwap is called after a new client becomes active
+	onSwap []func(client *lsp.Client)
+
+	// startClient creates and initializes a client, and stopClient shuts one
+	// down, replaceable in tests
+	startClient func(ctx context.Context) (*lsp.Client, error)
+	stopClient  func(ctx context.Context, client *lsp.Client)
+}
+
+// newClientPool creates a pool for the configured language server
+func newClientPool(cfg config) *clientPool {
+	p := &clientPool{config: cfg}
+	p.startClient = p.startLSPClient
+	p.stopClient = shutdownClient
+	return p
+}
+
+// Active returns the client tool calls should use
+func (p *clientPool) Active() *lsp.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active
+}
+
+// Clients returns every running client, active first
+func (p *clientPool) Clients() []*lsp.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	clients := []*lsp.Client{p.active}
+	if p.standby != nil {
+		clients = append(clients, p.standby)
+	}
+	return append(clients, p.peers...)
+}
+
+// Peers returns the running peer language servers
+func (p *clientPool) Peers() []*lsp.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]*lsp.Client(nil), p.peers...)
+}
+
+// StartPeers starts the configured peer language servers. A peer that fails to
+// start is logged and left out rather than stopping the server.
+func (p *clientPool) StartPeers(ctx context.Context, servers []settings.ServerCommand) {
+	p.mu.Lock()
+	p.peerServers = servers
+	p.mu.Unlock()
+	for _, server := range servers {
+		client, err := startLanguageServer(ctx, p.config, server.Command, server.Args)
+		if err != nil {
+			coreLogger.Error("Failed to start peer language server %s: %v", server.Command, err)
+			continue
+		}
+		p.mu.Lock()
+		if p.stopped || ctx.Err() != nil {
+			p.mu.Unlock()
+			p.stopClient(context.Background(), client)
+			return
+		}
+		p.peers = append(p.peers, client)
+		p.mu.Unlock()
+	}
+}
+
+// OnSwap registers a function called whenever a new client becomes active
+func (p *clientPool) OnSwap(fn func(client *lsp.Client)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onSwap = append(p.onSwap, fn)
+}
+
+// Start starts the active client and, if enabled, the standby. The standby is
+// started in the background so that it does not delay startup.
+func (p *clientPool) Start(ctx context.Context) error {
+	client, err := p.startClient(ctx)
+	if err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	p.active = client
+	p.parent = ctx
+	p.run = runCtx
+	p.cancelRun = cancel
+	p.stopped = false
+	p.mu.Unlock()
+
+	if p.config.settings.Standby.Enabled {
+		go p.refillStandby(runCtx)
+		go p.monitor(runCtx)
+	}
+	return nil
+}
+
+// Stopped reports whether the servers are shut down for being idle
+func (p *clientPool) Stopped() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.stopped
+}
+
+// Stop shuts down every server until Resume is called. The stopped client
+// stays the active one, so that callers never see a nil client.
+func (p *clientPool) Stop(ctx context.Context) {
+	p.resumeMu.Lock()
+	defer p.resumeMu.Unlock()
+
+	p.mu.Lock()
+	if p.stopped || p.active == nil {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	p.cancelRun()
+	clients := []*lsp.Client{p.active}
+	if p.standby != nil {
+		clients = append(clients, p.standby)
+	}
+	clients = append(clients, p.peers...)
+	p.standby = nil
+	p.peers = nil
+	p.mu.Unlock()
+
+	for _, client := range clients {
+		p.stopClient(ctx, client)
+	}
+}
+
+// Resume starts the servers again after Stop. It returns once the new active
+// server is ready; peers are started in the background.
+func (p *clientPool) Resume() error {
+	p.resumeMu.Lock()
+	defer p.resumeMu.Unlock()
+
+	p.mu.RLock()
+	stopped, parent := p.stopped, p.parent
+	p.mu.RUnlock()
+	if !stopped {
+		return nil
+	}
+
+	coreLogger.Info("Restarting language server")
+	if err := p.Start(parent); err != nil {
+		return err
+	}
+	p.mu.RLock()
+	next, run, peers := p.active, p.run, p.peerServers
+	hooks := append([]func(*lsp.Client){}, p.onSwap...)
+	p.mu.RUnlock()
+	for _, fn := range hooks {
+		fn(next)
+	}
+	go p.StartPeers(run, peers)
+	return nil
+}
+
+// startLSPClient starts the configured language server
+func (p *clientPool) startLSPClient(ctx context.Context) (*lsp.Client, error) {
+	return startLanguageServer(ctx, p.config, p.config.lspCommand, p.config.lspArgs)
+}
+
+// startLanguageServer starts a language server process and initializes it
+func startLanguageServer(ctx context.Context, cfg config, command string, args []string) (*lsp.Client, error) {
+	client, err := lsp.NewClient(command, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LSP client: %v", err)
+	}
+
+	var overrides []lsp.LanguageOverride
+	for _, override := range cfg.settings.LanguageOverrides {
+		overrides = append(overrides, lsp.LanguageOverride{
+			Pattern:    override.Pattern,
+			LanguageID: protocol.LanguageKind(override.LanguageID),
+		})
+	}
+	client.SetLanguageOverrides(overrides)
+
+	initResult, err := client.InitializeLSPClient(ctx, cfg.workspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("initialize failed: %v", err)
+	}
+
+	coreLogger.Debug("Server capabilities: %+v", initResult.Capabilities)
+
+	if err := client.WaitForServerReady(ctx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// refillStandby starts a new standby client if there is none
+func (p *clientPool) refillStandby(ctx context.Context) {
+	p.mu.RLock()
+	hasStandby := p.standby != nil
+	p.mu.RUnlock()
+	if hasStandby || ctx.Err() != nil {
+		return
+	}
+
+	coreLogger.Info("Starting standby language server")
+	client, err := p.startClient(ctx)
+	if err != nil {
+		coreLogger.Error("Failed to start standby language server: %v", err)
+		return
+	}
+
+	p.mu.Lock()
+	if ctx.Err() != nil {
+		// The pool was stopped while the standby started
+		p.mu.Unlock()
+		p.stopClient(context.Background(), client)
+		return
+	}
+	p.standby = client
+	p.mu.Unlock()
+	coreLogger.Info("Standby language server ready")
+}
+
+// monitor waits for the active server to exit and promotes the standby
+func (p *clientPool) monitor(ctx context.Context) {
+	for {
+		active := p.Active()
+		select {
+		case <-ctx.Done():
+			return
+		case <-active.Done():
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		coreLogger.Warn("Language server exited unexpectedly")
+		if err := p.promote(ctx); err != nil {
+			coreLogger.Error("Failed to replace language server: %v", err)
+			return
+		}
+		go p.refillStandby(ctx)
+	}
+}
+
+// promote makes the standby the active client. Without a ready standby a new
+// client is started in its place.
+func (p *clientPool) promote(ctx context.Context) error {
+	p.mu.Lock()
+	next := p.standby
+	p.standby = nil
+	p.mu.Unlock()
+
+	if next == nil {
+		coreLogger.Warn("No standby language server ready, starting a new one")
+		var err error
+		next, err = p.startClient(ctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		coreLogger.Info("Promoting standby language server")
+	}
+
+	p.mu.Lock()
+	p.active = next
+	hooks := append([]func(*lsp.Client){}, p.onSwap...)
+	p.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(next)
+	}
+	return nil
+}
+
+// IsFileOpen implements watcher.LSPClient for the active client
+func (p *clientPool) IsFileOpen(path string) bool {
+	return !p.Stopped() && p.Active().IsFileOpen(path)
+}
+
+// OpenFile implements watcher.LSPClient for the active client. Files are not
+// opened while the servers are stopped; they read the workspace when resumed.
+func (p *clientPool) OpenFile(ctx context.Context, path string) error {
+	if p.Stopped() {
+		return nil
+	}
+	return p.Active().OpenFile(ctx, path)
+}
+
+// NotifyChange implements watcher.LSPClient for the active client. Peers that
+// have the file open are notified as well.
+func (p *clientPool) NotifyChange(ctx context.Context, path string) error {
+	if p.Stopped() {
+		return nil
+	}
+	for _, peer := range p.Peers() {
+		if peer.IsFileOpen(path) {
+			if err := peer.NotifyChange(ctx, path); err != nil {
+				coreLogger.Error("Error notifying peer of change: %v", err)
+			}
+		}
+	}
+	return p.Active().NotifyChange(ctx, path)
+}
+
+// DidChangeWatchedFiles implements watcher.LSPClient. Events are sent to every
+// client so that the standby index stays current.
+func (p *clientPool) DidChangeWatchedFiles(ctx context.Context, params protocol.DidChangeWatchedFilesParams) error {
+	if p.Stopped() {
+		return nil
+	}
+	var firstErr error
+	for _, client := range p.Clients() {
+		if err := client.DidChangeWatchedFiles(ctx, params); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startSleepingClient starts a process that never answers, which is enough to
+// exercise the pool without a real language server
+func startSleepingClient(t *testing.T, started chan<- *lsp.Client) func(ctx context.Context) (*lsp.Client, error) {
+	return func(ctx context.Context) (*lsp.Client, error) {
+		client, err := lsp.NewClient("sleep", "60")
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { _ = client.Cmd.Process.Kill() })
+		started <- client
+		return client, nil
+	}
+}
+
+func TestClientPoolPromotesStandby(t *testing.T) {
+	cfg := config{settings: settings.Default()}
+	cfg.settings.Standby.Enabled = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan *lsp.Client, 4)
+	pool := newClientPool(cfg)
+	pool.startClient = startSleepingClient(t, started)
+
+	swapped := make(chan *lsp.Client, 1)
+	pool.OnSwap(func(client *lsp.Client) { swapped <- client })
+
+	require.NoError(t, pool.Start(ctx))
+	active := <-started
+	standby := <-started
+	assert.Equal(t, active, pool.Active())
+	assert.Eventually(t, func() bool { return len(pool.Clients()) == 2 }, time.Second, 10*time.Millisecond)
+
+	// The active server crashes and the standby takes over
+	require.NoError(t, active.Cmd.Process.Kill())
+	select {
+	case client := <-swapped:
+		assert.Equal(t, standby, client)
+	case <-time.After(5 * time.Second):
+		t.Fatal("standby was not promoted")
+	}
+	assert.Equal(t, standby, pool.Active())
+
+	// A replacement standby is started
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no new standby was started")
+	}
+}
+
+func TestClientPoolWithoutStandby(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan *lsp.Client, 2)
+	pool := newClientPool(config{settings: settings.Default()})
+	pool.startClient = startSleepingClient(t, started)
+
+	require.NoError(t, pool.Start(ctx))
+	<-started
+	assert.Len(t, pool.Clients(), 1)
+
+	select {
+	case <-started:
+		t.Fatal("standby started although disabled")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// stopByKilling replaces shutting a client down with killing its process
+func stopByKilling(stopped chan<- *lsp.Client) func(ctx context.Context, client *lsp.Client) {
+	return func(ctx context.Context, client *lsp.Client) {
+		_ = client.Cmd.Process.Kill()
+		stopped <- client
+	}
+}
+
+func TestClientPoolStopAndResume(t *testing.T) {
+	cfg := config{settings: settings.Default()}
+	cfg.settings.Standby.Enabled = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan *lsp.Client, 4)
+	stopped := make(chan *lsp.Client, 4)
+	pool := newClientPool(cfg)
+	pool.startClient = startSleepingClient(t, started)
+	pool.stopClient = stopByKilling(stopped)
+
+	swapped := make(chan *lsp.Client, 1)
+	pool.OnSwap(func(client *lsp.Client) { swapped <- client })
+
+	require.NoError(t, pool.Start(ctx))
+	active := <-started
+	<-started
+	assert.Eventually(t, func() bool { return len(pool.Clients()) == 2 }, time.Second, 10*time.Millisecond)
+
+	// Stopping shuts down the active server and the standby without promoting
+	pool.Stop(ctx)
+	assert.True(t, pool.Stopped())
+	assert.Len(t, stopped, 2)
+	assert.Equal(t, active, pool.Active())
+	assert.NoError(t, pool.NotifyChange(ctx, "/ws/main.go"))
+	select {
+	case <-swapped:
+		t.Fatal("stopped server was replaced")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Resuming starts a new active server and standby
+	require.NoError(t, pool.Resume())
+	assert.False(t, pool.Stopped())
+	resumed := <-started
+	assert.Equal(t, resumed, pool.Active())
+	assert.Equal(t, resumed, <-swapped)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no new standby was started")
+	}
+
+	// Resuming a running pool does nothing
+	require.NoError(t, pool.Resume())
+	assert.Equal(t, resumed, pool.Active())
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// redactMiddleware passes the text of every tool result through the redactor,
+// covering tools whose output is not a document, such as hover or
+// run_command. It runs closest to the tools, so results are redacted before
+// they are cached, counted against budgets or journaled.
+func (s *mcpServer) redactMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if result == nil || s.redactor == nil {
+			return result, err
+		}
+		for i, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				text.Text = s.redactor.Text(text.Text)
+				result.Content[i] = text
+			}
+		}
+		return result, err
+	}
+}
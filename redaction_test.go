@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/redact"
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactMiddleware(t *testing.T) {
+	cfg := settings.Default().Redaction
+	cfg.Patterns = []string{`internal\.example\.com`}
+	s := &mcpServer{redactor: redact.New(cfg)}
+
+	handler := s.redactMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result := mcp.NewToolResultText(`apiKey := "sk-abcdefghijklmnopqrstuvwxyz"`)
+		result.Content = append(result.Content, mcp.NewTextContent("see https://internal.example.com/docs"))
+		return result, nil
+	})
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, `apiKey := "[REDACTED]"`, result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, "see https://[REDACTED]/docs", result.Content[1].(mcp.TextContent).Text)
+
+	// Disabled redaction returns results as they are
+	s.redactor = redact.New(settings.RedactionSettings{Disabled: true})
+	result, _ = handler(context.Background(), mcp.CallToolRequest{})
+	assert.Equal(t, `apiKey := "sk-abcdefghijklmnopqrstuvwxyz"`, result.Content[0].(mcp.TextContent).Text)
+}
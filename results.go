@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxStoredResults is how many results are kept for diff_results. Older
+// results are dropped first.
+const maxStoredResults = 100
+
+// presentationArguments change how a result is shown, not what it contains,
+// so they are left out of the query a stored result describes
+var presentationArguments = map[string]bool{
+	"format":        true,
+	"max_tokens":    true,
+	"owners":        true,
+	"git":           true,
+	"blame":         true,
+	"highlight":     true,
+	timeoutArgument: true,
+	refreshArgument: true,
+}
+
+// resultLocation is a line a result points at. Text is the trimmed content of
+// the line, which identifies it when edits above it move it to another line.
+type resultLocation struct {
+	Path string
+	Line int
+	Text string
+}
+
+// storedResult is a tool result kept so that it can be compared with a later one
+type storedResult struct {
+	ID        string
+	Tool      string
+	Query     string
+	Locations []resultLocation
+}
+
+// resultStore keeps the locations of the recent tool results of each session
+// by result ID. IDs are unique across sessions, so that a session that shared
+// the run of an identical call from another session can be given its result.
+type resultStore struct {
+	sessions map[string][]storedResult
+	next     int
+	mu       sync.Mutex
+}
+
+func newResultStore() *resultStore {
+	return &resultStore{sessions: make(map[string][]storedResult)}
+}
+
+// Record keeps the locations of a tool result for a session and returns its
+// result ID
+func (r *resultStore) Record(session, tool string, arguments map[string]any, doc format.Document) string {
+	query := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		if !presentationArguments[name] {
+			query[name] = value
+		}
+	}
+	encoded, _ := json.Marshal(query)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next++
+	id := fmt.Sprintf("r%d", r.next)
+	r.add(session, storedResult{
+		ID:        id,
+		Tool:      tool,
+		Query:     string(encoded),
+		Locations: documentLocations(doc),
+	})
+	return id
+}
+
+// add appends a result to those of a session, dropping the oldest ones over
+// the limit
+func (r *resultStore) add(session string, result storedResult) {
+	results := append(r.sessions[session], result)
+	if len(results) > maxStoredResults {
+		results = results[len(results)-maxStoredResults:]
+	}
+	r.sessions[session] = results
+}
+
+// Share gives a session a result recorded by another session, when a call of
+// the session shared the run that recorded it
+func (r *resultStore) Share(session, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.find(session, id); ok {
+		return
+	}
+	for _, results := range r.sessions {
+		for _, result := range results {
+			if result.ID == id {
+				r.add(session, result)
+				return
+			}
+		}
+	}
+}
+
+// Get returns a stored result of a session by ID
+func (r *resultStore) Get(session, id string) (storedResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if result, ok := r.find(session, id); ok {
+		return result, nil
+	}
+	return storedResult{}, fmt.Errorf("unknown result ID %s, only the last %d results of this session are kept", id, maxStoredResults)
+}
+
+func (r *resultStore) find(session, id string) (storedResult, bool) {
+	for _, result := range r.sessions[session] {
+		if result.ID == id {
+			return result, true
+		}
+	}
+	return storedResult{}, false
+}
+
+// Forget drops the results of a session that has disconnected
+func (r *resultStore) Forget(ctx context.Context, session server.ClientSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, session.SessionID())
+}
+
+// documentLocations lists the lines a document points at: the focus lines of
+// each section, or the file itself for sections without any
+func documentLocations(doc format.Document) []resultLocation {
+	var locations []resultLocation
+	for _, section := range doc.Sections {
+		if section.Path == "" {
+			continue
+		}
+		if len(section.Focus) == 0 {
+			locations = append(locations, resultLocation{Path: section.Path})
+			continue
+		}
+		for _, line := range section.Focus {
+			locations = append(locations, resultLocation{Path: section.Path, Line: line, Text: snippetLine(section.Snippets, line)})
+		}
+	}
+	return locations
+}
+
+// snippetLine returns the trimmed text of a 1-indexed line from the snippets
+// that show it, or "" when none does
+func snippetLine(snippets []format.Snippet, line int) string {
+	for _, snippet := range snippets {
+		if i := line - snippet.StartLine; i >= 0 && i < len(snippet.Lines) {
+			return strings.TrimSpace(snippet.Lines[i])
+		}
+	}
+	return ""
+}
+
+// resultDiff is the locations one result has and another has not
+type resultDiff struct {
+	Removed   []resultLocation
+	Added     []resultLocation
+	Unchanged int
+}
+
+// diffResults compares the locations of two results. Locations are matched by
+// file and line text first, so that lines moved by edits count as unchanged,
+// and by line number when the text is not known.
+func diffResults(before, after storedResult) resultDiff {
+	key := func(loc resultLocation) string {
+		if loc.Text == "" {
+			return fmt.Sprintf("%s\x00%d", loc.Path, loc.Line)
+		}
+		return loc.Path + "\x00" + loc.Text
+	}
+
+	remaining := make(map[string]int)
+	for _, loc := range after.Locations {
+		remaining[key(loc)]++
+	}
+	var diff resultDiff
+	matched := make(map[string]int)
+	for _, loc := range before.Locations {
+		k := key(loc)
+		if remaining[k] > 0 {
+			remaining[k]--
+			matched[k]++
+			diff.Unchanged++
+			continue
+		}
+		diff.Removed = append(diff.Removed, loc)
+	}
+	for _, loc := range after.Locations {
+		k := key(loc)
+		if matched[k] > 0 {
+			matched[k]--
+			continue
+		}
+		diff.Added = append(diff.Added, loc)
+	}
+	sortLocations(diff.Removed)
+	sortLocations(diff.Added)
+	return diff
+}
+
+func sortLocations(locations []resultLocation) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		if locations[i].Path != locations[j].Path {
+			return locations[i].Path < locations[j].Path
+		}
+		return locations[i].Line < locations[j].Line
+	})
+}
+
+// formatResultDiff describes the difference between two results for
+// diff_results
+func formatResultDiff(workspaceDir string, before, after storedResult, diff resultDiff) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Comparing %s (%s %s) with %s (%s %s)\n", before.ID, before.Tool, before.Query, after.ID, after.Tool, after.Query))
+	switch {
+	case len(diff.Removed) == 0 && len(diff.Added) == 0:
+		b.WriteString(fmt.Sprintf("No changes, both results have the same %d locations\n", diff.Unchanged))
+		return b.String()
+	case len(after.Locations) == 0:
+		b.WriteString(fmt.Sprintf("All %d locations were removed, none are left\n", len(diff.Removed)))
+	default:
+		b.WriteString(fmt.Sprintf("Removed: %d, added: %d, unchanged: %d\n", len(diff.Removed), len(diff.Added), diff.Unchanged))
+	}
+
+	for _, group := range []struct {
+		name      string
+		locations []resultLocation
+	}{{"Removed", diff.Removed}, {"Added", diff.Added}} {
+		if len(group.locations) == 0 {
+			continue
+		}
+		b.WriteString("\n" + group.name + ":\n")
+		for _, loc := range group.locations {
+			b.WriteString("  " + relativePath(workspaceDir, loc.Path))
+			if loc.Line > 0 {
+				b.WriteString(fmt.Sprintf(":L%d", loc.Line))
+			}
+			if loc.Text != "" {
+				b.WriteString(": " + loc.Text)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
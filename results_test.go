@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referencesDoc builds a references result with one focus line per reference
+func referencesDoc(refs map[string][]int, text map[int]string) format.Document {
+	var doc format.Document
+	for _, path := range []string{"/ws/a.go", "/ws/b.go"} {
+		lines, ok := refs[path]
+		if !ok {
+			continue
+		}
+		section := format.Section{Path: path, Focus: lines}
+		for _, line := range lines {
+			section.Snippets = append(section.Snippets, format.Snippet{StartLine: line, Lines: []string{"\t" + text[line]}})
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc
+}
+
+func TestResultStore(t *testing.T) {
+	store := newResultStore()
+	doc := referencesDoc(map[string][]int{"/ws/a.go": {3}}, map[int]string{3: "Foo()"})
+	id := store.Record("a", "references", map[string]any{"symbolName": "Foo", "format": "json", "max_tokens": float64(500)}, doc)
+	assert.Equal(t, "r1", id)
+
+	result, err := store.Get("a", "r1")
+	require.NoError(t, err)
+	assert.Equal(t, "references", result.Tool)
+	assert.Equal(t, `{"symbolName":"Foo"}`, result.Query)
+	assert.Equal(t, []resultLocation{{Path: "/ws/a.go", Line: 3, Text: "Foo()"}}, result.Locations)
+
+	// Other sessions cannot read the result until they share it
+	_, err = store.Get("b", "r1")
+	assert.EqualError(t, err, "unknown result ID r1, only the last 100 results of this session are kept")
+	store.Share("b", "r1")
+	store.Share("b", "r1")
+	_, err = store.Get("b", "r1")
+	assert.NoError(t, err)
+	assert.Len(t, store.sessions["b"], 1)
+
+	// Old results are dropped
+	for i := 0; i < maxStoredResults; i++ {
+		store.Record("a", "references", nil, doc)
+	}
+	_, err = store.Get("a", "r1")
+	assert.Error(t, err)
+	_, err = store.Get("a", "r2")
+	assert.NoError(t, err)
+
+	// IDs stay unique across sessions
+	assert.Equal(t, "r102", store.Record("b", "references", nil, doc))
+
+	store.Forget(context.Background(), fakeSession{id: "a"})
+	_, err = store.Get("a", "r2")
+	assert.Error(t, err)
+	_, err = store.Get("b", "r1")
+	assert.NoError(t, err)
+}
+
+func TestDiffResults(t *testing.T) {
+	text := map[int]string{3: "Foo()", 5: "x := Foo()", 7: "Foo()", 9: "Bar()"}
+	before := storedResult{ID: "r1", Tool: "references", Query: `{"symbolName":"Foo"}`, Locations: documentLocations(
+		referencesDoc(map[string][]int{"/ws/a.go": {3, 7}, "/ws/b.go": {5}}, text))}
+
+	// An edit above moved b.go's reference from line 5 to 6 and one of the
+	// two identical calls in a.go was removed
+	after := storedResult{ID: "r2", Tool: "references", Query: `{"symbolName":"Foo"}`, Locations: documentLocations(
+		referencesDoc(map[string][]int{"/ws/a.go": {3, 9}, "/ws/b.go": {6}}, map[int]string{3: "Foo()", 6: "x := Foo()", 9: "Bar()"}))}
+
+	diff := diffResults(before, after)
+	assert.Equal(t, 2, diff.Unchanged)
+	assert.Equal(t, []resultLocation{{Path: "/ws/a.go", Line: 7, Text: "Foo()"}}, diff.Removed)
+	assert.Equal(t, []resultLocation{{Path: "/ws/a.go", Line: 9, Text: "Bar()"}}, diff.Added)
+	assert.Equal(t, `Comparing r1 (references {"symbolName":"Foo"}) with r2 (references {"symbolName":"Foo"})
+Removed: 1, added: 1, unchanged: 2
+
+Removed:
+  a.go:L7: Foo()
+
+Added:
+  a.go:L9: Bar()
+`, formatResultDiff("/ws", before, after, diff))
+
+	empty := storedResult{ID: "r3", Tool: "references", Query: `{"symbolName":"Foo"}`}
+	assert.Contains(t, formatResultDiff("/ws", before, empty, diffResults(before, empty)), "All 3 locations were removed, none are left\n")
+	assert.Contains(t, formatResultDiff("/ws", before, before, diffResults(before, before)), "No changes, both results have the same 3 locations\n")
+}
+
+func TestDocumentLocationsWithoutFocus(t *testing.T) {
+	doc := format.Document{Sections: []format.Section{{Path: "/ws/a.go"}, {Notes: []string{"summary"}}}}
+	assert.Equal(t, []resultLocation{{Path: "/ws/a.go"}}, documentLocations(doc))
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// statusTool is the name of the tool reporting scheduler metrics
+const statusTool = "status"
+
+// unscheduledTools never take a scheduler slot: status so that it answers while
+// the language server is busy, and the tools that spend most of a long run
+// waiting for the server, which would otherwise hold slots other sessions need
+var unscheduledTools = map[string]bool{
+	statusTool:          true,
+	"watch_diagnostics": true,
+	"warmup":            true,
+}
+
+// scheduler admits tool calls to the shared language server. At most slots
+// calls run at once; waiting calls are admitted by taking turns between
+// sessions, so one session queueing many workspace-wide queries cannot
+// starve another session's quick hover.
+type scheduler struct {
+	slots int
+
+	mu      sync.Mutex
+	running int
+	queues  map[string][]*waiter
+	order   []string // sessions with waiting calls, in turn order
+	next    int
+	stats   map[string]*sessionStats
+}
+
+// waiter is a queued tool call
+type waiter struct {
+	ready    chan struct{}
+	granted  bool
+	queuedAt time.Time
+}
+
+// sessionStats are the queue metrics of one session
+type sessionStats struct {
+	Queued    int
+	Running   int
+	Completed int
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// newScheduler creates a scheduler running up to slots calls at once. Zero
+// or less means calls are never queued.
+func newScheduler(slots int) *scheduler {
+	return &scheduler{
+		slots:  slots,
+		queues: make(map[string][]*waiter),
+		stats:  make(map[string]*sessionStats),
+	}
+}
+
+// Acquire waits for the turn of a call from the given session and returns the
+// function that must be called when the call is done
+func (s *scheduler) Acquire(ctx context.Context, session string) (func(), error) {
+	s.mu.Lock()
+	stats := s.sessionStats(session)
+	if s.slots <= 0 || (s.running < s.slots && len(s.order) == 0) {
+		s.running++
+		stats.Running++
+		s.mu.Unlock()
+		return s.releaser(session), nil
+	}
+
+	w := &waiter{ready: make(chan struct{}), queuedAt: time.Now()}
+	if len(s.queues[session]) == 0 {
+		s.order = append(s.order, session)
+	}
+	s.queues[session] = append(s.queues[session], w)
+	stats.Queued++
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(session), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.granted {
+			// Admitted while being cancelled, hand the slot on
+			s.release(session)
+		} else {
+			s.remove(session, w)
+			stats.Queued--
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// sessionStats returns the metrics of a session, creating them if needed.
+// s.mu must be held.
+func (s *scheduler) sessionStats(session string) *sessionStats {
+	stats, ok := s.stats[session]
+	if !ok {
+		stats = &sessionStats{}
+		s.stats[session] = stats
+	}
+	return stats
+}
+
+// releaser returns a function releasing a slot of a session once
+func (s *scheduler) releaser(session string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.release(session)
+		})
+	}
+}
+
+// release frees the slot of a finished call and admits waiting calls, one
+// session at a time. s.mu must be held.
+func (s *scheduler) release(session string) {
+	s.running--
+	stats := s.sessionStats(session)
+	stats.Running--
+	stats.Completed++
+
+	for (s.slots <= 0 || s.running < s.slots) && len(s.order) > 0 {
+		s.next %= len(s.order)
+		turn := s.order[s.next]
+		queue := s.queues[turn]
+		w := queue[0]
+		s.queues[turn] = queue[1:]
+		if len(s.queues[turn]) == 0 {
+			delete(s.queues, turn)
+			s.order = append(s.order[:s.next], s.order[s.next+1:]...)
+		} else {
+			s.next++
+		}
+
+		wait := time.Since(w.queuedAt)
+		turnStats := s.sessionStats(turn)
+		turnStats.Queued--
+		turnStats.Running++
+		turnStats.TotalWait += wait
+		turnStats.MaxWait = max(turnStats.MaxWait, wait)
+		s.running++
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// remove drops a cancelled waiter from its session's queue. s.mu must be held.
+func (s *scheduler) remove(session string, w *waiter) {
+	queue := s.queues[session]
+	for i, queued := range queue {
+		if queued == w {
+			s.queues[session] = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(s.queues[session]) > 0 {
+		return
+	}
+	delete(s.queues, session)
+	for i, turn := range s.order {
+		if turn == session {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			if s.next > i {
+				s.next--
+			}
+			break
+		}
+	}
+}
+
+// Forget drops the metrics of a session that has ended
+func (s *scheduler) Forget(ctx context.Context, session server.ClientSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stats, ok := s.stats[session.SessionID()]; ok && stats.Queued == 0 && stats.Running == 0 {
+		delete(s.stats, session.SessionID())
+	}
+}
+
+// Status describes the slots in use and the queue metrics of every session
+func (s *scheduler) Status() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result strings.Builder
+	queued := 0
+	for _, queue := range s.queues {
+		queued += len(queue)
+	}
+	if s.slots <= 0 {
+		result.WriteString(fmt.Sprintf("Scheduler: %d calls running, no concurrency limit\n", s.running))
+	} else {
+		result.WriteString(fmt.Sprintf("Scheduler: %d of %d slots in use, %d calls queued\n", s.running, s.slots, queued))
+	}
+
+	sessions := make([]string, 0, len(s.stats))
+	for session := range s.stats {
+		sessions = append(sessions, session)
+	}
+	sort.Strings(sessions)
+	if len(sessions) == 0 {
+		return result.String()
+	}
+
+	result.WriteString("\nSessions:\n")
+	for _, session := range sessions {
+		stats := s.stats[session]
+		name := session
+		if name == "" {
+			name = "(no session)"
+		}
+		average := time.Duration(0)
+		if admitted := stats.Completed + stats.Running; admitted > 0 {
+			average = stats.TotalWait / time.Duration(admitted)
+		}
+		result.WriteString(fmt.Sprintf("  %s: %d running, %d queued, %d completed, average wait %s, max wait %s\n",
+			name, stats.Running, stats.Queued, stats.Completed, average.Round(time.Millisecond), stats.MaxWait.Round(time.Millisecond)))
+	}
+	return result.String()
+}
+
+// scheduleMiddleware queues tool calls in the scheduler, except the
+// unscheduled tools. It runs inside the timeout middleware, so time spent
+// waiting counts towards a call's timeout.
+func (s *mcpServer) scheduleMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if unscheduledTools[request.Params.Name] {
+			return next(ctx, request)
+		}
+
+		release, err := s.scheduler.Acquire(ctx, sessionID(ctx))
+		if err != nil {
+			return s.toolError(ctx, request, fmt.Errorf("%s was cancelled while waiting for the language server: %w", request.Params.Name, err)), nil
+		}
+		defer release()
+		return next(ctx, request)
+	}
+}
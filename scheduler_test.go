@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerTakesTurnsBetweenSessions(t *testing.T) {
+	sched := newScheduler(1)
+	release, err := sched.Acquire(context.Background(), "busy")
+	assert.NoError(t, err)
+
+	// The busy session queues several calls before a quick call from another session
+	var mu sync.Mutex
+	var admitted []string
+	var wg sync.WaitGroup
+	enqueue := func(session string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := sched.Acquire(context.Background(), session)
+			assert.NoError(t, err)
+			mu.Lock()
+			admitted = append(admitted, session)
+			mu.Unlock()
+			done()
+		}()
+		assert.Eventually(t, func() bool {
+			sched.mu.Lock()
+			defer sched.mu.Unlock()
+			return sched.stats[session].Queued > 0
+		}, time.Second, time.Millisecond)
+	}
+	enqueue("busy")
+	enqueue("busy")
+	enqueue("busy")
+	enqueue("quick")
+	assert.Contains(t, sched.Status(), "Scheduler: 1 of 1 slots in use, 4 calls queued")
+
+	release()
+	wg.Wait()
+	assert.Equal(t, []string{"busy", "quick", "busy", "busy"}, admitted)
+
+	status := sched.Status()
+	assert.Contains(t, status, "Scheduler: 0 of 1 slots in use, 0 calls queued")
+	assert.Contains(t, status, "  busy: 0 running, 0 queued, 4 completed")
+	assert.Contains(t, status, "  quick: 0 running, 0 queued, 1 completed")
+}
+
+func TestSchedulerCancelledWhileQueued(t *testing.T) {
+	sched := newScheduler(1)
+	release, err := sched.Acquire(context.Background(), "a")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = sched.Acquire(ctx, "b")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release() // releasing twice is harmless
+	next, err := sched.Acquire(context.Background(), "b")
+	assert.NoError(t, err)
+	next()
+	assert.Contains(t, sched.Status(), "0 of 1 slots in use, 0 calls queued")
+}
+
+func TestSchedulerUnlimited(t *testing.T) {
+	sched := newScheduler(0)
+	var releases []func()
+	for i := 0; i < 10; i++ {
+		release, err := sched.Acquire(context.Background(), "a")
+		assert.NoError(t, err)
+		releases = append(releases, release)
+	}
+	assert.Contains(t, sched.Status(), "10 calls running, no concurrency limit")
+	for _, release := range releases {
+		release()
+	}
+}
+
+func TestScheduleMiddlewareUnscheduledTools(t *testing.T) {
+	s := &mcpServer{scheduler: newScheduler(1)}
+	release, err := s.scheduler.Acquire(context.Background(), "busy")
+	assert.NoError(t, err)
+	defer release()
+
+	// With every slot taken, the long waits still start right away
+	handler := s.scheduleMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ran"), nil
+	})
+	for _, name := range []string{statusTool, "watch_diagnostics", "warmup"} {
+		var request mcp.CallToolRequest
+		request.Params.Name = name
+		result, err := handler(sessionContext("other"), request)
+		assert.NoError(t, err)
+		assert.Equal(t, "ran", result.Content[0].(mcp.TextContent).Text, name)
+	}
+	assert.Contains(t, s.scheduler.Status(), "1 of 1 slots in use, 0 calls queued")
+}
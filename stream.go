@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// streamArgument makes a tool send each file section of its result to the
+// client as soon as it is found
+const streamArgument = "stream"
+
+// withStream adds the stream parameter to tools whose results can span the
+// whole workspace
+func withStream() mcp.ToolOption {
+	return mcp.WithBoolean(streamArgument,
+		mcp.Description("Send each file of the result in a notification as soon as it is found, and end with a count instead of the whole result. For clients that show progress or log notifications, on queries across a large workspace."),
+	)
+}
+
+// sectionStream returns a function that renders each section of a streamed
+// result on its own, in the format and with the annotations the call asked
+// for, and sends it to the client: as notifications/progress when the call
+// has a progress token, and as notifications/message otherwise. The sections
+// share the documentBudget of the call, each shrunk to what is left of it,
+// and sections past it are not sent. Sent sections count towards the
+// session's output budget.
+func (s *mcpServer) sectionStream(ctx context.Context, request mcp.CallToolRequest) func(format.Document, format.Section) bool {
+	var progressToken mcp.ProgressToken
+	if request.Params.Meta != nil {
+		progressToken = request.Params.Meta.ProgressToken
+	}
+	sent := 0
+	sentTokens := 0
+	return func(doc format.Document, section format.Section) bool {
+		chunk, renderer, err := s.prepareDocument(ctx, request, format.Document{
+			Sections: []format.Section{section},
+			Banner:   doc.Banner,
+		})
+		if err != nil {
+			// The result reports the error once the call is done
+			return true
+		}
+		budget := s.documentBudget(ctx, request)
+		if budget.MaxTokens > 0 {
+			if sentTokens >= budget.MaxTokens {
+				return false
+			}
+			budget.MaxTokens -= sentTokens
+		}
+		// Notes about the whole document belong to the final result
+		chunk.Preamble = ""
+		text := format.Fit(chunk, renderer, budget)
+		sent++
+		tokens := textTokens(text)
+		sentTokens += tokens
+		if s.outputBudget.enabled() {
+			s.outputBudget.Add(sessionID(ctx), tokens)
+		}
+
+		if progressToken != nil {
+			err = s.mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+				"progressToken": progressToken,
+				"progress":      sent,
+				"message":       text,
+			})
+		} else {
+			err = s.mcpServer.SendNotificationToClient(ctx, "notifications/message", map[string]any{
+				"level":  "info",
+				"logger": request.Params.Name,
+				"data":   text,
+			})
+		}
+		if err != nil {
+			coreLogger.Debug("Failed to send a section of %s: %v", request.Params.Name, err)
+		}
+		return true
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notifyingSession keeps the notifications sent to it
+type notifyingSession struct {
+	fakeSession
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (n notifyingSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return n.notifications
+}
+
+// streamTestServer returns a server with an output budget and the context of a
+// session that receives notifications
+func streamTestServer(t *testing.T) (*mcpServer, context.Context, chan mcp.JSONRPCNotification) {
+	cfg := settings.Default()
+	cfg.OutputBudget.MaxTokens = 100000
+	srv := server.NewMCPServer("test", "v0")
+	s := &mcpServer{
+		config:         config{settings: cfg},
+		mcpServer:      srv,
+		pool:           newClientPool(config{settings: cfg}),
+		outputVersions: newOutputVersions("v1"),
+		outputBudget:   newOutputBudget(cfg.OutputBudget),
+	}
+	notifications := make(chan mcp.JSONRPCNotification, 10)
+	ctx := srv.WithContext(context.Background(), notifyingSession{fakeSession{"stream"}, notifications})
+	t.Cleanup(func() { close(notifications) })
+	return s, ctx, notifications
+}
+
+// streamSection is a file section with a long snippet
+func streamSection(name string) format.Section {
+	snippet := format.Snippet{StartLine: 1}
+	for i := 1; i <= 40; i++ {
+		snippet.Lines = append(snippet.Lines, fmt.Sprintf("var value%d = compute(%d)", i, i))
+	}
+	return format.Section{Path: "/ws/" + name, Language: "go", Snippets: []format.Snippet{snippet}, Focus: []int{20}}
+}
+
+func streamedText(t *testing.T, notifications chan mcp.JSONRPCNotification) string {
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, "notifications/message", notification.Method)
+		return notification.Params.AdditionalFields["data"].(string)
+	default:
+		require.Fail(t, "no notification was sent")
+		return ""
+	}
+}
+
+func TestSectionStream(t *testing.T) {
+	s, ctx, notifications := streamTestServer(t)
+	var request mcp.CallToolRequest
+	request.Params.Name = "references"
+	request.Params.Arguments = map[string]any{"stream": true}
+
+	stream := s.sectionStream(ctx, request)
+	doc := format.Document{Preamble: "Found 2 files\n", Banner: "---\n\n"}
+	assert.True(t, stream(doc, streamSection("a.go")))
+	assert.True(t, stream(doc, streamSection("b.go")))
+
+	first := streamedText(t, notifications)
+	second := streamedText(t, notifications)
+	assert.Contains(t, first, "/ws/a.go")
+	assert.Contains(t, first, "var value40 = compute(40)")
+	assert.NotContains(t, first, "Found 2 files", "the preamble belongs to the final result")
+	assert.Contains(t, second, "/ws/b.go")
+
+	// Streamed sections count towards the session's output budget
+	assert.Equal(t, textTokens(first)+textTokens(second), s.outputBudget.Used("stream"))
+}
+
+func TestSectionStreamMaxTokens(t *testing.T) {
+	s, ctx, notifications := streamTestServer(t)
+	var request mcp.CallToolRequest
+	request.Params.Name = "references"
+	request.Params.Arguments = map[string]any{"stream": true, "max_tokens": float64(60)}
+
+	// The sections share max_tokens, each is shrunk to what is left
+	stream := s.sectionStream(ctx, request)
+	doc := format.Document{Banner: "---\n\n"}
+	sent := 0
+	for i := 0; i < 10 && stream(doc, streamSection(fmt.Sprintf("f%d.go", i))); i++ {
+		sent++
+	}
+	assert.Greater(t, sent, 0)
+	assert.Less(t, sent, 10, "sections past max_tokens are not sent")
+
+	used := 0
+	for i := 0; i < sent; i++ {
+		text := streamedText(t, notifications)
+		assert.NotContains(t, text, "var value1 ", "snippets are shrunk to fit")
+		used += textTokens(text)
+	}
+	assert.LessOrEqual(t, used, 60+textTokens("---\n\n/ws/f0.go\n"))
+	assert.Equal(t, used, s.outputBudget.Used("stream"))
+}
+
+func TestRenderDocumentStreamed(t *testing.T) {
+	s, ctx, _ := streamTestServer(t)
+	var request mcp.CallToolRequest
+	request.Params.Name = "references"
+
+	result := s.renderDocument(ctx, request, format.Document{Streamed: 3, EmptyKind: "symbol_not_found"})
+	assert.Equal(t, "Sections sent in notifications while the result was produced: 3\n", result.Content[0].(mcp.TextContent).Text)
+
+	result = s.renderDocument(ctx, request, format.Document{Streamed: 2, StreamOmitted: 4})
+	assert.Equal(t, "Sections sent in notifications while the result was produced: 2\n... 4 more results omitted to fit max_tokens\n", result.Content[0].(mcp.TextContent).Text)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// timeoutArgument is the argument every tool accepts to bound a single call
+const timeoutArgument = "timeout_ms"
+
+// toolTimeout returns how long a tool call may run: the timeout_ms argument
+// capped at the configured maximum, or the configured default when the call
+// does not pass one. Zero means no timeout.
+func toolTimeout(request mcp.CallToolRequest, cfg settings.ToolTimeoutSettings) time.Duration {
+	ms := cfg.DefaultMs
+	if requested, ok := numberArgument(request, timeoutArgument); ok && requested > 0 {
+		ms = requested
+	}
+	if cfg.MaxMs > 0 && (ms <= 0 || ms > cfg.MaxMs) {
+		ms = cfg.MaxMs
+	}
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// timeoutMiddleware cancels tool calls that run longer than their timeout.
+// Results returned before the deadline, including partial results from tools
+// that stop early when cancelled, are passed through unchanged.
+func (s *mcpServer) timeoutMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		timeout := toolTimeout(request, s.config.settings.ToolTimeouts)
+		if timeout == 0 {
+			return next(ctx, request)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, err := next(ctx, request)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && (err != nil || result == nil || result.IsError) {
+			coreLogger.Warn("Tool %s timed out after %s", request.Params.Name, timeout)
+			return s.toolError(ctx, request, tools.NewError(tools.KindTimeout, fmt.Errorf("%s timed out after %s, pass a larger %s (up to %dms) to allow more time",
+				request.Params.Name, timeout, timeoutArgument, s.config.settings.ToolTimeouts.MaxMs))), nil
+		}
+		return result, err
+	}
+}
+
+// withTimeoutParameter is a tool filter that advertises timeout_ms on every tool
+func (s *mcpServer) withTimeoutParameter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	description := "Maximum time in milliseconds to spend on this call"
+	if cfg := s.config.settings.ToolTimeouts; cfg.DefaultMs > 0 {
+		description += fmt.Sprintf(" (default %d", cfg.DefaultMs)
+		if cfg.MaxMs > 0 {
+			description += fmt.Sprintf(", max %d", cfg.MaxMs)
+		}
+		description += ")"
+	}
+
+	for i, tool := range tools {
+		properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+1)
+		for name, property := range tool.InputSchema.Properties {
+			properties[name] = property
+		}
+		properties[timeoutArgument] = map[string]interface{}{
+			"type":        "number",
+			"description": description,
+		}
+		tools[i].InputSchema.Properties = properties
+	}
+	return tools
+}
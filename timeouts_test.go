@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func callWithArguments(arguments map[string]interface{}) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "definition"
+	request.Params.Arguments = arguments
+	return request
+}
+
+func TestToolTimeout(t *testing.T) {
+	cfg := settings.ToolTimeoutSettings{DefaultMs: 1000, MaxMs: 5000}
+
+	assert.Equal(t, time.Second, toolTimeout(callWithArguments(nil), cfg))
+	assert.Equal(t, 250*time.Millisecond, toolTimeout(callWithArguments(map[string]interface{}{"timeout_ms": float64(250)}), cfg))
+	assert.Equal(t, 5*time.Second, toolTimeout(callWithArguments(map[string]interface{}{"timeout_ms": float64(60000)}), cfg))
+	assert.Equal(t, time.Second, toolTimeout(callWithArguments(map[string]interface{}{"timeout_ms": float64(-1)}), cfg))
+
+	// No default and no cap means no timeout
+	assert.Equal(t, time.Duration(0), toolTimeout(callWithArguments(nil), settings.ToolTimeoutSettings{}))
+	// A cap without a default still bounds calls
+	assert.Equal(t, 5*time.Second, toolTimeout(callWithArguments(nil), settings.ToolTimeoutSettings{MaxMs: 5000}))
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	s := &mcpServer{config: config{settings: settings.Default()}, outputVersions: newOutputVersions("v1")}
+
+	slow := s.timeoutMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	result, err := slow(context.Background(), callWithArguments(map[string]interface{}{"timeout_ms": float64(10)}))
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "definition timed out after 10ms")
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "\nError kind: timeout")
+
+	// Partial results returned when the deadline passes are kept
+	partial := s.timeoutMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return mcp.NewToolResultText("partial"), nil
+	})
+	result, err = partial(context.Background(), callWithArguments(map[string]interface{}{"timeout_ms": float64(10)}))
+	assert.NoError(t, err)
+	assert.False(t, result.IsError)
+}
+
+func TestWithTimeoutParameter(t *testing.T) {
+	s := &mcpServer{config: config{settings: settings.Default()}}
+	tool := mcp.NewTool("definition", mcp.WithString("symbolName", mcp.Required()))
+
+	listed := s.withTimeoutParameter(context.Background(), []mcp.Tool{tool})
+	assert.Contains(t, listed[0].InputSchema.Properties, "timeout_ms")
+	assert.Contains(t, listed[0].InputSchema.Properties, "symbolName")
+	// The registered tool is not modified
+	assert.NotContains(t, tool.InputSchema.Properties, "timeout_ms")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// contextLinesFromEnv returns the context lines set by LSP_CONTEXT_LINES, or
+// -1 for each tool's default
+func contextLinesFromEnv() int {
+	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
+		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
+			return val
+		}
+	}
+	return -1
+}
+
+// sessionOverrides tracks the tool settings each client session changed for
+// itself, falling back to the server's for sessions that changed nothing
+type sessionOverrides struct {
+	contextLines map[string]int
+	focus        map[string]string
+	mu           sync.RWMutex
+}
+
+func newSessionOverrides() *sessionOverrides {
+	return &sessionOverrides{contextLines: make(map[string]int), focus: make(map[string]string)}
+}
+
+// SetContextLines sets the context lines for the session of ctx. Negative
+// values go back to the server's setting.
+func (o *sessionOverrides) SetContextLines(ctx context.Context, lines int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if lines < 0 {
+		delete(o.contextLines, sessionID(ctx))
+		return
+	}
+	o.contextLines[sessionID(ctx)] = lines
+}
+
+// SetFocus limits the results of the session of ctx to a directory, resolved
+// with tools.ResolveFocus. An empty directory clears the focus.
+func (o *sessionOverrides) SetFocus(ctx context.Context, dir string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if dir == "" {
+		delete(o.focus, sessionID(ctx))
+		return
+	}
+	o.focus[sessionID(ctx)] = dir
+}
+
+// Apply applies the overrides of the session of ctx to a tool context
+func (o *sessionOverrides) Apply(ctx context.Context, tc *tools.ToolContext) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if lines, ok := o.contextLines[sessionID(ctx)]; ok {
+		tc.ContextLines = lines
+	}
+	tc.FocusDir = o.focus[sessionID(ctx)]
+}
+
+// Forget drops the overrides of a session that has disconnected
+func (o *sessionOverrides) Forget(ctx context.Context, session server.ClientSession) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.contextLines, session.SessionID())
+	delete(o.focus, session.SessionID())
+}
+
+// toolContext returns the context a tool call runs with: the active language
+// server, the configuration and the overrides of the caller's session
+func (s *mcpServer) toolContext(ctx context.Context) *tools.ToolContext {
+	tc := &tools.ToolContext{
+		Client:       s.client(),
+		Peers:        s.pool.Peers(),
+		WorkspaceDir: s.config.workspaceDir,
+		Settings:     s.config.settings,
+		Resolver:     s.resolver,
+		Redactor:     s.redactor,
+		ContextLines: s.contextLines,
+	}
+	s.overrides.Apply(ctx, tc)
+	return tc
+}
+
+// callToolContext returns the context of a tool call with the arguments that
+// change it for the call: focus replaces the session's focus, and from_file
+// names the file the caller is reading
+func (s *mcpServer) callToolContext(ctx context.Context, request mcp.CallToolRequest) (*tools.ToolContext, error) {
+	tc := s.toolContext(ctx)
+	if path, ok := request.Params.Arguments["focus"].(string); ok {
+		dir, err := tools.ResolveFocus(s.config.workspaceDir, path)
+		if err != nil {
+			return nil, err
+		}
+		tc.FocusDir = dir
+	}
+	tc.FromFile, _ = request.Params.Arguments["from_file"].(string)
+	if stream, _ := request.Params.Arguments[streamArgument].(bool); stream {
+		tc.Stream = s.sectionStream(ctx, request)
+	}
+	return tc, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/settings"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionOverridesContextLines(t *testing.T) {
+	overrides := newSessionOverrides()
+	ctx := context.Background()
+
+	tc := &tools.ToolContext{ContextLines: 5}
+	overrides.Apply(ctx, tc)
+	assert.Equal(t, 5, tc.ContextLines)
+
+	overrides.SetContextLines(ctx, 0)
+	overrides.Apply(ctx, tc)
+	assert.Equal(t, 0, tc.ContextLines)
+
+	// A negative value goes back to the server's setting
+	overrides.SetContextLines(ctx, -1)
+	tc = &tools.ToolContext{ContextLines: 5}
+	overrides.Apply(ctx, tc)
+	assert.Equal(t, 5, tc.ContextLines)
+}
+
+func TestContextLinesFromEnv(t *testing.T) {
+	t.Setenv("LSP_CONTEXT_LINES", "3")
+	assert.Equal(t, 3, contextLinesFromEnv())
+
+	t.Setenv("LSP_CONTEXT_LINES", "many")
+	assert.Equal(t, -1, contextLinesFromEnv())
+}
+
+func TestSessionOverridesFocus(t *testing.T) {
+	overrides := newSessionOverrides()
+	ctx := context.Background()
+
+	overrides.SetFocus(ctx, "/ws/services/api")
+	tc := &tools.ToolContext{}
+	overrides.Apply(ctx, tc)
+	assert.Equal(t, "/ws/services/api", tc.FocusDir)
+
+	overrides.SetFocus(ctx, "")
+	overrides.Apply(ctx, tc)
+	assert.Empty(t, tc.FocusDir)
+}
+
+func TestCallToolContext(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "services", "api"), 0755))
+	cfg := config{workspaceDir: dir, settings: settings.Default()}
+	s := &mcpServer{config: cfg, pool: newClientPool(cfg), overrides: newSessionOverrides()}
+	ctx := context.Background()
+	s.overrides.SetFocus(ctx, filepath.Join(dir, "services"))
+
+	request := callWithArguments(nil)
+	tc, err := s.callToolContext(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "services"), tc.FocusDir)
+
+	// A call can narrow the focus or drop it
+	tc, err = s.callToolContext(ctx, callWithArguments(map[string]interface{}{"focus": "services/api"}))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "services", "api"), tc.FocusDir)
+	tc, err = s.callToolContext(ctx, callWithArguments(map[string]interface{}{"focus": "."}))
+	require.NoError(t, err)
+	assert.Empty(t, tc.FocusDir)
+
+	_, err = s.callToolContext(ctx, callWithArguments(map[string]interface{}{"focus": "../elsewhere"}))
+	assert.Equal(t, tools.KindWorkspaceViolation, tools.KindOf(err))
+
+	tc, err = s.callToolContext(ctx, callWithArguments(map[string]interface{}{"from_file": "services/api/main.go"}))
+	require.NoError(t, err)
+	assert.Equal(t, "services/api/main.go", tc.FromFile)
+}
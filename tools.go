@@ -2,15 +2,331 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/isaacphi/mcp-language-server/internal/codeowners"
+	"github.com/isaacphi/mcp-language-server/internal/debugbundle"
+	"github.com/isaacphi/mcp-language-server/internal/gitinfo"
+	"github.com/isaacphi/mcp-language-server/internal/logging"
 	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/tools/format"
+	"github.com/isaacphi/mcp-language-server/internal/tools/resolve"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
+// numberArgument reads a numeric tool argument, accepting both float64 and int
+// because of JSON parsing
+func numberArgument(request mcp.CallToolRequest, name string) (int, bool) {
+	switch v := request.Params.Arguments[name].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// withFormat adds the output format and max_tokens parameters shared by tools
+// returning documents
+func withFormat() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithString("format",
+			mcp.Description("Output format: plain, markdown or json. Defaults to the session's output version (see set_output_version)."),
+			mcp.Enum(format.Names()...),
+		)(tool)
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Approximate token limit for the result. Larger results are shrunk by dropping context lines, then code snippets, then details, rather than being cut off."),
+		)(tool)
+		mcp.WithBoolean("owners",
+			mcp.Description("Annotate each file with its owners from the workspace's CODEOWNERS file"),
+		)(tool)
+		mcp.WithBoolean("git",
+			mcp.Description("Annotate each file with its git status (modified, untracked, ...) and whether it changed on the current branch compared to the default branch"),
+		)(tool)
+	}
+}
+
+// withBlame adds the blame parameter to tools whose results point at call
+// sites or references
+func withBlame() mcp.ToolOption {
+	return mcp.WithBoolean("blame",
+		mcp.Description("Show the author and age of the last commit that changed each result line, from git blame"),
+	)
+}
+
+// withHighlight adds the highlight parameter to tools whose results point at
+// ranges within lines
+func withHighlight() mcp.ToolOption {
+	return mcp.WithString("highlight",
+		mcp.Description("Mark the exact range each result points at within its line: underline prints ^ under it, brackets wraps it in [[ and ]]. Useful on long lines with several candidate tokens."),
+		mcp.Enum(format.HighlightStyles()...),
+	)
+}
+
+// withFromFile adds the from_file parameter to tools that resolve a symbol name
+func withFromFile() mcp.ToolOption {
+	return mcp.WithString("from_file",
+		mcp.Description("The file you are reading where the symbol is used. An ambiguous name such as Client resolves to the symbol this file uses, through its imports and scope."),
+	)
+}
+
+// withFocus adds the focus parameter to tools whose results are limited to
+// the session's focus directory
+func withFocus() mcp.ToolOption {
+	return mcp.WithString("focus",
+		mcp.Description("Directory to limit results to for this call instead of the session's focus set with set_focus. Use \".\" for the whole workspace."),
+	)
+}
+
+// maxBlameFiles bounds the files git blame runs on for one result
+const maxBlameFiles = 20
+
+// documentBudgets tunes how each tool's result shrinks to fit max_tokens. Tools
+// whose notes carry the result keep them longest.
+var documentBudgets = map[string]format.Budget{
+	"references":     {MinContext: 1},
+	"incoming_calls": {MinContext: 1},
+	"diagnostics":    {KeepNotes: true},
+	"search_symbols": {KeepNotes: true},
+	"run_command":    {KeepNotes: true},
+	"run_pipeline":   {MinContext: 1, KeepNotes: true},
+}
+
+// documentBudget returns the budget a tool result is shrunk to: the caller's
+// max_tokens, or the summary size once the session's output budget is nearly
+// used
+func (s *mcpServer) documentBudget(ctx context.Context, request mcp.CallToolRequest) format.Budget {
+	budget := documentBudgets[request.Params.Name]
+	if maxTokens, ok := numberArgument(request, "max_tokens"); ok && maxTokens > 0 {
+		budget.MaxTokens = maxTokens
+	} else if s.outputBudget.Summarizing(sessionID(ctx)) {
+		budget.MaxTokens = s.config.settings.OutputBudget.SummaryTokens
+	}
+	return budget
+}
+
+// renderDocument renders a tool result in the format requested by the caller,
+// or in the format of the session's output version when none is requested, and
+// shrinks it to the documentBudget. The locations of the result are kept for
+// diff_results under the result ID of structured output. A result whose
+// sections were streamed only reports how many were sent.
+func (s *mcpServer) renderDocument(ctx context.Context, request mcp.CallToolRequest, doc format.Document) *mcp.CallToolResult {
+	doc, renderer, err := s.prepareDocument(ctx, request, doc)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	if doc.Streamed > 0 || doc.StreamOmitted > 0 {
+		// The sections were sent as they were found, so there is nothing left
+		// to compare with a later result
+		doc.Empty = fmt.Sprintf("Sections sent in notifications while the result was produced: %d\n", doc.Streamed)
+		if doc.StreamOmitted > 0 {
+			doc.Empty += fmt.Sprintf("... %d more results omitted to fit max_tokens\n", doc.StreamOmitted)
+		}
+		doc.EmptyKind = ""
+		return mcp.NewToolResultText(renderer.Render(doc))
+	}
+
+	budget := s.documentBudget(ctx, request)
+	doc.ResultID = s.results.Record(sessionID(ctx), request.Params.Name, request.Params.Arguments, doc)
+	reportResultID(ctx, doc.ResultID)
+	return mcp.NewToolResultText(format.Fit(doc, renderer, budget))
+}
+
+// prepareDocument applies the presentation arguments of a call to a document
+// and returns the renderer of the requested format
+func (s *mcpServer) prepareDocument(ctx context.Context, request mcp.CallToolRequest, doc format.Document) (format.Document, format.Renderer, error) {
+	name, _ := request.Params.Arguments["format"].(string)
+	if name == "" {
+		name = s.outputVersions.Format(ctx)
+	}
+	renderer, err := format.Get(name)
+	if err != nil {
+		return doc, nil, err
+	}
+
+	if owners, _ := request.Params.Arguments["owners"].(bool); owners {
+		doc = s.annotateOwners(doc)
+	}
+	if git, _ := request.Params.Arguments["git"].(bool); git {
+		doc = s.annotateGit(ctx, doc)
+	}
+	if blame, _ := request.Params.Arguments["blame"].(bool); blame {
+		doc = annotateBlame(ctx, doc, time.Now())
+	}
+	doc = s.annotateLanguages(doc)
+	doc = s.redactor.Document(doc)
+	doc.MaxLineLength = s.config.settings.MaxLineLength
+	if highlight, _ := request.Params.Arguments["highlight"].(string); highlight != "" {
+		if !slices.Contains(format.HighlightStyles(), highlight) {
+			return doc, nil, fmt.Errorf("unknown highlight %q, expected one of: %s", highlight, strings.Join(format.HighlightStyles(), ", "))
+		}
+		doc.Highlight = highlight
+	}
+	return doc, renderer, nil
+}
+
+// toolError reports a failed tool call. The kind of failure is added as a
+// line of text output and as a field of structured output, so that clients
+// can branch on it instead of parsing the message.
+func (s *mcpServer) toolError(ctx context.Context, request mcp.CallToolRequest, err error) *mcp.CallToolResult {
+	kind := tools.KindOf(err)
+	name, _ := request.Params.Arguments["format"].(string)
+	if name == "" {
+		name = s.outputVersions.Format(ctx)
+	}
+	if name == "json" {
+		return mcp.NewToolResultError(format.RenderErrorJSON(string(kind), err.Error()))
+	}
+	if kind == tools.KindOther {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("%v\nError kind: %s", err, kind))
+}
+
+// annotateLanguages sets the languageId of every file section with snippets,
+// as the language server sees the file, so that clients can highlight them
+// even for unconventional extensions
+func (s *mcpServer) annotateLanguages(doc format.Document) format.Document {
+	client := s.client()
+	sections := make([]format.Section, len(doc.Sections))
+	for i, section := range doc.Sections {
+		sections[i] = section
+		if section.Path != "" && section.Language == "" && len(section.Snippets) > 0 {
+			sections[i].Language = string(client.LanguageID(section.Path))
+		}
+	}
+	doc.Sections = sections
+	return doc
+}
+
+// annotateOwners adds an Owners field from CODEOWNERS to every file section
+func (s *mcpServer) annotateOwners(doc format.Document) format.Document {
+	file, err := s.codeOwners.Load()
+	if err != nil {
+		coreLogger.Warn("Failed to load CODEOWNERS: %v", err)
+	}
+	if file == nil {
+		doc.Preamble = "No CODEOWNERS file found, files are not annotated with owners\n\n" + doc.Preamble
+		return doc
+	}
+
+	sections := make([]format.Section, len(doc.Sections))
+	for i, section := range doc.Sections {
+		sections[i] = section
+		if section.Path == "" {
+			continue
+		}
+		owners, ok, _ := s.codeOwners.Owners(section.Path)
+		if !ok {
+			continue
+		}
+		value := strings.Join(owners, " ")
+		if value == "" {
+			value = "none"
+		}
+		sections[i].Fields = append(append([]format.Field(nil), section.Fields...), format.Field{Name: "Owners", Value: value})
+	}
+	doc.Sections = sections
+	return doc
+}
+
+// annotateGit adds a Git field with the file's git status to every file
+// section, and the current branch to the preamble
+func (s *mcpServer) annotateGit(ctx context.Context, doc format.Document) format.Document {
+	status, err := s.gitInfo.Load(ctx)
+	if err != nil {
+		coreLogger.Warn("Failed to read git status: %v", err)
+	}
+	if status == nil {
+		doc.Preamble = "The workspace is not in a git repository, files are not annotated with git status\n\n" + doc.Preamble
+		return doc
+	}
+
+	sections := make([]format.Section, len(doc.Sections))
+	for i, section := range doc.Sections {
+		sections[i] = section
+		if section.Path == "" {
+			continue
+		}
+		file, ok := status.File(section.Path)
+		if !ok {
+			continue
+		}
+		sections[i].Fields = append(append([]format.Field(nil), section.Fields...), format.Field{Name: "Git", Value: status.Describe(file)})
+	}
+	doc.Sections = sections
+	doc.Preamble = status.Summary() + "\n\n" + doc.Preamble
+	return doc
+}
+
+// annotateBlame adds a note with the last commit of each result line to the
+// first maxBlameFiles file sections
+func annotateBlame(ctx context.Context, doc format.Document, now time.Time) format.Document {
+	sections := make([]format.Section, len(doc.Sections))
+	blamed := 0
+	for i, section := range doc.Sections {
+		sections[i] = section
+		if section.Path == "" || len(section.Focus) == 0 {
+			continue
+		}
+		if blamed == maxBlameFiles {
+			sections[i].Notes = append(append([]string(nil), section.Notes...), fmt.Sprintf("Blame: not shown, only the first %d files are blamed", maxBlameFiles))
+			continue
+		}
+		blamed++
+		lines, err := gitinfo.Blame(ctx, section.Path, section.Focus)
+		if err != nil {
+			coreLogger.Debug("Failed to blame %s: %v", section.Path, err)
+			sections[i].Notes = append(append([]string(nil), section.Notes...), "Blame: not available for this file")
+			continue
+		}
+		notes := append([]string(nil), section.Notes...)
+		for _, line := range section.Focus {
+			if commit, ok := lines[line]; ok {
+				notes = append(notes, fmt.Sprintf("Blame L%d: %s", line, commit.Describe(now)))
+			}
+		}
+		sections[i].Notes = notes
+	}
+	doc.Sections = sections
+	return doc
+}
+
+// addTool registers a built-in tool, remembering its name so that plugins
+// cannot replace it
+func (s *mcpServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if s.toolNames == nil {
+		s.toolNames = make(map[string]bool)
+	}
+	s.toolNames[tool.Name] = true
+	s.mcpServer.AddTool(tool, handler)
+}
+
 func (s *mcpServer) registerTools() error {
 	coreLogger.Debug("Registering MCP tools")
 
+	s.scratchStore = tools.NewScratchStore(s.client())
+	s.codeOwners = codeowners.NewCache(s.config.workspaceDir)
+	s.gitInfo = gitinfo.NewCache(s.config.workspaceDir)
+	s.pool.OnSwap(s.scratchStore.Reset)
+	s.resolver = resolve.New(s.config.settings.SymbolMatch)
+	s.contextLines = contextLinesFromEnv()
+	if err := tools.ValidateExternalSources(s.config.settings.ExternalSources); err != nil {
+		return err
+	}
+	if err := resolve.ValidateRules(s.config.settings.SymbolMatch.Normalize); err != nil {
+		return err
+	}
+
 	applyTextEditTool := mcp.NewTool("edit_file",
 		mcp.WithDescription("Apply multiple text edits to a file."),
 		mcp.WithArray("edits",
@@ -39,9 +355,13 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("Path to the file to edit"),
 		),
+		mcp.WithBoolean("force",
+			mcp.Description("Apply the edits even if the file currently has errors that the edit policy would block on"),
+			mcp.DefaultBool(false),
+		),
 	)
 
-	s.mcpServer.AddTool(applyTextEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(applyTextEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
@@ -86,24 +406,33 @@ func (s *mcpServer) registerTools() error {
 			})
 		}
 
+		force, _ := request.Params.Arguments["force"].(bool)
+		if !force {
+			if err := tools.CheckEditPolicy(ctx, s.client(), s.config.settings.EditPolicy, []string{filePath}); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
 		coreLogger.Debug("Executing edit_file for file: %s", filePath)
-		response, err := tools.ApplyTextEdits(s.ctx, s.lspClient, filePath, edits)
+		response, err := tools.ApplyTextEdits(ctx, s.client(), filePath, edits)
 		if err != nil {
 			coreLogger.Error("Failed to apply edits: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to apply edits: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to apply edits: %w", err)), nil
 		}
 		return mcp.NewToolResultText(response), nil
 	})
 
 	readDefinitionTool := mcp.NewTool("definition",
-		mcp.WithDescription("Read the source code definition of a symbol (function, type, constant, etc.) from the codebase. Returns the complete implementation code where the symbol is defined."),
+		mcp.WithDescription("Read the source code definition of a symbol (function, type, constant, etc.) from the codebase. Returns the complete implementation code where the symbol is defined. Symbols from dependencies, e.g. http.Client, are read from the module cache, site-packages or node_modules and labeled as external."),
 		mcp.WithString("symbolName",
 			mcp.Required(),
 			mcp.Description("The name of the symbol whose definition you want to find (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
 		),
+		withFormat(),
+		withFromFile(),
 	)
 
-	s.mcpServer.AddTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, ok := request.Params.Arguments["symbolName"].(string)
 		if !ok {
@@ -111,12 +440,16 @@ func (s *mcpServer) registerTools() error {
 		}
 
 		coreLogger.Debug("Executing definition for symbol: %s", symbolName)
-		text, err := tools.ReadDefinition(s.ctx, s.lspClient, symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		doc, err := tools.ReadDefinitionDocument(ctx, tc, symbolName)
 		if err != nil {
 			coreLogger.Error("Failed to get definition: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get definition: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to get definition: %w", err)), nil
 		}
-		return mcp.NewToolResultText(text), nil
+		return s.renderDocument(ctx, request, doc), nil
 	})
 
 	findReferencesTool := mcp.NewTool("references",
@@ -125,9 +458,18 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The name of the symbol to search for (e.g. 'mypackage.MyFunction', 'MyType')"),
 		),
+		mcp.WithBoolean("text_fallback",
+			mcp.Description("When the language server finds no references, for example in a project that does not build, search the workspace text for the name instead. Text matches are labeled as such and may include comments, strings and other symbols with the same name."),
+		),
+		withFormat(),
+		withHighlight(),
+		withFocus(),
+		withBlame(),
+		withFromFile(),
+		withStream(),
 	)
 
-	s.mcpServer.AddTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, ok := request.Params.Arguments["symbolName"].(string)
 		if !ok {
@@ -135,12 +477,23 @@ func (s *mcpServer) registerTools() error {
 		}
 
 		coreLogger.Debug("Executing references for symbol: %s", symbolName)
-		text, err := tools.FindReferences(s.ctx, s.lspClient, symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		doc, err := tools.FindReferencesDocument(ctx, tc, symbolName)
 		if err != nil {
 			coreLogger.Error("Failed to find references: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to find references: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to find references: %w", err)), nil
 		}
-		return mcp.NewToolResultText(text), nil
+		if fallback, _ := request.Params.Arguments["text_fallback"].(bool); fallback && len(doc.Sections) == 0 && doc.Streamed == 0 {
+			coreLogger.Debug("No references for %s, searching the workspace text", symbolName)
+			doc, err = tools.TextReferencesDocument(ctx, tc, symbolName)
+			if err != nil {
+				return s.toolError(ctx, request, fmt.Errorf("failed to search for text matches: %w", err)), nil
+			}
+		}
+		return s.renderDocument(ctx, request, doc), nil
 	})
 
 	getDiagnosticsTool := mcp.NewTool("diagnostics",
@@ -157,9 +510,15 @@ func (s *mcpServer) registerTools() error {
 			mcp.Description("If true, adds line numbers to the output"),
 			mcp.DefaultBool(true),
 		),
+		mcp.WithBoolean("includeQuickFixes",
+			mcp.Description("If true, lists the quick fixes available for each diagnostic"),
+			mcp.DefaultBool(false),
+		),
+		withFormat(),
+		withHighlight(),
 	)
 
-	s.mcpServer.AddTool(getDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(getDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
@@ -167,6 +526,9 @@ func (s *mcpServer) registerTools() error {
 		}
 
 		contextLines := 5 // default value
+		if tc := s.toolContext(ctx); tc.ContextLines >= 0 {
+			contextLines = tc.ContextLines
+		}
 		if contextLinesArg, ok := request.Params.Arguments["contextLines"].(int); ok {
 			contextLines = contextLinesArg
 		}
@@ -176,13 +538,18 @@ func (s *mcpServer) registerTools() error {
 			showLineNumbers = showLineNumbersArg
 		}
 
+		includeQuickFixes := false
+		if includeQuickFixesArg, ok := request.Params.Arguments["includeQuickFixes"].(bool); ok {
+			includeQuickFixes = includeQuickFixesArg
+		}
+
 		coreLogger.Debug("Executing diagnostics for file: %s", filePath)
-		text, err := tools.GetDiagnosticsForFile(s.ctx, s.lspClient, filePath, contextLines, showLineNumbers)
+		doc, err := tools.GetDiagnosticsDocument(ctx, s.client(), filePath, contextLines, showLineNumbers, includeQuickFixes)
 		if err != nil {
 			coreLogger.Error("Failed to get diagnostics: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get diagnostics: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to get diagnostics: %w", err)), nil
 		}
-		return mcp.NewToolResultText(text), nil
+		return s.renderDocument(ctx, request, doc), nil
 	})
 
 	// Uncomment to add codelens tools
@@ -195,7 +562,7 @@ func (s *mcpServer) registerTools() error {
 	// 	),
 	// )
 	//
-	// s.mcpServer.AddTool(getCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// s.addTool(getCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// 	// Extract arguments
 	// 	filePath, ok := request.Params.Arguments["filePath"].(string)
 	// 	if !ok {
@@ -203,10 +570,10 @@ func (s *mcpServer) registerTools() error {
 	// 	}
 	//
 	// 	coreLogger.Debug("Executing get_codelens for file: %s", filePath)
-	// 	text, err := tools.GetCodeLens(s.ctx, s.lspClient, filePath)
+	// 	text, err := tools.GetCodeLens(ctx, s.client(), filePath)
 	// 	if err != nil {
 	// 		coreLogger.Error("Failed to get code lens: %v", err)
-	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to get code lens: %v", err)), nil
+	// 		return s.toolError(ctx, request, fmt.Errorf("failed to get code lens: %w", err)), nil
 	// 	}
 	// 	return mcp.NewToolResultText(text), nil
 	// })
@@ -223,7 +590,7 @@ func (s *mcpServer) registerTools() error {
 	// 	),
 	// )
 	//
-	// s.mcpServer.AddTool(executeCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// s.addTool(executeCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// 	// Extract arguments
 	// 	filePath, ok := request.Params.Arguments["filePath"].(string)
 	// 	if !ok {
@@ -242,10 +609,10 @@ func (s *mcpServer) registerTools() error {
 	// 	}
 	//
 	// 	coreLogger.Debug("Executing execute_codelens for file: %s index: %d", filePath, index)
-	// 	text, err := tools.ExecuteCodeLens(s.ctx, s.lspClient, filePath, index)
+	// 	text, err := tools.ExecuteCodeLens(ctx, s.client(), filePath, index)
 	// 	if err != nil {
 	// 		coreLogger.Error("Failed to execute code lens: %v", err)
-	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to execute code lens: %v", err)), nil
+	// 		return s.toolError(ctx, request, fmt.Errorf("failed to execute code lens: %w", err)), nil
 	// 	}
 	// 	return mcp.NewToolResultText(text), nil
 	// })
@@ -266,7 +633,7 @@ func (s *mcpServer) registerTools() error {
 		),
 	)
 
-	s.mcpServer.AddTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
@@ -294,10 +661,56 @@ func (s *mcpServer) registerTools() error {
 		}
 
 		coreLogger.Debug("Executing hover for file: %s line: %d column: %d", filePath, line, column)
-		text, err := tools.GetHoverInfo(s.ctx, s.lspClient, filePath, line, column)
+		text, err := tools.GetHoverInfo(ctx, s.client(), filePath, line, column)
 		if err != nil {
 			coreLogger.Error("Failed to get hover information: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get hover information: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to get hover information: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	completionTool := mcp.NewTool("completion",
+		mcp.WithDescription("List the completions the language server offers at a position, such as the methods available on a value. Deprecated items are labeled [DEPRECATED] and listed last."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to get completions for"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where completions are requested (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where completions are requested (1-indexed)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of completions to list (default 50)"),
+		),
+	)
+
+	s.addTool(completionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, ok := request.Params.Arguments["filePath"].(string)
+		if !ok {
+			return mcp.NewToolResultError("filePath must be a string"), nil
+		}
+		line, ok := numberArgument(request, "line")
+		if !ok {
+			return mcp.NewToolResultError("line must be a number"), nil
+		}
+		column, ok := numberArgument(request, "column")
+		if !ok {
+			return mcp.NewToolResultError("column must be a number"), nil
+		}
+		limit := 50
+		if v, ok := numberArgument(request, "limit"); ok && v > 0 {
+			limit = v
+		}
+
+		coreLogger.Debug("Executing completion for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetCompletions(ctx, s.client(), filePath, line, column, limit)
+		if err != nil {
+			coreLogger.Error("Failed to get completions: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to get completions: %w", err)), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -320,9 +733,13 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The new name for the symbol"),
 		),
+		mcp.WithBoolean("force",
+			mcp.Description("Apply the rename even if affected files currently have errors that the edit policy would block on"),
+			mcp.DefaultBool(false),
+		),
 	)
 
-	s.mcpServer.AddTool(renameSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(renameSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		filePath, ok := request.Params.Arguments["filePath"].(string)
 		if !ok {
@@ -355,10 +772,59 @@ func (s *mcpServer) registerTools() error {
 		}
 
 		coreLogger.Debug("Executing rename_symbol for file: %s line: %d column: %d newName: %s", filePath, line, column, newName)
-		text, err := tools.RenameSymbol(s.ctx, s.lspClient, filePath, line, column, newName)
+		force, _ := request.Params.Arguments["force"].(bool)
+		text, err := tools.RenameSymbolAcrossServers(ctx, s.toolContext(ctx), filePath, line, column, newName, force)
 		if err != nil {
 			coreLogger.Error("Failed to rename symbol: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to rename symbol: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to rename symbol: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	replaceSymbolTool := mcp.NewTool("replace_symbol",
+		mcp.WithDescription("Replace the whole definition of a symbol (function, method, type, etc.) with new code. The existing doc comment or Python docstring is kept when the new code has none, so rewriting a function body does not delete its documentation."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol to replace (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+		mcp.WithString("newText",
+			mcp.Required(),
+			mcp.Description("The new definition, from its signature to its end"),
+		),
+		mcp.WithString("doc_comment",
+			mcp.Description("keep (default) keeps the existing doc comment unless newText has its own, replace uses newText as given and removes the existing doc comment when newText has none"),
+			mcp.Enum(tools.DocCommentKeep, tools.DocCommentReplace),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Apply the edit even if the file currently has errors that the edit policy would block on"),
+			mcp.DefaultBool(false),
+		),
+		withFromFile(),
+	)
+
+	s.addTool(replaceSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		symbolName, ok := request.Params.Arguments["symbolName"].(string)
+		if !ok {
+			return mcp.NewToolResultError("symbolName must be a string"), nil
+		}
+
+		newText, ok := request.Params.Arguments["newText"].(string)
+		if !ok {
+			return mcp.NewToolResultError("newText must be a string"), nil
+		}
+
+		docComment, _ := request.Params.Arguments["doc_comment"].(string)
+		force, _ := request.Params.Arguments["force"].(bool)
+
+		coreLogger.Debug("Executing replace_symbol for symbol: %s", symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		text, err := tools.ReplaceSymbol(ctx, tc, symbolName, newText, docComment, force)
+		if err != nil {
+			coreLogger.Error("Failed to replace symbol: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to replace symbol: %w", err)), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
@@ -369,9 +835,25 @@ func (s *mcpServer) registerTools() error {
 			mcp.Required(),
 			mcp.Description("The name of the function or method to find callers for (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
 		),
+		mcp.WithArray("caller_kinds",
+			mcp.Description("Only show callers of these kinds: function, method, lambda (anonymous functions) or init (init functions, static initializers and module level code)"),
+			mcp.Items(map[string]any{
+				"type": "string",
+				"enum": tools.CallerKinds(),
+			}),
+		),
+		mcp.WithBoolean("exported_only",
+			mcp.Description("Only show callers that can be called from outside their package or module, e.g. for the impact of an API change"),
+		),
+		withFormat(),
+		withHighlight(),
+		withFocus(),
+		withBlame(),
+		withFromFile(),
+		withStream(),
 	)
 
-	s.mcpServer.AddTool(incomingCallsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.addTool(incomingCallsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract arguments
 		symbolName, ok := request.Params.Arguments["symbolName"].(string)
 		if !ok {
@@ -379,14 +861,931 @@ func (s *mcpServer) registerTools() error {
 		}
 
 		coreLogger.Debug("Executing incoming_calls for symbol: %s", symbolName)
-		text, err := tools.FindIncomingCalls(s.ctx, s.lspClient, symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		var filter tools.CallerFilter
+		if kindsArray, ok := request.Params.Arguments["caller_kinds"].([]any); ok {
+			for _, item := range kindsArray {
+				kind, ok := item.(string)
+				if !ok {
+					return mcp.NewToolResultError("caller_kinds must be strings"), nil
+				}
+				filter.Kinds = append(filter.Kinds, kind)
+			}
+		}
+		filter.ExportedOnly, _ = request.Params.Arguments["exported_only"].(bool)
+
+		doc, err := tools.FindFilteredIncomingCallsDocument(ctx, tc, symbolName, filter)
 		if err != nil {
 			coreLogger.Error("Failed to find incoming calls: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("failed to find incoming calls: %v", err)), nil
+			return s.toolError(ctx, request, fmt.Errorf("failed to find incoming calls: %w", err)), nil
+		}
+		return s.renderDocument(ctx, request, doc), nil
+	})
+
+	reviewChangesTool := mcp.NewTool("review_changes",
+		mcp.WithDescription("Review a change, such as a pending pull request, by limiting analysis to the lines it touches: diagnostics on the changed lines, and the references and callers of every symbol whose definition overlaps them. Pass either a unified diff or a list of changed line ranges."),
+		mcp.WithString("diff",
+			mcp.Description("Unified diff of the change, e.g. the output of git diff. Paths are relative to the workspace."),
+		),
+		mcp.WithArray("changes",
+			mcp.Description("Changed line ranges, used instead of diff"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"filePath": map[string]any{
+						"type":        "string",
+						"description": "Path to the changed file",
+					},
+					"startLine": map[string]any{
+						"type":        "number",
+						"description": "First changed line, inclusive, one-indexed",
+					},
+					"endLine": map[string]any{
+						"type":        "number",
+						"description": "Last changed line, inclusive, one-indexed",
+					},
+				},
+				"required": []string{"filePath", "startLine", "endLine"},
+			}),
+		),
+		mcp.WithArray("analyses",
+			mcp.Description("Analyses to run (default all): diagnostics, references, callers"),
+			mcp.Items(map[string]any{
+				"type": "string",
+				"enum": []string{tools.AnalysisDiagnostics, tools.AnalysisReferences, tools.AnalysisCallers},
+			}),
+		),
+	)
+
+	s.addTool(reviewChangesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var changes []tools.ChangedFile
+		if diff, _ := request.Params.Arguments["diff"].(string); diff != "" {
+			parsed, err := tools.ParseUnifiedDiff(diff)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			changes = parsed
+		} else {
+			changesArray, ok := request.Params.Arguments["changes"].([]any)
+			if !ok || len(changesArray) == 0 {
+				return mcp.NewToolResultError("either diff or changes is required"), nil
+			}
+			byPath := make(map[string]int)
+			for _, item := range changesArray {
+				changeMap, ok := item.(map[string]any)
+				if !ok {
+					return mcp.NewToolResultError("each change must be an object"), nil
+				}
+				filePath, ok := changeMap["filePath"].(string)
+				if !ok {
+					return mcp.NewToolResultError("filePath must be a string"), nil
+				}
+				startLine, ok := changeMap["startLine"].(float64)
+				if !ok {
+					return mcp.NewToolResultError("startLine must be a number"), nil
+				}
+				endLine, ok := changeMap["endLine"].(float64)
+				if !ok {
+					return mcp.NewToolResultError("endLine must be a number"), nil
+				}
+
+				lines := tools.LineRange{Start: int(startLine), End: int(endLine)}
+				if i, ok := byPath[filePath]; ok {
+					changes[i].Ranges = append(changes[i].Ranges, lines)
+					continue
+				}
+				byPath[filePath] = len(changes)
+				changes = append(changes, tools.ChangedFile{Path: filePath, Ranges: []tools.LineRange{lines}})
+			}
+		}
+
+		analyses := []string{tools.AnalysisDiagnostics, tools.AnalysisReferences, tools.AnalysisCallers}
+		if analysesArray, ok := request.Params.Arguments["analyses"].([]any); ok && len(analysesArray) > 0 {
+			analyses = nil
+			for _, item := range analysesArray {
+				analysis, ok := item.(string)
+				if !ok {
+					return mcp.NewToolResultError("analyses must be strings"), nil
+				}
+				analyses = append(analyses, analysis)
+			}
+		}
+
+		coreLogger.Debug("Executing review_changes for %d files", len(changes))
+		text, err := tools.ReviewChanges(ctx, s.client(), s.config.workspaceDir, changes, analyses)
+		if err != nil {
+			coreLogger.Error("Failed to review changes: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to review changes: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findTestsTool := mcp.NewTool("find_tests",
+		mcp.WithDescription("Find the tests that exercise a symbol or a file, to know what to run after an edit. Combines references from test files, test naming conventions (config_test.go, test_config.py, config.test.ts, TestParseConfig) and the language server's run test code lenses, and suggests commands to run the tests."),
+		mcp.WithString("target",
+			mcp.Required(),
+			mcp.Description("A symbol name (e.g. 'ParseConfig', 'Config.Load') or the path to a source file"),
+		),
+	)
+
+	s.addTool(findTestsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		target, ok := request.Params.Arguments["target"].(string)
+		if !ok {
+			return mcp.NewToolResultError("target must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing find_tests for: %s", target)
+		text, err := tools.FindTests(ctx, s.toolContext(ctx), target)
+		if err != nil {
+			coreLogger.Error("Failed to find tests: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to find tests: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	entryPointsTool := mcp.NewTool("entry_points",
+		mcp.WithDescription("List the probable entry points of the workspace to start top-down exploration: main functions, CLI command definitions, HTTP handlers and exported library API, found with workspace symbol search and per-language naming conventions."),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of entry points to list for each kind (default 20)"),
+		),
+	)
+
+	s.addTool(entryPointsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limit := 20
+		if v, ok := numberArgument(request, "limit"); ok && v > 0 {
+			limit = v
+		}
+
+		coreLogger.Debug("Executing entry_points")
+		text, err := tools.FindEntryPoints(ctx, s.toolContext(ctx), limit)
+		if err != nil {
+			coreLogger.Error("Failed to find entry points: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to find entry points: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	warmupTool := mcp.NewTool("warmup",
+		mcp.WithDescription("Get the language server to index the workspace before the real work starts, so that the first queries are fast: opens the files of the entry points and the most recently modified source files, and runs a broad workspace symbol query. Progress is reported as notifications to clients that support them."),
+		mcp.WithNumber("maxFiles",
+			mcp.Description(fmt.Sprintf("Maximum number of files to open (default %d)", tools.DefaultWarmupFiles)),
+		),
+	)
+
+	s.addTool(warmupTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		maxFiles := tools.DefaultWarmupFiles
+		if v, ok := numberArgument(request, "maxFiles"); ok && v > 0 {
+			maxFiles = v
+		}
+
+		coreLogger.Debug("Executing warmup")
+		text, err := tools.Warmup(ctx, s.toolContext(ctx), maxFiles, s.warmupProgress(ctx, request))
+		if err != nil {
+			coreLogger.Error("Failed to warm up: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to warm up: %w", err)), nil
 		}
 		return mcp.NewToolResultText(text), nil
 	})
 
+	bookmarkSymbolTool := mcp.NewTool("bookmark_symbol",
+		mcp.WithDescription("Add a symbol to this session's working set of important symbols, so that list_bookmarks can show where they are without querying each one by name again. Bookmarks follow their symbols when edits move them."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol to bookmark (e.g. 'mypackage.MyFunction', 'MyType'). Every symbol the name matches is bookmarked."),
+		),
+		mcp.WithString("note",
+			mcp.Description("Why the symbol matters, shown with the bookmark"),
+		),
+		mcp.WithBoolean("remove",
+			mcp.Description("Remove the bookmarks of this name from the working set instead"),
+		),
+		withFromFile(),
+	)
+
+	s.addTool(bookmarkSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		symbolName, ok := request.Params.Arguments["symbolName"].(string)
+		if !ok {
+			return mcp.NewToolResultError("symbolName must be a string"), nil
+		}
+		session := sessionID(ctx)
+
+		if remove, _ := request.Params.Arguments["remove"].(bool); remove {
+			removed, left := s.bookmarks.Remove(session, symbolName)
+			if removed == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("No bookmarks for %s (%d in the working set)", symbolName, left)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Removed %d bookmarks for %s (%d left in the working set)", removed, symbolName, left)), nil
+		}
+
+		coreLogger.Debug("Executing bookmark_symbol for symbol: %s", symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		note, _ := request.Params.Arguments["note"].(string)
+		bookmarks, err := tools.BookmarkSymbols(ctx, tc, symbolName, note)
+		if err != nil {
+			return s.toolError(ctx, request, fmt.Errorf("failed to bookmark symbol: %w", err)), nil
+		}
+		total, err := s.bookmarks.Add(session, bookmarks)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("Bookmarked %d symbols (%d in the working set):\n", len(bookmarks), total))
+		for _, bookmark := range bookmarks {
+			result.WriteString(fmt.Sprintf("  %s\n", bookmark))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+
+	listBookmarksTool := mcp.NewTool("list_bookmarks",
+		mcp.WithDescription("List this session's bookmarked symbols with where they are now. Symbols moved by edits are found again, and symbols that were renamed or removed are reported as not found."),
+	)
+
+	s.addTool(listBookmarksTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session := sessionID(ctx)
+		tc := s.toolContext(ctx)
+		bookmarks := s.bookmarks.List(session)
+		refreshed := make([]tools.RefreshedBookmark, 0, len(bookmarks))
+		for _, bookmark := range bookmarks {
+			if ctx.Err() != nil {
+				return s.toolError(ctx, request, ctx.Err()), nil
+			}
+			refreshed = append(refreshed, tools.RefreshBookmark(ctx, tc, bookmark))
+		}
+		s.bookmarks.Update(session, refreshed)
+		return mcp.NewToolResultText(tools.FormatBookmarks(refreshed)), nil
+	})
+
+	traceSinkTool := mcp.NewTool("trace_sink",
+		mcp.WithDescription("Trace how execution reaches a sensitive function such as exec.Command or db.Query for a security review. Returns the tree of incoming calls up to a depth, marking the entry points (main, tests, functions without callers) and listing the files involved."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The sink function or method (e.g. 'exec.Command', 'DB.Query')"),
+		),
+		mcp.WithNumber("maxDepth",
+			mcp.Description("How many calls away from the sink to follow (default 5)"),
+		),
+		mcp.WithNumber("maxNodes",
+			mcp.Description("Maximum number of functions in the tree (default 200)"),
+		),
+		withFromFile(),
+	)
+
+	s.addTool(traceSinkTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		symbolName, ok := request.Params.Arguments["symbolName"].(string)
+		if !ok {
+			return mcp.NewToolResultError("symbolName must be a string"), nil
+		}
+
+		maxDepth := 5
+		if v, ok := numberArgument(request, "maxDepth"); ok && v > 0 {
+			maxDepth = v
+		}
+		maxNodes := 200
+		if v, ok := numberArgument(request, "maxNodes"); ok && v > 0 {
+			maxNodes = v
+		}
+
+		coreLogger.Debug("Executing trace_sink for symbol: %s", symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		text, err := tools.TraceSink(ctx, tc, symbolName, maxDepth, maxNodes)
+		if err != nil {
+			coreLogger.Error("Failed to trace sink: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to trace sink: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	watchDiagnosticsTool := mcp.NewTool("watch_diagnostics",
+		mcp.WithDescription("Watch a set of files and report diagnostics as the language server publishes them. Each update is streamed as a notification to clients that support it; the result contains the timeline of updates and the latest diagnostics for each file."),
+		mcp.WithArray("filePaths",
+			mcp.Required(),
+			mcp.Description("Paths of the files to watch"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("durationSeconds",
+			mcp.Description("How long to watch for diagnostics, in seconds (max 300)"),
+			mcp.DefaultNumber(30),
+		),
+	)
+
+	s.addTool(watchDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePathsArg, ok := request.Params.Arguments["filePaths"].([]any)
+		if !ok {
+			return mcp.NewToolResultError("filePaths must be an array"), nil
+		}
+		var filePaths []string
+		for _, item := range filePathsArg {
+			filePath, ok := item.(string)
+			if !ok {
+				return mcp.NewToolResultError("each file path must be a string"), nil
+			}
+			filePaths = append(filePaths, filePath)
+		}
+
+		durationSeconds := 30.0
+		if durationArg, ok := request.Params.Arguments["durationSeconds"].(float64); ok {
+			durationSeconds = durationArg
+		}
+		if durationSeconds <= 0 || durationSeconds > 300 {
+			return mcp.NewToolResultError("durationSeconds must be between 0 and 300"), nil
+		}
+
+		var progressToken mcp.ProgressToken
+		if request.Params.Meta != nil {
+			progressToken = request.Params.Meta.ProgressToken
+		}
+		updates := 0
+
+		emit := func(update tools.DiagnosticsUpdate) {
+			updates++
+			var diagnostics []string
+			positions := update.Positions(s.client().PositionEncoding())
+			for i, diag := range update.Diagnostics {
+				diagnostics = append(diagnostics, fmt.Sprintf("%s %s", positions[i], diag.Message))
+			}
+			message := fmt.Sprintf("%s: %s", update.FilePath, update.Summary())
+			if err := s.mcpServer.SendNotificationToClient(ctx, "notifications/message", map[string]any{
+				"level":  "info",
+				"logger": "diagnostics",
+				"data": map[string]any{
+					"message":     message,
+					"filePath":    update.FilePath,
+					"diagnostics": diagnostics,
+				},
+			}); err != nil {
+				coreLogger.Debug("Failed to send diagnostics notification: %v", err)
+			}
+			if progressToken != nil {
+				if err := s.mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+					"progressToken": progressToken,
+					"progress":      updates,
+					"message":       message,
+				}); err != nil {
+					coreLogger.Debug("Failed to send progress notification: %v", err)
+				}
+			}
+		}
+
+		coreLogger.Debug("Executing watch_diagnostics for files: %v", filePaths)
+		text, err := tools.WatchDiagnostics(ctx, s.client(), filePaths, time.Duration(durationSeconds*float64(time.Second)), emit)
+		if err != nil {
+			coreLogger.Error("Failed to watch diagnostics: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to watch diagnostics: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	writeScratchTool := mcp.NewTool("write_scratch",
+		mcp.WithDescription("Create or replace an in-memory scratch document (opened with an untitled: URI) so candidate code can be checked with scratch_diagnostics and scratch_hover before writing anything to disk."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("File name of the scratch document, e.g. 'candidate.go'. The extension is used to detect the language"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("Full content of the scratch document"),
+		),
+		mcp.WithString("languageId",
+			mcp.Description("Language identifier, e.g. 'go' or 'python'. Detected from the name when omitted"),
+		),
+	)
+
+	s.addTool(writeScratchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok {
+			return mcp.NewToolResultError("name must be a string"), nil
+		}
+		content, ok := request.Params.Arguments["content"].(string)
+		if !ok {
+			return mcp.NewToolResultError("content must be a string"), nil
+		}
+		languageID, _ := request.Params.Arguments["languageId"].(string)
+
+		coreLogger.Debug("Executing write_scratch for: %s", name)
+		text, err := tools.WriteScratch(ctx, s.scratchStore, name, languageID, content)
+		if err != nil {
+			coreLogger.Error("Failed to write scratch document: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to write scratch document: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	scratchDiagnosticsTool := mcp.NewTool("scratch_diagnostics",
+		mcp.WithDescription("Get diagnostics for an in-memory scratch document created with write_scratch."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the scratch document"),
+		),
+	)
+
+	s.addTool(scratchDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok {
+			return mcp.NewToolResultError("name must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing scratch_diagnostics for: %s", name)
+		text, err := tools.ScratchDiagnostics(ctx, s.toolContext(ctx), s.scratchStore, name, 5*time.Second)
+		if err != nil {
+			coreLogger.Error("Failed to get scratch diagnostics: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to get scratch diagnostics: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	scratchHoverTool := mcp.NewTool("scratch_hover",
+		mcp.WithDescription("Get hover information (type, documentation) at a position in an in-memory scratch document."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the scratch document"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the hover is requested (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number where the hover is requested (1-indexed)"),
+		),
+	)
+
+	s.addTool(scratchHoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok {
+			return mcp.NewToolResultError("name must be a string"), nil
+		}
+		line, ok := numberArgument(request, "line")
+		if !ok {
+			return mcp.NewToolResultError("line must be a number"), nil
+		}
+		column, ok := numberArgument(request, "column")
+		if !ok {
+			return mcp.NewToolResultError("column must be a number"), nil
+		}
+
+		coreLogger.Debug("Executing scratch_hover for: %s line: %d column: %d", name, line, column)
+		text, err := tools.ScratchHover(ctx, s.scratchStore, name, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get scratch hover information: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to get hover information: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	closeScratchTool := mcp.NewTool("close_scratch",
+		mcp.WithDescription("Close an in-memory scratch document and discard its content."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the scratch document"),
+		),
+	)
+
+	s.addTool(closeScratchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok {
+			return mcp.NewToolResultError("name must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing close_scratch for: %s", name)
+		text, err := tools.CloseScratch(ctx, s.scratchStore, name)
+		if err != nil {
+			coreLogger.Error("Failed to close scratch document: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to close scratch document: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	peekSymbolTool := mcp.NewTool("peek_symbol",
+		mcp.WithDescription("Get a consolidated view of a symbol in one call: its definition, hover documentation and top references across files, kept within a token budget."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol to peek at (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+		mcp.WithNumber("maxReferences",
+			mcp.Description("Maximum number of references to include (default 5)"),
+		),
+		mcp.WithNumber("maxTokens",
+			mcp.Description("Approximate token budget for the whole response (default 2000)"),
+		),
+		withFromFile(),
+	)
+
+	s.addTool(peekSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		symbolName, ok := request.Params.Arguments["symbolName"].(string)
+		if !ok {
+			return mcp.NewToolResultError("symbolName must be a string"), nil
+		}
+
+		maxReferences := 5
+		if v, ok := numberArgument(request, "maxReferences"); ok && v >= 0 {
+			maxReferences = v
+		}
+		maxTokens := 2000
+		if v, ok := numberArgument(request, "maxTokens"); ok && v > 0 {
+			maxTokens = v
+		}
+
+		coreLogger.Debug("Executing peek_symbol for symbol: %s", symbolName)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		text, err := tools.PeekSymbol(ctx, tc, symbolName, maxReferences, maxTokens)
+		if err != nil {
+			coreLogger.Error("Failed to peek symbol: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to peek symbol: %w", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	searchSymbolsTool := mcp.NewTool("search_symbols",
+		mcp.WithDescription("Search the workspace for symbols matching a query, best matches first. Large result sets start with a breakdown by kind and directory so the query can be refined."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The symbol name or fragment to search for"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of symbols to list (default 50)"),
+		),
+		withFormat(),
+		withFocus(),
+	)
+
+	s.addTool(searchSymbolsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, ok := request.Params.Arguments["query"].(string)
+		if !ok {
+			return mcp.NewToolResultError("query must be a string"), nil
+		}
+
+		limit := 50
+		if v, ok := numberArgument(request, "limit"); ok && v > 0 {
+			limit = v
+		}
+
+		coreLogger.Debug("Executing search_symbols for query: %s", query)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		doc, err := tools.SearchSymbolsDocument(ctx, tc, query, limit)
+		if err != nil {
+			coreLogger.Error("Failed to search symbols: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to search symbols: %w", err)), nil
+		}
+		return s.renderDocument(ctx, request, doc), nil
+	})
+
+	runPipelineTool := mcp.NewTool("run_pipeline",
+		mcp.WithDescription("Chain tools server-side and get one consolidated result instead of several round trips. Symbol steps (definition, references, incoming_calls) run on the symbol; the diagnostics step runs on each file the previous step found something in. For example steps [definition, references, diagnostics] reads a symbol, finds its references and checks the referencing files for problems. Each section is labeled with the step it came from."),
+		mcp.WithString("symbol",
+			mcp.Description("The symbol the symbol steps run on"),
+		),
+		mcp.WithArray("steps",
+			mcp.Required(),
+			mcp.Description("Steps to run in order"),
+			mcp.Items(map[string]any{
+				"type": "string",
+				"enum": tools.PipelineStepNames(),
+			}),
+		),
+		mcp.WithArray("files",
+			mcp.Description("Files for a pipeline that starts with the diagnostics step"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("maxFiles",
+			mcp.Description("Maximum number of files the diagnostics step runs on (default 10)"),
+		),
+		withFormat(),
+		withHighlight(),
+		withFocus(),
+		withFromFile(),
+	)
+
+	s.addTool(runPipelineTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		spec := tools.PipelineSpec{}
+		spec.Symbol, _ = request.Params.Arguments["symbol"].(string)
+
+		stepsArray, ok := request.Params.Arguments["steps"].([]any)
+		if !ok {
+			return mcp.NewToolResultError("steps must be an array"), nil
+		}
+		for _, item := range stepsArray {
+			step, ok := item.(string)
+			if !ok {
+				return mcp.NewToolResultError("each step must be a string"), nil
+			}
+			spec.Steps = append(spec.Steps, step)
+		}
+
+		if filesArray, ok := request.Params.Arguments["files"].([]any); ok {
+			for _, item := range filesArray {
+				file, ok := item.(string)
+				if !ok {
+					return mcp.NewToolResultError("each file must be a string"), nil
+				}
+				spec.Files = append(spec.Files, file)
+			}
+		}
+
+		if v, ok := numberArgument(request, "maxFiles"); ok {
+			spec.MaxFiles = v
+		}
+
+		coreLogger.Debug("Executing run_pipeline %v for symbol: %s", spec.Steps, spec.Symbol)
+		tc, err := s.callToolContext(ctx, request)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		doc, err := tools.RunPipeline(ctx, tc, spec)
+		if err != nil {
+			coreLogger.Error("Failed to run pipeline: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to run pipeline: %w", err)), nil
+		}
+		return s.renderDocument(ctx, request, doc), nil
+	})
+
+	// run_command is opt-in and only available when commands are allowlisted
+	if len(s.config.settings.RunCommand.Allowlist) > 0 {
+		runCommandTool := mcp.NewTool("run_command",
+			mcp.WithDescription("Run an allowlisted build or test command in the workspace. Returns the command output and the file:line locations it reports, shown in the context of the surrounding code. Allowed commands: "+strings.Join(s.config.settings.RunCommand.Allowlist, ", ")),
+			mcp.WithString("command",
+				mcp.Required(),
+				mcp.Description("The command to run. Must exactly match one of the allowed commands"),
+				mcp.Enum(s.config.settings.RunCommand.Allowlist...),
+			),
+			withFormat(),
+			withFocus(),
+		)
+
+		s.addTool(runCommandTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Extract arguments
+			command, ok := request.Params.Arguments["command"].(string)
+			if !ok {
+				return mcp.NewToolResultError("command must be a string"), nil
+			}
+
+			coreLogger.Debug("Executing run_command: %s", command)
+			tc, err := s.callToolContext(ctx, request)
+			if err != nil {
+				return s.toolError(ctx, request, err), nil
+			}
+			doc, err := tools.RunCommandDocument(ctx, tc, command)
+			if err != nil {
+				coreLogger.Error("Failed to run command: %v", err)
+				return s.toolError(ctx, request, fmt.Errorf("failed to run command: %w", err)), nil
+			}
+			return s.renderDocument(ctx, request, doc), nil
+		})
+	}
+
+	setOutputVersionTool := mcp.NewTool("set_output_version",
+		mcp.WithDescription("Choose the output contract for this session. v1 returns the original text output of every tool. v2 returns structured JSON from tools that accept a format parameter. Tools keep the chosen version until it is changed, and an explicit format argument always wins."),
+		mcp.WithString("version",
+			mcp.Required(),
+			mcp.Description("Output version to use"),
+			mcp.Enum(outputVersionNames()...),
+		),
+	)
+
+	s.addTool(setOutputVersionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		version, ok := request.Params.Arguments["version"].(string)
+		if !ok {
+			return mcp.NewToolResultError("version must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing set_output_version for version: %s", version)
+		if err := s.outputVersions.Set(ctx, version); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Output version set to %s for this session", version)), nil
+	})
+
+	setContextLinesTool := mcp.NewTool("set_context_lines",
+		mcp.WithDescription("Choose how many lines of code around each match references, incoming calls, diagnostics and run_command findings show in this session. Other sessions are not affected. A negative number goes back to the server's setting."),
+		mcp.WithNumber("lines",
+			mcp.Required(),
+			mcp.Description("Lines of context to show around each match"),
+		),
+	)
+
+	s.addTool(setContextLinesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		lines, ok := numberArgument(request, "lines")
+		if !ok {
+			return mcp.NewToolResultError("lines must be a number"), nil
+		}
+
+		coreLogger.Debug("Executing set_context_lines for lines: %d", lines)
+		s.overrides.SetContextLines(ctx, lines)
+		if lines < 0 {
+			return mcp.NewToolResultText("Context lines reset to the server's setting for this session"), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Context lines set to %d for this session", lines)), nil
+	})
+
+	diffResultsTool := mcp.NewTool("diff_results",
+		mcp.WithDescription("Compare two earlier results of the same tool by their result IDs, e.g. references before and after a refactor, and list the locations removed and added. Lines that only moved count as unchanged. Answers \"did my change remove all usages?\" in one call. Tools that return file locations give their result ID in the resultId field of JSON output (output version v2 or format: json). Only results of this session can be compared."),
+		mcp.WithString("before",
+			mcp.Required(),
+			mcp.Description("Result ID of the earlier result, e.g. r3"),
+		),
+		mcp.WithString("after",
+			mcp.Required(),
+			mcp.Description("Result ID of the later result"),
+		),
+	)
+
+	s.addTool(diffResultsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		beforeID, ok := request.Params.Arguments["before"].(string)
+		if !ok {
+			return mcp.NewToolResultError("before must be a string"), nil
+		}
+		afterID, ok := request.Params.Arguments["after"].(string)
+		if !ok {
+			return mcp.NewToolResultError("after must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing diff_results for %s and %s", beforeID, afterID)
+		before, err := s.results.Get(sessionID(ctx), beforeID)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		after, err := s.results.Get(sessionID(ctx), afterID)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		if before.Tool != after.Tool {
+			return s.toolError(ctx, request, fmt.Errorf("%s is a %s result and %s a %s result, only results of the same tool can be compared", before.ID, before.Tool, after.ID, after.Tool)), nil
+		}
+		return mcp.NewToolResultText(formatResultDiff(s.config.workspaceDir, before, after, diffResults(before, after))), nil
+	})
+
+	setFocusTool := mcp.NewTool("set_focus",
+		mcp.WithDescription("Limit the results of references, incoming_calls, search_symbols, run_pipeline and run_command findings in this session to a directory subtree, such as the service being worked on in a monorepo. Results elsewhere are hidden and counted. Definitions are still found anywhere. Other sessions are not affected, and each call can pass focus to override it."),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Directory to focus on, relative to the workspace. Use \".\" to clear the focus."),
+		),
+	)
+
+	s.addTool(setFocusTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, ok := request.Params.Arguments["path"].(string)
+		if !ok {
+			return mcp.NewToolResultError("path must be a string"), nil
+		}
+
+		coreLogger.Debug("Executing set_focus for path: %s", path)
+		dir, err := tools.ResolveFocus(s.config.workspaceDir, path)
+		if err != nil {
+			return s.toolError(ctx, request, err), nil
+		}
+		s.overrides.SetFocus(ctx, dir)
+		if dir == "" {
+			return mcp.NewToolResultText("Focus cleared, results cover the whole workspace for this session"), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Focus set to %s for this session", relativePath(s.config.workspaceDir, dir))), nil
+	})
+
+	snapshotWorkspaceTool := mcp.NewTool("snapshot_workspace",
+		mcp.WithDescription("Take a snapshot of the files this session has changed, as a checkpoint before a risky refactor. restore_snapshot later returns every file edited, renamed, created or deleted through this server since the snapshot to its content at the snapshot, without git. Files changed by other means are not tracked."),
+		mcp.WithString("name",
+			mcp.Description("Name of the snapshot. Defaults to snapshot-N."),
+		),
+	)
+
+	s.addTool(snapshotWorkspaceTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+
+		coreLogger.Debug("Executing snapshot_workspace for name: %s", name)
+		snapshot, err := s.journals.Get(sessionID(ctx)).Snapshot(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Snapshot %s taken. Restore it with restore_snapshot.", snapshot.Name)), nil
+	})
+
+	restoreSnapshotTool := mcp.NewTool("restore_snapshot",
+		mcp.WithDescription("Restore the files this session changed since a snapshot to their content at the snapshot. Files created since are deleted. Files changed outside the server since it last wrote them are skipped unless force is set."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the snapshot, as shown by list_snapshots"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Also restore files that were changed outside the server, discarding those changes"),
+			mcp.DefaultBool(false),
+		),
+	)
+
+	s.addTool(restoreSnapshotTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, ok := request.Params.Arguments["name"].(string)
+		if !ok || name == "" {
+			return mcp.NewToolResultError("name must be a string"), nil
+		}
+		force, _ := request.Params.Arguments["force"].(bool)
+
+		coreLogger.Debug("Executing restore_snapshot for name: %s", name)
+		result, err := s.journals.Get(sessionID(ctx)).Restore(name, force)
+		if err != nil {
+			coreLogger.Error("Failed to restore snapshot: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to restore snapshot: %w", err)), nil
+		}
+		return mcp.NewToolResultText(formatRestore(s.config.workspaceDir, name, result)), nil
+	})
+
+	listSnapshotsTool := mcp.NewTool("list_snapshots",
+		mcp.WithDescription("List the snapshots this session has taken and the files changed since each."),
+	)
+
+	s.addTool(listSnapshotsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing list_snapshots")
+		return mcp.NewToolResultText(formatSnapshots(s.config.workspaceDir, s.journals.Get(sessionID(ctx)).Snapshots())), nil
+	})
+
+	serverStatusTool := mcp.NewTool(statusTool,
+		mcp.WithDescription("Show how busy the language server is: the tool calls running and queued, and per-session queue metrics (calls completed, average and maximum wait), how many calls shared the result of an identical call, and how much of its output budget this session has used. This tool never waits in the queue."),
+	)
+
+	s.addTool(serverStatusTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing status")
+		status := s.scheduler.Status()
+		status += "\n" + s.coalescer.Status()
+		if s.pool.Stopped() {
+			status += "\nLanguage server: stopped while idle, it starts again on the next tool call\n"
+		}
+		if s.outputBudget.enabled() {
+			status += "\n" + s.outputBudget.Status(sessionID(ctx))
+		}
+		return mcp.NewToolResultText(status), nil
+	})
+
+	debugBundleTool := mcp.NewTool("create_debug_bundle",
+		mcp.WithDescription("Write a zip archive for bug reports with recent logs, the negotiated LSP capabilities, the settings in use, version information and the last JSON-RPC messages exchanged with the language server. Secrets and home directory paths are redacted and file contents are left out, but review the archive before sharing it."),
+		mcp.WithString("path",
+			mcp.Description("Where to write the archive. Defaults to a timestamped file in the system temp directory. Existing files are never overwritten."),
+		),
+		mcp.WithNumber("exchanges",
+			mcp.Description("Number of recent JSON-RPC messages to include (default 50)"),
+		),
+	)
+
+	s.addTool(debugBundleTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, _ := request.Params.Arguments["path"].(string)
+		if path == "" {
+			path = filepath.Join(os.TempDir(), fmt.Sprintf("mcp-language-server-debug-%s.zip", time.Now().Format("20060102-150405")))
+		}
+		exchanges := 50
+		if n, ok := numberArgument(request, "exchanges"); ok {
+			exchanges = n
+		}
+
+		coreLogger.Debug("Executing create_debug_bundle for path: %s", path)
+		client := s.client()
+		clientCapabilities, initializeResult := client.Capabilities()
+		bundle := debugbundle.Bundle{
+			Version:            version,
+			Command:            append([]string{s.config.lspCommand}, s.config.lspArgs...),
+			Settings:           s.config.settings,
+			ClientCapabilities: clientCapabilities,
+			InitializeResult:   initializeResult,
+			Logs:               logging.Recent(),
+			Exchanges:          client.Exchanges(exchanges),
+		}
+
+		// Never overwrite an existing file: the path comes from the model and
+		// this write is neither audited nor journaled
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			return s.toolError(ctx, request, fmt.Errorf("%s already exists, choose a new path for the debug bundle", path)), nil
+		}
+		if err != nil {
+			return s.toolError(ctx, request, fmt.Errorf("failed to create debug bundle: %w", err)), nil
+		}
+		defer file.Close()
+		if err := debugbundle.Write(file, bundle); err != nil {
+			coreLogger.Error("Failed to write debug bundle: %v", err)
+			return s.toolError(ctx, request, fmt.Errorf("failed to write debug bundle: %w", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Debug bundle written to %s (%d log lines, %d JSON-RPC messages)\nSecrets are redacted, but review the archive before attaching it to a bug report.", path, len(bundle.Logs), len(bundle.Exchanges))), nil
+	})
+
+	if err := s.registerPlugins(); err != nil {
+		return err
+	}
+
 	coreLogger.Info("Successfully registered all MCP tools")
 	return nil
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// warmupProgress returns a function that forwards the progress of a warmup
+// tool call to the client: as notifications/message, and as
+// notifications/progress when the call has a progress token
+func (s *mcpServer) warmupProgress(ctx context.Context, request mcp.CallToolRequest) func(tools.WarmupProgress) {
+	var progressToken mcp.ProgressToken
+	if request.Params.Meta != nil {
+		progressToken = request.Params.Meta.ProgressToken
+	}
+	return func(progress tools.WarmupProgress) {
+		message := fmt.Sprintf("Warmup %d/%d: %s", progress.Step, progress.Total, progress.Message)
+		if err := s.mcpServer.SendNotificationToClient(ctx, "notifications/message", map[string]any{
+			"level":  "info",
+			"logger": "warmup",
+			"data":   message,
+		}); err != nil {
+			coreLogger.Debug("Failed to send warmup notification: %v", err)
+		}
+		if progressToken != nil {
+			if err := s.mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+				"progressToken": progressToken,
+				"progress":      progress.Step,
+				"total":         progress.Total,
+				"message":       message,
+			}); err != nil {
+				coreLogger.Debug("Failed to send progress notification: %v", err)
+			}
+		}
+	}
+}
+
+// warmupOnStart warms up the workspace in the background when the server
+// starts, logging the progress
+func (s *mcpServer) warmupOnStart(ctx context.Context) {
+	go func() {
+		text, err := tools.Warmup(ctx, s.toolContext(ctx), tools.DefaultWarmupFiles, func(progress tools.WarmupProgress) {
+			coreLogger.Debug("Warmup %d/%d: %s", progress.Step, progress.Total, progress.Message)
+		})
+		if err != nil {
+			coreLogger.Warn("Warmup failed: %v", err)
+			return
+		}
+		coreLogger.Info("%s", text)
+	}()
+}